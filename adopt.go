@@ -0,0 +1,64 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Adopt takes ownership of a foreign database: it installs the package's
+// config and schema_migrations tables in the existing database named by
+// cfg.Path. It doesn't apply cfg.Migrations; the next Open does, so the
+// migrations must build on the schema the database already has. Adopting
+// a database that is already initialized does nothing.
+//
+// Open with Config.AdoptForeign to inspect a database before adopting it.
+func Adopt(ctx context.Context, cfg Config) error {
+	cfg = cfg.defaults()
+	if cfg.isMemory() {
+		return fmt.Errorf("Adopt requires a persistent path, not :memory:")
+	}
+
+	cfg, err := applySymlinkPolicy(cfg)
+	if err != nil {
+		return err
+	}
+
+	// Open initializes the database when it has no config table; keep
+	// everything else out of the way.
+	cfg.AdoptForeign = false
+	cfg.SkipMigrations = false
+	cfg.Migrations = nil
+	cfg.MigrationBudget = 0
+	cfg.RequiredSchemaVersion = 0
+
+	var db *sql.DB
+	if cfg.isRemote() {
+		db, err = openRemote(ctx, cfg)
+	} else {
+		db, err = openPersistent(ctx, cfg)
+	}
+	if err != nil {
+		return fmt.Errorf("adopt: %w", err)
+	}
+	return db.Close()
+}
+
+// isForeign reports whether db holds tables, indexes or views but not the
+// package's schema_migrations table, i.e. it was created by something
+// else.
+func isForeign(ctx context.Context, db *sql.DB) (bool, error) {
+	var owned, objects int
+	err := db.QueryRowContext(ctx, `
+		SELECT
+			count(*) FILTER (WHERE type = 'table' AND name = 'schema_migrations'),
+			count(*) FILTER (WHERE name NOT LIKE 'sqlite\_%' ESCAPE '\')
+		FROM sqlite_master
+	`).Scan(&owned, &objects)
+	if err != nil {
+		return false, fmt.Errorf("inspect schema: %w", err)
+	}
+	return owned == 0 && objects != 0, nil
+}
@@ -0,0 +1,104 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+)
+
+// AttachSpec names a secondary database attached to every connection.
+type AttachSpec struct {
+	// Path is the database file, subject to the same path rules as
+	// Config.Path, or ":memory:" for a per-connection scratch database.
+	Path string
+
+	// Alias is the schema name the database is attached as, e.g.
+	// "analytics" for "SELECT * FROM analytics.events".
+	Alias string
+
+	// ReadOnly attaches the database read-only. The file must exist.
+	ReadOnly bool
+}
+
+// validate returns an error if the spec can't be attached under cfg's path
+// rules.
+func (a AttachSpec) validate(cfg Config) error {
+	switch strings.ToLower(a.Alias) {
+	case "":
+		return fmt.Errorf("attach %s: alias required", a.Path)
+	case "main", "temp":
+		return fmt.Errorf("attach %s: alias %q is reserved", a.Path, a.Alias)
+	}
+	if a.Path == ":memory:" {
+		return nil
+	}
+	if isMemoryPath(a.Path) || isRemotePath(a.Path) {
+		return fmt.Errorf("attach %s: path must be a local file or :memory:", a.Alias)
+	}
+	if err := validatePersistentPath(a.Path, cfg.pathPolicy()); err != nil {
+		return fmt.Errorf("attach %s: %w", a.Alias, err)
+	}
+	if a.ReadOnly && !fileExists(filePathOf(a.Path)) {
+		return fmt.Errorf("attach %s: %s: read-only database %w", a.Alias, a.Path, ErrNotFound)
+	}
+	return nil
+}
+
+// statement returns the ATTACH statement for the spec.
+func (a AttachSpec) statement() string {
+	name := a.Path
+	if name != ":memory:" {
+		name = hostPathStyle.uri(filePathOf(a.Path))
+		if a.ReadOnly {
+			name += "?mode=ro"
+		}
+	}
+	return fmt.Sprintf(`ATTACH DATABASE %s AS %s`, sqlQuote(name), quoteIdent(a.Alias))
+}
+
+// validateAttach checks cfg.Attach for invalid and duplicate specs.
+func validateAttach(cfg Config) error {
+	if len(cfg.Attach) != 0 && cfg.isRemote() {
+		return fmt.Errorf("Attach is not supported for remote databases")
+	}
+	seen := make(map[string]bool)
+	for _, a := range cfg.Attach {
+		if err := a.validate(cfg); err != nil {
+			return err
+		}
+		alias := strings.ToLower(a.Alias)
+		if seen[alias] {
+			return fmt.Errorf("attach: duplicate alias %q", a.Alias)
+		}
+		seen[alias] = true
+	}
+	return nil
+}
+
+// attachConnector attaches cfg.Attach databases to each new connection,
+// since ATTACH only applies to the connection that runs it.
+type attachConnector struct {
+	next   driver.Connector
+	attach []AttachSpec
+}
+
+func (c *attachConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.next.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range c.attach {
+		if err := execConn(ctx, conn, a.statement()); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("attach %s: %w", a.Alias, err)
+		}
+	}
+	return conn, nil
+}
+
+func (c *attachConnector) Driver() driver.Driver {
+	return c.next.Driver()
+}
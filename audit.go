@@ -0,0 +1,513 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"math/rand/v2"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// AccessAudit records which tables the application reads and writes, to
+// find tables nobody uses any more. Set Config.Audit to install it on the
+// handles Open and OpenDB return; one AccessAudit may be shared by several
+// handles on the same database.
+//
+// Only a sample of statements is inspected, and table names are found by
+// scanning the SQL rather than by asking SQLite, so the counts are
+// estimates and the last-access times are approximate. Statements the
+// package runs itself are not recorded.
+//
+// Accesses are kept in memory until Flush writes them to the table_access
+// table; use AuditTask to flush periodically.
+type AccessAudit struct {
+	// SampleRate is the fraction of statements inspected, from 0 to 1.
+	// Default: 0.01.
+	SampleRate float64
+
+	mu     sync.Mutex
+	tables map[string]*TableAccess
+}
+
+// TableAccess describes the recorded use of a table. Counts are of sampled
+// statements only.
+type TableAccess struct {
+	Table     string
+	LastRead  time.Time // zero if never read
+	LastWrite time.Time // zero if never written
+	Reads     int64
+	Writes    int64
+}
+
+// lastUse returns the later of the last read and the last write.
+func (t TableAccess) lastUse() time.Time {
+	if t.LastWrite.After(t.LastRead) {
+		return t.LastWrite
+	}
+	return t.LastRead
+}
+
+// sampleRate returns the sample rate with its default applied.
+func (a *AccessAudit) sampleRate() float64 {
+	if a.SampleRate == 0 {
+		return 0.01
+	}
+	return a.SampleRate
+}
+
+// record notes the tables query reads and writes, if the statement is
+// sampled.
+func (a *AccessAudit) record(ctx context.Context, query string) {
+	if isInternal(ctx) || rand.Float64() >= a.sampleRate() {
+		return
+	}
+	reads, writes := accessedTables(query)
+	if len(reads) == 0 && len(writes) == 0 {
+		return
+	}
+
+	now := time.Now().UTC()
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.tables == nil {
+		a.tables = make(map[string]*TableAccess)
+	}
+	entry := func(name string) *TableAccess {
+		t, ok := a.tables[name]
+		if !ok {
+			t = &TableAccess{Table: name}
+			a.tables[name] = t
+		}
+		return t
+	}
+	for _, name := range reads {
+		t := entry(name)
+		t.LastRead = now
+		t.Reads++
+	}
+	for _, name := range writes {
+		t := entry(name)
+		t.LastWrite = now
+		t.Writes++
+	}
+}
+
+// Flush adds the accesses recorded since the last flush to the
+// table_access table of db, creating it if needed, and clears them from
+// memory. Names that aren't tables in db (CTEs, table-valued functions)
+// are dropped.
+func (a *AccessAudit) Flush(ctx context.Context, db *sql.DB) error {
+	a.mu.Lock()
+	pending := a.tables
+	a.tables = nil
+	a.mu.Unlock()
+
+	err := a.flush(withInternal(ctx), db, pending)
+	if err != nil {
+		// Keep the accesses for the next flush.
+		a.mu.Lock()
+		for name, t := range pending {
+			if a.tables == nil {
+				a.tables = make(map[string]*TableAccess)
+			}
+			if cur, ok := a.tables[name]; ok {
+				cur.Reads += t.Reads
+				cur.Writes += t.Writes
+				if t.LastRead.After(cur.LastRead) {
+					cur.LastRead = t.LastRead
+				}
+				if t.LastWrite.After(cur.LastWrite) {
+					cur.LastWrite = t.LastWrite
+				}
+			} else {
+				a.tables[name] = t
+			}
+		}
+		a.mu.Unlock()
+	}
+	return err
+}
+
+// flush writes accesses to db in one transaction.
+func (a *AccessAudit) flush(ctx context.Context, db *sql.DB, accesses map[string]*TableAccess) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS table_access (
+		    table_name TEXT    NOT NULL PRIMARY KEY,
+		    last_read  INTEGER NOT NULL DEFAULT 0,
+		    last_write INTEGER NOT NULL DEFAULT 0,
+		    reads      INTEGER NOT NULL DEFAULT 0,
+		    writes     INTEGER NOT NULL DEFAULT 0
+		)
+	`); err != nil {
+		return fmt.Errorf("create table_access: %w", err)
+	}
+
+	ts := time.Now().UTC().Unix()
+	if _, err := tx.ExecContext(ctx, `
+		INSERT OR IGNORE INTO config (key, value, created_at, updated_at)
+		VALUES ('audit.started_at', ?, ?, ?)
+	`, strconv.FormatInt(ts, 10), ts, ts); err != nil {
+		return fmt.Errorf("set audit.started_at: %w", err)
+	}
+
+	for name, t := range accesses {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO table_access (table_name, last_read, last_write, reads, writes)
+			SELECT ?, ?, ?, ?, ?
+			WHERE EXISTS (SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = ?)
+			ON CONFLICT (table_name) DO UPDATE SET
+			    last_read  = max(last_read, excluded.last_read),
+			    last_write = max(last_write, excluded.last_write),
+			    reads      = reads + excluded.reads,
+			    writes     = writes + excluded.writes
+		`, name, unixOrZero(t.LastRead), unixOrZero(t.LastWrite), t.Reads, t.Writes, name)
+		if err != nil {
+			return fmt.Errorf("record %s: %w", name, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// Tables flushes pending accesses and returns the recorded use of every
+// table in db, including tables never accessed, sorted by name. The
+// package's own tables are omitted.
+func (a *AccessAudit) Tables(ctx context.Context, db *sql.DB) ([]TableAccess, error) {
+	if err := a.Flush(ctx, db); err != nil {
+		return nil, err
+	}
+	ctx = withInternal(ctx)
+
+	rows, err := db.QueryContext(ctx, `
+		SELECT m.name, coalesce(a.last_read, 0), coalesce(a.last_write, 0),
+		       coalesce(a.reads, 0), coalesce(a.writes, 0)
+		FROM sqlite_master m
+		LEFT JOIN table_access a ON a.table_name = m.name
+		WHERE m.type = 'table' AND m.name NOT LIKE 'sqlite\_%' ESCAPE '\'
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tables []TableAccess
+	for rows.Next() {
+		var t TableAccess
+		var lastRead, lastWrite int64
+		if err := rows.Scan(&t.Table, &lastRead, &lastWrite, &t.Reads, &t.Writes); err != nil {
+			return nil, err
+		}
+		if isInternalTable(t.Table) {
+			continue
+		}
+		t.LastRead, t.LastWrite = timeOrZero(lastRead), timeOrZero(lastWrite)
+		tables = append(tables, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	sort.Slice(tables, func(i, j int) bool { return tables[i].Table < tables[j].Table })
+	return tables, nil
+}
+
+// Untouched returns the names of tables in db that have not been read or
+// written for at least d. A table never seen is reported only once the
+// audit has been recording for d, so a new audit doesn't report every
+// table.
+func (a *AccessAudit) Untouched(ctx context.Context, db *sql.DB, d time.Duration) ([]string, error) {
+	tables, err := a.Tables(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	var started int64
+	err = db.QueryRowContext(withInternal(ctx), `SELECT CAST(value AS INTEGER) FROM config WHERE key = 'audit.started_at'`).Scan(&started)
+	if err != nil {
+		return nil, fmt.Errorf("fetch audit.started_at: %w", err)
+	}
+
+	cutoff := time.Now().Add(-d)
+	var untouched []string
+	for _, t := range tables {
+		last := t.lastUse()
+		if last.IsZero() {
+			last = time.Unix(started, 0)
+		}
+		if !last.After(cutoff) {
+			untouched = append(untouched, t.Table)
+		}
+	}
+	return untouched, nil
+}
+
+// AuditTask returns a maintenance task that flushes a to the database.
+func AuditTask(a *AccessAudit, interval time.Duration) MaintenanceTask {
+	return MaintenanceTask{
+		Name:     "audit",
+		Interval: interval,
+		Run:      a.Flush,
+	}
+}
+
+// unixOrZero returns t as Unix seconds, or 0 for the zero time.
+func unixOrZero(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+// timeOrZero returns Unix seconds as a time, or the zero time for 0.
+func timeOrZero(sec int64) time.Time {
+	if sec == 0 {
+		return time.Time{}
+	}
+	return time.Unix(sec, 0).UTC()
+}
+
+// auditConnector opens connections that report statements to an
+// AccessAudit.
+type auditConnector struct {
+	next  driver.Connector
+	audit *AccessAudit
+}
+
+func (c *auditConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.next.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &auditConn{Conn: conn, audit: c.audit}, nil
+}
+
+func (c *auditConnector) Driver() driver.Driver {
+	return c.next.Driver()
+}
+
+// auditConn wraps a driver connection and records the tables its
+// statements use.
+type auditConn struct {
+	driver.Conn
+	audit *AccessAudit
+}
+
+func (c *auditConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	c.audit.record(ctx, query)
+	return execer.ExecContext(ctx, query, args)
+}
+
+func (c *auditConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	c.audit.record(ctx, query)
+	return queryer.QueryContext(ctx, query, args)
+}
+
+func (c *auditConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	c.audit.record(ctx, query)
+	if p, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return p.PrepareContext(ctx, query)
+	}
+	return c.Conn.Prepare(query)
+}
+
+func (c *auditConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if b, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return b.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin()
+}
+
+func (c *auditConn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+// sqlToken is a word, quoted identifier or punctuation character in a
+// statement.
+type sqlToken struct {
+	text   string
+	quoted bool // a quoted identifier, never a keyword
+}
+
+// is returns true if the token is the unquoted keyword kw.
+func (t sqlToken) is(kw string) bool {
+	return !t.quoted && strings.EqualFold(t.text, kw)
+}
+
+// isName returns true if the token can name a table or alias.
+func (t sqlToken) isName() bool {
+	return t.quoted || isWordStart(t.text, 0) && !tableListKeywords[strings.ToUpper(t.text)]
+}
+
+// tableListKeywords end a table name, alias or table list.
+var tableListKeywords = map[string]bool{
+	"AS": true, "CROSS": true, "DEFAULT": true, "DO": true, "EXCEPT": true,
+	"FULL": true, "GROUP": true, "HAVING": true, "INDEXED": true, "INNER": true,
+	"INTERSECT": true, "JOIN": true, "LEFT": true, "LIMIT": true, "NATURAL": true,
+	"NOT": true, "ON": true, "ORDER": true, "OUTER": true, "RETURNING": true,
+	"RIGHT": true, "SELECT": true, "SET": true, "UNION": true, "USING": true,
+	"VALUES": true, "WHERE": true, "WINDOW": true,
+}
+
+// sqlTokens splits query into tokens, dropping comments, string literals
+// and numbers.
+func sqlTokens(query string) []sqlToken {
+	var tokens []sqlToken
+	for i := 0; i < len(query); {
+		switch c := query[i]; {
+		case strings.HasPrefix(query[i:], "--"):
+			j := strings.IndexByte(query[i:], '\n')
+			if j < 0 {
+				return tokens
+			}
+			i += j + 1
+		case strings.HasPrefix(query[i:], "/*"):
+			j := strings.Index(query[i+2:], "*/")
+			if j < 0 {
+				return tokens
+			}
+			i += j + 4
+		case c == '\'':
+			i = skipQuoted(query, i)
+		case c == '"' || c == '`':
+			j := skipQuoted(query, i)
+			name := strings.TrimSuffix(query[i+1:j], string(c))
+			tokens = append(tokens, sqlToken{text: strings.ReplaceAll(name, string(c)+string(c), string(c)), quoted: true})
+			i = j
+		case c == '[':
+			j := strings.IndexByte(query[i:], ']')
+			if j < 0 {
+				return tokens
+			}
+			tokens = append(tokens, sqlToken{text: query[i+1 : i+j], quoted: true})
+			i += j + 1
+		case isWordByte(c):
+			w := wordAt(query, i)
+			if isWordStart(query, i) {
+				tokens = append(tokens, sqlToken{text: w})
+			}
+			i += len(w)
+		case c == '(' || c == ')' || c == ',' || c == '.' || c == ';':
+			tokens = append(tokens, sqlToken{text: string(c)})
+			i++
+		default:
+			i++
+		}
+	}
+	return tokens
+}
+
+// accessedTables returns the tables query reads and writes, found by
+// scanning for the names that follow FROM, JOIN, INTO and UPDATE. It is a
+// heuristic: names of CTEs and table-valued functions are included, and
+// unusual syntax may hide a table.
+func accessedTables(query string) (reads, writes []string) {
+	tokens := sqlTokens(query)
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+
+	kind := strings.ToUpper(tokens[0].text)
+	if tokens[0].is("WITH") {
+		// The statement kind follows the CTEs; find the first top-level
+		// keyword after them.
+		depth := 0
+		for _, t := range tokens[1:] {
+			switch {
+			case t.is("("):
+				depth++
+			case t.is(")"):
+				depth--
+			case depth == 0 && (t.is("SELECT") || t.is("INSERT") || t.is("REPLACE") || t.is("UPDATE") || t.is("DELETE")):
+				kind = strings.ToUpper(t.text)
+			}
+			if kind != "WITH" {
+				break
+			}
+		}
+	}
+
+	// tableAt returns the table named at tokens[i], without any schema
+	// qualifier, and the index after it.
+	tableAt := func(i int) (string, int) {
+		if i >= len(tokens) || !tokens[i].isName() {
+			return "", i
+		}
+		if i+2 < len(tokens) && tokens[i+1].is(".") && tokens[i+2].isName() {
+			return tokens[i+2].text, i + 3
+		}
+		return tokens[i].text, i + 1
+	}
+
+	deleteTarget := kind == "DELETE"
+	depth := 0
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+		switch {
+		case t.is("("):
+			depth++
+		case t.is(")"):
+			depth--
+		case t.is("INTO") && (kind == "INSERT" || kind == "REPLACE"):
+			if name, _ := tableAt(i + 1); name != "" {
+				writes = append(writes, name)
+			}
+		case t.is("UPDATE") && kind == "UPDATE":
+			j := i + 1
+			if j < len(tokens) && tokens[j].is("OR") {
+				j += 2
+			}
+			if name, _ := tableAt(j); name != "" {
+				writes = append(writes, name)
+			}
+		case t.is("FROM") && deleteTarget && depth == 0:
+			deleteTarget = false
+			if name, _ := tableAt(i + 1); name != "" {
+				writes = append(writes, name)
+			}
+		case t.is("FROM") || t.is("JOIN"):
+			// A table list: name [[AS] alias] [, name [[AS] alias]]...
+			j := i + 1
+			for {
+				name, next := tableAt(j)
+				if name == "" {
+					break
+				}
+				reads = append(reads, name)
+				j = next
+				if j < len(tokens) && tokens[j].is("AS") {
+					j++
+				}
+				if j < len(tokens) && tokens[j].isName() {
+					j++
+				}
+				if j >= len(tokens) || !tokens[j].is(",") {
+					break
+				}
+				j++
+			}
+		}
+	}
+	return reads, writes
+}
@@ -0,0 +1,259 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// BackupOptions controls Backup.
+type BackupOptions struct {
+	// Verify opens the copy after it is written and runs PRAGMA
+	// quick_check on it, failing the backup if it reports a problem.
+	Verify bool
+
+	// Driver used to open the copy for verification. Default:
+	// DefaultDriver.
+	Driver Driver
+
+	// TempDir is where BackupTo stages a backup before sending it to its
+	// target. Default: os.TempDir().
+	TempDir string
+
+	// PathPolicy checks destPath. Default: DefaultPathPolicy.
+	PathPolicy PathPolicy
+}
+
+// Backup writes a consistent copy of db to destPath with VACUUM INTO, which
+// works with every driver and sees a single snapshot of a live WAL
+// database, so it is safe while other connections write. destPath is
+// checked against opts.PathPolicy and must not already exist. A destPath
+// ending in ".db.gz" or ".db.zst" is compressed with gzip or Zstandard
+// (see Compression for registering the Zstandard codec); the policy is
+// applied to the name without the compression suffix.
+//
+// The copy is written next to destPath, synced to disk and renamed into
+// place, so destPath never holds a partial backup, even after a crash.
+func Backup(ctx context.Context, db *sql.DB, destPath string, opts BackupOptions) error {
+	compression, base := compressionOf(destPath)
+	if err := validatePersistentPath(base, orDefaultPolicy(opts.PathPolicy)); err != nil {
+		return err
+	}
+	if err := checkCompression(compression); err != nil {
+		return fmt.Errorf("backup: %w", err)
+	}
+	if fileExists(destPath) {
+		return fmt.Errorf("%s: file %w", destPath, ErrAlreadyExists)
+	}
+	if err := checkDiskSpace(destPath, databaseSize(ctx, db)); err != nil {
+		return err
+	}
+
+	// VACUUM INTO refuses to overwrite, so clear out a failed attempt.
+	tmp := base + ".tmp"
+	if err := os.Remove(tmp); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("backup: %w", err)
+	}
+	defer os.Remove(tmp)
+
+	if _, err := db.ExecContext(withInternal(ctx), `VACUUM INTO ?`, tmp); err != nil {
+		return fmt.Errorf("backup: vacuum into %s: %w", destPath, err)
+	}
+	if opts.Verify {
+		if err := verifyBackup(ctx, opts.Driver, tmp); err != nil {
+			return fmt.Errorf("backup: verify: %w", err)
+		}
+	}
+
+	final := tmp
+	if compression != CompressionNone {
+		final = destPath + ".tmp"
+		os.Remove(final)
+		defer os.Remove(final)
+		if err := compressFile(compression, tmp, final); err != nil {
+			return fmt.Errorf("backup: compress: %w", err)
+		}
+	}
+	if err := syncFile(final); err != nil {
+		return fmt.Errorf("backup: %w", err)
+	}
+	if fileExists(destPath) {
+		return fmt.Errorf("%s: file %w", destPath, ErrAlreadyExists)
+	}
+	if err := os.Rename(final, destPath); err != nil {
+		return fmt.Errorf("backup: %w", err)
+	}
+	syncDir(filepath.Dir(destPath))
+	return nil
+}
+
+// RotationPolicy says which backups BackupRotate keeps. A backup is
+// deleted if either rule says so; the zero value keeps everything.
+type RotationPolicy struct {
+	// Keep is the number of most recent backups to keep. 0 means no limit.
+	Keep int
+
+	// MaxAge deletes backups older than this. 0 means no limit.
+	MaxAge time.Duration
+
+	// Compression compresses new backups. Existing backups are rotated
+	// whatever their compression.
+	Compression Compression
+
+	// Options are passed to Backup.
+	Options BackupOptions
+}
+
+// backupPrefix and backupTimeFormat make the names of rotated backups,
+// e.g. backup-20260301T020000Z.db, which sort by time.
+const (
+	backupPrefix     = "backup-"
+	backupTimeFormat = "20060102T150405Z"
+)
+
+// backupName returns the file name of a rotated backup taken at t.
+func backupName(t time.Time, c Compression) string {
+	return backupPrefix + t.UTC().Format(backupTimeFormat) + c.Ext()
+}
+
+// BackupRotate writes a backup of db into dir, named after the current UTC
+// time (backup-YYYYMMDDTHHMMSSZ.db, or .db.gz or .db.zst with
+// policy.Compression), and then deletes older backups in dir that policy
+// doesn't keep. It returns the path of the new backup. Only files with that
+// naming pattern are considered, and their age is read from the name, not
+// the file's modification time. The new backup is never deleted. See
+// BackupRotateTo for other storage.
+func BackupRotate(ctx context.Context, db *sql.DB, dir string, policy RotationPolicy) (string, error) {
+	name, err := BackupRotateTo(ctx, db, DirTarget{Dir: dir}, policy)
+	if name == "" {
+		return "", err
+	}
+	return filepath.Join(dir, name), err
+}
+
+// BackupRotateTo is BackupRotate for any target. It returns the name of
+// the new backup. Old backups are pruned only if target also implements
+// BackupLister.
+func BackupRotateTo(ctx context.Context, db *sql.DB, target BackupTarget, policy RotationPolicy) (string, error) {
+	if policy.Keep < 0 || policy.MaxAge < 0 {
+		return "", fmt.Errorf("backup rotate: Keep and MaxAge must not be negative")
+	}
+	now := time.Now()
+	name := backupName(now, policy.Compression)
+	if err := BackupTo(ctx, db, target, name, policy.Options); err != nil {
+		return "", err
+	}
+
+	lister, ok := target.(BackupLister)
+	if !ok || policy.Keep == 0 && policy.MaxAge == 0 {
+		return name, nil
+	}
+	names, err := lister.List(ctx)
+	if err != nil {
+		return name, fmt.Errorf("backup rotate: %w", err)
+	}
+	backups := rotatedBackups(names)
+	var errs []error
+	kept := 0
+	for i := len(backups) - 1; i >= 0; i-- { // newest first
+		b := backups[i]
+		if b.name == name {
+			kept++
+			continue
+		}
+		if (policy.Keep == 0 || kept < policy.Keep) && (policy.MaxAge == 0 || now.Sub(b.taken) <= policy.MaxAge) {
+			kept++
+			continue
+		}
+		if err := lister.Delete(ctx, b.name); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return name, fmt.Errorf("backup rotate: prune: %w", err)
+	}
+	return name, nil
+}
+
+// rotatedBackup is a backup written by BackupRotateTo.
+type rotatedBackup struct {
+	name  string
+	taken time.Time
+}
+
+// rotatedBackups returns the rotated backups among names, oldest first.
+func rotatedBackups(names []string) []rotatedBackup {
+	var backups []rotatedBackup
+	for _, name := range names {
+		stamp, ok := strings.CutPrefix(name, backupPrefix)
+		if !ok {
+			continue
+		}
+		_, stamp = compressionOf(stamp)
+		stamp, ok = strings.CutSuffix(stamp, ".db")
+		if !ok {
+			continue
+		}
+		taken, err := time.Parse(backupTimeFormat, stamp)
+		if err != nil {
+			continue
+		}
+		backups = append(backups, rotatedBackup{name: name, taken: taken})
+	}
+	slices.SortFunc(backups, func(a, b rotatedBackup) int { return a.taken.Compare(b.taken) })
+	return backups
+}
+
+// verifyBackup opens the database at path and checks it with
+// PRAGMA quick_check.
+func verifyBackup(ctx context.Context, drv Driver, path string) error {
+	if drv == nil {
+		drv = DefaultDriver
+	}
+	dsn, _ := drv.BuildDSN(path, nil)
+	db, err := sql.Open(drv.Name(), dsn)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	var result string
+	if err := db.QueryRowContext(ctx, `PRAGMA quick_check(1)`).Scan(&result); err != nil {
+		return err
+	}
+	if result != "ok" {
+		return fmt.Errorf("quick_check: %s", result)
+	}
+	return db.Close()
+}
+
+// syncFile flushes the file at path to stable storage.
+func syncFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// syncDir flushes a directory's entries, making a rename in it durable.
+// It is best effort: some platforms, such as Windows, can't sync a
+// directory.
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
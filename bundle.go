@@ -0,0 +1,278 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// bundleFormat is the version of the bundle layout written by Bundle.
+const bundleFormat = 1
+
+// Names of the entries in a bundle archive.
+const (
+	bundleManifest   = "manifest.json"
+	bundleMigrations = "migrations.json"
+	bundleConfig     = "config.json"
+	bundleDatabase   = "database.db"
+)
+
+// BundleManifest describes the contents of a bundle.
+type BundleManifest struct {
+	Format        int       `json:"format"`
+	CreatedAt     time.Time `json:"created_at"`
+	Source        string    `json:"source"`
+	Driver        string    `json:"driver"`
+	SchemaVersion int       `json:"schema_version"`
+	Migrations    int       `json:"migrations"`
+}
+
+// Bundle writes the complete managed state of the persistent database at
+// cfg.Path to w as a gzip-compressed tar archive containing:
+//
+//   - manifest.json: a BundleManifest
+//   - migrations.json: the applied migration history
+//   - config.json: the config table as key/value pairs
+//   - database.db: a compacted snapshot of the database
+//
+// The JSON entries duplicate what is in the snapshot so the bundle can be
+// inspected without SQLite. Use Unbundle to restore it.
+func Bundle(ctx context.Context, cfg Config, w io.Writer) error {
+	cfg = cfg.defaults()
+	if cfg.isMemory() || cfg.isRemote() {
+		return fmt.Errorf("bundle: only local persistent databases can be bundled")
+	}
+
+	cfg.SkipMigrations = true
+	db, err := Open(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	version, err := fetchSchemaVersion(ctx, db)
+	if err != nil {
+		return err
+	}
+	if version == nil {
+		return fmt.Errorf("bundle: %s: %w", cfg.filePath(), ErrNotInitialized)
+	}
+	applied, err := fetchAppliedMigrations(ctx, db)
+	if err != nil {
+		return fmt.Errorf("fetch migrations: %w", err)
+	}
+	config, err := fetchConfig(ctx, db)
+	if err != nil {
+		return fmt.Errorf("fetch config: %w", err)
+	}
+
+	tmpDir, err := os.MkdirTemp("", "sqliteinit-bundle-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmpDir)
+	snapshot := filepath.Join(tmpDir, bundleDatabase)
+	if err := checkDiskSpace(snapshot, databaseSize(ctx, db)); err != nil {
+		return err
+	}
+	if _, err := db.ExecContext(ctx, `VACUUM INTO ?`, snapshot); err != nil {
+		return fmt.Errorf("snapshot: %w", err)
+	}
+
+	manifest := BundleManifest{
+		Format:        bundleFormat,
+		CreatedAt:     time.Now().UTC(),
+		Source:        cfg.filePath(),
+		Driver:        cfg.driver().Name(),
+		SchemaVersion: *version,
+		Migrations:    len(applied),
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	for _, entry := range []struct {
+		name string
+		v    any
+	}{
+		{bundleManifest, manifest},
+		{bundleMigrations, applied},
+		{bundleConfig, config},
+	} {
+		data, err := json.MarshalIndent(entry.v, "", "  ")
+		if err != nil {
+			return fmt.Errorf("%s: %w", entry.name, err)
+		}
+		if err := writeTarEntry(tw, entry.name, data, manifest.CreatedAt); err != nil {
+			return err
+		}
+	}
+	if err := writeTarFile(tw, bundleDatabase, snapshot, manifest.CreatedAt); err != nil {
+		return err
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// Unbundle restores a bundle written by Bundle to cfg.Path, which must not
+// already exist. The restored database is opened with cfg to confirm it is
+// usable (pending migrations in cfg.Migrations are applied unless
+// cfg.SkipMigrations is set) and its schema version is checked against the
+// manifest. It returns the bundle's manifest.
+func Unbundle(ctx context.Context, r io.Reader, cfg Config) (*BundleManifest, error) {
+	cfg = cfg.defaults()
+	if cfg.isMemory() || cfg.isRemote() {
+		return nil, fmt.Errorf("unbundle: only local persistent databases can be restored")
+	}
+	path := cfg.filePath()
+	if err := validatePersistentPath(path, cfg.pathPolicy()); err != nil {
+		return nil, err
+	}
+	if fileExists(path) {
+		return nil, fmt.Errorf("%s: file %w", path, ErrAlreadyExists)
+	}
+
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("unbundle: %w", err)
+	}
+	defer gz.Close()
+
+	var manifest *BundleManifest
+	restored := false
+	success := false
+	tmp := path + ".tmp"
+	defer func() {
+		os.Remove(tmp)
+		if restored && !success {
+			os.Remove(path)
+		}
+	}()
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("unbundle: %w", err)
+		}
+
+		switch hdr.Name {
+		case bundleManifest:
+			manifest = &BundleManifest{}
+			if err := json.NewDecoder(tr).Decode(manifest); err != nil {
+				return nil, fmt.Errorf("%s: %w", bundleManifest, err)
+			}
+			if manifest.Format != bundleFormat {
+				return nil, fmt.Errorf("unsupported bundle format %d", manifest.Format)
+			}
+		case bundleDatabase:
+			if err := checkDiskSpace(path, hdr.Size); err != nil {
+				return nil, err
+			}
+			if err := copyToFile(tmp, tr); err != nil {
+				return nil, fmt.Errorf("restore database: %w", err)
+			}
+			if err := os.Rename(tmp, path); err != nil {
+				return nil, fmt.Errorf("restore database: %w", err)
+			}
+			restored = true
+		}
+	}
+	if manifest == nil {
+		return nil, fmt.Errorf("unbundle: missing %s", bundleManifest)
+	}
+	if !restored {
+		return nil, fmt.Errorf("unbundle: missing %s", bundleDatabase)
+	}
+
+	db, err := Open(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	version, err := fetchSchemaVersion(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+	if version == nil || *version < manifest.SchemaVersion {
+		return nil, fmt.Errorf("unbundle: restored schema version does not match manifest version %d", manifest.SchemaVersion)
+	}
+
+	success = true
+	return manifest, nil
+}
+
+// fetchConfig returns the contents of the config table.
+func fetchConfig(ctx context.Context, db *sql.DB) (map[string]string, error) {
+	rows, err := db.QueryContext(ctx, `SELECT key, value FROM config ORDER BY key`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	config := make(map[string]string)
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, err
+		}
+		config[key] = value
+	}
+	return config, rows.Err()
+}
+
+// writeTarEntry writes data to tw as a regular file named name.
+func writeTarEntry(tw *tar.Writer, name string, data []byte, modTime time.Time) error {
+	hdr := &tar.Header{Name: name, Mode: 0o644, Size: int64(len(data)), ModTime: modTime}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
+
+// writeTarFile copies the file at path into tw as name.
+func writeTarFile(tw *tar.Writer, name, path string, modTime time.Time) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	hdr := &tar.Header{Name: name, Mode: 0o644, Size: fi.Size(), ModTime: modTime}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+// copyToFile writes r to a new file at path.
+func copyToFile(path string, r io.Reader) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
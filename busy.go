@@ -0,0 +1,56 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"context"
+	"log/slog"
+	"math/rand/v2"
+	"strings"
+	"time"
+)
+
+// Retry policy for SQLITE_BUSY during initialization and migration. The
+// busy_timeout pragma already waits inside SQLite; these retries cover the
+// cases it doesn't, such as lock upgrades that fail immediately to avoid
+// deadlock and contention that outlasts the timeout on a crowded startup.
+const (
+	busyRetries      = 5
+	busyInitialDelay = 100 * time.Millisecond
+	busyMaxDelay     = 2 * time.Second
+)
+
+// isBusy returns true if err reports a locked or busy database.
+func isBusy(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "database is locked") ||
+		strings.Contains(msg, "SQLITE_BUSY") ||
+		strings.Contains(msg, "database table is locked")
+}
+
+// retryBusy calls fn, retrying with jittered exponential backoff while it
+// fails with SQLITE_BUSY, up to busyRetries times or until ctx is done.
+func retryBusy(ctx context.Context, logger *slog.Logger, op string, fn func() error) error {
+	delay := busyInitialDelay
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if !isBusy(err) || attempt > busyRetries {
+			return err
+		}
+
+		wait := delay/2 + rand.N(delay/2+1)
+		logger.Warn("database busy, retrying", "op", op, "attempt", attempt, "wait", wait, "error", err)
+
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return err
+		case <-t.C:
+		}
+		delay = min(2*delay, busyMaxDelay)
+	}
+}
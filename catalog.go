@@ -0,0 +1,166 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrNoSnapshot is returned by SnapshotCatalog.OpenAsOf when no snapshot
+// was taken at or before the requested time.
+var ErrNoSnapshot = errors.New("no snapshot at or before requested time")
+
+// snapshotTimeFormat is the UTC timestamp embedded in snapshot file names.
+const snapshotTimeFormat = "20060102T150405.000000000Z"
+
+// SnapshotCatalog retains timestamped read-only snapshots of a database in
+// a directory and opens them by time, giving coarse time-travel debugging
+// for single-file deployments.
+type SnapshotCatalog struct {
+	// Dir holds the snapshots. It must be an absolute path to an existing
+	// directory.
+	Dir string
+
+	// Prefix starts every snapshot file name. Default: "snapshot".
+	Prefix string
+
+	// Retain is the number of snapshots kept by Take; older ones are
+	// deleted. Zero keeps all snapshots.
+	Retain int
+
+	// Options are passed to ExportSnapshot.
+	Options SnapshotOptions
+}
+
+// Snapshot is an entry in a SnapshotCatalog.
+type Snapshot struct {
+	Path  string
+	Taken time.Time
+}
+
+// prefix returns the file name prefix with its default applied.
+func (c *SnapshotCatalog) prefix() string {
+	if c.Prefix == "" {
+		return "snapshot"
+	}
+	return c.Prefix
+}
+
+// Take exports a snapshot of db into the catalog and prunes old snapshots.
+func (c *SnapshotCatalog) Take(ctx context.Context, db *sql.DB) (Snapshot, error) {
+	taken := time.Now().UTC()
+	path := filepath.Join(c.Dir, c.prefix()+"-"+taken.Format(snapshotTimeFormat)+".db")
+
+	if err := ExportSnapshot(ctx, db, path, c.Options); err != nil {
+		return Snapshot{}, err
+	}
+	if err := c.Prune(); err != nil {
+		return Snapshot{}, fmt.Errorf("prune: %w", err)
+	}
+	return Snapshot{Path: path, Taken: taken}, nil
+}
+
+// List returns the snapshots in the catalog, oldest first.
+func (c *SnapshotCatalog) List() ([]Snapshot, error) {
+	entries, err := os.ReadDir(c.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var snaps []Snapshot
+	for _, e := range entries {
+		if !e.Type().IsRegular() {
+			continue
+		}
+		ts, ok := strings.CutPrefix(e.Name(), c.prefix()+"-")
+		if !ok {
+			continue
+		}
+		ts, ok = strings.CutSuffix(ts, ".db")
+		if !ok {
+			continue
+		}
+		taken, err := time.Parse(snapshotTimeFormat, ts)
+		if err != nil {
+			continue
+		}
+		snaps = append(snaps, Snapshot{Path: filepath.Join(c.Dir, e.Name()), Taken: taken})
+	}
+
+	sort.Slice(snaps, func(i, j int) bool {
+		return snaps[i].Taken.Before(snaps[j].Taken)
+	})
+	return snaps, nil
+}
+
+// Prune deletes all but the newest Retain snapshots.
+func (c *SnapshotCatalog) Prune() error {
+	if c.Retain <= 0 {
+		return nil
+	}
+	snaps, err := c.List()
+	if err != nil {
+		return err
+	}
+	for len(snaps) > c.Retain {
+		if err := os.Remove(snaps[0].Path); err != nil {
+			return err
+		}
+		snaps = snaps[1:]
+	}
+	return nil
+}
+
+// OpenAsOf opens, read-only, the newest snapshot taken at or before t.
+// It returns an error wrapping ErrNoSnapshot if there is none.
+func (c *SnapshotCatalog) OpenAsOf(ctx context.Context, t time.Time) (*sql.DB, Snapshot, error) {
+	snaps, err := c.List()
+	if err != nil {
+		return nil, Snapshot{}, err
+	}
+
+	var found *Snapshot
+	for i := range snaps {
+		if snaps[i].Taken.After(t) {
+			break
+		}
+		found = &snaps[i]
+	}
+	if found == nil {
+		return nil, Snapshot{}, fmt.Errorf("%s: %w", t.Format(time.RFC3339), ErrNoSnapshot)
+	}
+
+	db, err := Open(ctx, Config{
+		Path:           hostPathStyle.uri(found.Path) + "?mode=ro&immutable=1",
+		SkipMigrations: true,
+		Driver:         c.Options.Driver,
+		Logger:         slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err != nil {
+		return nil, Snapshot{}, err
+	}
+	return db, *found, nil
+}
+
+// SnapshotTask returns a maintenance task that takes a snapshot into the
+// catalog on each run.
+func SnapshotTask(c *SnapshotCatalog, interval time.Duration) MaintenanceTask {
+	return MaintenanceTask{
+		Name:     "snapshot",
+		Interval: interval,
+		Run: func(ctx context.Context, db *sql.DB) error {
+			_, err := c.Take(ctx, db)
+			return err
+		},
+	}
+}
@@ -0,0 +1,78 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// CheckpointMode selects how hard Checkpoint tries; see SQLite's
+// documentation of wal_checkpoint.
+type CheckpointMode int
+
+const (
+	// CheckpointPassive copies as many frames as it can without waiting
+	// for readers or writers.
+	CheckpointPassive CheckpointMode = iota
+
+	// CheckpointFull waits for writers, then copies every frame.
+	CheckpointFull
+
+	// CheckpointRestart is CheckpointFull, and then waits for readers so
+	// the next writer starts the WAL from the beginning.
+	CheckpointRestart
+
+	// CheckpointTruncate is CheckpointRestart, and then truncates the WAL
+	// file to zero bytes. Use it before backups or at shutdown.
+	CheckpointTruncate
+)
+
+// String returns the mode's wal_checkpoint argument.
+func (m CheckpointMode) String() string {
+	switch m {
+	case CheckpointPassive:
+		return "PASSIVE"
+	case CheckpointFull:
+		return "FULL"
+	case CheckpointRestart:
+		return "RESTART"
+	case CheckpointTruncate:
+		return "TRUNCATE"
+	}
+	return fmt.Sprintf("CheckpointMode(%d)", int(m))
+}
+
+// CheckpointResult reports what Checkpoint did. Both counts are zero for a
+// database that isn't in WAL mode.
+type CheckpointResult struct {
+	// Busy is true if the checkpoint couldn't finish because of other
+	// connections, after waiting up to the busy timeout.
+	Busy bool
+
+	// Checkpointed is the number of WAL frames copied into the database.
+	Checkpointed int
+
+	// Remaining is the number of WAL frames not yet copied.
+	Remaining int
+}
+
+// Checkpoint copies frames from db's WAL into the database file, as mode
+// says.
+func Checkpoint(ctx context.Context, db *sql.DB, mode CheckpointMode) (CheckpointResult, error) {
+	if mode < CheckpointPassive || mode > CheckpointTruncate {
+		return CheckpointResult{}, fmt.Errorf("checkpoint: unknown mode %d", int(mode))
+	}
+	var busy, log, checkpointed int
+	err := db.QueryRowContext(withInternal(ctx), `PRAGMA wal_checkpoint(`+mode.String()+`)`).Scan(&busy, &log, &checkpointed)
+	if err != nil {
+		return CheckpointResult{}, fmt.Errorf("checkpoint: %w", err)
+	}
+	r := CheckpointResult{Busy: busy != 0}
+	if log >= 0 && checkpointed >= 0 {
+		r.Checkpointed = checkpointed
+		r.Remaining = log - checkpointed
+	}
+	return r, nil
+}
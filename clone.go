@@ -0,0 +1,58 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+)
+
+// Clone is Files{}.Clone: it checks both paths against DefaultPathPolicy.
+func Clone(ctx context.Context, srcPath, dstPath string) error {
+	return Files{}.Clone(ctx, srcPath, dstPath)
+}
+
+// Clone copies the database at srcPath to dstPath. The source may be open
+// and in use elsewhere: it is opened read-only and copied with Backup,
+// which takes a consistent snapshot including anything still in its WAL.
+// Both paths are checked against f.PathPolicy, like Move, and Clone
+// refuses to overwrite an existing destination. The copy is verified with
+// PRAGMA quick_check before it is put in place.
+func (f Files) Clone(ctx context.Context, srcPath, dstPath string) error {
+	if isMemoryPath(srcPath) || isMemoryPath(dstPath) || isRemotePath(srcPath) || isRemotePath(dstPath) {
+		return fmt.Errorf("Clone requires local persistent paths")
+	}
+	srcPath, dstPath = filePathOf(srcPath), filePathOf(dstPath)
+	policy := orDefaultPolicy(f.PathPolicy)
+	if err := validatePersistentPath(srcPath, policy); err != nil {
+		return err
+	}
+	if err := validatePersistentPath(dstPath, policy); err != nil {
+		return err
+	}
+	if !fileExists(srcPath) {
+		return fmt.Errorf("%s: database file %w", srcPath, ErrNotFound)
+	}
+	if fileExists(dstPath) || hostPathStyle.same(srcPath, dstPath) {
+		return fmt.Errorf("%s: file %w", dstPath, ErrAlreadyExists)
+	}
+
+	src, err := Open(ctx, Config{
+		Path:           hostPathStyle.uri(srcPath) + "?mode=ro",
+		PathPolicy:     policy,
+		SkipMigrations: true,
+		DetectDrift:    DriftIgnore,
+		Logger:         slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err != nil {
+		return fmt.Errorf("clone: open %s: %w", srcPath, err)
+	}
+	defer src.Close()
+
+	if err := Backup(ctx, src, dstPath, BackupOptions{Verify: true, PathPolicy: policy}); err != nil {
+		return fmt.Errorf("clone: %w", err)
+	}
+	return src.Close()
+}
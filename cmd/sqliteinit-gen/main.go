@@ -0,0 +1,69 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+// Command sqliteinit-gen generates Go constants (and optionally row
+// structs) for the tables and columns created by a directory of sqliteinit
+// migrations. It applies the migrations to an in-memory database and
+// introspects the result.
+//
+// Usage:
+//
+//	//go:generate go run github.com/mdhender/sqliteinit/cmd/sqliteinit-gen -migrations migrations -pkg store -o schema_gen.go
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/mdhender/sqliteinit"
+	_ "modernc.org/sqlite"
+)
+
+func main() {
+	migrations := flag.String("migrations", "migrations", "directory containing migration scripts")
+	pkg := flag.String("pkg", "", "package name for the generated file (required)")
+	out := flag.String("o", "", "output file (default: stdout)")
+	structs := flag.Bool("structs", false, "also generate a row struct per table")
+	internal := flag.Bool("internal", false, "include the schema_migrations and config tables")
+	flag.Parse()
+
+	if err := run(*migrations, *pkg, *out, *structs, *internal); err != nil {
+		fmt.Fprintf(os.Stderr, "sqliteinit-gen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(migrations, pkg, out string, structs, internal bool) error {
+	ctx := context.Background()
+
+	db, err := sqliteinit.Open(ctx, sqliteinit.Config{
+		Path:                    ":memory:",
+		Migrations:              os.DirFS(migrations),
+		Logger:                  slog.New(slog.NewTextHandler(io.Discard, nil)),
+		AllowMemoryInProduction: true,
+	})
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	var buf bytes.Buffer
+	err = sqliteinit.GenerateGo(ctx, db, &buf, sqliteinit.CodegenOptions{
+		Package:         pkg,
+		Structs:         structs,
+		IncludeInternal: internal,
+	})
+	if err != nil {
+		return err
+	}
+
+	if out == "" {
+		_, err = os.Stdout.Write(buf.Bytes())
+		return err
+	}
+	return os.WriteFile(out, buf.Bytes(), 0o644)
+}
@@ -0,0 +1,193 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"go/format"
+	"io"
+	"slices"
+	"strings"
+)
+
+// CodegenOptions controls GenerateGo.
+type CodegenOptions struct {
+	// Package is the package name for the generated file. Required.
+	Package string
+
+	// Structs also emits a row struct per table with db-tagged fields.
+	Structs bool
+
+	// IncludeInternal includes the package's schema_migrations, config,
+	// table_access, flags and seed_runs tables.
+	IncludeInternal bool
+}
+
+// GenerateGo writes a Go source file to w with constants for the names of
+// every table and column in db, and optionally a row struct per table.
+// Run it against a database that has been migrated (an in-memory database
+// opened with the application's migrations works well) so application code
+// stays in sync with what the migrations actually created.
+//
+// For table "user_roles" with column "role_id" it emits:
+//
+//	const TableUserRoles = "user_roles"
+//	const UserRolesRoleID = "role_id"
+func GenerateGo(ctx context.Context, db *sql.DB, w io.Writer, opts CodegenOptions) error {
+	if opts.Package == "" {
+		return fmt.Errorf("codegen: package name is required")
+	}
+
+	tables, err := userTables(ctx, db, opts.IncludeInternal)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("// Table names.\nconst (\n")
+	for _, t := range tables {
+		fmt.Fprintf(&buf, "\tTable%s = %q\n", goIdent(t), t)
+	}
+	buf.WriteString(")\n")
+
+	for _, t := range tables {
+		cols, err := tableColumns(ctx, db, t)
+		if err != nil {
+			return err
+		}
+		tident := goIdent(t)
+
+		fmt.Fprintf(&buf, "\n// Columns of table %s.\nconst (\n", t)
+		for _, c := range cols {
+			fmt.Fprintf(&buf, "\t%s%s = %q\n", tident, goIdent(c.Name), c.Name)
+		}
+		buf.WriteString(")\n")
+
+		if opts.Structs {
+			fmt.Fprintf(&buf, "\n// %sRow is a row of table %s.\ntype %sRow struct {\n", tident, t, tident)
+			for _, c := range cols {
+				fmt.Fprintf(&buf, "\t%s %s `db:%q`", goIdent(c.Name), goFieldType(c), c.Name)
+				if c.Generated != "" {
+					// Generated columns can be read but not written.
+					fmt.Fprintf(&buf, " // generated (%s)", strings.ToLower(c.Generated))
+				}
+				buf.WriteString("\n")
+			}
+			buf.WriteString("}\n")
+		}
+	}
+
+	var file bytes.Buffer
+	fmt.Fprintf(&file, "// Code generated by sqliteinit. DO NOT EDIT.\n\npackage %s\n\n", opts.Package)
+	if bytes.Contains(buf.Bytes(), []byte(" sql.Null")) {
+		file.WriteString("import \"database/sql\"\n\n")
+	}
+	file.Write(buf.Bytes())
+
+	src, err := format.Source(file.Bytes())
+	if err != nil {
+		return fmt.Errorf("codegen: format: %w", err)
+	}
+	_, err = w.Write(src)
+	return err
+}
+
+// userTables returns the names of the tables in db, sorted by name.
+func userTables(ctx context.Context, db *sql.DB, includeInternal bool) ([]string, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT name FROM sqlite_master
+		WHERE type = 'table' AND name NOT LIKE 'sqlite\_%' ESCAPE '\'
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("list tables: %w", err)
+	}
+	defer rows.Close()
+
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		if !includeInternal && isInternalTable(name) {
+			continue
+		}
+		tables = append(tables, name)
+	}
+	return tables, rows.Err()
+}
+
+// internalTables are the tables owned by this package.
+var internalTables = []string{"schema_migrations", "config", "table_access", "flags", "seed_runs"}
+
+// isInternalTable returns true for tables owned by this package.
+func isInternalTable(name string) bool {
+	return slices.Contains(internalTables, name)
+}
+
+// commonInitialisms are rendered in upper case in Go identifiers.
+var commonInitialisms = map[string]bool{
+	"API": true, "CSV": true, "DB": true, "HTML": true, "HTTP": true, "ID": true,
+	"IP": true, "JSON": true, "SQL": true, "URI": true, "URL": true, "UUID": true,
+}
+
+// goIdent converts a snake_case SQL name to an exported CamelCase Go
+// identifier.
+func goIdent(name string) string {
+	var sb strings.Builder
+	for _, part := range strings.FieldsFunc(name, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	}) {
+		if up := strings.ToUpper(part); commonInitialisms[up] {
+			sb.WriteString(up)
+			continue
+		}
+		sb.WriteString(strings.ToUpper(part[:1]))
+		sb.WriteString(part[1:])
+	}
+	ident := sb.String()
+	if ident == "" || ident[0] >= '0' && ident[0] <= '9' {
+		ident = "X" + ident
+	}
+	return ident
+}
+
+// goFieldType returns the Go type used for a column in a generated struct.
+func goFieldType(c columnInfo) string {
+	switch c.affinity() {
+	case affinityInteger:
+		if c.NotNull {
+			return "int64"
+		}
+		return "sql.NullInt64"
+	case affinityReal:
+		if c.NotNull {
+			return "float64"
+		}
+		return "sql.NullFloat64"
+	case affinityText:
+		if c.NotNull {
+			return "string"
+		}
+		return "sql.NullString"
+	case affinityBlob:
+		if c.Type == "" || c.isAny() {
+			return "any"
+		}
+		return "[]byte"
+	}
+	if strings.HasPrefix(strings.ToUpper(c.Type), "BOOL") {
+		if c.NotNull {
+			return "bool"
+		}
+		return "sql.NullBool"
+	}
+	if c.NotNull {
+		return "float64"
+	}
+	return "sql.NullFloat64"
+}
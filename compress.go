@@ -0,0 +1,187 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Compression selects how a backup file is compressed. Backup and Restore
+// choose it from the file name: ".db.gz" is gzip and ".db.zst" is
+// Zstandard. SQLite files usually compress to a fraction of their size.
+//
+// Gzip is built in. Zstandard needs a codec registered with
+// RegisterCompression, which importing the separate
+// github.com/mdhender/sqliteinit/sqliteinitzstd module does.
+type Compression int
+
+const (
+	// CompressionNone writes a plain SQLite file, ending in ".db".
+	CompressionNone Compression = iota
+
+	// CompressionGzip writes a gzip stream, ending in ".db.gz".
+	CompressionGzip
+
+	// CompressionZstd writes a Zstandard stream, ending in ".db.zst". It
+	// compresses better and faster than gzip.
+	CompressionZstd
+)
+
+// Compressor returns a writer that compresses what is written to it into
+// w. Closing the writer flushes it but must not close w.
+type Compressor func(w io.Writer) (io.WriteCloser, error)
+
+// Decompressor returns a reader that decompresses r.
+type Decompressor func(r io.Reader) (io.ReadCloser, error)
+
+type codec struct {
+	compress   Compressor
+	decompress Decompressor
+}
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = map[Compression]codec{
+		CompressionGzip: {
+			compress:   func(w io.Writer) (io.WriteCloser, error) { return gzip.NewWriter(w), nil },
+			decompress: func(r io.Reader) (io.ReadCloser, error) { return gzip.NewReader(r) },
+		},
+	}
+)
+
+// RegisterCompression makes compression c available to Backup, Restore and
+// BackupRotate, usually from the init function of a codec package. It
+// panics if c is CompressionNone or already registered, or if either
+// function is nil.
+func RegisterCompression(c Compression, compress Compressor, decompress Decompressor) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	if c == CompressionNone || compress == nil || decompress == nil {
+		panic("sqliteinit: RegisterCompression: invalid codec for " + c.Ext())
+	}
+	if _, ok := codecs[c]; ok {
+		panic("sqliteinit: RegisterCompression: " + c.Ext() + " already registered")
+	}
+	codecs[c] = codec{compress: compress, decompress: decompress}
+}
+
+// codecFor returns the codec registered for c.
+func codecFor(c Compression) (codec, error) {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	if cd, ok := codecs[c]; ok {
+		return cd, nil
+	}
+	if c == CompressionZstd {
+		return codec{}, fmt.Errorf("no codec registered for %s; import github.com/mdhender/sqliteinit/sqliteinitzstd", c.Ext())
+	}
+	return codec{}, fmt.Errorf("no codec registered for %s", c.Ext())
+}
+
+// checkCompression returns an error if c is neither CompressionNone nor
+// registered, so that a backup fails before any work is done.
+func checkCompression(c Compression) error {
+	if c == CompressionNone {
+		return nil
+	}
+	_, err := codecFor(c)
+	return err
+}
+
+// Ext returns the file name extension of a backup compressed with c.
+func (c Compression) Ext() string {
+	switch c {
+	case CompressionGzip:
+		return ".db.gz"
+	case CompressionZstd:
+		return ".db.zst"
+	}
+	return ".db"
+}
+
+// compressionOf returns the compression path's name calls for, and path
+// without the compression suffix.
+func compressionOf(path string) (Compression, string) {
+	for _, c := range []Compression{CompressionGzip, CompressionZstd} {
+		if base, ok := strings.CutSuffix(path, c.Ext()); ok {
+			return c, base + ".db"
+		}
+	}
+	return CompressionNone, path
+}
+
+// compressFile writes the file at from, compressed with c, to a new file
+// at to.
+func compressFile(c Compression, from, to string) error {
+	src, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(to, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	if err := compressTo(c, dst, src); err != nil {
+		return err
+	}
+	return dst.Close()
+}
+
+// compressTo writes r to w compressed with c.
+func compressTo(c Compression, w io.Writer, r io.Reader) error {
+	if c == CompressionNone {
+		_, err := io.Copy(w, r)
+		return err
+	}
+	cd, err := codecFor(c)
+	if err != nil {
+		return err
+	}
+	zw, err := cd.compress(w)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(zw, r); err != nil {
+		zw.Close()
+		return err
+	}
+	return zw.Close()
+}
+
+// decompressFile writes the file at from, compressed with c, uncompressed
+// to a new file at to.
+func decompressFile(c Compression, from, to string) error {
+	cd, err := codecFor(c)
+	if err != nil {
+		return err
+	}
+	src, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	zr, err := cd.decompress(src)
+	if err != nil {
+		return err
+	}
+	defer zr.Close()
+
+	dst, err := os.OpenFile(to, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, zr); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
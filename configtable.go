@@ -0,0 +1,129 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// reservedConfigPrefixes are the config key prefixes owned by the package.
+// Applications may read these keys but not change them.
+var reservedConfigPrefixes = []string{"schema.", "app.", "db.", "audit."}
+
+// isReservedConfigKey returns true if key is owned by the package.
+func isReservedConfigKey(key string) bool {
+	for _, p := range reservedConfigPrefixes {
+		if strings.HasPrefix(key, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkConfigKey returns an error if the application may not set or
+// delete key.
+func checkConfigKey(key string) error {
+	if key == "" {
+		return fmt.Errorf("config: key is required")
+	}
+	if isReservedConfigKey(key) {
+		return fmt.Errorf("config: key %q is reserved (schema.*, app.*, db.* and audit.* belong to sqliteinit)", key)
+	}
+	return nil
+}
+
+// GetConfig returns the value of key in the config table, or an error
+// wrapping ErrNotFound if it isn't set. Reserved keys such as
+// "schema.version" can be read.
+func GetConfig(ctx context.Context, db *sql.DB, key string) (string, error) {
+	var value string
+	err := db.QueryRowContext(withInternal(ctx), `SELECT value FROM config WHERE key = ?`, key).Scan(&value)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("config %q: %w", key, ErrNotFound)
+	}
+	if err != nil {
+		return "", fmt.Errorf("config %q: %w", key, err)
+	}
+	return value, nil
+}
+
+// SetConfig sets key to value in the config table, creating the key if
+// needed. Keys starting with "schema.", "app.", "db." or "audit." are
+// reserved for the package and are rejected.
+func SetConfig(ctx context.Context, db *sql.DB, key, value string) error {
+	if err := checkConfigKey(key); err != nil {
+		return err
+	}
+	ts := time.Now().UTC().Unix()
+	_, err := db.ExecContext(withInternal(ctx), `
+		INSERT INTO config (key, value, created_at, updated_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (key) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at
+	`, key, value, ts, ts)
+	if err != nil {
+		return fmt.Errorf("config %q: %w", key, err)
+	}
+	return nil
+}
+
+// DeleteConfig removes key from the config table. Deleting a key that
+// isn't set is not an error. Reserved keys are rejected as for SetConfig.
+func DeleteConfig(ctx context.Context, db *sql.DB, key string) error {
+	if err := checkConfigKey(key); err != nil {
+		return err
+	}
+	if _, err := db.ExecContext(withInternal(ctx), `DELETE FROM config WHERE key = ?`, key); err != nil {
+		return fmt.Errorf("config %q: %w", key, err)
+	}
+	return nil
+}
+
+// ConfigValue is the set of types GetConfigAs can parse config values as.
+type ConfigValue interface {
+	int | bool | string | time.Time
+}
+
+// GetConfigAs returns the value of key parsed as T:
+//
+//   - int: a base-10 integer
+//   - bool: as strconv.ParseBool ("1", "true", "0", "false", ...)
+//   - string: the value as stored
+//   - time.Time: Unix seconds, as the package stores timestamps, or RFC 3339
+//
+// If key isn't set, it returns T's zero value and an error wrapping
+// ErrNotFound. For types other than string an empty value counts as not
+// set, since the package leaves its own keys empty until they are known.
+func GetConfigAs[T ConfigValue](ctx context.Context, db *sql.DB, key string) (T, error) {
+	var result T
+	value, err := GetConfig(ctx, db, key)
+	if err != nil {
+		return result, err
+	}
+	if _, ok := any(result).(string); !ok && value == "" {
+		return result, fmt.Errorf("config %q: empty: %w", key, ErrNotFound)
+	}
+
+	switch p := any(&result).(type) {
+	case *int:
+		*p, err = strconv.Atoi(value)
+	case *bool:
+		*p, err = strconv.ParseBool(value)
+	case *string:
+		*p = value
+	case *time.Time:
+		if secs, perr := strconv.ParseInt(value, 10, 64); perr == nil {
+			*p = time.Unix(secs, 0).UTC()
+		} else {
+			*p, err = time.Parse(time.RFC3339, value)
+		}
+	}
+	if err != nil {
+		var zero T
+		return zero, fmt.Errorf("config %q: %w", key, err)
+	}
+	return result, nil
+}
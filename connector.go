@@ -0,0 +1,150 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+)
+
+// openDB opens the database handle for dsn. When the configuration needs
+// per-connection behavior (encryption keys, post pragmas the driver
+// couldn't put in dsn, extensions, functions, attached databases, access
+// auditing, DevStrict checks, query timeouts) or a CloseEvent, the
+// registered driver is wrapped in a chain of connectors; otherwise
+// sql.Open is used directly. readOnly marks handles that must not write,
+// for DevStrict.
+func openDB(dsn string, post []Pragma, cfg Config, readOnly bool) (*sql.DB, error) {
+	var keyPragmas []Pragma
+	if cfg.EncryptionKey != "" {
+		kd, ok := cfg.driver().(KeyedDriver)
+		if !ok {
+			return nil, fmt.Errorf("driver %q does not support encryption", cfg.driver().Name())
+		}
+		keyPragmas = kd.KeyPragmas(cfg.EncryptionKey)
+	}
+
+	// Drivers that register functions process-wide need no connector.
+	perConnFuncs := false
+	if len(cfg.Functions) != 0 {
+		for _, f := range cfg.Functions {
+			if err := f.validate(); err != nil {
+				return nil, err
+			}
+		}
+		global := false
+		if registerGlobalFuncs != nil {
+			var err error
+			if global, err = registerGlobalFuncs(cfg.driver().Name(), cfg.Functions); err != nil {
+				return nil, err
+			}
+		}
+		perConnFuncs = !global
+	}
+
+	if err := validateAttach(cfg); err != nil {
+		return nil, err
+	}
+
+	if cfg.QueryTimeout <= 0 && keyPragmas == nil && len(post) == 0 && len(cfg.Extensions) == 0 && !perConnFuncs &&
+		len(cfg.Attach) == 0 && cfg.Audit == nil && !cfg.DevStrict && cfg.closeNotifier == nil {
+		return sql.Open(cfg.driver().Name(), dsn)
+	}
+
+	// Look up the registered driver so it can be wrapped.
+	probe, err := sql.Open(cfg.driver().Name(), dsn)
+	if err != nil {
+		return nil, err
+	}
+	drv := probe.Driver()
+	probe.Close()
+
+	var c driver.Connector = &dsnConnector{drv: drv, dsn: dsn}
+	if keyPragmas != nil {
+		c = &keyConnector{next: c, pragmas: keyPragmas}
+	}
+	if len(post) != 0 {
+		c = &pragmaConnector{next: c, pragmas: post}
+	}
+	if len(cfg.Extensions) != 0 {
+		c = &extensionConnector{next: c, driverName: cfg.driver().Name(), extensions: cfg.Extensions}
+	}
+	if perConnFuncs {
+		c = &funcConnector{next: c, driverName: cfg.driver().Name(), fns: cfg.Functions}
+	}
+	if len(cfg.Attach) != 0 {
+		c = &attachConnector{next: c, attach: cfg.Attach}
+	}
+	if cfg.Audit != nil {
+		c = &auditConnector{next: c, audit: cfg.Audit}
+	}
+	if cfg.DevStrict {
+		c = &strictConnector{next: c, readOnly: readOnly}
+	}
+	if cfg.QueryTimeout > 0 {
+		c = &timeoutConnector{next: c, timeout: cfg.QueryTimeout}
+	}
+	if cfg.closeNotifier != nil {
+		c = &closeConnector{next: c, notifier: cfg.closeNotifier}
+	}
+	return sql.OpenDB(c), nil
+}
+
+// dsnConnector opens connections from a driver and DSN. It is the base of
+// the connector chain built by openDB.
+type dsnConnector struct {
+	drv driver.Driver
+	dsn string
+}
+
+func (c *dsnConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return c.drv.Open(c.dsn)
+}
+
+func (c *dsnConnector) Driver() driver.Driver {
+	return c.drv
+}
+
+// pragmaConnector runs pragmas on every new connection, after any
+// encryption key, so that each connection of a pool gets them.
+type pragmaConnector struct {
+	next    driver.Connector
+	pragmas []Pragma
+}
+
+func (c *pragmaConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.next.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range c.pragmas {
+		if err := execConn(ctx, conn, fmt.Sprintf(`PRAGMA %s = %s`, p.Name, p.Value)); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("pragma %s: %w", p.Name, err)
+		}
+	}
+	return conn, nil
+}
+
+func (c *pragmaConnector) Driver() driver.Driver {
+	return c.next.Driver()
+}
+
+// execConn executes a statement directly on a driver connection.
+func execConn(ctx context.Context, conn driver.Conn, query string) error {
+	if execer, ok := conn.(driver.ExecerContext); ok {
+		_, err := execer.ExecContext(ctx, query, nil)
+		if err != driver.ErrSkip {
+			return err
+		}
+	}
+	stmt, err := conn.Prepare(query)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	_, err = stmt.Exec(nil)
+	return err
+}
@@ -0,0 +1,240 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// CopyOptions controls CopyData.
+type CopyOptions struct {
+	// Where restricts the rows copied from a table to those matching an SQL
+	// condition, e.g. {"orders": "tenant_id = 7"}. It is spliced into the
+	// query as is, so it must be trusted. Tables without an entry are
+	// copied whole.
+	Where map[string]string
+
+	// Truncate deletes the rows of the tables in dst before copying.
+	Truncate bool
+}
+
+// CopyReport summarizes a CopyData run.
+type CopyReport struct {
+	// Tables lists the tables in the order they were copied, parents before
+	// the tables that refer to them.
+	Tables []string
+
+	// Rows is the number of rows copied into each table.
+	Rows map[string]int64
+}
+
+// CopyData copies the rows of tables from src to dst, which must be
+// different databases, e.g. to split a database into per-tenant files or to
+// fill a freshly migrated schema from an old one. A nil tables copies every
+// table of src except virtual tables. Tables are copied parents first,
+// following dst's foreign keys, and only the columns that exist in both
+// databases are copied; dst's generated columns are left to compute
+// themselves.
+//
+// src is read in a single read transaction and rows are streamed into a
+// single transaction on dst with foreign key checks deferred to its commit,
+// so dst gets every table or none, and self-referencing or cyclic foreign
+// keys work. A table missing from either database is an error wrapping
+// ErrNotFound.
+func CopyData(ctx context.Context, src, dst *sql.DB, tables []string, opts CopyOptions) (*CopyReport, error) {
+	ctx = withInternal(ctx)
+	if src == dst {
+		return nil, fmt.Errorf("copy data: src and dst are the same database")
+	}
+	if tables == nil {
+		all, err := Tables(ctx, src)
+		if err != nil {
+			return nil, fmt.Errorf("copy data: %w", err)
+		}
+		for _, t := range all {
+			if !t.Virtual {
+				tables = append(tables, t.Name)
+			}
+		}
+	}
+
+	columns := make(map[string][]string, len(tables))
+	for _, table := range tables {
+		cols, err := copyColumns(ctx, src, dst, table)
+		if err != nil {
+			return nil, fmt.Errorf("copy data: %w", err)
+		}
+		columns[table] = cols
+	}
+	order, err := parentsFirst(ctx, dst, tables)
+	if err != nil {
+		return nil, fmt.Errorf("copy data: %w", err)
+	}
+
+	rtx, err := src.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, fmt.Errorf("copy data: %w", err)
+	}
+	defer rtx.Rollback()
+	wtx, err := dst.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("copy data: %w", err)
+	}
+	defer wtx.Rollback()
+	if _, err := wtx.ExecContext(ctx, `PRAGMA defer_foreign_keys = ON`); err != nil {
+		return nil, fmt.Errorf("copy data: %w", err)
+	}
+	if opts.Truncate {
+		for _, table := range slices.Backward(order) {
+			if _, err := wtx.ExecContext(ctx, `DELETE FROM `+quoteIdent(table)); err != nil {
+				return nil, fmt.Errorf("copy data: truncate %s: %w", table, err)
+			}
+		}
+	}
+
+	report := &CopyReport{Tables: order, Rows: make(map[string]int64, len(order))}
+	for _, table := range order {
+		n, err := copyRows(ctx, rtx, wtx, table, columns[table], opts.Where[table])
+		if err != nil {
+			return nil, fmt.Errorf("copy data: %s: %w", table, err)
+		}
+		report.Rows[table] = n
+	}
+	if err := wtx.Commit(); err != nil {
+		return nil, fmt.Errorf("copy data: commit: %w", err)
+	}
+	return report, nil
+}
+
+// copyColumns returns the columns of table that dst can be given and src
+// has, in dst's declaration order.
+func copyColumns(ctx context.Context, src, dst *sql.DB, table string) ([]string, error) {
+	srcCols, err := Columns(ctx, src, table)
+	if err != nil {
+		return nil, fmt.Errorf("source: %w", err)
+	}
+	dstCols, err := Columns(ctx, dst, table)
+	if err != nil {
+		return nil, fmt.Errorf("destination: %w", err)
+	}
+	has := make(map[string]bool, len(srcCols))
+	for _, c := range srcCols {
+		has[strings.ToLower(c.Name)] = true
+	}
+	var names []string
+	for _, c := range dstCols {
+		if c.Generated == "" && has[strings.ToLower(c.Name)] {
+			names = append(names, c.Name)
+		}
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("%s: no columns in common", table)
+	}
+	return names, nil
+}
+
+// parentsFirst orders tables so that each comes after the tables its
+// foreign keys in db refer to. Ties are broken by name, and tables in a
+// foreign key cycle come last, by name.
+func parentsFirst(ctx context.Context, db *sql.DB, tables []string) ([]string, error) {
+	names := slices.Clone(tables)
+	slices.Sort(names)
+	byName := make(map[string]string, len(names))
+	for _, t := range names {
+		byName[strings.ToLower(t)] = t
+	}
+	parents := make(map[string]map[string]bool, len(names))
+	for _, t := range names {
+		keys, err := foreignKeys(ctx, db, t)
+		if err != nil {
+			return nil, fmt.Errorf("%s: foreign keys: %w", t, err)
+		}
+		parents[t] = make(map[string]bool)
+		for _, k := range keys {
+			if p, ok := byName[strings.ToLower(k.Parent)]; ok && p != t {
+				parents[t][p] = true
+			}
+		}
+	}
+
+	var order []string
+	done := make(map[string]bool, len(names))
+	for len(order) < len(names) {
+		progress := false
+		for _, t := range names {
+			if done[t] {
+				continue
+			}
+			ready := true
+			for p := range parents[t] {
+				if !done[p] {
+					ready = false
+					break
+				}
+			}
+			if ready {
+				order = append(order, t)
+				done[t] = true
+				progress = true
+			}
+		}
+		if !progress {
+			for _, t := range names {
+				if !done[t] {
+					order = append(order, t)
+				}
+			}
+			break
+		}
+	}
+	return order, nil
+}
+
+// copyRows streams the rows of table matching where from rtx into wtx.
+func copyRows(ctx context.Context, rtx, wtx *sql.Tx, table string, columns []string, where string) (int64, error) {
+	quoted := make([]string, len(columns))
+	selected := make([]string, len(columns))
+	for i, c := range columns {
+		quoted[i] = quoteIdent(c)
+		// A unary plus hides the declared type, so drivers hand back the
+		// stored value instead of converting e.g. DATETIME text to
+		// time.Time and writing it back in another format.
+		selected[i] = "+" + quoted[i]
+	}
+	list := strings.Join(quoted, ", ")
+	query := `SELECT ` + strings.Join(selected, ", ") + ` FROM ` + quoteIdent(table)
+	if where != "" {
+		query += ` WHERE ` + where
+	}
+	stmt, err := wtx.PrepareContext(ctx, `INSERT INTO `+quoteIdent(table)+` (`+list+`) VALUES (?`+strings.Repeat(", ?", len(columns)-1)+`)`)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+	rows, err := rtx.QueryContext(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	values := make([]any, len(columns))
+	dest := make([]any, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	var n int64
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return n, err
+		}
+		if _, err := stmt.ExecContext(ctx, values...); err != nil {
+			return n, fmt.Errorf("row %d: %w", n+1, err)
+		}
+		n++
+	}
+	return n, rows.Err()
+}
@@ -0,0 +1,334 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrDBClosed is returned when using a DB after Close.
+var ErrDBClosed = errors.New("database closed")
+
+// DB is a database handle that splits traffic between a single-connection
+// writer and a multi-connection read pool over the same file. This is the
+// usual way to get concurrent reads from SQLite while keeping writes
+// serialized.
+//
+// For in-memory databases the reader and writer share one handle, since a
+// separate pool would not see the same data. Remote databases also share
+// one handle; the server handles concurrency.
+//
+// With Config.IdleClose set, the handles are closed while idle and
+// reopened on demand; use ReadTx and WriteTx rather than Writer and Reader
+// directly in that mode.
+type DB struct {
+	// Writer is the single-connection handle used for all writes.
+	Writer *sql.DB
+
+	// Reader is the read-only connection pool. It may be the same handle
+	// as Writer.
+	Reader *sql.DB
+
+	cfg           Config
+	pragmas       []Pragma
+	schemaVersion int
+	applied       []AppliedMigration
+
+	mu      sync.Mutex
+	active  int
+	lastUse time.Time
+	idle    bool
+	closed  bool
+	timer   *time.Timer
+}
+
+// OpenDB opens a database like Open and returns a reader/writer split
+// handle. Migrations are applied through the writer before the read pool
+// is opened.
+func OpenDB(ctx context.Context, cfg Config) (*DB, error) {
+	cfg = cfg.defaults()
+	if cfg.IdleClose > 0 && (cfg.isMemory() || cfg.isRemote()) {
+		return nil, fmt.Errorf("IdleClose requires a local persistent database")
+	}
+	cfg, err := applySymlinkPolicy(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	writer, err := Open(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	db := &DB{Writer: writer, Reader: writer, cfg: cfg}
+	if err := db.loadMetadata(ctx); err != nil {
+		writer.Close()
+		return nil, err
+	}
+
+	if cfg.isMemory() || cfg.isRemote() {
+		return db, nil
+	}
+
+	db.Reader, err = openReader(ctx, cfg)
+	if err != nil {
+		writer.Close()
+		return nil, fmt.Errorf("open read pool: %w", err)
+	}
+
+	if cfg.IdleClose > 0 {
+		db.lastUse = time.Now()
+		db.timer = time.AfterFunc(cfg.IdleClose, db.closeIfIdle)
+	}
+	return db, nil
+}
+
+// acquire marks the handles in use, reopening them if they were closed for
+// idleness. The caller must call release when done.
+func (db *DB) acquire(ctx context.Context) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if db.closed {
+		return ErrDBClosed
+	}
+	if db.idle {
+		if err := db.reopen(ctx); err != nil {
+			return fmt.Errorf("reopen: %w", err)
+		}
+		db.idle = false
+		db.cfg.Logger.Debug("database reopened", "path", db.cfg.Path)
+	}
+	db.active++
+	return nil
+}
+
+// release marks the end of a use started by acquire.
+func (db *DB) release() {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	db.active--
+	db.lastUse = time.Now()
+	if db.active == 0 && db.timer != nil && !db.closed {
+		db.timer.Reset(db.cfg.IdleClose)
+	}
+}
+
+// reopen opens the writer and read pool again after an idle close.
+// Migrations were applied on the first open and are not re-run.
+func (db *DB) reopen(ctx context.Context) error {
+	cfg := db.cfg
+	cfg.SkipMigrations = true
+
+	writer, err := Open(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	reader, err := openReader(ctx, cfg)
+	if err != nil {
+		writer.Close()
+		return fmt.Errorf("open read pool: %w", err)
+	}
+	db.Writer, db.Reader = writer, reader
+	return nil
+}
+
+// closeIfIdle checkpoints and closes the handles if the database has not
+// been used for IdleClose.
+func (db *DB) closeIfIdle() {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if db.closed || db.idle || db.active > 0 {
+		return
+	}
+	if wait := db.cfg.IdleClose - time.Since(db.lastUse); wait > 0 {
+		db.timer.Reset(wait)
+		return
+	}
+
+	if _, err := db.Writer.ExecContext(withInternal(context.Background()), `PRAGMA wal_checkpoint(TRUNCATE)`); err != nil {
+		db.cfg.Logger.Warn("idle checkpoint failed", "path", db.cfg.Path, "error", err)
+	}
+	if err := db.closeHandles(); err != nil {
+		db.cfg.Logger.Warn("idle close failed", "path", db.cfg.Path, "error", err)
+	}
+	db.idle = true
+	db.cfg.Logger.Debug("database closed while idle", "path", db.cfg.Path)
+}
+
+// Idle returns true if the database file is currently closed because the
+// DB has been idle for Config.IdleClose.
+func (db *DB) Idle() bool {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return db.idle
+}
+
+// loadMetadata records the resolved pragmas and schema state after open.
+func (db *DB) loadMetadata(ctx context.Context) error {
+	pragmas, err := withExtraPragmas(db.cfg.builtinPragmas(), db.cfg.ExtraPragmas)
+	if err != nil {
+		return err
+	}
+	db.pragmas = pragmas
+
+	ctx = withInternal(ctx)
+	version, err := fetchSchemaVersion(ctx, db.Writer)
+	if err != nil {
+		return fmt.Errorf("fetch schema version: %w", err)
+	}
+	if version != nil {
+		db.schemaVersion = *version
+	}
+	db.applied, err = fetchAppliedMigrations(ctx, db.Writer)
+	if err != nil {
+		return fmt.Errorf("fetch applied migrations: %w", err)
+	}
+	return nil
+}
+
+// Config returns the configuration the database was opened with, with
+// defaults applied.
+func (db *DB) Config() Config {
+	return db.cfg
+}
+
+// Path returns the database path.
+func (db *DB) Path() string {
+	return db.cfg.Path
+}
+
+// DriverName returns the database/sql driver name in use.
+func (db *DB) DriverName() string {
+	return db.cfg.driver().Name()
+}
+
+// Pragmas returns the pragmas applied to the writer connection.
+func (db *DB) Pragmas() []Pragma {
+	return append([]Pragma(nil), db.pragmas...)
+}
+
+// SchemaVersion returns the schema version recorded when the database was
+// opened.
+func (db *DB) SchemaVersion() int {
+	return db.schemaVersion
+}
+
+// Applied returns the migrations that had been applied when the database
+// was opened.
+func (db *DB) Applied() []AppliedMigration {
+	return append([]AppliedMigration(nil), db.applied...)
+}
+
+// Status returns the current migration status, querying the database.
+func (db *DB) Status(ctx context.Context) (*MigrationStatus, error) {
+	if err := db.acquire(ctx); err != nil {
+		return nil, err
+	}
+	defer db.release()
+	return getStatus(withInternal(ctx), db.Writer, db.cfg)
+}
+
+// openReader opens a read-only connection pool on a persistent database.
+func openReader(ctx context.Context, cfg Config) (*sql.DB, error) {
+	pragmas, err := withExtraPragmas(append(cfg.builtinPragmas(), readOnlyPragma), cfg.ExtraPragmas)
+	if err != nil {
+		return nil, err
+	}
+
+	dsn, postPragmas := cfg.driver().BuildDSN(cfg.Path, pragmas)
+	db, err := openDB(dsn, postPragmas, cfg, true)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(cfg.ReadConns)
+	db.SetMaxIdleConns(cfg.ReadConns)
+
+	ctx = withInternal(ctx)
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("ping: %w", err)
+	}
+	return db, nil
+}
+
+// ReadTx runs fn in a read-only transaction on the read pool.
+// The transaction is always rolled back.
+func (db *DB) ReadTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	if err := db.acquire(ctx); err != nil {
+		return err
+	}
+	defer db.release()
+
+	tx, err := db.Reader.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	return fn(tx)
+}
+
+// WriteTx runs fn in a transaction on the writer. The transaction is
+// committed if fn returns nil and rolled back otherwise. With Config.Gate
+// set, it first enters the gate, so it waits (or fails with
+// ErrGateClosed) while the gate is paused.
+func (db *DB) WriteTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	if db.cfg.Gate != nil {
+		release, err := db.cfg.Gate.Enter(ctx)
+		if err != nil {
+			return err
+		}
+		defer release()
+	}
+	if err := db.acquire(ctx); err != nil {
+		return err
+	}
+	defer db.release()
+
+	tx, err := db.Writer.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// Close closes the read pool and the writer.
+func (db *DB) Close() error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	if db.closed {
+		return nil
+	}
+	db.closed = true
+	if db.timer != nil {
+		db.timer.Stop()
+	}
+	if db.idle {
+		return nil
+	}
+	return db.closeHandles()
+}
+
+// closeHandles closes the read pool and the writer.
+func (db *DB) closeHandles() error {
+	var err error
+	if db.Reader != db.Writer {
+		err = db.Reader.Close()
+	}
+	if werr := db.Writer.Close(); werr != nil && err == nil {
+		err = werr
+	}
+	return err
+}
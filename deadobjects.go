@@ -0,0 +1,200 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DeadObjectOptions selects the evidence FindDeadObjects uses. At least
+// one of Audit and Queries must be set.
+type DeadObjectOptions struct {
+	// Audit supplies recorded table use. Tables it reports as untouched
+	// for UnusedFor are candidates.
+	Audit *AccessAudit
+
+	// UnusedFor is how long a table must go untouched, per Audit, to be
+	// reported. Default: 30 days.
+	UnusedFor time.Duration
+
+	// Queries is the application's query corpus. Tables no query refers
+	// to are candidates, and indexes no query plan uses are reported.
+	Queries []NamedQuery
+}
+
+// DeadObject is a table or index that appears to be unused.
+type DeadObject struct {
+	// Type is "table" or "index".
+	Type string
+
+	// Name is the name of the table or index.
+	Name string
+
+	// Table is the table an index belongs to; it equals Name for tables.
+	Table string
+
+	// Reason explains why the object was reported.
+	Reason string
+}
+
+// reIndexUse matches the index named in an EXPLAIN QUERY PLAN step.
+var reIndexUse = regexp.MustCompile(`USING (?:COVERING )?INDEX (\S+)`)
+
+// FindDeadObjects cross-references the evidence in opts with the schema of
+// db and returns the tables and indexes that appear unused, sorted by type
+// and name. When both Audit and Queries are set, a table is reported only
+// if both agree it is unused.
+//
+// Indexes are only judged against Queries. Indexes SQLite creates for
+// PRIMARY KEY and UNIQUE constraints, and unique indexes, are never
+// reported, since dropping them would change behavior; neither are the
+// indexes of tables that are themselves reported.
+//
+// The result is a suggestion: a query missing from the corpus, or one the
+// audit didn't sample, makes a live object look dead. Review it before
+// acting; DropMigration renders it as a migration to review.
+func FindDeadObjects(ctx context.Context, db *sql.DB, opts DeadObjectOptions) ([]DeadObject, error) {
+	if opts.Audit == nil && len(opts.Queries) == 0 {
+		return nil, fmt.Errorf("dead object analysis needs an Audit or Queries")
+	}
+	if opts.UnusedFor == 0 {
+		opts.UnusedFor = 30 * 24 * time.Hour
+	}
+
+	tables, err := userTables(ctx, db, false)
+	if err != nil {
+		return nil, err
+	}
+
+	// A table is dead if every source of evidence says so.
+	dead := make(map[string][]string)
+	for _, t := range tables {
+		dead[t] = nil
+	}
+	if opts.Audit != nil {
+		untouched, err := opts.Audit.Untouched(ctx, db, opts.UnusedFor)
+		if err != nil {
+			return nil, fmt.Errorf("audit: %w", err)
+		}
+		isUntouched := make(map[string]bool, len(untouched))
+		for _, t := range untouched {
+			isUntouched[t] = true
+		}
+		for t := range dead {
+			if !isUntouched[t] {
+				delete(dead, t)
+				continue
+			}
+			dead[t] = append(dead[t], fmt.Sprintf("not accessed for %s", opts.UnusedFor))
+		}
+	}
+
+	usedIndexes := make(map[string]bool)
+	if len(opts.Queries) != 0 {
+		referenced := make(map[string]bool)
+		for _, q := range opts.Queries {
+			reads, writes := accessedTables(q.Query)
+			for _, t := range append(reads, writes...) {
+				referenced[t] = true
+			}
+			plan, err := ExplainQueryPlan(ctx, db, q.Query, q.Args...)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", q.Name, err)
+			}
+			for _, step := range plan {
+				if m := reIndexUse.FindStringSubmatch(step); m != nil {
+					usedIndexes[m[1]] = true
+				}
+			}
+		}
+		for t := range dead {
+			if referenced[t] {
+				delete(dead, t)
+				continue
+			}
+			dead[t] = append(dead[t], "not referenced by any query in the corpus")
+		}
+	}
+
+	var objects []DeadObject
+	for t, reasons := range dead {
+		objects = append(objects, DeadObject{Type: "table", Name: t, Table: t, Reason: strings.Join(reasons, "; ")})
+	}
+
+	if len(opts.Queries) != 0 {
+		indexes, err := droppableIndexes(ctx, db)
+		if err != nil {
+			return nil, err
+		}
+		for _, idx := range indexes {
+			if _, tableDead := dead[idx.Table]; tableDead || usedIndexes[idx.Name] || isInternalTable(idx.Table) {
+				continue
+			}
+			idx.Reason = "not used by any query plan in the corpus"
+			objects = append(objects, idx)
+		}
+	}
+
+	sort.Slice(objects, func(i, j int) bool {
+		if objects[i].Type != objects[j].Type {
+			return objects[i].Type > objects[j].Type // tables first
+		}
+		return objects[i].Name < objects[j].Name
+	})
+	return objects, nil
+}
+
+// droppableIndexes returns the indexes created with CREATE INDEX that
+// don't enforce uniqueness.
+func droppableIndexes(ctx context.Context, db *sql.DB) ([]DeadObject, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT il.name, m.name
+		FROM sqlite_master m, pragma_index_list(m.name) il
+		WHERE m.type = 'table' AND il.origin = 'c' AND il."unique" = 0
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("list indexes: %w", err)
+	}
+	defer rows.Close()
+
+	var indexes []DeadObject
+	for rows.Next() {
+		idx := DeadObject{Type: "index"}
+		if err := rows.Scan(&idx.Name, &idx.Table); err != nil {
+			return nil, err
+		}
+		indexes = append(indexes, idx)
+	}
+	return indexes, rows.Err()
+}
+
+// DropMigration renders objects as a migration script that drops them,
+// with each object's reason as a comment, for review before it is added to
+// the application's migrations.
+func DropMigration(objects []DeadObject) string {
+	var sb strings.Builder
+	sb.WriteString("-- Drop schema objects that appear unused.\n")
+	sb.WriteString("-- Generated by sqliteinit.FindDeadObjects; review every statement before applying.\n")
+	for _, o := range objects {
+		sb.WriteString("\n")
+		if o.Type == "index" {
+			fmt.Fprintf(&sb, "-- index on %s: %s\n", o.Table, o.Reason)
+			fmt.Fprintf(&sb, "DROP INDEX IF EXISTS %s;\n", quoteIdent(o.Name))
+		} else {
+			fmt.Fprintf(&sb, "-- table: %s\n", o.Reason)
+			fmt.Fprintf(&sb, "DROP TABLE IF EXISTS %s;\n", quoteIdent(o.Name))
+		}
+	}
+	return sb.String()
+}
+
+// quoteIdent returns name as a quoted SQL identifier.
+func quoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
@@ -0,0 +1,137 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"sync"
+	"time"
+)
+
+// deferrableDirective is the header comment that marks a migration as safe
+// to finish after Open returns (e.g. a data backfill).
+const deferrableDirective = "-- sqliteinit:deferrable"
+
+// hasDirective returns true if the leading comment lines of a migration
+// script contain directive on a line of its own.
+func hasDirective(sqlBytes []byte, directive string) bool {
+	for _, line := range strings.Split(string(sqlBytes), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "--") {
+			break
+		}
+		if line == directive {
+			return true
+		}
+	}
+	return false
+}
+
+// DeferredMigrations reports the progress of migrations that Open left to
+// finish in the background because Config.MigrationBudget ran out.
+type DeferredMigrations struct {
+	done chan struct{}
+
+	mu        sync.Mutex
+	remaining []string
+	err       error
+}
+
+// deferredByDB maps handles returned by Open to their deferred migrations.
+// Entries are removed when the handle is closed.
+var deferredByDB sync.Map // *sql.DB -> *DeferredMigrations
+
+// Deferred returns the background migrations started by Open for db, or
+// nil if Open applied every migration before returning or db is closed.
+func Deferred(db *sql.DB) *DeferredMigrations {
+	if d, ok := deferredByDB.Load(db); ok {
+		return d.(*DeferredMigrations)
+	}
+	return nil
+}
+
+// Remaining returns the paths of deferred migrations not yet applied.
+func (d *DeferredMigrations) Remaining() []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]string(nil), d.remaining...)
+}
+
+// Done returns a channel that is closed when the background migrations
+// finish or fail.
+func (d *DeferredMigrations) Done() <-chan struct{} {
+	return d.done
+}
+
+// Err returns the error that stopped the background migrations, or nil if
+// they are still running or all succeeded.
+func (d *DeferredMigrations) Err() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.err
+}
+
+// Wait blocks until the background migrations finish, then returns Err.
+// It returns ctx's error if ctx is done first.
+func (d *DeferredMigrations) Wait(ctx context.Context) error {
+	select {
+	case <-d.done:
+		return d.Err()
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// startDeferred applies scripts to db in a background goroutine, bounded
+// by cfg.MigrationTimeout, and registers the progress for Deferred.
+// Each migration runs in its own transaction, so other statements on db
+// proceed between migrations.
+func startDeferred(db *sql.DB, cfg Config, scripts []migrationScript) {
+	d := &DeferredMigrations{done: make(chan struct{})}
+	for _, s := range scripts {
+		d.remaining = append(d.remaining, s.Path)
+	}
+	deferredByDB.Store(db, d)
+	if cfg.closeNotifier != nil {
+		cfg.closeNotifier.onClose(func() { deferredByDB.Delete(db) })
+	}
+
+	cfg.Logger.Info("deferring migrations to background", "count", len(scripts))
+
+	go func() {
+		defer close(d.done)
+
+		ctx, cancel := context.WithTimeout(withoutQueryTimeout(withInternal(context.Background())), cfg.MigrationTimeout)
+		defer cancel()
+
+		now := cfg.Now().UTC()
+		for _, s := range scripts {
+			cfg.Logger.Debug("applying deferred migration", "path", s.Path)
+			cfg.emit(MigrationStartEvent{ID: s.ID, Path: s.Path, Deferred: true})
+			began := time.Now()
+			err := retryBusy(ctx, cfg.Logger, "migrate", func() error {
+				return applyMigration(ctx, db, cfg, s, now)
+			})
+			elapsed := time.Since(began)
+			cfg.emit(MigrationFinishEvent{ID: s.ID, Path: s.Path, Deferred: true, Duration: elapsed, Err: err})
+			d.mu.Lock()
+			if err != nil {
+				d.err = err
+			} else {
+				d.remaining = d.remaining[1:]
+			}
+			d.mu.Unlock()
+			if err != nil {
+				cfg.Logger.Error("deferred migration failed", "path", s.Path, "error", err)
+				return
+			}
+			warnIfSlow(cfg, s, elapsed)
+		}
+		cfg.Logger.Info("deferred migrations complete", "count", len(scripts))
+	}()
+}
@@ -0,0 +1,209 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"sort"
+	"strings"
+)
+
+// SchemaChange is a difference between a database's schema and the schema
+// its migrations produce.
+type SchemaChange struct {
+	// Kind is "added" for an object in the database that the migrations
+	// don't create, "removed" for one they create that the database
+	// lacks, and "changed" for one that differs.
+	Kind string
+
+	// Type is "table", "column", "index", "view" or "trigger".
+	Type string
+
+	// Name names the object; columns are named "table.column".
+	Name string
+
+	// Detail describes a change as "expected ...; found ...".
+	Detail string
+}
+
+// String formats the change for logs and test failures.
+func (c SchemaChange) String() string {
+	s := fmt.Sprintf("%s %s %s", c.Kind, c.Type, c.Name)
+	if c.Detail != "" {
+		s += ": " + c.Detail
+	}
+	return s
+}
+
+// Diff applies the migrations in expected to a scratch in-memory database
+// and compares the resulting schema with db's, returning the differences
+// sorted by type and name, or nil if there are none. It catches drift from
+// manual edits that the migration history can't show.
+//
+// Tables are compared column by column (declared type, NOT NULL, default,
+// primary key and generated columns) and by kind (WITHOUT ROWID, STRICT);
+// indexes, views and triggers by their CREATE statements, ignoring
+// whitespace. The package's own tables are not compared. Migrations are
+// selected for the environment named by the ENV environment variable, as
+// with a default Config.
+func Diff(ctx context.Context, db *sql.DB, expected fs.FS) ([]SchemaChange, error) {
+	scratch, err := Open(ctx, Config{
+		Path:                    "file:sqliteinit-diff?mode=memory",
+		Migrations:              expected,
+		Logger:                  slog.New(slog.NewTextHandler(io.Discard, nil)),
+		AllowMemoryInProduction: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("diff: apply migrations: %w", err)
+	}
+	defer scratch.Close()
+
+	ctx = withInternal(ctx)
+	want, err := schemaObjects(ctx, scratch)
+	if err != nil {
+		return nil, fmt.Errorf("diff: %w", err)
+	}
+	got, err := schemaObjects(ctx, db)
+	if err != nil {
+		return nil, fmt.Errorf("diff: %w", err)
+	}
+
+	byName := func(objects []schemaObject) map[string]schemaObject {
+		m := make(map[string]schemaObject, len(objects))
+		for _, o := range objects {
+			if o.Type == "table" && isInternalTable(o.Name) {
+				continue
+			}
+			m[o.Type+" "+o.Name] = o
+		}
+		return m
+	}
+	wantObjs, gotObjs := byName(want), byName(got)
+
+	var changes []SchemaChange
+	for key, w := range wantObjs {
+		g, ok := gotObjs[key]
+		switch {
+		case !ok:
+			changes = append(changes, SchemaChange{Kind: "removed", Type: w.Type, Name: w.Name})
+		case w.Type == "table":
+			tableChanges, err := diffTable(ctx, scratch, db, w.Name)
+			if err != nil {
+				return nil, fmt.Errorf("diff: %w", err)
+			}
+			changes = append(changes, tableChanges...)
+		case strings.Join(strings.Fields(w.SQL), " ") != strings.Join(strings.Fields(g.SQL), " "):
+			changes = append(changes, SchemaChange{
+				Kind: "changed", Type: w.Type, Name: w.Name,
+				Detail: fmt.Sprintf("expected %s; found %s", w.SQL, g.SQL),
+			})
+		}
+	}
+	for key, g := range gotObjs {
+		if _, ok := wantObjs[key]; !ok {
+			changes = append(changes, SchemaChange{Kind: "added", Type: g.Type, Name: g.Name})
+		}
+	}
+
+	typeOrder := map[string]int{"table": 0, "column": 1, "index": 2, "view": 3, "trigger": 4}
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Type != changes[j].Type {
+			return typeOrder[changes[i].Type] < typeOrder[changes[j].Type]
+		}
+		return changes[i].Name < changes[j].Name
+	})
+	return changes, nil
+}
+
+// diffTable compares the kind and columns of table in want and got.
+func diffTable(ctx context.Context, want, got *sql.DB, table string) ([]SchemaChange, error) {
+	var changes []SchemaChange
+
+	wantWR, wantStrict, err := tableKind(ctx, want, table)
+	if err != nil {
+		return nil, err
+	}
+	gotWR, gotStrict, err := tableKind(ctx, got, table)
+	if err != nil {
+		return nil, err
+	}
+	if wantWR != gotWR || wantStrict != gotStrict {
+		changes = append(changes, SchemaChange{
+			Kind: "changed", Type: "table", Name: table,
+			Detail: fmt.Sprintf("expected %s; found %s", describeTableKind(wantWR, wantStrict), describeTableKind(gotWR, gotStrict)),
+		})
+	}
+
+	wantCols, err := tableColumns(ctx, want, table)
+	if err != nil {
+		return nil, err
+	}
+	gotCols, err := tableColumns(ctx, got, table)
+	if err != nil {
+		return nil, err
+	}
+	gotByName := make(map[string]columnInfo, len(gotCols))
+	for _, c := range gotCols {
+		gotByName[strings.ToLower(c.Name)] = c
+	}
+	for _, w := range wantCols {
+		name := table + "." + w.Name
+		g, ok := gotByName[strings.ToLower(w.Name)]
+		if !ok {
+			changes = append(changes, SchemaChange{Kind: "removed", Type: "column", Name: name})
+			continue
+		}
+		delete(gotByName, strings.ToLower(w.Name))
+		if describeColumn(w) != describeColumn(g) {
+			changes = append(changes, SchemaChange{
+				Kind: "changed", Type: "column", Name: name,
+				Detail: fmt.Sprintf("expected %s; found %s", describeColumn(w), describeColumn(g)),
+			})
+		}
+	}
+	for _, g := range gotCols {
+		if _, ok := gotByName[strings.ToLower(g.Name)]; ok {
+			changes = append(changes, SchemaChange{Kind: "added", Type: "column", Name: table + "." + g.Name})
+		}
+	}
+	return changes, nil
+}
+
+// describeTableKind renders a table's kind, e.g. "STRICT, WITHOUT ROWID".
+func describeTableKind(withoutRowID, strict bool) string {
+	var parts []string
+	if strict {
+		parts = append(parts, "STRICT")
+	}
+	if withoutRowID {
+		parts = append(parts, "WITHOUT ROWID")
+	}
+	if len(parts) == 0 {
+		return "rowid table"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// describeColumn renders a column's definition for comparison, e.g.
+// "TEXT NOT NULL DEFAULT 'x' PRIMARY KEY".
+func describeColumn(c columnInfo) string {
+	parts := []string{declOrNone(strings.ToUpper(c.Type))}
+	if c.NotNull {
+		parts = append(parts, "NOT NULL")
+	}
+	if c.Default.Valid {
+		parts = append(parts, "DEFAULT "+c.Default.String)
+	}
+	if c.PrimaryKey > 0 {
+		parts = append(parts, fmt.Sprintf("PRIMARY KEY(%d)", c.PrimaryKey))
+	}
+	if c.Generated != "" {
+		parts = append(parts, "GENERATED "+c.Generated)
+	}
+	return strings.Join(parts, " ")
+}
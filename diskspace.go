@@ -0,0 +1,51 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ErrInsufficientSpace is returned when a preflight check finds that the
+// file system holding a database does not have enough free space for an
+// operation. Use errors.Is to test for it.
+var ErrInsufficientSpace = errors.New("insufficient disk space")
+
+// minFreeSpace is the headroom required on top of any estimated need, to
+// leave room for the journal, WAL and shared-memory sidecar files.
+const minFreeSpace = 1 << 20 // 1 MiB
+
+// freeSpace looks up the space available in a directory. Tests replace it
+// to simulate a full disk.
+var freeSpace = availableSpace
+
+// checkDiskSpace verifies that the file system containing path has at least
+// need bytes (plus minFreeSpace) available. If free space can't be
+// determined on this platform, the check is skipped.
+func checkDiskSpace(path string, need int64) error {
+	dir := filepath.Dir(path)
+	avail, ok, err := freeSpace(dir)
+	if err != nil {
+		return fmt.Errorf("%s: check free space: %w", dir, err)
+	}
+	if !ok {
+		return nil
+	}
+	need += minFreeSpace
+	if avail < need {
+		return fmt.Errorf("%s: %w: need %d bytes, have %d", dir, ErrInsufficientSpace, need, avail)
+	}
+	return nil
+}
+
+// fileSize returns the size of the file at path, or 0 if it can't be read.
+func fileSize(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
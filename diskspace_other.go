@@ -0,0 +1,11 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+//go:build !(linux || darwin || freebsd)
+
+package sqliteinit
+
+// availableSpace reports that free space can't be determined on this
+// platform, which disables the preflight checks.
+func availableSpace(dir string) (int64, bool, error) {
+	return 0, false, nil
+}
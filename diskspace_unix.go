@@ -0,0 +1,17 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+//go:build linux || darwin || freebsd
+
+package sqliteinit
+
+import "syscall"
+
+// availableSpace returns the number of bytes available to an unprivileged
+// user on the file system containing dir.
+func availableSpace(dir string) (int64, bool, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(dir, &st); err != nil {
+		return 0, false, err
+	}
+	return int64(st.Bavail) * int64(st.Bsize), true, nil
+}
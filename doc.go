@@ -24,9 +24,11 @@
 //
 // # Driver Support
 //
-// This package supports two SQLite drivers via build tags:
+// This package supports two SQLite drivers:
 //   - modernc.org/sqlite (default, pure Go, no CGO)
-//   - github.com/mattn/go-sqlite3 (CGO, use -tags mattn)
+//   - github.com/mattn/go-sqlite3 (CGO, default with -tags mattn)
+//
+// Set Config.Driver to Modernc or Mattn to choose at runtime.
 //
 // You must import the appropriate driver in your application:
 //
@@ -40,12 +42,19 @@
 // infrastructure tables (schema_migrations, config); users provide only
 // their application-specific migrations.
 //
+// A migration whose leading comments contain "-- sqliteinit:env dev,test"
+// is applied only when Config.Environment matches one of the listed names.
+//
 // # Configuration
 //
 // Key Config fields:
 //   - Path: ":memory:" for in-memory, or absolute path with .db extension
+//     (see AllowedExtensions and PathPolicy),
+//     optionally as a "file:" URI with query parameters
 //   - Migrations: fs.FS containing your application's SQL migrations
 //   - SkipMigrations: set to true to open without running migrations
 //   - AppVersion: optional version string written to config table
+//   - Environment: selects environment-scoped migrations
 //   - ProductionEnvVar: env var to check for production mode (default: "ENV")
+//   - IsProduction: optional production detector replacing ProductionEnvVar
 package sqliteinit
@@ -0,0 +1,160 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrSchemaDrift is returned by Open with DetectDrift set to DriftFail
+// when the schema was changed outside the migrations. Use errors.Is to
+// test for it.
+var ErrSchemaDrift = errors.New("schema drift")
+
+// DriftPolicy says what Open does when the schema was changed outside the
+// migrations since the last Open. See Config.DetectDrift.
+type DriftPolicy int
+
+const (
+	// DriftIgnore doesn't check for drift.
+	DriftIgnore DriftPolicy = iota
+
+	// DriftWarn logs a warning and accepts the schema as it is.
+	DriftWarn
+
+	// DriftFail fails Open with an error wrapping ErrSchemaDrift.
+	DriftFail
+)
+
+// SchemaFingerprint returns a deterministic hash of db's schema: the
+// SHA-256, hex encoded, of the CREATE statements in sqlite_master with
+// whitespace collapsed, leaving out the package's own tables. Open stores
+// it in the config table as "schema.fingerprint" after migrating; release
+// builds can capture it for Config.RequiredSchemaFingerprint.
+func SchemaFingerprint(ctx context.Context, db *sql.DB) (string, error) {
+	return schemaFingerprint(withInternal(ctx), db)
+}
+
+// schemaFingerprint returns a hash of the CREATE statements of db's
+// objects, with whitespace collapsed. The package's own tables and their
+// indexes are left out, since the package changes them itself.
+func schemaFingerprint(ctx context.Context, db *sql.DB) (string, error) {
+	objects, err := schemaObjects(ctx, db)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	for _, o := range objects {
+		if isInternalTable(o.Name) || isInternalTable(o.Table) {
+			continue
+		}
+		fmt.Fprintf(h, "%s\x00%s\x00%s\n", o.Type, o.Name, strings.Join(strings.Fields(o.SQL), " "))
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// checkDrift compares db's schema with the fingerprint recorded by the
+// last Open at the same schema version and, if strict is set, looks for
+// user tables that are not STRICT. It returns an error wrapping
+// ErrSchemaDrift if it finds either; a missing fingerprint, or one
+// recorded at another version (by a release that didn't record it), isn't
+// drift.
+func checkDrift(ctx context.Context, db *sql.DB, strict bool) error {
+	if err := checkFingerprint(ctx, db); err != nil || !strict {
+		return err
+	}
+	tables, err := nonStrictTables(ctx, db)
+	if err != nil {
+		return err
+	}
+	if len(tables) != 0 {
+		return fmt.Errorf("%w: tables are not STRICT: %s", ErrSchemaDrift, strings.Join(tables, ", "))
+	}
+	return nil
+}
+
+// checkFingerprint compares db's schema with the recorded fingerprint for
+// checkDrift.
+func checkFingerprint(ctx context.Context, db *sql.DB) error {
+	var recorded, recordedAt string
+	err := db.QueryRowContext(ctx, `
+		SELECT
+			(SELECT value FROM config WHERE key = 'schema.fingerprint'),
+			(SELECT value FROM config WHERE key = 'schema.fingerprint_version')
+		WHERE EXISTS (SELECT 1 FROM config WHERE key = 'schema.fingerprint')
+	`).Scan(&recorded, &recordedAt)
+	if err == sql.ErrNoRows || isNoSuchTable(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read schema.fingerprint: %w", err)
+	}
+
+	version, err := fetchSchemaVersion(ctx, db)
+	if err != nil || version == nil || strconv.Itoa(*version) != recordedAt {
+		return err
+	}
+	current, err := schemaFingerprint(ctx, db)
+	if err != nil {
+		return err
+	}
+	if current != recorded {
+		return fmt.Errorf("%w: schema changed outside migrations at version %d (use Diff to see how)", ErrSchemaDrift, *version)
+	}
+	return nil
+}
+
+// recordFingerprint stores db's schema fingerprint and the schema version
+// it was taken at in the config table, if they changed, stamped with now.
+func recordFingerprint(ctx context.Context, db *sql.DB, now time.Time) error {
+	version, err := fetchSchemaVersion(ctx, db)
+	if err != nil || version == nil {
+		return err
+	}
+	fingerprint, err := schemaFingerprint(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	// Skip the write when nothing changed, so read-only opens succeed.
+	var unchanged bool
+	if err := db.QueryRowContext(ctx, `
+		SELECT count(*) = 2 FROM config
+		WHERE (key = 'schema.fingerprint' AND value = ?) OR (key = 'schema.fingerprint_version' AND value = ?)
+	`, fingerprint, strconv.Itoa(*version)).Scan(&unchanged); err != nil {
+		return err
+	}
+	if unchanged {
+		return nil
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	ts := now.UTC().Unix()
+	// A slice rather than a map, so the writes happen in the same order on
+	// every run.
+	for _, kv := range [][2]string{
+		{"schema.fingerprint", fingerprint},
+		{"schema.fingerprint_version", strconv.Itoa(*version)},
+	} {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO config (key, value, created_at, updated_at) VALUES (?, ?, ?, ?)
+			ON CONFLICT (key) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at
+		`, kv[0], kv[1], ts, ts); err != nil {
+			return fmt.Errorf("set %s: %w", kv[0], err)
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,106 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import "strconv"
+
+// Driver describes how the package talks to a database/sql SQLite driver:
+// the name the driver registers, its built-in pragma sets, and how pragmas
+// are encoded in its DSN. Set Config.Driver to choose a driver at runtime;
+// the application must still import (register) the driver itself.
+//
+// Pragma names are always bare SQLite names (e.g. "journal_mode"); each
+// Driver maps them to its own DSN syntax.
+type Driver interface {
+	// Name is the name passed to sql.Open.
+	Name() string
+
+	// MemoryPragmas are the built-in pragmas for in-memory databases.
+	MemoryPragmas() []Pragma
+
+	// PersistentPragmas are the built-in pragmas for persistent databases.
+	PersistentPragmas() []Pragma
+
+	// BuildDSN returns a DSN for path with pragmas applied. Pragmas the
+	// driver can't express in a DSN are returned in post and are executed
+	// after the connection is opened.
+	BuildDSN(path string, pragmas []Pragma) (dsn string, post []Pragma)
+}
+
+// Built-in drivers. DefaultDriver is Modernc unless the package is built
+// with -tags mattn.
+var (
+	// Modernc targets modernc.org/sqlite (pure Go).
+	Modernc Driver = moderncDriver{}
+
+	// Mattn targets github.com/mattn/go-sqlite3 (CGO).
+	Mattn Driver = mattnDriver{}
+
+	// LibSQL targets github.com/tursodatabase/libsql-client-go for remote
+	// libSQL servers such as Turso. It is the default for remote paths.
+	LibSQL Driver = libsqlDriver{}
+)
+
+// readOnlyPragma makes a connection reject writes.
+var readOnlyPragma = Pragma{Name: "query_only", Value: "ON"}
+
+// driver returns the configured driver, or the default for the path:
+// LibSQL for remote URLs and DefaultDriver otherwise.
+func (cfg Config) driver() Driver {
+	if cfg.Driver != nil {
+		return cfg.Driver
+	}
+	if cfg.isRemote() {
+		return LibSQL
+	}
+	return DefaultDriver
+}
+
+// builtinPragmas returns the driver's built-in pragmas for the database
+// mode, with busy_timeout set from cfg.BusyTimeout, followed by the pragmas
+// for the Config tuning fields and MaxDatabaseSize.
+func (cfg Config) builtinPragmas() []Pragma {
+	var pragmas []Pragma
+	if cfg.isMemory() {
+		pragmas = cfg.driver().MemoryPragmas()
+	} else {
+		pragmas = cfg.driver().PersistentPragmas()
+	}
+
+	pragmas = append([]Pragma(nil), pragmas...)
+	for i, p := range pragmas {
+		if p.Name == "busy_timeout" && cfg.BusyTimeout > 0 {
+			pragmas[i].Value = strconv.FormatInt(cfg.BusyTimeout.Milliseconds(), 10)
+		}
+	}
+
+	if cfg.CacheSizeKB > 0 && !cfg.isRemote() {
+		// A negative cache_size is a size in KiB rather than pages.
+		pragmas = append(pragmas, Pragma{Name: "cache_size", Value: strconv.Itoa(-cfg.CacheSizeKB)})
+	}
+	if !cfg.isMemory() && !cfg.isRemote() {
+		if cfg.MmapSize > 0 {
+			pragmas = append(pragmas, Pragma{Name: "mmap_size", Value: strconv.FormatInt(cfg.MmapSize, 10)})
+		}
+		switch {
+		case cfg.WALAutocheckpoint > 0:
+			pragmas = append(pragmas, Pragma{Name: "wal_autocheckpoint", Value: strconv.Itoa(cfg.WALAutocheckpoint)})
+		case cfg.WALAutocheckpoint < 0:
+			pragmas = append(pragmas, Pragma{Name: "wal_autocheckpoint", Value: "0"})
+		}
+		if cfg.JournalSizeLimit != 0 {
+			pragmas = append(pragmas, Pragma{Name: "journal_size_limit", Value: strconv.FormatInt(cfg.JournalSizeLimit, 10)})
+		}
+	}
+	if p, ok := cfg.quotaPragma(); ok {
+		pragmas = append(pragmas, p)
+	}
+	return pragmas
+}
+
+// Pragmas returns the pragmas Open applies for cfg: the driver's built-in
+// set for the database mode followed by ExtraPragmas.
+func (cfg Config) Pragmas() ([]Pragma, error) {
+	cfg = cfg.defaults()
+	return withExtraPragmas(cfg.builtinPragmas(), cfg.ExtraPragmas)
+}
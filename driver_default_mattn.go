@@ -0,0 +1,8 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+//go:build mattn
+
+package sqliteinit
+
+// DefaultDriver is the driver used when Config.Driver is nil.
+var DefaultDriver = Mattn
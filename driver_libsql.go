@@ -0,0 +1,73 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"net/url"
+	"strings"
+)
+
+// libsqlDriver targets github.com/tursodatabase/libsql-client-go, which
+// talks to remote libSQL servers (Turso, sqld) over HTTP or WebSockets.
+//
+// Journal mode, synchronous and locking are managed by the server, so only
+// connection-level pragmas are applied, and they are executed after the
+// connection is opened because the URL can't carry them.
+type libsqlDriver struct{}
+
+// Name returns the driver name registered by libsql-client-go.
+func (libsqlDriver) Name() string { return "libsql" }
+
+// MemoryPragmas are the same as PersistentPragmas; a remote database is
+// never in-memory.
+func (d libsqlDriver) MemoryPragmas() []Pragma {
+	return d.PersistentPragmas()
+}
+
+// PersistentPragmas are the pragmas a libSQL server honors per connection.
+func (libsqlDriver) PersistentPragmas() []Pragma {
+	return []Pragma{
+		{Name: "foreign_keys", Value: "ON"},
+	}
+}
+
+// BuildDSN returns the URL unchanged; all pragmas are returned as post.
+func (libsqlDriver) BuildDSN(path string, pragmas []Pragma) (string, []Pragma) {
+	return path, pragmas
+}
+
+// isRemotePath returns true if path is a libSQL server URL.
+func isRemotePath(path string) bool {
+	for _, scheme := range []string{"libsql://", "https://", "http://", "wss://", "ws://"} {
+		if strings.HasPrefix(path, scheme) {
+			return true
+		}
+	}
+	return false
+}
+
+// withAuthToken adds token to a libSQL URL as the authToken parameter.
+func withAuthToken(path, token string) string {
+	if token == "" {
+		return path
+	}
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	return path + sep + "authToken=" + url.QueryEscape(token)
+}
+
+// redactDSN hides any auth token in a DSN so it can be logged.
+func redactDSN(dsn string) string {
+	base, query, ok := strings.Cut(dsn, "?")
+	if !ok {
+		return dsn
+	}
+	values, err := url.ParseQuery(query)
+	if err != nil || !values.Has("authToken") {
+		return dsn
+	}
+	values.Set("authToken", "REDACTED")
+	return base + "?" + values.Encode()
+}
@@ -0,0 +1,86 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"fmt"
+	"strings"
+)
+
+// mattnDriver targets github.com/mattn/go-sqlite3.
+type mattnDriver struct{}
+
+// Name returns the driver name registered by mattn/go-sqlite3.
+func (mattnDriver) Name() string { return "sqlite3" }
+
+// MemoryPragmas are optimized for in-memory databases. txlock is a mattn
+// DSN option rather than a pragma.
+func (mattnDriver) MemoryPragmas() []Pragma {
+	return []Pragma{
+		{Name: "foreign_keys", Value: "1"},
+		{Name: "busy_timeout", Value: "5000"},
+		{Name: "journal_mode", Value: "MEMORY"},
+		{Name: "synchronous", Value: "OFF"},
+		{Name: "txlock", Value: "exclusive"},
+	}
+}
+
+// PersistentPragmas are optimized for durable persistent databases.
+func (mattnDriver) PersistentPragmas() []Pragma {
+	return []Pragma{
+		{Name: "foreign_keys", Value: "1"},
+		{Name: "busy_timeout", Value: "5000"},
+		{Name: "journal_mode", Value: "WAL"},
+		{Name: "synchronous", Value: "NORMAL"},
+	}
+}
+
+// mattnDSNParams lists the pragmas (and options) mattn accepts as DSN
+// parameters.
+var mattnDSNParams = map[string]bool{
+	"auto_vacuum":              true,
+	"busy_timeout":             true,
+	"cache_size":               true,
+	"case_sensitive_like":      true,
+	"defer_foreign_keys":       true,
+	"foreign_keys":             true,
+	"ignore_check_constraints": true,
+	"journal_mode":             true,
+	"locking_mode":             true,
+	"query_only":               true,
+	"recursive_triggers":       true,
+	"secure_delete":            true,
+	"synchronous":              true,
+	"txlock":                   true,
+	"writable_schema":          true,
+}
+
+// BuildDSN constructs a DSN for github.com/mattn/go-sqlite3.
+// mattn uses the syntax: file:path?_foreign_keys=1&_journal_mode=WAL
+// Pragmas mattn doesn't accept in the DSN are returned for execution after
+// open.
+func (mattnDriver) BuildDSN(path string, pragmas []Pragma) (string, []Pragma) {
+	var sb strings.Builder
+	var post []Pragma
+
+	base, query := dsnBase(path)
+	sb.WriteString(base)
+	sep := "?"
+	if query != "" {
+		sb.WriteString("?")
+		sb.WriteString(query)
+		sep = "&"
+	}
+
+	for _, p := range pragmas {
+		if !mattnDSNParams[p.Name] {
+			post = append(post, p)
+			continue
+		}
+		sb.WriteString(sep)
+		sep = "&"
+		fmt.Fprintf(&sb, "_%s=%s", p.Name, p.Value)
+	}
+
+	return sb.String(), post
+}
@@ -0,0 +1,62 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"fmt"
+	"strings"
+)
+
+// moderncDriver targets modernc.org/sqlite.
+type moderncDriver struct{}
+
+// Name returns the driver name registered by modernc.org/sqlite.
+func (moderncDriver) Name() string { return "sqlite" }
+
+// MemoryPragmas are optimized for in-memory databases.
+func (moderncDriver) MemoryPragmas() []Pragma {
+	return []Pragma{
+		{Name: "foreign_keys", Value: "ON"},
+		{Name: "busy_timeout", Value: "5000"},
+		{Name: "journal_mode", Value: "MEMORY"},
+		{Name: "synchronous", Value: "OFF"},
+		{Name: "temp_store", Value: "MEMORY"},
+		{Name: "locking_mode", Value: "EXCLUSIVE"},
+	}
+}
+
+// PersistentPragmas are optimized for durable persistent databases.
+func (moderncDriver) PersistentPragmas() []Pragma {
+	return []Pragma{
+		{Name: "foreign_keys", Value: "ON"},
+		{Name: "busy_timeout", Value: "5000"},
+		{Name: "journal_mode", Value: "WAL"},
+		{Name: "synchronous", Value: "NORMAL"},
+		{Name: "temp_store", Value: "FILE"},
+		{Name: "locking_mode", Value: "NORMAL"},
+	}
+}
+
+// BuildDSN constructs a DSN for modernc.org/sqlite.
+// modernc uses the syntax: file:path?_pragma=name(value)&_pragma=name2(value2)
+// and accepts any pragma in the DSN.
+func (moderncDriver) BuildDSN(path string, pragmas []Pragma) (string, []Pragma) {
+	var sb strings.Builder
+
+	base, query := dsnBase(path)
+	sb.WriteString(base)
+	sep := "?"
+	if query != "" {
+		sb.WriteString("?")
+		sb.WriteString(query)
+		sep = "&"
+	}
+
+	for _, p := range pragmas {
+		sb.WriteString(sep)
+		sep = "&"
+		fmt.Fprintf(&sb, "_pragma=%s(%s)", p.Name, p.Value)
+	}
+
+	return sb.String(), nil
+}
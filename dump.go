@@ -0,0 +1,259 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// schemaObject is a table, index, view or trigger in a database schema.
+type schemaObject struct {
+	Type  string // "table", "index", "view" or "trigger"
+	Name  string
+	Table string // the table an index or trigger belongs to
+	SQL   string // the normalized CREATE statement, ending in ";"
+}
+
+// schemaObjects returns the objects in db's main schema that have a CREATE
+// statement, sorted by type (tables, indexes, views, triggers, so that
+// replaying them in order works) and then by name. SQLite's own objects,
+// automatic indexes and the shadow tables of virtual tables are omitted,
+// since SQLite creates them itself.
+func schemaObjects(ctx context.Context, q queryer) ([]schemaObject, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT m.type, m.name, m.tbl_name, m.sql
+		FROM sqlite_master m
+		WHERE m.sql IS NOT NULL
+		  AND m.name NOT LIKE 'sqlite\_%' ESCAPE '\'
+		  AND NOT EXISTS (
+			SELECT 1 FROM pragma_table_list tl
+			WHERE tl.schema = 'main' AND tl.name = m.name AND tl.type = 'shadow'
+		  )
+		ORDER BY CASE m.type WHEN 'table' THEN 0 WHEN 'index' THEN 1 WHEN 'view' THEN 2 ELSE 3 END, m.name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("list schema: %w", err)
+	}
+	defer rows.Close()
+
+	var objects []schemaObject
+	for rows.Next() {
+		var o schemaObject
+		if err := rows.Scan(&o.Type, &o.Name, &o.Table, &o.SQL); err != nil {
+			return nil, err
+		}
+		if o.Type == "table" || o.Type == "view" {
+			o.Table = ""
+		}
+		o.SQL = normalizeSchemaSQL(o.SQL)
+		objects = append(objects, o)
+	}
+	return objects, rows.Err()
+}
+
+// DumpSchema writes the CREATE statements for db's schema to w, sorted by
+// type (tables, indexes, views, triggers) and then by name, separated by
+// blank lines. SQLite's own objects, automatic indexes and the shadow
+// tables of virtual tables are omitted. The output has no timestamps, so
+// it can be committed after each release and diffed; replaying it into
+// an empty database recreates the schema.
+func DumpSchema(ctx context.Context, db *sql.DB, w io.Writer) error {
+	objects, err := schemaObjects(ctx, db)
+	if err != nil {
+		return err
+	}
+	var sb strings.Builder
+	sb.WriteString("-- Schema dump generated by sqliteinit.DumpSchema.\n")
+	for _, o := range objects {
+		sb.WriteString("\n")
+		sb.WriteString(o.SQL)
+		sb.WriteString("\n")
+	}
+	_, err = io.WriteString(w, sb.String())
+	return err
+}
+
+// DumpOptions controls Dump.
+type DumpOptions struct {
+	// SchemaOnly leaves out the INSERT statements.
+	SchemaOnly bool
+
+	// Tables limits the dump to these tables and their indexes and
+	// triggers. Default: every table, index, view and trigger.
+	Tables []string
+}
+
+// Dump writes db as a SQL script to w, like the sqlite3 shell's .dump: the
+// CREATE statement of each table followed by INSERT statements for its
+// rows, then the indexes, views and triggers, all inside one transaction
+// with foreign keys off. Running the script in an empty database recreates
+// db, including the package's own tables. The dump is read from a single
+// read transaction, so it is consistent even while other connections
+// write.
+//
+// The contents of virtual tables, which live in shadow tables, are not
+// dumped; rebuild full-text indexes after loading the script.
+func Dump(ctx context.Context, db *sql.DB, w io.Writer, opts DumpOptions) error {
+	ctx = withInternal(ctx)
+	tx, err := db.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return fmt.Errorf("dump: %w", err)
+	}
+	defer tx.Rollback()
+
+	objects, err := schemaObjects(ctx, tx)
+	if err != nil {
+		return fmt.Errorf("dump: %w", err)
+	}
+	if len(opts.Tables) != 0 {
+		want := make(map[string]bool, len(opts.Tables))
+		for _, name := range opts.Tables {
+			want[name] = false
+		}
+		var kept []schemaObject
+		for _, o := range objects {
+			table := o.Table
+			if o.Type == "table" {
+				table = o.Name
+			}
+			if _, ok := want[table]; ok {
+				want[table] = want[table] || o.Type == "table"
+				kept = append(kept, o)
+			}
+		}
+		for _, name := range opts.Tables {
+			if !want[name] {
+				return fmt.Errorf("dump: table %q: %w", name, ErrNotFound)
+			}
+		}
+		objects = kept
+	}
+
+	bw := bufio.NewWriter(w)
+	bw.WriteString("PRAGMA foreign_keys=OFF;\nBEGIN TRANSACTION;\n")
+	for _, o := range objects {
+		if o.Type != "table" {
+			continue
+		}
+		bw.WriteString(o.SQL + "\n")
+		if opts.SchemaOnly || strings.HasPrefix(strings.ToUpper(o.SQL), "CREATE VIRTUAL TABLE") {
+			continue
+		}
+		if err := dumpRows(ctx, tx, bw, o.Name); err != nil {
+			return fmt.Errorf("dump: %s: %w", o.Name, err)
+		}
+	}
+	if !opts.SchemaOnly {
+		if err := dumpSequences(ctx, tx, bw, objects); err != nil {
+			return fmt.Errorf("dump: sqlite_sequence: %w", err)
+		}
+	}
+	for _, o := range objects {
+		if o.Type != "table" {
+			bw.WriteString(o.SQL + "\n")
+		}
+	}
+	bw.WriteString("COMMIT;\n")
+	return bw.Flush()
+}
+
+// dumpRows writes an INSERT statement for each row of table. SQLite's
+// quote() formats the values, so reals, blobs and text round-trip exactly.
+// Generated columns are left out, and then the columns are listed.
+func dumpRows(ctx context.Context, tx *sql.Tx, w *bufio.Writer, table string) error {
+	rows, err := tx.QueryContext(ctx, `SELECT name, hidden FROM pragma_table_xinfo(?) ORDER BY cid`, table)
+	if err != nil {
+		return err
+	}
+	var names, quoted []string
+	generated := false
+	for rows.Next() {
+		var name string
+		var hidden int
+		if err := rows.Scan(&name, &hidden); err != nil {
+			rows.Close()
+			return err
+		}
+		if hidden == 2 || hidden == 3 {
+			generated = true
+			continue
+		}
+		names = append(names, quoteIdent(name))
+		quoted = append(quoted, "quote("+quoteIdent(name)+")")
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	insert := "INSERT INTO " + quoteIdent(table)
+	if generated {
+		insert += "(" + strings.Join(names, ",") + ")"
+	}
+	insert += " VALUES("
+
+	rows, err = tx.QueryContext(ctx, `SELECT `+strings.Join(quoted, ` || ',' || `)+` FROM `+quoteIdent(table))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var values string
+		if err := rows.Scan(&values); err != nil {
+			return err
+		}
+		w.WriteString(insert + values + ");\n")
+	}
+	return rows.Err()
+}
+
+// dumpSequences writes the AUTOINCREMENT counters of the dumped tables, so
+// that the recreated tables don't reuse ids.
+func dumpSequences(ctx context.Context, tx *sql.Tx, w *bufio.Writer, objects []schemaObject) error {
+	var exists int
+	if err := tx.QueryRowContext(ctx, `SELECT count(*) FROM sqlite_master WHERE name = 'sqlite_sequence'`).Scan(&exists); err != nil || exists == 0 {
+		return err
+	}
+	dumped := make(map[string]bool)
+	for _, o := range objects {
+		if o.Type == "table" {
+			dumped[o.Name] = true
+		}
+	}
+	rows, err := tx.QueryContext(ctx, `SELECT quote(name), name, seq FROM sqlite_sequence ORDER BY name`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var quoted, name string
+		var seq int64
+		if err := rows.Scan(&quoted, &name, &seq); err != nil {
+			return err
+		}
+		if !dumped[name] {
+			continue
+		}
+		fmt.Fprintf(w, "DELETE FROM sqlite_sequence WHERE name = %s;\n", quoted)
+		fmt.Fprintf(w, "INSERT INTO sqlite_sequence VALUES(%s,%d);\n", quoted, seq)
+	}
+	return rows.Err()
+}
+
+// normalizeSchemaSQL normalizes a CREATE statement from sqlite_master:
+// line endings become "\n", trailing spaces are removed from each line,
+// and the statement ends in a single ";". The text is otherwise kept as
+// written, since reformatting it safely would require a full SQL parser.
+func normalizeSchemaSQL(stmt string) string {
+	lines := strings.Split(strings.ReplaceAll(stmt, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	stmt = strings.TrimSpace(strings.Join(lines, "\n"))
+	return strings.TrimRight(stmt, "; \n") + ";"
+}
@@ -0,0 +1,119 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+)
+
+// KeyedDriver is implemented by drivers that support encryption at rest.
+// Set Config.EncryptionKey to use it.
+type KeyedDriver interface {
+	Driver
+
+	// KeyPragmas are executed on every new connection before any other
+	// statement to unlock (or, for a new file, encrypt) the database.
+	KeyPragmas(key string) []Pragma
+
+	// RekeyPragmas change the key of an unlocked database.
+	RekeyPragmas(newKey string) []Pragma
+}
+
+// SQLCipher targets SQLCipher builds of the mattn driver (for example
+// github.com/mutecomm/go-sqlcipher, or mattn/go-sqlite3 linked against
+// libsqlcipher). It uses mattn's DSN syntax and registers as "sqlite3".
+var SQLCipher Driver = sqlcipherDriver{}
+
+// sqlcipherDriver is the mattn driver with SQLCipher key support.
+type sqlcipherDriver struct {
+	mattnDriver
+}
+
+// BuildDSN keeps every pragma out of the DSN. mattn executes DSN pragmas
+// as soon as it opens the file, before the key could be supplied, which
+// SQLCipher rejects; returned as post pragmas they run after the key.
+func (sqlcipherDriver) BuildDSN(path string, pragmas []Pragma) (string, []Pragma) {
+	base, query := dsnBase(path)
+	if query != "" {
+		base += "?" + query
+	}
+	return base, pragmas
+}
+
+// KeyPragmas returns PRAGMA key for the passphrase.
+func (sqlcipherDriver) KeyPragmas(key string) []Pragma {
+	return []Pragma{{Name: "key", Value: sqlQuote(key)}}
+}
+
+// RekeyPragmas returns PRAGMA rekey for the new passphrase.
+func (sqlcipherDriver) RekeyPragmas(newKey string) []Pragma {
+	return []Pragma{{Name: "rekey", Value: sqlQuote(newKey)}}
+}
+
+// keyConnector unlocks each new connection with the driver's key pragmas
+// and confirms the key by reading the schema, so a wrong key fails at
+// connect time rather than on first use.
+type keyConnector struct {
+	next    driver.Connector
+	pragmas []Pragma
+}
+
+func (c *keyConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.next.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range c.pragmas {
+		if err := execConn(ctx, conn, fmt.Sprintf(`PRAGMA %s = %s`, p.Name, p.Value)); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("pragma %s: %w", p.Name, err)
+		}
+	}
+	if err := execConn(ctx, conn, `SELECT count(*) FROM sqlite_master`); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("encryption key rejected: %w", err)
+	}
+	return conn, nil
+}
+
+func (c *keyConnector) Driver() driver.Driver {
+	return c.next.Driver()
+}
+
+// Rekey changes the encryption key of the persistent database described by
+// cfg, which must hold the current key in EncryptionKey. Close other
+// handles to the database first. With cfg.Gate set, the gate is paused
+// while the database is rekeyed.
+func Rekey(ctx context.Context, cfg Config, newKey string) error {
+	cfg = cfg.defaults()
+	if cfg.EncryptionKey == "" || newKey == "" {
+		return fmt.Errorf("rekey requires the current and new encryption keys")
+	}
+	kd, ok := cfg.driver().(KeyedDriver)
+	if !ok {
+		return fmt.Errorf("driver %q does not support encryption", cfg.driver().Name())
+	}
+
+	if cfg.Gate != nil {
+		if err := cfg.Gate.Pause(ctx); err != nil {
+			return fmt.Errorf("rekey: %w", err)
+		}
+		defer cfg.Gate.Resume()
+	}
+
+	cfg.SkipMigrations = true
+	db, err := Open(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	for _, p := range kd.RekeyPragmas(newKey) {
+		if _, err := db.ExecContext(ctx, fmt.Sprintf(`PRAGMA %s = %s`, p.Name, p.Value)); err != nil {
+			return fmt.Errorf("pragma %s: %w", p.Name, err)
+		}
+	}
+	return db.Close()
+}
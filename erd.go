@@ -0,0 +1,202 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"fmt"
+	"html"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// ERDFormat selects the diagram language written by ExportERD.
+type ERDFormat int
+
+const (
+	// FormatDOT writes a Graphviz digraph, rendered with e.g.
+	// "dot -Tsvg schema.dot".
+	FormatDOT ERDFormat = iota
+
+	// FormatMermaid writes a Mermaid erDiagram, which GitHub and most
+	// documentation sites render inline.
+	FormatMermaid
+)
+
+// foreignKey is a foreign key of a table, possibly over several columns.
+type foreignKey struct {
+	Table  string // the child table
+	From   []string
+	Parent string
+	To     []string // empty if it refers to the parent's primary key
+}
+
+// ExportERD writes a relationship diagram of db's tables to w, derived from
+// the table definitions and their foreign keys. Tables appear sorted by
+// name with their columns in declaration order, so the output is stable
+// between releases. The package's own tables are left out.
+func ExportERD(ctx context.Context, db *sql.DB, w io.Writer, format ERDFormat) error {
+	ctx = withInternal(ctx)
+	tables, err := Tables(ctx, db)
+	if err != nil {
+		return fmt.Errorf("erd: %w", err)
+	}
+	columns := make(map[string][]Column, len(tables))
+	var keys []foreignKey
+	for _, t := range tables {
+		if columns[t.Name], err = Columns(ctx, db, t.Name); err != nil {
+			return fmt.Errorf("erd: %w", err)
+		}
+		fks, err := foreignKeys(ctx, db, t.Name)
+		if err != nil {
+			return fmt.Errorf("erd: %s: %w", t.Name, err)
+		}
+		keys = append(keys, fks...)
+	}
+
+	bw := bufio.NewWriter(w)
+	switch format {
+	case FormatDOT:
+		writeDOT(bw, tables, columns, keys)
+	case FormatMermaid:
+		writeMermaid(bw, tables, columns, keys)
+	default:
+		return fmt.Errorf("erd: unknown format %d", format)
+	}
+	return bw.Flush()
+}
+
+// foreignKeys returns the foreign keys of table in declaration order.
+func foreignKeys(ctx context.Context, db *sql.DB, table string) ([]foreignKey, error) {
+	rows, err := db.QueryContext(ctx, `SELECT id, "table", "from", "to" FROM pragma_foreign_key_list(?) ORDER BY id DESC, seq`, table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	// SQLite lists foreign keys last declared first.
+	var keys []foreignKey
+	last := -1
+	for rows.Next() {
+		var id int
+		var parent, from string
+		var to sql.NullString
+		if err := rows.Scan(&id, &parent, &from, &to); err != nil {
+			return nil, err
+		}
+		if id != last {
+			keys = append(keys, foreignKey{Table: table, Parent: parent})
+			last = id
+		}
+		k := &keys[len(keys)-1]
+		k.From = append(k.From, from)
+		if to.Valid {
+			k.To = append(k.To, to.String)
+		}
+	}
+	return keys, rows.Err()
+}
+
+// writeDOT writes a Graphviz digraph with one HTML-like table per node and
+// an edge from each foreign key column to the column it refers to.
+func writeDOT(w io.Writer, tables []Table, columns map[string][]Column, keys []foreignKey) {
+	fmt.Fprintln(w, "digraph schema {")
+	fmt.Fprintln(w, "\trankdir=LR;")
+	fmt.Fprintln(w, "\tnode [shape=plaintext];")
+	for _, t := range tables {
+		fmt.Fprintf(w, "\t%s [label=<<table border=\"0\" cellborder=\"1\" cellspacing=\"0\">", dotID(t.Name))
+		fmt.Fprintf(w, "<tr><td bgcolor=\"lightgrey\"><b>%s</b></td></tr>", html.EscapeString(t.Name))
+		for _, c := range columns[t.Name] {
+			label := c.Name + " " + declOrNone(c.Type)
+			if c.PrimaryKey > 0 {
+				label += " PK"
+			}
+			fmt.Fprintf(w, "<tr><td port=\"%s\" align=\"left\">%s</td></tr>", html.EscapeString(c.Name), html.EscapeString(label))
+		}
+		fmt.Fprintln(w, "</table>>];")
+	}
+	for _, k := range keys {
+		for i, from := range k.From {
+			to := ""
+			if i < len(k.To) {
+				to = ":" + dotID(k.To[i])
+			}
+			fmt.Fprintf(w, "\t%s:%s -> %s%s;\n", dotID(k.Table), dotID(from), dotID(k.Parent), to)
+		}
+	}
+	fmt.Fprintln(w, "}")
+}
+
+// dotID quotes s as a DOT identifier.
+func dotID(s string) string {
+	return `"` + strings.ReplaceAll(strings.ReplaceAll(s, `\`, `\\`), `"`, `\"`) + `"`
+}
+
+// writeMermaid writes a Mermaid erDiagram. A foreign key whose columns are
+// all NOT NULL is drawn as "exactly one" parent, otherwise "zero or one".
+func writeMermaid(w io.Writer, tables []Table, columns map[string][]Column, keys []foreignKey) {
+	fkCols := make(map[string]bool)
+	for _, k := range keys {
+		for _, from := range k.From {
+			fkCols[k.Table+"."+from] = true
+		}
+	}
+
+	fmt.Fprintln(w, "erDiagram")
+	for _, t := range tables {
+		fmt.Fprintf(w, "    %s {\n", mermaidID(t.Name))
+		for _, c := range columns[t.Name] {
+			var marks []string
+			if c.PrimaryKey > 0 {
+				marks = append(marks, "PK")
+			}
+			if fkCols[t.Name+"."+c.Name] {
+				marks = append(marks, "FK")
+			}
+			typ := c.Type
+			if typ == "" {
+				typ = "ANY"
+			}
+			line := mermaidType(typ) + " " + mermaidID(c.Name)
+			if len(marks) != 0 {
+				line += " " + strings.Join(marks, ", ")
+			}
+			fmt.Fprintf(w, "        %s\n", line)
+		}
+		fmt.Fprintln(w, "    }")
+	}
+	for _, k := range keys {
+		parent := "||"
+		notNull := make(map[string]bool)
+		for _, c := range columns[k.Table] {
+			notNull[c.Name] = c.NotNull
+		}
+		for _, from := range k.From {
+			if !notNull[from] {
+				parent = "|o"
+			}
+		}
+		fmt.Fprintf(w, "    %s %s--o{ %s : %q\n", mermaidID(k.Parent), parent, mermaidID(k.Table), strings.Join(k.From, ", "))
+	}
+}
+
+// Characters Mermaid doesn't accept in entity and attribute names, and in
+// attribute types, which may also have parentheses and brackets.
+var (
+	mermaidUnsafeName = regexp.MustCompile(`[^A-Za-z0-9_-]+`)
+	mermaidUnsafeType = regexp.MustCompile(`[^A-Za-z0-9_()\[\]-]+`)
+)
+
+// mermaidID makes s safe to use as a Mermaid name.
+func mermaidID(s string) string {
+	return mermaidUnsafeName.ReplaceAllString(s, "_")
+}
+
+// mermaidType makes s safe to use as a Mermaid attribute type, e.g.
+// "DECIMAL(10,2)" becomes "DECIMAL(10_2)".
+func mermaidType(s string) string {
+	return mermaidUnsafeType.ReplaceAllString(s, "_")
+}
@@ -0,0 +1,38 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import "errors"
+
+// Errors returned, wrapped, by the package's lifecycle and lookup
+// functions, so callers can branch on the kind of failure. Use errors.Is
+// to test for them. Errors specific to one feature, such as ErrSchemaDrift
+// or ErrQueryTimeout, are declared next to it.
+var (
+	// ErrNotFound: a requested item, such as a database file, config key,
+	// table or snapshot, doesn't exist.
+	ErrNotFound = errors.New("not found")
+
+	// ErrAlreadyExists: Create, Move, a snapshot or an unbundle would
+	// overwrite an existing file.
+	ErrAlreadyExists = errors.New("already exists")
+
+	// ErrNotInitialized: the database lacks the package's tables.
+	ErrNotInitialized = errors.New("database not initialized")
+
+	// ErrSchemaVersionMismatch: the schema doesn't match
+	// Config.RequiredSchemaVersion or Config.RequiredSchemaFingerprint.
+	ErrSchemaVersionMismatch = errors.New("schema version mismatch")
+
+	// ErrMemoryInProduction: an in-memory database was requested in
+	// production without Config.AllowMemoryInProduction.
+	ErrMemoryInProduction = errors.New("in-memory database not allowed in production")
+
+	// ErrChecksumMismatch: with Config.VerifyChecksums, an applied
+	// migration's script changed since it was applied.
+	ErrChecksumMismatch = errors.New("migration checksum mismatch")
+
+	// ErrCorrupt: Config.IntegrityCheckOnOpen found problems. The error is
+	// a *CorruptionError listing them.
+	ErrCorrupt = errors.New("database is corrupt")
+)
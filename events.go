@@ -0,0 +1,154 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"context"
+	"database/sql/driver"
+	"io"
+	"sync"
+	"time"
+)
+
+// Event is a lifecycle event passed to Config.OnEvent. It is one of
+// OpenEvent, InitEvent, MigrationStartEvent, MigrationFinishEvent,
+// ChecksumEvent, QuotaEvent or CloseEvent; use a type switch to tell them
+// apart.
+type Event interface {
+	event()
+}
+
+// OpenEvent is sent when Open finishes, successfully or not.
+type OpenEvent struct {
+	Path     string // the database path, with credentials redacted
+	Duration time.Duration
+	Err      error
+}
+
+// InitEvent is sent after the package's own tables are created in a new
+// database.
+type InitEvent struct {
+	Duration time.Duration
+}
+
+// MigrationStartEvent is sent before a migration is applied.
+type MigrationStartEvent struct {
+	ID   int
+	Path string
+
+	// Deferred is true for a migration finished in the background after
+	// Open returned (see Config.MigrationBudget).
+	Deferred bool
+}
+
+// MigrationFinishEvent is sent after a migration is applied or fails.
+type MigrationFinishEvent struct {
+	ID       int
+	Path     string
+	Deferred bool
+	Duration time.Duration
+	Err      error
+}
+
+// ChecksumEvent is sent for each applied migration whose checksum was
+// recorded, after comparing it with the script's current contents.
+type ChecksumEvent struct {
+	ID       int
+	Path     string
+	Recorded string // SHA-256 recorded when the migration was applied
+	Current  string // SHA-256 of the script now
+}
+
+// Match reports whether the script is unchanged since it was applied.
+func (e ChecksumEvent) Match() bool {
+	return e.Recorded == e.Current
+}
+
+// QuotaEvent is sent by Open when the database is within 10% of
+// Config.MaxDatabaseSize.
+type QuotaEvent struct {
+	Path  string
+	Usage QuotaUsage
+}
+
+// CloseEvent is sent when the handle returned by Open is closed.
+type CloseEvent struct {
+	Path string
+}
+
+func (OpenEvent) event()            {}
+func (InitEvent) event()            {}
+func (MigrationStartEvent) event()  {}
+func (MigrationFinishEvent) event() {}
+func (ChecksumEvent) event()        {}
+func (QuotaEvent) event()           {}
+func (CloseEvent) event()           {}
+
+// emit sends e to cfg.OnEvent, if set.
+func (cfg Config) emit(e Event) {
+	if cfg.OnEvent != nil {
+		cfg.OnEvent(e)
+	}
+}
+
+// closeNotifier sends a CloseEvent when the handle it is armed for is
+// closed, and runs the cleanups registered with onClose. It is shared by
+// the connectors of every handle openAndMigrate opens, so handles closed
+// before Open succeeds don't send one.
+type closeNotifier struct {
+	mu       sync.Mutex
+	event    func()
+	cleanups []func()
+}
+
+// arm makes the next close send a CloseEvent for path.
+func (n *closeNotifier) arm(cfg Config, path string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.event = func() { cfg.emit(CloseEvent{Path: path}) }
+}
+
+// onClose registers fn to run when the handle is closed.
+func (n *closeNotifier) onClose(fn func()) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.cleanups = append(n.cleanups, fn)
+}
+
+// fire sends the CloseEvent and runs the cleanups, at most once.
+func (n *closeNotifier) fire() {
+	n.mu.Lock()
+	event, cleanups := n.event, n.cleanups
+	n.event, n.cleanups = nil, nil
+	n.mu.Unlock()
+	for _, fn := range cleanups {
+		fn()
+	}
+	if event != nil {
+		event()
+	}
+}
+
+// closeConnector tells its notifier when database/sql closes the handle.
+type closeConnector struct {
+	next     driver.Connector
+	notifier *closeNotifier
+}
+
+func (c *closeConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	return c.next.Connect(ctx)
+}
+
+func (c *closeConnector) Driver() driver.Driver {
+	return c.next.Driver()
+}
+
+// Close implements io.Closer, which sql.DB.Close calls on its connector.
+func (c *closeConnector) Close() error {
+	var err error
+	if closer, ok := c.next.(io.Closer); ok {
+		err = closer.Close()
+	}
+	c.notifier.fire()
+	return err
+}
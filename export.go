@@ -0,0 +1,197 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DataFormat selects the row encoding of ExportTable and ImportTable.
+type DataFormat int
+
+const (
+	// FormatCSV writes RFC 4180 CSV with a header row of column names.
+	// NULL is an empty field and the empty string a quoted one (""), as in
+	// PostgreSQL's COPY ... CSV.
+	FormatCSV DataFormat = iota
+
+	// FormatJSONLines writes one JSON object per row, with the columns as
+	// keys in declaration order. NULL is null.
+	FormatJSONLines
+)
+
+// String returns "csv" or "jsonl".
+func (f DataFormat) String() string {
+	switch f {
+	case FormatCSV:
+		return "csv"
+	case FormatJSONLines:
+		return "jsonl"
+	}
+	return fmt.Sprintf("DataFormat(%d)", int(f))
+}
+
+// ExportTable writes every row of table to w in format and returns the
+// number of rows written. Columns appear in declaration order, including
+// generated ones. Values are encoded by their storage class rather than
+// their declared type: integers and reals as numbers, text as is, blobs in
+// standard base64, and timestamps the driver returns as time.Time in
+// RFC 3339 UTC. A missing table is an error wrapping ErrNotFound.
+func ExportTable(ctx context.Context, db *sql.DB, table string, w io.Writer, format DataFormat) (int64, error) {
+	ctx = withInternal(ctx)
+	if format != FormatCSV && format != FormatJSONLines {
+		return 0, fmt.Errorf("export %s: unknown format %v", table, format)
+	}
+	cols, err := Columns(ctx, db, table)
+	if err != nil {
+		return 0, fmt.Errorf("export %s: %w", table, err)
+	}
+	names := make([]string, len(cols))
+	quoted := make([]string, len(cols))
+	for i, c := range cols {
+		names[i] = c.Name
+		quoted[i] = quoteIdent(c.Name)
+	}
+
+	rows, err := db.QueryContext(ctx, `SELECT `+strings.Join(quoted, ", ")+` FROM `+quoteIdent(table))
+	if err != nil {
+		return 0, fmt.Errorf("export %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	bw := bufio.NewWriter(w)
+	keys := make([][]byte, len(names))
+	for i, name := range names {
+		keys[i], _ = json.Marshal(name)
+	}
+	if format == FormatCSV {
+		for i, name := range names {
+			if i > 0 {
+				bw.WriteByte(',')
+			}
+			writeCSVField(bw, name)
+		}
+		bw.WriteString("\r\n")
+	}
+
+	values := make([]any, len(cols))
+	dest := make([]any, len(cols))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	var n int64
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return n, fmt.Errorf("export %s: %w", table, err)
+		}
+		if format == FormatCSV {
+			err = writeCSVRow(bw, values)
+		} else {
+			err = writeJSONRow(bw, keys, values)
+		}
+		if err != nil {
+			return n, fmt.Errorf("export %s: row %d: %w", table, n+1, err)
+		}
+		n++
+	}
+	if err := rows.Err(); err != nil {
+		return n, fmt.Errorf("export %s: %w", table, err)
+	}
+	if err := bw.Flush(); err != nil {
+		return n, fmt.Errorf("export %s: %w", table, err)
+	}
+	return n, nil
+}
+
+// exportText returns the text of a non-NULL value as ExportTable writes it.
+func exportText(v any) (string, error) {
+	switch v := v.(type) {
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64), nil
+	case string:
+		return v, nil
+	case []byte:
+		return base64.StdEncoding.EncodeToString(v), nil
+	case time.Time:
+		return v.UTC().Format(time.RFC3339Nano), nil
+	case bool:
+		if v {
+			return "1", nil
+		}
+		return "0", nil
+	}
+	return "", fmt.Errorf("unsupported value of type %T", v)
+}
+
+// writeCSVRow writes one CSV record. NULL is an empty field.
+func writeCSVRow(w *bufio.Writer, values []any) error {
+	for i, v := range values {
+		if i > 0 {
+			w.WriteByte(',')
+		}
+		if v == nil {
+			continue
+		}
+		s, err := exportText(v)
+		if err != nil {
+			return err
+		}
+		writeCSVField(w, s)
+	}
+	_, err := w.WriteString("\r\n")
+	return err
+}
+
+// writeCSVField writes s, quoting it if it is empty, so that it isn't read
+// back as NULL, or if it holds a separator, quote or line break.
+func writeCSVField(w *bufio.Writer, s string) {
+	if s != "" && !strings.ContainsAny(s, ",\"\r\n") {
+		w.WriteString(s)
+		return
+	}
+	w.WriteByte('"')
+	w.WriteString(strings.ReplaceAll(s, `"`, `""`))
+	w.WriteByte('"')
+}
+
+// writeJSONRow writes one row as a JSON object on its own line.
+func writeJSONRow(w *bufio.Writer, keys [][]byte, values []any) error {
+	w.WriteByte('{')
+	for i, v := range values {
+		if i > 0 {
+			w.WriteByte(',')
+		}
+		w.Write(keys[i])
+		w.WriteByte(':')
+		switch v.(type) {
+		case nil:
+			w.WriteString("null")
+			continue
+		case int64, float64, bool:
+		default:
+			s, err := exportText(v)
+			if err != nil {
+				return err
+			}
+			v = s
+		}
+		data, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		w.Write(data)
+	}
+	_, err := w.WriteString("}\n")
+	return err
+}
@@ -0,0 +1,42 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+// Test hooks for package sqliteinit_test.
+
+// NormalizePathFor exposes path normalization for an arbitrary GOOS.
+func NormalizePathFor(goos, p string) string { return pathStyleFor(goos).normalize(p) }
+
+// IsAbsPathFor exposes absolute path detection for an arbitrary GOOS.
+func IsAbsPathFor(goos, p string) bool { return pathStyleFor(goos).isAbs(p) }
+
+// SamePathFor exposes path comparison for an arbitrary GOOS.
+func SamePathFor(goos, a, b string) bool { return pathStyleFor(goos).same(a, b) }
+
+// FileURIFor exposes file: URI construction for an arbitrary GOOS.
+func FileURIFor(goos, p string) string { return pathStyleFor(goos).uri(p) }
+
+// AccessedTables exposes the audit's table scanner.
+func AccessedTables(query string) (reads, writes []string) { return accessedTables(query) }
+
+// InfraSchemaVersion exposes the revision of the package's tables.
+const InfraSchemaVersion = infraSchemaVersion
+
+// SetFreeSpace makes the disk space preflight checks see avail bytes free,
+// until the returned function is called.
+func SetFreeSpace(avail int64) (restore func()) {
+	saved := freeSpace
+	freeSpace = func(string) (int64, bool, error) { return avail, true, nil }
+	return func() { freeSpace = saved }
+}
+
+// PostgresPrepass exposes the pg_dump COPY block conversion.
+func PostgresPrepass(script string) (string, error) { return postgresPrepass(script) }
+
+// SetRename replaces the rename Move and Restore use, until the returned
+// function is called.
+func SetRename(fn func(from, to string) error) (restore func()) {
+	saved := rename
+	rename = fn
+	return func() { rename = saved }
+}
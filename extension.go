@@ -0,0 +1,86 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// extensionLoader is implemented by driver connections that can load
+// SQLite extensions, such as mattn/go-sqlite3's *SQLiteConn.
+type extensionLoader interface {
+	LoadExtension(lib, entry string) error
+}
+
+// extensionConnector loads cfg.Extensions on each new connection, so
+// virtual tables and functions they provide are available to migrations
+// and to every connection in the pool.
+type extensionConnector struct {
+	next       driver.Connector
+	driverName string
+	extensions []string
+}
+
+func (c *extensionConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.next.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	loader, ok := conn.(extensionLoader)
+	if !ok {
+		conn.Close()
+		return nil, fmt.Errorf("driver %q does not support loading extensions", c.driverName)
+	}
+	for _, ext := range c.extensions {
+		if err := loadExtension(loader, ext); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("load extension %s: %w", ext, err)
+		}
+	}
+	return conn, nil
+}
+
+func (c *extensionConnector) Driver() driver.Driver {
+	return c.next.Driver()
+}
+
+// loadExtension loads lib, trying the entry point SQLite derives from the
+// file name and then the generic sqlite3_extension_init, as
+// sqlite3_load_extension does when no entry point is given. Drivers such as
+// mattn pass an empty entry point through rather than deriving one.
+func loadExtension(loader extensionLoader, lib string) error {
+	err := loader.LoadExtension(lib, extensionEntryPoint(lib))
+	if err == nil {
+		return nil
+	}
+	if loader.LoadExtension(lib, "sqlite3_extension_init") == nil {
+		return nil
+	}
+	return err
+}
+
+// extensionEntryPoint returns the entry point SQLite derives for an
+// extension file: "sqlite3_" followed by the lower-cased letters of the
+// base name, up to the first ".", with any "lib" prefix removed, then
+// "_init". For example, "/usr/lib/mod_spatialite.so" gives
+// "sqlite3_modspatialite_init".
+func extensionEntryPoint(lib string) string {
+	name := filepath.Base(lib)
+	name, _, _ = strings.Cut(name, ".")
+	name = strings.TrimPrefix(name, "lib")
+
+	var sb strings.Builder
+	sb.WriteString("sqlite3_")
+	for _, r := range name {
+		if unicode.IsLetter(r) {
+			sb.WriteRune(unicode.ToLower(r))
+		}
+	}
+	sb.WriteString("_init")
+	return sb.String()
+}
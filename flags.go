@@ -0,0 +1,79 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Flag is a feature flag stored in the database.
+type Flag struct {
+	Name      string
+	Enabled   bool
+	UpdatedAt time.Time
+}
+
+// FlagSet reads and writes the feature flags of a database. The flags are
+// kept in the package's flags table, which is created with its other
+// tables. Get one with Flags.
+type FlagSet struct {
+	db *sql.DB
+}
+
+// Flags returns the feature flags stored in db.
+func Flags(db *sql.DB) *FlagSet {
+	return &FlagSet{db: db}
+}
+
+// Enabled reports whether the flag called name is enabled. A flag that
+// has never been set is disabled.
+func (f *FlagSet) Enabled(ctx context.Context, name string) (bool, error) {
+	var enabled bool
+	err := f.db.QueryRowContext(withInternal(ctx), `SELECT enabled FROM flags WHERE name = ?`, name).Scan(&enabled)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("flag %q: %w", name, err)
+	}
+	return enabled, nil
+}
+
+// Set enables or disables the flag called name, creating it if needed.
+func (f *FlagSet) Set(ctx context.Context, name string, enabled bool) error {
+	if name == "" {
+		return fmt.Errorf("flag: name is required")
+	}
+	ts := time.Now().UTC().Unix()
+	if _, err := f.db.ExecContext(withInternal(ctx), `
+		INSERT INTO flags (name, enabled, created_at, updated_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (name) DO UPDATE SET enabled = excluded.enabled, updated_at = excluded.updated_at
+	`, name, enabled, ts, ts); err != nil {
+		return fmt.Errorf("flag %q: %w", name, err)
+	}
+	return nil
+}
+
+// List returns every flag that has been set, sorted by name.
+func (f *FlagSet) List(ctx context.Context) ([]Flag, error) {
+	rows, err := f.db.QueryContext(withInternal(ctx), `SELECT name, enabled, updated_at FROM flags ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("list flags: %w", err)
+	}
+	defer rows.Close()
+
+	var flags []Flag
+	for rows.Next() {
+		var flag Flag
+		var updatedAt int64
+		if err := rows.Scan(&flag.Name, &flag.Enabled, &updatedAt); err != nil {
+			return nil, err
+		}
+		flag.UpdatedAt = time.Unix(updatedAt, 0).UTC()
+		flags = append(flags, flag)
+	}
+	return flags, rows.Err()
+}
@@ -0,0 +1,140 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+)
+
+// Func is a Go function made available to SQL on every connection, before
+// migrations run. Set exactly one of Scalar and NewAggregate.
+//
+// Arguments and results use the SQLite storage types: int64, float64,
+// string, []byte and nil.
+type Func struct {
+	// Name is the SQL name of the function. A function named "regexp"
+	// implements the REGEXP operator: X REGEXP Y calls regexp(Y, X).
+	Name string
+
+	// NArgs is the number of arguments, or -1 for any number.
+	NArgs int
+
+	// Deterministic promises the result depends only on the arguments,
+	// which lets SQLite use the function in indexes and CHECK constraints.
+	Deterministic bool
+
+	// Scalar computes the result for one call.
+	Scalar func(args []any) (any, error)
+
+	// NewAggregate returns the state for one evaluation of an aggregate
+	// function.
+	NewAggregate func() Aggregate
+}
+
+// Aggregate is the state of one evaluation of an aggregate Func.
+type Aggregate interface {
+	// Step adds one row's arguments.
+	Step(args []any) error
+
+	// Value returns the result after the last row.
+	Value() (any, error)
+}
+
+// checkArgs returns an error if args doesn't match f.NArgs.
+func (f Func) checkArgs(args []any) error {
+	if f.NArgs >= 0 && len(args) != f.NArgs {
+		return fmt.Errorf("%s: expected %d arguments, got %d", f.Name, f.NArgs, len(args))
+	}
+	return nil
+}
+
+// validate returns an error if f can't be registered.
+func (f Func) validate() error {
+	switch {
+	case f.Name == "":
+		return fmt.Errorf("function name required")
+	case (f.Scalar == nil) == (f.NewAggregate == nil):
+		return fmt.Errorf("%s: set exactly one of Scalar and NewAggregate", f.Name)
+	case f.NArgs < -1:
+		return fmt.Errorf("%s: NArgs must be -1 or more", f.Name)
+	}
+	return nil
+}
+
+// registerGlobalFuncs registers functions for drivers whose registration
+// is process-wide rather than per connection (modernc). It is nil when no
+// such driver is built in; see functions_modernc.go.
+var registerGlobalFuncs func(driverName string, fns []Func) (ok bool, err error)
+
+// funcRegistrar is implemented by driver connections that register Go
+// functions per connection, such as mattn/go-sqlite3's *SQLiteConn.
+type funcRegistrar interface {
+	RegisterFunc(name string, impl any, pure bool) error
+	RegisterAggregator(name string, impl any, pure bool) error
+}
+
+// funcConnector registers functions on each new connection.
+type funcConnector struct {
+	next       driver.Connector
+	driverName string
+	fns        []Func
+}
+
+func (c *funcConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.next.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	r, ok := conn.(funcRegistrar)
+	if !ok {
+		conn.Close()
+		return nil, fmt.Errorf("driver %q does not support custom functions", c.driverName)
+	}
+	for _, f := range c.fns {
+		if err := registerConnFunc(r, f); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("register function %s: %w", f.Name, err)
+		}
+	}
+	return conn, nil
+}
+
+func (c *funcConnector) Driver() driver.Driver {
+	return c.next.Driver()
+}
+
+// registerConnFunc registers f through the reflection-based API of
+// funcRegistrar.
+func registerConnFunc(r funcRegistrar, f Func) error {
+	if f.Scalar != nil {
+		return r.RegisterFunc(f.Name, func(args ...any) (any, error) {
+			if err := f.checkArgs(args); err != nil {
+				return nil, err
+			}
+			return f.Scalar(args)
+		}, f.Deterministic)
+	}
+	return r.RegisterAggregator(f.Name, func() *connAggregate {
+		return &connAggregate{f: f, agg: f.NewAggregate()}
+	}, f.Deterministic)
+}
+
+// connAggregate adapts an Aggregate to the Step/Done methods funcRegistrar
+// expects.
+type connAggregate struct {
+	f   Func
+	agg Aggregate
+}
+
+func (a *connAggregate) Step(args ...any) error {
+	if err := a.f.checkArgs(args); err != nil {
+		return err
+	}
+	return a.agg.Step(args)
+}
+
+func (a *connAggregate) Done() (any, error) {
+	return a.agg.Value()
+}
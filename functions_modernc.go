@@ -0,0 +1,103 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+//go:build !mattn
+
+package sqliteinit
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"sync"
+
+	"modernc.org/sqlite"
+)
+
+// modernc registers functions for every connection in the process, and
+// only once per name. Each name is registered with a trampoline that calls
+// the Func most recently given for it, so reopening with an updated Config
+// takes effect.
+var moderncFuncs struct {
+	mu  sync.Mutex
+	fns map[string]Func
+}
+
+func init() {
+	registerGlobalFuncs = registerModerncFuncs
+}
+
+// registerModerncFuncs registers fns with modernc.org/sqlite. ok is false
+// if driverName is not modernc's.
+func registerModerncFuncs(driverName string, fns []Func) (ok bool, err error) {
+	if driverName != Modernc.Name() {
+		return false, nil
+	}
+
+	moderncFuncs.mu.Lock()
+	defer moderncFuncs.mu.Unlock()
+	if moderncFuncs.fns == nil {
+		moderncFuncs.fns = make(map[string]Func)
+	}
+
+	for _, f := range fns {
+		if prev, ok := moderncFuncs.fns[f.Name]; ok {
+			if prev.NArgs != f.NArgs || prev.Deterministic != f.Deterministic || (prev.Scalar == nil) != (f.Scalar == nil) {
+				return true, fmt.Errorf("function %s: already registered with a different signature", f.Name)
+			}
+			moderncFuncs.fns[f.Name] = f
+			continue
+		}
+
+		name := f.Name
+		impl := &sqlite.FunctionImpl{NArgs: int32(f.NArgs), Deterministic: f.Deterministic}
+		if f.Scalar != nil {
+			impl.Scalar = func(_ *sqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+				f := moderncFunc(name)
+				return f.Scalar(anyArgs(args))
+			}
+		} else {
+			impl.MakeAggregate = func(sqlite.FunctionContext) (sqlite.AggregateFunction, error) {
+				return &moderncAggregate{agg: moderncFunc(name).NewAggregate()}, nil
+			}
+		}
+		if err := sqlite.RegisterFunction(name, impl); err != nil {
+			return true, fmt.Errorf("function %s: %w", name, err)
+		}
+		moderncFuncs.fns[name] = f
+	}
+	return true, nil
+}
+
+// moderncFunc returns the current Func registered under name.
+func moderncFunc(name string) Func {
+	moderncFuncs.mu.Lock()
+	defer moderncFuncs.mu.Unlock()
+	return moderncFuncs.fns[name]
+}
+
+// anyArgs copies driver values to a []any.
+func anyArgs(args []driver.Value) []any {
+	out := make([]any, len(args))
+	for i, a := range args {
+		out[i] = a
+	}
+	return out
+}
+
+// moderncAggregate adapts an Aggregate to sqlite.AggregateFunction.
+type moderncAggregate struct {
+	agg Aggregate
+}
+
+func (a *moderncAggregate) Step(_ *sqlite.FunctionContext, args []driver.Value) error {
+	return a.agg.Step(anyArgs(args))
+}
+
+func (a *moderncAggregate) WindowInverse(*sqlite.FunctionContext, []driver.Value) error {
+	return fmt.Errorf("custom aggregates can't be used as window functions")
+}
+
+func (a *moderncAggregate) WindowValue(*sqlite.FunctionContext) (driver.Value, error) {
+	return a.agg.Value()
+}
+
+func (a *moderncAggregate) Final(*sqlite.FunctionContext) {}
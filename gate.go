@@ -0,0 +1,118 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+// ErrGateClosed is returned by Gate.Enter when the gate is paused and the
+// gate is configured to fail fast.
+var ErrGateClosed = errors.New("write gate closed")
+
+// Gate lets maintenance and migration operations briefly quiesce
+// application writes without a process restart. Application code wraps
+// each write (or write transaction) in Enter and the returned release
+// function; an operation that needs a stable file calls Pause, does its
+// work, then calls Resume.
+//
+// The zero value is an open gate whose Enter blocks while paused.
+type Gate struct {
+	// FailFast makes Enter return ErrGateClosed immediately while the gate
+	// is paused instead of blocking until it is resumed.
+	FailFast bool
+
+	mu      sync.Mutex
+	paused  bool
+	active  int
+	resumed chan struct{} // closed on Resume
+	drained chan struct{} // closed when active drops to zero while paused
+}
+
+// Enter admits a writer. While the gate is paused it blocks until Resume or
+// until ctx is done, or returns ErrGateClosed if FailFast is set. The caller
+// must call the returned release function when the write completes.
+func (g *Gate) Enter(ctx context.Context) (release func(), err error) {
+	g.mu.Lock()
+	for g.paused {
+		if g.FailFast {
+			g.mu.Unlock()
+			return nil, ErrGateClosed
+		}
+		resumed := g.resumed
+		g.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-resumed:
+		}
+		g.mu.Lock()
+	}
+	g.active++
+	g.mu.Unlock()
+
+	var once sync.Once
+	return func() { once.Do(g.leave) }, nil
+}
+
+// leave records that a writer has finished.
+func (g *Gate) leave() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.active--
+	if g.active == 0 && g.drained != nil {
+		close(g.drained)
+		g.drained = nil
+	}
+}
+
+// Pause closes the gate to new writers and waits for in-flight writers to
+// release. If ctx is done before they drain, the gate is reopened and the
+// context error is returned.
+func (g *Gate) Pause(ctx context.Context) error {
+	g.mu.Lock()
+	if g.paused {
+		g.mu.Unlock()
+		return errors.New("gate already paused")
+	}
+	g.paused = true
+	g.resumed = make(chan struct{})
+	if g.active == 0 {
+		g.mu.Unlock()
+		return nil
+	}
+	drained := make(chan struct{})
+	g.drained = drained
+	g.mu.Unlock()
+
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		g.mu.Lock()
+		g.drained = nil
+		g.mu.Unlock()
+		g.Resume()
+		return ctx.Err()
+	}
+}
+
+// Resume reopens the gate and releases any blocked writers.
+func (g *Gate) Resume() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !g.paused {
+		return
+	}
+	g.paused = false
+	close(g.resumed)
+}
+
+// Paused returns true if the gate is currently paused.
+func (g *Gate) Paused() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return g.paused
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"time"
+)
+
+// Health is the state of a database for readiness probes. It is designed
+// to be embedded in a service's /readyz response.
+type Health struct {
+	// Initialized reports whether the package's schema is present.
+	Initialized bool `json:"initialized"`
+
+	// SchemaVersion is the ID of the last applied migration, or 0.
+	SchemaVersion int `json:"schema_version"`
+
+	// Pending is the number of migrations not yet applied. It is 0 for an
+	// uninitialized database.
+	Pending int `json:"pending"`
+
+	// LastMigrationAt is when the most recent migration was applied, or the
+	// zero time if none has been.
+	LastMigrationAt time.Time `json:"-"`
+
+	// PingLatency is how long a round trip to the database took.
+	PingLatency time.Duration `json:"-"`
+}
+
+// Ready reports whether the database is initialized and fully migrated.
+func (h Health) Ready() bool {
+	return h.Initialized && h.Pending == 0
+}
+
+// MarshalJSON encodes last_migration_at as an RFC 3339 timestamp in UTC,
+// or null if no migration has been applied, and the ping latency as
+// microseconds in ping_latency_us.
+func (h Health) MarshalJSON() ([]byte, error) {
+	type health Health // without the MarshalJSON method
+	var last *string
+	if !h.LastMigrationAt.IsZero() {
+		s := h.LastMigrationAt.UTC().Format(time.RFC3339)
+		last = &s
+	}
+	return json.Marshal(struct {
+		health
+		Ready           bool    `json:"ready"`
+		LastMigrationAt *string `json:"last_migration_at"`
+		PingLatencyUS   int64   `json:"ping_latency_us"`
+	}{health(h), h.Ready(), last, h.PingLatency.Microseconds()})
+}
+
+// HealthCheck pings db and compares it with migrations. It returns an
+// error only if the database can't be reached or read; an uninitialized
+// or partly migrated database is reported in the Health, not as an error.
+func HealthCheck(ctx context.Context, db *sql.DB, migrations fs.FS) (*Health, error) {
+	ctx = withInternal(ctx)
+	start := time.Now()
+	if err := db.PingContext(ctx); err != nil {
+		return nil, fmt.Errorf("health: ping: %w", err)
+	}
+	h := &Health{PingLatency: time.Since(start)}
+
+	status, err := getStatus(ctx, db, Config{Migrations: migrations}.defaults())
+	if err != nil {
+		return nil, fmt.Errorf("health: %w", err)
+	}
+	h.Initialized = status.IsInitialized
+	h.SchemaVersion = status.SchemaVersion
+	h.Pending = len(status.Pending)
+	for _, m := range status.Applied {
+		if m.AppliedAt.After(h.LastMigrationAt) {
+			h.LastMigrationAt = m.AppliedAt
+		}
+	}
+	return h, nil
+}
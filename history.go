@@ -0,0 +1,115 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/user"
+	"time"
+)
+
+// HistoryFilter selects the applied migrations returned by History.
+type HistoryFilter struct {
+	// Since, if non-zero, excludes migrations applied before it.
+	Since time.Time
+
+	// Limit, if positive, caps the number of migrations returned.
+	Limit int
+
+	// Offset skips this many migrations, for paging.
+	Offset int
+}
+
+// History returns the migrations applied to db, newest first, filtered
+// by filter, for admin UIs that page through the history. The init entry
+// for the package's own schema (ID 0, "schema.sql") is included.
+//
+// Duration, Checksum and AppliedBy are recorded for migrations applied by
+// this release of the package; they are zero for older entries.
+func History(ctx context.Context, db *sql.DB, filter HistoryFilter) ([]AppliedMigration, error) {
+	if filter.Limit < 0 || filter.Offset < 0 {
+		return nil, fmt.Errorf("history: Limit and Offset must not be negative")
+	}
+	query := `WHERE applied_at >= ? ORDER BY applied_at DESC, id DESC`
+	var since int64
+	if !filter.Since.IsZero() {
+		since = filter.Since.Unix()
+	}
+	limit := -1
+	if filter.Limit > 0 {
+		limit = filter.Limit
+	}
+	query += ` LIMIT ? OFFSET ?`
+	return queryApplied(withInternal(ctx), db, query, since, limit, filter.Offset)
+}
+
+// queryApplied returns the schema_migrations rows selected by clauses
+// (WHERE, ORDER BY and LIMIT, as needed), or nil if the table doesn't
+// exist.
+func queryApplied(ctx context.Context, db *sql.DB, clauses string, args ...any) ([]AppliedMigration, error) {
+	// Databases created by older releases that haven't been migrated
+	// since lack the metadata columns.
+	metadata := `duration_ms, checksum, applied_by`
+	var n int
+	if err := db.QueryRowContext(ctx, `SELECT count(*) FROM pragma_table_info('schema_migrations') WHERE name = 'checksum'`).Scan(&n); err != nil {
+		return nil, err
+	}
+	if n == 0 {
+		metadata = `0, '', ''`
+	}
+
+	rows, err := db.QueryContext(ctx, `SELECT id, comment, path, applied_at, `+metadata+` FROM schema_migrations `+clauses, args...)
+	if err != nil {
+		if isNoSuchTable(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []AppliedMigration
+	for rows.Next() {
+		var m AppliedMigration
+		var appliedAt, durationMS int64
+		if err := rows.Scan(&m.ID, &m.Comment, &m.Path, &appliedAt, &durationMS, &m.Checksum, &m.AppliedBy); err != nil {
+			return nil, err
+		}
+		m.AppliedAt = time.Unix(appliedAt, 0).UTC()
+		m.Duration = time.Duration(durationMS) * time.Millisecond
+		result = append(result, m)
+	}
+	return result, rows.Err()
+}
+
+// checksum returns the SHA-256 of a migration script, hex encoded.
+func checksum(script []byte) string {
+	sum := sha256.Sum256(script)
+	return hex.EncodeToString(sum[:])
+}
+
+// appliedBy describes who is applying migrations, as "user@host", followed
+// by the application version if cfg.AppVersion is set. In deterministic
+// mode it leaves out the user and host.
+func appliedBy(cfg Config) string {
+	if cfg.Deterministic {
+		if cfg.AppVersion != "" {
+			return "app " + cfg.AppVersion
+		}
+		return ""
+	}
+	name := os.Getenv("USER")
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+	host, _ := os.Hostname()
+	by := name + "@" + host
+	if cfg.AppVersion != "" {
+		by += " (app " + cfg.AppVersion + ")"
+	}
+	return by
+}
@@ -0,0 +1,451 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// Dialect identifies the SQL dialect of a dump file.
+type Dialect int
+
+const (
+	// DialectSQLite dumps are executed as-is.
+	DialectSQLite Dialect = iota
+	// DialectPostgres dumps are in pg_dump plain-text format.
+	DialectPostgres
+	// DialectMySQL dumps are in mysqldump format.
+	DialectMySQL
+)
+
+// ImportOptions controls ImportDump and ImportTable.
+type ImportOptions struct {
+	// Dialect of the dump. Default: DialectSQLite.
+	Dialect Dialect
+
+	// Strict aborts the import on the first statement that can't be
+	// translated or executed. By default such statements are skipped and
+	// reported.
+	Strict bool
+
+	// Format of the rows read by ImportTable. Default: FormatCSV.
+	Format DataFormat
+
+	// BatchSize is the number of rows ImportTable inserts per transaction.
+	// Default: 1000.
+	BatchSize int
+
+	// Truncate makes ImportTable delete the table's rows first, in the
+	// transaction of the first batch.
+	Truncate bool
+}
+
+// ImportReport summarizes an ImportDump run.
+type ImportReport struct {
+	// Executed is the number of statements executed successfully.
+	Executed int
+
+	// Ignored is the number of statements dropped because they have no
+	// SQLite equivalent and don't affect the data (SET, GRANT, OWNER TO...).
+	Ignored int
+
+	// Untranslatable lists statements that were skipped because they could
+	// not be translated or failed to execute.
+	Untranslatable []UntranslatableStatement
+}
+
+// UntranslatableStatement describes a statement ImportDump skipped.
+type UntranslatableStatement struct {
+	Statement string
+	Reason    string
+}
+
+// ImportDump reads a SQL dump from r, translates common PostgreSQL or MySQL
+// constructs (types, serial/AUTO_INCREMENT, quoting, COPY blocks, table
+// options) into SQLite, and executes the result in a single transaction.
+//
+// Translation is best-effort and intended for migrating small services.
+// Review ImportReport.Untranslatable after importing.
+func ImportDump(ctx context.Context, db *sql.DB, r io.Reader, opts ImportOptions) (*ImportReport, error) {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("read dump: %w", err)
+	}
+	script := string(raw)
+
+	var translate func(string) (string, string, bool)
+	var routines []string
+	switch opts.Dialect {
+	case DialectSQLite:
+		translate = func(s string) (string, string, bool) { return s, "", true }
+	case DialectPostgres:
+		if script, err = postgresPrepass(script); err != nil {
+			return nil, fmt.Errorf("read dump: %w", err)
+		}
+		translate = translatePostgres
+	case DialectMySQL:
+		script, routines = extractDelimiterBlocks(script)
+		script = mysqlPrepass(script)
+		translate = translateMySQL
+	default:
+		return nil, fmt.Errorf("unknown dialect %d", opts.Dialect)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	report := &ImportReport{}
+	for _, stmt := range routines {
+		if opts.Strict {
+			return nil, fmt.Errorf("untranslatable statement: stored routines are not supported by SQLite: %s", abbreviate(stmt))
+		}
+		report.Untranslatable = append(report.Untranslatable, UntranslatableStatement{Statement: stmt, Reason: "stored routines are not supported by SQLite"})
+	}
+	for _, stmt := range splitStatements(script) {
+		out, reason, ok := translate(stmt)
+		if !ok {
+			if reason == "" {
+				report.Ignored++
+				continue
+			}
+			if opts.Strict {
+				return nil, fmt.Errorf("untranslatable statement: %s: %s", reason, abbreviate(stmt))
+			}
+			report.Untranslatable = append(report.Untranslatable, UntranslatableStatement{Statement: stmt, Reason: reason})
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, out); err != nil {
+			if opts.Strict || ctx.Err() != nil {
+				return nil, fmt.Errorf("exec %s: %w", abbreviate(out), err)
+			}
+			report.Untranslatable = append(report.Untranslatable, UntranslatableStatement{Statement: stmt, Reason: err.Error()})
+			continue
+		}
+		report.Executed++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// abbreviate shortens a statement for use in error messages.
+func abbreviate(stmt string) string {
+	stmt = strings.Join(strings.Fields(stmt), " ")
+	if len(stmt) > 60 {
+		return stmt[:57] + "..."
+	}
+	return stmt
+}
+
+// hasPrefixFold reports whether stmt begins with any of the given
+// space-separated keyword sequences, ignoring case and extra whitespace.
+func hasPrefixFold(stmt string, prefixes ...string) bool {
+	norm := strings.ToUpper(strings.Join(strings.Fields(stmt), " "))
+	for _, p := range prefixes {
+		if strings.HasPrefix(norm, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// sqlQuote returns s as a SQL string literal.
+func sqlQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+var (
+	reTableOptions  = regexp.MustCompile(`\)[^)]*$`)
+	reTrailingComma = regexp.MustCompile(`,(\s*\))$`)
+
+	reMySQLInt       = regexp.MustCompile(`(?i)\b(?:tiny|small|medium|big)?int(?:eger)?(?:\(\d+\))?(?:\s+unsigned)?(?:\s+zerofill)?\b`)
+	reMySQLUnsigned  = regexp.MustCompile(`(?i)\s+unsigned\b`)
+	reMySQLAutoInc   = regexp.MustCompile(`(?i)\s+AUTO_INCREMENT\b`)
+	reMySQLCharset   = regexp.MustCompile(`(?i)\s+(?:CHARACTER SET|CHARSET)\s+\w+`)
+	reMySQLCollate   = regexp.MustCompile(`(?i)\s+COLLATE\s+\w+`)
+	reMySQLOnUpdate  = regexp.MustCompile(`(?i)\s+ON UPDATE CURRENT_TIMESTAMP(?:\(\d*\))?`)
+	reMySQLEnum      = regexp.MustCompile(`(?i)\b(?:enum|set)\((?:[^()']|'(?:[^']|'')*')*\)`)
+	reMySQLComment   = regexp.MustCompile(`(?i)\s+COMMENT\s+'(?:[^']|'')*'`)
+	reMySQLIndexLine = regexp.MustCompile(`(?i)^\s*(?:FULLTEXT\s+|SPATIAL\s+)?(?:KEY|INDEX)\s`)
+	reMySQLUniqueKey = regexp.MustCompile(`(?i)^(\s*)UNIQUE\s+(?:KEY|INDEX)\s+(?:` + "`[^`]*`" + `|\w+)\s*\(`)
+
+	rePgSerial   = regexp.MustCompile(`(?i)\b(?:small|big)?serial\b`)
+	rePgNextval  = regexp.MustCompile(`(?i)\s+DEFAULT\s+nextval\('[^']*'(?:::regclass)?\)`)
+	rePgCast     = regexp.MustCompile(`::(?:"[^"]+"|[A-Za-z_][\w.]*)(?:\s+varying)?(?:\(\d+(?:,\s*\d+)?\))?(?:\[\])?`)
+	rePgSchema   = regexp.MustCompile(`\b(?:public|"public")\.`)
+	rePgBytea    = regexp.MustCompile(`(?i)\bbytea\b`)
+	rePgUsing    = regexp.MustCompile(`(?i)\s+USING\s+\w+\s*\(`)
+	rePgCopyHead = regexp.MustCompile(`(?i)^COPY\s+(\S+)\s*(\([^)]*\))?\s+FROM\s+stdin;?\s*$`)
+)
+
+// extractDelimiterBlocks removes mysqldump DELIMITER blocks, which only
+// hold stored routines and triggers in MySQL syntax, and returns the
+// statements they contained.
+func extractDelimiterBlocks(script string) (string, []string) {
+	var sb, block strings.Builder
+	var routines []string
+	delim := ";"
+
+	for _, line := range strings.SplitAfter(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if f := strings.Fields(trimmed); len(f) == 2 && strings.EqualFold(f[0], "DELIMITER") {
+			delim = f[1]
+			continue
+		}
+		if delim == ";" {
+			sb.WriteString(line)
+			continue
+		}
+		block.WriteString(line)
+		if strings.HasSuffix(trimmed, delim) {
+			stmt := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(block.String()), delim))
+			if stmt != "" {
+				routines = append(routines, stmt)
+			}
+			block.Reset()
+		}
+	}
+	return sb.String(), routines
+}
+
+// mysqlPrepass rewrites MySQL backslash escapes inside single-quoted
+// strings into standard SQL so the script can be split safely.
+func mysqlPrepass(script string) string {
+	var sb strings.Builder
+	for i := 0; i < len(script); i++ {
+		c := script[i]
+		switch {
+		case c == '-' && i+1 < len(script) && script[i+1] == '-',
+			c == '#':
+			j := strings.IndexByte(script[i:], '\n')
+			if j < 0 {
+				j = len(script) - i
+			}
+			if c == '#' {
+				// MySQL-only comment syntax; drop it.
+				i += j - 1
+				continue
+			}
+			sb.WriteString(script[i : i+j])
+			i += j - 1
+		case c == '/' && i+1 < len(script) && script[i+1] == '*':
+			j := strings.Index(script[i+2:], "*/")
+			if j < 0 {
+				j = len(script) - i - 4
+			}
+			sb.WriteString(script[i : i+j+4])
+			i += j + 3
+		case c == '`' || c == '"':
+			j := skipQuoted(script, i)
+			sb.WriteString(script[i:j])
+			i = j - 1
+		case c == '\'':
+			sb.WriteByte('\'')
+			for i++; i < len(script); i++ {
+				c = script[i]
+				if c == '\\' && i+1 < len(script) {
+					i++
+					switch script[i] {
+					case '\'':
+						sb.WriteString("''")
+					case 'n':
+						sb.WriteByte('\n')
+					case 'r':
+						sb.WriteByte('\r')
+					case 't':
+						sb.WriteByte('\t')
+					case '0':
+						// NUL bytes can't appear in SQLite text; drop them.
+					case 'Z':
+						sb.WriteByte(0x1a)
+					default:
+						sb.WriteByte(script[i])
+					}
+					continue
+				}
+				if c == '\'' {
+					if i+1 < len(script) && script[i+1] == '\'' {
+						sb.WriteString("''")
+						i++
+						continue
+					}
+					sb.WriteByte('\'')
+					break
+				}
+				sb.WriteByte(c)
+			}
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return sb.String()
+}
+
+// translateMySQL translates a single mysqldump statement. It returns
+// ok=false with an empty reason for statements that should be ignored.
+func translateMySQL(stmt string) (string, string, bool) {
+	switch {
+	case hasPrefixFold(stmt, "SET ", "LOCK TABLES", "UNLOCK TABLES", "USE ", "CREATE DATABASE", "START TRANSACTION", "COMMIT", "BEGIN"):
+		return "", "", false
+	case hasPrefixFold(stmt, "ALTER TABLE") && strings.Contains(strings.ToUpper(stmt), "KEYS"):
+		return "", "", false
+	case hasPrefixFold(stmt, "CREATE TABLE"):
+		return translateMySQLCreateTable(stmt), "", true
+	case hasPrefixFold(stmt, "CREATE PROCEDURE", "CREATE FUNCTION", "CREATE DEFINER", "CREATE EVENT"):
+		return "", "stored routines are not supported by SQLite", false
+	case hasPrefixFold(stmt, "ALTER TABLE"):
+		return "", "SQLite supports only limited ALTER TABLE", false
+	}
+	return stmt, "", true
+}
+
+// translateMySQLCreateTable rewrites a mysqldump CREATE TABLE statement.
+func translateMySQLCreateTable(stmt string) string {
+	stmt = reTableOptions.ReplaceAllString(stmt, ")")
+
+	var lines []string
+	for _, line := range strings.Split(stmt, "\n") {
+		if reMySQLIndexLine.MatchString(line) {
+			continue
+		}
+		line = reMySQLUniqueKey.ReplaceAllString(line, "${1}UNIQUE (")
+		lines = append(lines, line)
+	}
+	stmt = strings.Join(lines, "\n")
+
+	stmt = reMySQLComment.ReplaceAllString(stmt, "")
+	stmt = reMySQLEnum.ReplaceAllString(stmt, "TEXT")
+	stmt = reMySQLInt.ReplaceAllString(stmt, "INTEGER")
+	stmt = reMySQLUnsigned.ReplaceAllString(stmt, "")
+	stmt = reMySQLAutoInc.ReplaceAllString(stmt, "")
+	stmt = reMySQLCharset.ReplaceAllString(stmt, "")
+	stmt = reMySQLCollate.ReplaceAllString(stmt, "")
+	stmt = reMySQLOnUpdate.ReplaceAllString(stmt, "")
+	stmt = reTrailingComma.ReplaceAllString(stmt, "$1")
+	return stmt
+}
+
+// postgresPrepass converts pg_dump COPY blocks into INSERT statements and
+// drops psql meta-commands.
+func postgresPrepass(script string) (string, error) {
+	var sb strings.Builder
+	sc := bufio.NewScanner(strings.NewReader(script))
+	// No line is longer than the script, so large COPY rows always fit.
+	sc.Buffer(make([]byte, 0, 64*1024), max(64*1024, len(script)+1))
+
+	var table, columns string
+	inCopy := false
+	for sc.Scan() {
+		line := sc.Text()
+		if inCopy {
+			if line == `\.` {
+				inCopy = false
+				continue
+			}
+			var values []string
+			for _, field := range strings.Split(line, "\t") {
+				values = append(values, copyFieldLiteral(field))
+			}
+			fmt.Fprintf(&sb, "INSERT INTO %s%s VALUES (%s);\n", table, columns, strings.Join(values, ", "))
+			continue
+		}
+		if m := rePgCopyHead.FindStringSubmatch(line); m != nil {
+			table = rePgSchema.ReplaceAllString(m[1], "")
+			columns = ""
+			if m[2] != "" {
+				columns = " " + m[2]
+			}
+			inCopy = true
+			continue
+		}
+		if strings.HasPrefix(line, `\`) {
+			continue
+		}
+		sb.WriteString(line)
+		sb.WriteByte('\n')
+	}
+	if err := sc.Err(); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// copyFieldLiteral converts a field in COPY text format to a SQL literal.
+func copyFieldLiteral(field string) string {
+	if field == `\N` {
+		return "NULL"
+	}
+	if !strings.Contains(field, `\`) {
+		return sqlQuote(field)
+	}
+	var sb strings.Builder
+	for i := 0; i < len(field); i++ {
+		if field[i] != '\\' || i+1 == len(field) {
+			sb.WriteByte(field[i])
+			continue
+		}
+		i++
+		switch field[i] {
+		case 'n':
+			sb.WriteByte('\n')
+		case 'r':
+			sb.WriteByte('\r')
+		case 't':
+			sb.WriteByte('\t')
+		case 'b':
+			sb.WriteByte('\b')
+		case 'f':
+			sb.WriteByte('\f')
+		case 'v':
+			sb.WriteByte('\v')
+		default:
+			sb.WriteByte(field[i])
+		}
+	}
+	return sqlQuote(sb.String())
+}
+
+// translatePostgres translates a single pg_dump statement. It returns
+// ok=false with an empty reason for statements that should be ignored.
+func translatePostgres(stmt string) (string, string, bool) {
+	switch {
+	case hasPrefixFold(stmt, "SET ", "SELECT PG_CATALOG.", "CREATE EXTENSION", "COMMENT ON", "GRANT ", "REVOKE ",
+		"CREATE SEQUENCE", "ALTER SEQUENCE", "CREATE SCHEMA", "ALTER SCHEMA", "ALTER DEFAULT PRIVILEGES"):
+		return "", "", false
+	case hasPrefixFold(stmt, "ALTER ") && strings.Contains(strings.ToUpper(stmt), " OWNER TO "):
+		return "", "", false
+	case hasPrefixFold(stmt, "ALTER TABLE") && strings.Contains(strings.ToUpper(stmt), "NEXTVAL("):
+		// Sequence-backed defaults are covered by the INTEGER rowid alias.
+		return "", "", false
+	case hasPrefixFold(stmt, "CREATE TABLE"):
+		stmt = rePgSchema.ReplaceAllString(stmt, "")
+		stmt = rePgNextval.ReplaceAllString(stmt, "")
+		stmt = rePgCast.ReplaceAllString(stmt, "")
+		stmt = rePgSerial.ReplaceAllString(stmt, "INTEGER")
+		stmt = rePgBytea.ReplaceAllString(stmt, "BLOB")
+		return stmt, "", true
+	case hasPrefixFold(stmt, "CREATE INDEX", "CREATE UNIQUE INDEX"):
+		stmt = rePgSchema.ReplaceAllString(stmt, "")
+		stmt = strings.Replace(stmt, " ON ONLY ", " ON ", 1)
+		stmt = rePgUsing.ReplaceAllString(stmt, " (")
+		return stmt, "", true
+	case hasPrefixFold(stmt, "INSERT INTO"):
+		return rePgSchema.ReplaceAllString(stmt, ""), "", true
+	case hasPrefixFold(stmt, "ALTER TABLE"):
+		return "", "SQLite does not support adding constraints with ALTER TABLE", false
+	case hasPrefixFold(stmt, "CREATE FUNCTION", "CREATE OR REPLACE FUNCTION", "CREATE TYPE", "CREATE DOMAIN", "CREATE AGGREGATE", "CREATE MATERIALIZED VIEW", "CREATE POLICY"):
+		return "", "construct not supported by SQLite", false
+	}
+	return rePgSchema.ReplaceAllString(stmt, ""), "", true
+}
@@ -0,0 +1,447 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// defaultImportBatchSize is the default ImportOptions.BatchSize.
+const defaultImportBatchSize = 1000
+
+// TableImportReport summarizes an ImportTable run.
+type TableImportReport struct {
+	// Rows is the number of rows inserted and committed.
+	Rows int64
+
+	// Errors lists the rows that were skipped because they couldn't be
+	// decoded or inserted.
+	Errors []RowError
+}
+
+// RowError describes a row ImportTable skipped.
+type RowError struct {
+	// Row is the 1-based number of the record in the input, not counting
+	// the CSV header or blank lines.
+	Row int64
+
+	Err error
+}
+
+func (e RowError) Error() string {
+	return fmt.Sprintf("row %d: %v", e.Row, e.Err)
+}
+
+func (e RowError) Unwrap() error {
+	return e.Err
+}
+
+// ImportTable loads rows in opts.Format from r into table, in transactions
+// of opts.BatchSize rows with one prepared INSERT per set of columns. It
+// reads what ExportTable writes: a CSV file's header names the columns,
+// and a JSON object's keys do; columns left out get their defaults, and
+// generated columns are ignored. Empty CSV fields and JSON nulls are NULL,
+// and text for a column whose declared type contains BLOB is decoded from
+// base64. Other values are handed to SQLite as is, so column affinity, or a
+// STRICT table's type check, decides how they are stored.
+//
+// A row that can't be decoded or inserted, e.g. because it breaks a
+// constraint, is skipped and listed in the report. Malformed CSV, an
+// unknown column in the CSV header, or a failure to commit aborts the
+// import; batches committed before then stay. A missing table is an error
+// wrapping ErrNotFound.
+func ImportTable(ctx context.Context, db *sql.DB, table string, r io.Reader, opts ImportOptions) (*TableImportReport, error) {
+	cols, err := Columns(ctx, db, table)
+	if err != nil {
+		return nil, fmt.Errorf("import %s: %w", table, err)
+	}
+	return importRows(ctx, db, nil, table, cols, r, opts)
+}
+
+// importRows does the work of ImportTable for a table with columns cols.
+// If outer is set, every row is inserted in it rather than in batches of
+// their own, nothing is committed, and the first row that can't be
+// imported is returned as an error.
+func importRows(ctx context.Context, db *sql.DB, outer *sql.Tx, table string, cols []Column, r io.Reader, opts ImportOptions) (*TableImportReport, error) {
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultImportBatchSize
+	}
+
+	var src interface {
+		next() (importRow, error)
+	}
+	var err error
+	switch opts.Format {
+	case FormatCSV:
+		src, err = newCSVRows(r, cols)
+	case FormatJSONLines:
+		src = &jsonRows{r: bufio.NewReader(r), cols: cols}
+	default:
+		err = fmt.Errorf("unknown format %v", opts.Format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("import %s: %w", table, err)
+	}
+
+	report := &TableImportReport{}
+	var tx *sql.Tx
+	var stmts map[string]*sql.Stmt
+	var pending int64
+	truncate := opts.Truncate
+	defer func() {
+		if tx != nil && outer == nil {
+			tx.Rollback()
+		}
+	}()
+	begin := func() error {
+		var err error
+		if outer != nil {
+			tx = outer
+		} else if tx, err = db.BeginTx(ctx, nil); err != nil {
+			return err
+		}
+		stmts = make(map[string]*sql.Stmt)
+		if truncate {
+			truncate = false
+			if _, err := tx.ExecContext(ctx, `DELETE FROM `+quoteIdent(table)); err != nil {
+				return fmt.Errorf("truncate: %w", err)
+			}
+		}
+		return nil
+	}
+	commit := func() error {
+		var err error
+		if outer == nil {
+			err = tx.Commit()
+		}
+		tx = nil
+		if err != nil {
+			return err
+		}
+		report.Rows += pending
+		pending = 0
+		return nil
+	}
+
+	for row := int64(1); ; row++ {
+		rec, err := src.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return report, fmt.Errorf("import %s: %w", table, err)
+		}
+		if rec.err != nil {
+			if outer != nil {
+				return report, fmt.Errorf("import %s: %w", table, RowError{Row: row, Err: rec.err})
+			}
+			report.Errors = append(report.Errors, RowError{Row: row, Err: rec.err})
+			continue
+		}
+		if tx == nil {
+			if err := begin(); err != nil {
+				return report, fmt.Errorf("import %s: %w", table, err)
+			}
+		}
+		key := strings.Join(rec.columns, "\x00")
+		stmt := stmts[key]
+		if stmt == nil {
+			quoted := make([]string, len(rec.columns))
+			for i, c := range rec.columns {
+				quoted[i] = quoteIdent(c)
+			}
+			query := `INSERT INTO ` + quoteIdent(table) + ` DEFAULT VALUES`
+			if len(quoted) > 0 {
+				query = `INSERT INTO ` + quoteIdent(table) + ` (` + strings.Join(quoted, ", ") + `) VALUES (?` + strings.Repeat(", ?", len(quoted)-1) + `)`
+			}
+			if stmt, err = tx.PrepareContext(ctx, query); err != nil {
+				return report, fmt.Errorf("import %s: %w", table, err)
+			}
+			stmts[key] = stmt
+		}
+		if _, err := stmt.ExecContext(ctx, rec.values...); err != nil {
+			if outer != nil || ctx.Err() != nil || isBusy(err) {
+				return report, fmt.Errorf("import %s: row %d: %w", table, row, err)
+			}
+			report.Errors = append(report.Errors, RowError{Row: row, Err: err})
+			continue
+		}
+		if pending++; pending == int64(batchSize) {
+			if err := commit(); err != nil {
+				return report, fmt.Errorf("import %s: %w", table, err)
+			}
+		}
+	}
+	if tx == nil && truncate {
+		if err := begin(); err != nil {
+			return report, fmt.Errorf("import %s: %w", table, err)
+		}
+	}
+	if tx != nil {
+		if err := commit(); err != nil {
+			return report, fmt.Errorf("import %s: %w", table, err)
+		}
+	}
+	return report, nil
+}
+
+// importRow is a decoded input record.
+type importRow struct {
+	columns []string // the columns the record sets, in declaration order
+	values  []any
+	err     error // why the record can't be imported
+}
+
+// importValue converts decoded text for column c: base64 for BLOB columns,
+// as is otherwise.
+func importValue(c Column, s string) (any, error) {
+	if !strings.Contains(strings.ToUpper(c.Type), "BLOB") {
+		return s, nil
+	}
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("column %s: %w", c.Name, err)
+	}
+	return b, nil
+}
+
+// csvRows reads records of an RFC 4180 CSV file with a header row.
+type csvRows struct {
+	r       csvReader
+	columns []string // the insertable columns, in header order
+	fields  []int    // the field index of each of columns
+	want    int      // the number of fields in a record
+	byName  map[string]Column
+}
+
+// newCSVRows reads the header and matches it to cols.
+func newCSVRows(r io.Reader, cols []Column) (*csvRows, error) {
+	c := &csvRows{r: csvReader{r: bufio.NewReader(r)}, byName: make(map[string]Column, len(cols))}
+	for _, col := range cols {
+		c.byName[col.Name] = col
+	}
+	header, err := c.r.read()
+	if err == io.EOF {
+		return nil, fmt.Errorf("csv: missing header")
+	}
+	if err != nil {
+		return nil, err
+	}
+	c.want = len(header)
+	seen := make(map[string]bool)
+	for i, f := range header {
+		col, ok := c.byName[f.value]
+		if !ok {
+			return nil, fmt.Errorf("csv header: unknown column %q", f.value)
+		}
+		if seen[f.value] {
+			return nil, fmt.Errorf("csv header: duplicate column %q", f.value)
+		}
+		seen[f.value] = true
+		if col.Generated != "" {
+			continue
+		}
+		c.columns = append(c.columns, f.value)
+		c.fields = append(c.fields, i)
+	}
+	return c, nil
+}
+
+func (c *csvRows) next() (importRow, error) {
+	fields, err := c.r.read()
+	if err != nil {
+		return importRow{}, err
+	}
+	if len(fields) != c.want {
+		return importRow{err: fmt.Errorf("has %d fields, want %d", len(fields), c.want)}, nil
+	}
+	rec := importRow{columns: c.columns, values: make([]any, len(c.columns))}
+	for i, name := range c.columns {
+		f := fields[c.fields[i]]
+		if f.value == "" && !f.quoted {
+			continue // NULL
+		}
+		if rec.values[i], err = importValue(c.byName[name], f.value); err != nil {
+			return importRow{err: err}, nil
+		}
+	}
+	return rec, nil
+}
+
+// csvField is a CSV field and whether it was quoted, which tells an empty
+// string ("") from NULL (nothing).
+type csvField struct {
+	value  string
+	quoted bool
+}
+
+// csvReader reads RFC 4180 records. Unlike encoding/csv it reports which
+// fields were quoted. Blank lines are skipped and lines may end in CRLF or
+// LF.
+type csvReader struct {
+	r      *bufio.Reader
+	record int
+}
+
+// read returns the next record, or io.EOF after the last.
+func (c *csvReader) read() ([]csvField, error) {
+	var fields []csvField
+	for {
+		f, delim, err := c.field()
+		if err != nil {
+			return nil, err
+		}
+		if fields == nil && delim != ',' && f == (csvField{}) {
+			if delim == 0 {
+				return nil, io.EOF
+			}
+			continue // a blank line
+		}
+		fields = append(fields, f)
+		if delim != ',' {
+			c.record++
+			return fields, nil
+		}
+	}
+}
+
+// field reads a field and the byte that ended it: ',', '\n', or 0 at the
+// end of the input.
+func (c *csvReader) field() (csvField, byte, error) {
+	var b strings.Builder
+	ch, err := c.r.ReadByte()
+	if err == io.EOF {
+		return csvField{}, 0, nil
+	} else if err != nil {
+		return csvField{}, 0, err
+	}
+
+	if ch == '"' {
+		for {
+			ch, err := c.r.ReadByte()
+			if err == io.EOF {
+				return csvField{}, 0, fmt.Errorf("csv: record %d: unterminated quoted field", c.record+1)
+			} else if err != nil {
+				return csvField{}, 0, err
+			}
+			if ch != '"' {
+				b.WriteByte(ch)
+				continue
+			}
+			next, err := c.r.ReadByte()
+			if err == io.EOF {
+				return csvField{b.String(), true}, 0, nil
+			} else if err != nil {
+				return csvField{}, 0, err
+			}
+			switch next {
+			case '"':
+				b.WriteByte('"')
+				continue
+			case ',', '\n':
+				return csvField{b.String(), true}, next, nil
+			case '\r':
+				if lf, err := c.r.ReadByte(); err == nil && lf == '\n' {
+					return csvField{b.String(), true}, '\n', nil
+				}
+			}
+			return csvField{}, 0, fmt.Errorf("csv: record %d: unexpected text after quoted field", c.record+1)
+		}
+	}
+
+	for {
+		switch ch {
+		case ',':
+			return csvField{value: b.String()}, ch, nil
+		case '\n':
+			return csvField{value: strings.TrimSuffix(b.String(), "\r")}, ch, nil
+		}
+		b.WriteByte(ch)
+		if ch, err = c.r.ReadByte(); err == io.EOF {
+			return csvField{value: strings.TrimSuffix(b.String(), "\r")}, 0, nil
+		} else if err != nil {
+			return csvField{}, 0, err
+		}
+	}
+}
+
+// jsonRows reads JSON objects, one per line.
+type jsonRows struct {
+	r    *bufio.Reader
+	cols []Column
+}
+
+func (j *jsonRows) next() (importRow, error) {
+	var line []byte
+	for {
+		var err error
+		line, err = j.r.ReadBytes('\n')
+		if err != nil && err != io.EOF {
+			return importRow{}, err
+		}
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			break
+		}
+		if err == io.EOF {
+			return importRow{}, io.EOF
+		}
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+	var obj map[string]any
+	if err := dec.Decode(&obj); err != nil {
+		return importRow{err: err}, nil
+	}
+	if obj == nil {
+		return importRow{err: errors.New("not a JSON object")}, nil
+	}
+	var rec importRow
+	for _, c := range j.cols {
+		v, ok := obj[c.Name]
+		if !ok {
+			continue
+		}
+		delete(obj, c.Name)
+		if c.Generated != "" {
+			continue
+		}
+		switch x := v.(type) {
+		case json.Number:
+			if n, err := x.Int64(); err == nil {
+				v = n
+			} else if v, err = x.Float64(); err != nil {
+				return importRow{err: fmt.Errorf("column %s: %w", c.Name, err)}, nil
+			}
+		case string:
+			var err error
+			if v, err = importValue(c, x); err != nil {
+				return importRow{err: err}, nil
+			}
+		case bool:
+			v = 0
+			if x {
+				v = 1
+			}
+		case map[string]any, []any:
+			data, _ := json.Marshal(x)
+			v = string(data)
+		}
+		rec.columns = append(rec.columns, c.Name)
+		rec.values = append(rec.values, v)
+	}
+	for name := range obj {
+		return importRow{err: fmt.Errorf("unknown column %q", name)}, nil
+	}
+	return rec, nil
+}
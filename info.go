@@ -0,0 +1,85 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// DBInfo describes the storage of an open database, for capacity planning.
+type DBInfo struct {
+	// Path is the main database file, or "" for an in-memory database.
+	Path string `json:"path"`
+
+	// FileSize is the size of the database file in bytes, or 0 if it has
+	// no file.
+	FileSize int64 `json:"file_size"`
+
+	// WALSize is the size of the -wal file in bytes, or 0 if there is none.
+	WALSize int64 `json:"wal_size"`
+
+	PageSize      int64 `json:"page_size"`
+	PageCount     int64 `json:"page_count"`
+	FreelistCount int64 `json:"freelist_count"`
+
+	// JournalMode is the journal mode, e.g. "wal" or "memory".
+	JournalMode string `json:"journal_mode"`
+
+	// ForeignKeys reports whether foreign key enforcement is on for the
+	// connection that answered.
+	ForeignKeys bool `json:"foreign_keys"`
+
+	// SQLiteVersion is the version of the SQLite library, e.g. "3.46.0".
+	SQLiteVersion string `json:"sqlite_version"`
+
+	// InfraVersion is the revision of the package's own tables, or 0 if the
+	// database isn't initialized.
+	InfraVersion int `json:"infra_version"`
+}
+
+// Info returns the size and settings of db's main database. It only reads
+// pragmas and the sizes of its files, so it is cheap enough to call from
+// health endpoints.
+func Info(ctx context.Context, db *sql.DB) (*DBInfo, error) {
+	ctx = withInternal(ctx)
+	var info DBInfo
+	var err error
+	if info.InfraVersion, err = fetchInfraVersion(ctx, db); err != nil {
+		return nil, fmt.Errorf("info: %w", err)
+	}
+
+	// The pragmas are per connection; ask them all of the same one.
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("info: %w", err)
+	}
+	defer conn.Close()
+
+	if err := conn.QueryRowContext(ctx, `SELECT file FROM pragma_database_list WHERE name = 'main'`).Scan(&info.Path); err != nil {
+		return nil, fmt.Errorf("info: database_list: %w", err)
+	}
+	for _, p := range []struct {
+		name string
+		dest any
+	}{
+		{"page_size", &info.PageSize},
+		{"page_count", &info.PageCount},
+		{"freelist_count", &info.FreelistCount},
+		{"journal_mode", &info.JournalMode},
+		{"foreign_keys", &info.ForeignKeys},
+	} {
+		if err := conn.QueryRowContext(ctx, `PRAGMA `+p.name).Scan(p.dest); err != nil {
+			return nil, fmt.Errorf("info: %s: %w", p.name, err)
+		}
+	}
+	if err := conn.QueryRowContext(ctx, `SELECT sqlite_version()`).Scan(&info.SQLiteVersion); err != nil {
+		return nil, fmt.Errorf("info: sqlite_version: %w", err)
+	}
+	if info.Path != "" {
+		info.FileSize = fileSize(info.Path)
+		info.WALSize = fileSize(info.Path + "-wal")
+	}
+	return &info, nil
+}
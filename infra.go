@@ -0,0 +1,125 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// infraSchemaVersion is the revision of the package's own tables that
+// schema.sql creates. Bump it, here and in schema.sql's schema.infra_version
+// row, and add an entry to infraUpgrades whenever schema.sql changes.
+const infraSchemaVersion = 3
+
+// infraUpgrades bring the package's tables from one revision to the next:
+// infraUpgrades[i] upgrades revision i+1 to i+2. Each must be safe to run
+// on a database that is already partly upgraded, since releases before
+// the revision was recorded upgraded without recording it.
+var infraUpgrades = []func(ctx context.Context, db *sql.DB) error{
+	addMigrationMetadata, // 2: duration_ms, checksum and applied_by
+	addFlagsTable,        // 3: flags
+}
+
+// fetchInfraVersion returns the revision of the package's tables in db,
+// from the config key "schema.infra_version". It returns 0 if db isn't
+// initialized and 1 if it was initialized before the revision was recorded.
+func fetchInfraVersion(ctx context.Context, db *sql.DB) (int, error) {
+	var value string
+	err := db.QueryRowContext(ctx, `SELECT value FROM config WHERE key = 'schema.infra_version'`).Scan(&value)
+	if isNoSuchTable(err) {
+		return 0, nil
+	}
+	if err == sql.ErrNoRows {
+		return 1, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("fetch schema.infra_version: %w", err)
+	}
+	v, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid schema.infra_version %q: %w", value, err)
+	}
+	return v, nil
+}
+
+// setInfraVersion records the revision of the package's tables at now.
+func setInfraVersion(ctx context.Context, db *sql.DB, version int, now time.Time) error {
+	ts := now.UTC().Unix()
+	_, err := db.ExecContext(ctx, `
+		INSERT INTO config (key, value, created_at, updated_at) VALUES ('schema.infra_version', ?, ?, ?)
+		ON CONFLICT (key) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at
+	`, strconv.Itoa(version), ts, ts)
+	if err != nil {
+		return fmt.Errorf("set schema.infra_version: %w", err)
+	}
+	return nil
+}
+
+// upgradeInfraSchema brings the package's tables in a database created by
+// an older release up to infraSchemaVersion. It doesn't write to a database
+// that is already current.
+func upgradeInfraSchema(ctx context.Context, db *sql.DB, now time.Time) error {
+	version, err := fetchInfraVersion(ctx, db)
+	if err != nil {
+		return err
+	}
+	if version > infraSchemaVersion {
+		return fmt.Errorf("infrastructure schema revision %d is newer than this release supports (%d)", version, infraSchemaVersion)
+	}
+	if version == infraSchemaVersion {
+		return nil
+	}
+	for v := version; v < infraSchemaVersion; v++ {
+		if err := infraUpgrades[v-1](ctx, db); err != nil {
+			return fmt.Errorf("upgrade to revision %d: %w", v+1, err)
+		}
+	}
+	return setInfraVersion(ctx, db, infraSchemaVersion, now)
+}
+
+// addMigrationMetadata adds the schema_migrations columns that were added
+// to schema.sql in revision 2.
+func addMigrationMetadata(ctx context.Context, db *sql.DB) error {
+	cols, err := tableColumns(ctx, db, "schema_migrations")
+	if err != nil {
+		return err
+	}
+	have := make(map[string]bool, len(cols))
+	for _, c := range cols {
+		have[c.Name] = true
+	}
+	for _, add := range []struct{ name, def string }{
+		{"duration_ms", "INTEGER NOT NULL DEFAULT 0"},
+		{"checksum", "TEXT NOT NULL DEFAULT ''"},
+		{"applied_by", "TEXT NOT NULL DEFAULT ''"},
+	} {
+		if have[add.name] {
+			continue
+		}
+		if _, err := db.ExecContext(ctx, `ALTER TABLE schema_migrations ADD COLUMN `+add.name+` `+add.def); err != nil {
+			return fmt.Errorf("add schema_migrations.%s: %w", add.name, err)
+		}
+	}
+	return nil
+}
+
+// addFlagsTable creates the flags table that was added to schema.sql in
+// revision 3.
+func addFlagsTable(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS flags (
+		    name       TEXT    NOT NULL PRIMARY KEY,
+		    enabled    INTEGER NOT NULL DEFAULT 0,
+		    created_at INTEGER NOT NULL,
+		    updated_at INTEGER NOT NULL
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("create flags: %w", err)
+	}
+	return nil
+}
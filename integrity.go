@@ -0,0 +1,63 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// IntegrityCheck selects the check Open runs before migrating. See
+// Config.IntegrityCheckOnOpen.
+type IntegrityCheck int
+
+const (
+	// IntegrityCheckOff doesn't check.
+	IntegrityCheckOff IntegrityCheck = iota
+
+	// IntegrityCheckQuick runs PRAGMA quick_check, which takes about
+	// linear time but doesn't check that indexes match their tables.
+	IntegrityCheckQuick
+
+	// IntegrityCheckFull runs PRAGMA integrity_check.
+	IntegrityCheckFull
+)
+
+// CorruptionError is returned, wrapped, by Open when
+// Config.IntegrityCheckOnOpen finds problems. It matches ErrCorrupt with
+// errors.Is; use errors.As to get the report.
+type CorruptionError struct {
+	Path       string      // the database path, with credentials redacted
+	Violations []Violation // the problems found, each with Check "integrity"
+}
+
+func (e *CorruptionError) Error() string {
+	if len(e.Violations) == 1 {
+		return fmt.Sprintf("%s: %v: %s", e.Path, ErrCorrupt, e.Violations[0].Message)
+	}
+	return fmt.Sprintf("%s: %v: %d problems, first: %s", e.Path, ErrCorrupt, len(e.Violations), e.Violations[0].Message)
+}
+
+func (e *CorruptionError) Unwrap() error {
+	return ErrCorrupt
+}
+
+// checkIntegrity runs the check cfg.IntegrityCheckOnOpen selects,
+// returning a *CorruptionError if it finds problems.
+func checkIntegrity(ctx context.Context, db *sql.DB, cfg Config) error {
+	if cfg.IntegrityCheckOnOpen == IntegrityCheckOff {
+		return nil
+	}
+	start := time.Now()
+	violations, err := integrityViolations(ctx, db, cfg.IntegrityCheckOnOpen == IntegrityCheckQuick)
+	if err != nil {
+		return fmt.Errorf("integrity check: %w", err)
+	}
+	if len(violations) != 0 {
+		return &CorruptionError{Path: redactDSN(cfg.Path), Violations: violations}
+	}
+	cfg.Logger.Debug("integrity check passed", "elapsed", time.Since(start))
+	return nil
+}
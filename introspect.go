@@ -0,0 +1,170 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Table describes a table in the main database.
+type Table struct {
+	Name string
+
+	// Virtual is true for virtual tables, such as FTS5 tables.
+	Virtual bool
+
+	WithoutRowID bool
+	Strict       bool
+}
+
+// Column describes a table column.
+type Column struct {
+	Name string
+
+	// Type is the declared type, as written in CREATE TABLE; it may be "".
+	Type string
+
+	NotNull bool
+
+	// Default is the default value expression, as written in CREATE TABLE.
+	Default sql.NullString
+
+	// PrimaryKey is the column's 1-based position in the primary key, or 0.
+	PrimaryKey int
+
+	// Generated is "VIRTUAL" or "STORED" for generated columns, else "".
+	Generated string
+}
+
+// Index describes an index on a table.
+type Index struct {
+	Name string
+
+	Unique bool
+
+	// Origin is "c" for an index made by CREATE INDEX, "u" for one made by
+	// a UNIQUE constraint and "pk" for one made by a PRIMARY KEY.
+	Origin string
+
+	// Partial is true for an index with a WHERE clause.
+	Partial bool
+
+	// Columns are the indexed columns in key order. Expressions are "".
+	Columns []string
+}
+
+// Tables returns the tables in db's main database, sorted by name. SQLite's
+// own tables, the shadow tables of virtual tables and the package's tables
+// are left out.
+func Tables(ctx context.Context, db *sql.DB) ([]Table, error) {
+	rows, err := db.QueryContext(withInternal(ctx), `
+		SELECT name, type = 'virtual', wr, strict FROM pragma_table_list
+		WHERE schema = 'main' AND type IN ('table', 'virtual') AND name NOT LIKE 'sqlite\_%' ESCAPE '\'
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("tables: %w", err)
+	}
+	defer rows.Close()
+
+	var tables []Table
+	for rows.Next() {
+		var t Table
+		if err := rows.Scan(&t.Name, &t.Virtual, &t.WithoutRowID, &t.Strict); err != nil {
+			return nil, fmt.Errorf("tables: %w", err)
+		}
+		if isInternalTable(t.Name) {
+			continue
+		}
+		tables = append(tables, t)
+	}
+	return tables, rows.Err()
+}
+
+// Columns returns the columns of table in declaration order, including
+// generated columns, or an error wrapping ErrNotFound if the table doesn't
+// exist. The column facts match SQLite's behavior rather than the pragma's
+// raw output: primary key columns that can never be NULL are NotNull.
+func Columns(ctx context.Context, db *sql.DB, table string) ([]Column, error) {
+	cols, err := tableColumns(withInternal(ctx), db, table)
+	if err != nil {
+		return nil, fmt.Errorf("columns: %w", err)
+	}
+	if len(cols) == 0 {
+		return nil, fmt.Errorf("columns: table %q: %w", table, ErrNotFound)
+	}
+	result := make([]Column, len(cols))
+	for i, c := range cols {
+		result[i] = Column{
+			Name:       c.Name,
+			Type:       c.Type,
+			NotNull:    c.NotNull,
+			Default:    c.Default,
+			PrimaryKey: c.PrimaryKey,
+			Generated:  c.Generated,
+		}
+	}
+	return result, nil
+}
+
+// Indexes returns the indexes on table, sorted by name, including the
+// automatic indexes behind UNIQUE and PRIMARY KEY constraints. A table
+// without indexes has none; a missing table is an error wrapping
+// ErrNotFound.
+func Indexes(ctx context.Context, db *sql.DB, table string) ([]Index, error) {
+	ctx = withInternal(ctx)
+	var exists bool
+	if err := db.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM pragma_table_list(?) WHERE schema = 'main')`, table).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("indexes: %w", err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("indexes: table %q: %w", table, ErrNotFound)
+	}
+
+	rows, err := db.QueryContext(ctx, `SELECT name, "unique", origin, partial FROM pragma_index_list(?) ORDER BY name`, table)
+	if err != nil {
+		return nil, fmt.Errorf("indexes: %w", err)
+	}
+	var indexes []Index
+	for rows.Next() {
+		var idx Index
+		if err := rows.Scan(&idx.Name, &idx.Unique, &idx.Origin, &idx.Partial); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("indexes: %w", err)
+		}
+		indexes = append(indexes, idx)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("indexes: %w", err)
+	}
+
+	for i := range indexes {
+		if indexes[i].Columns, err = indexColumns(ctx, db, indexes[i].Name); err != nil {
+			return nil, fmt.Errorf("indexes: %s: %w", indexes[i].Name, err)
+		}
+	}
+	return indexes, nil
+}
+
+// indexColumns returns the key columns of index in order, with "" for
+// expressions.
+func indexColumns(ctx context.Context, db *sql.DB, index string) ([]string, error) {
+	rows, err := db.QueryContext(ctx, `SELECT name FROM pragma_index_info(?) ORDER BY seqno`, index)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var cols []string
+	for rows.Next() {
+		var name sql.NullString
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		cols = append(cols, name.String)
+	}
+	return cols, rows.Err()
+}
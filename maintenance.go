@@ -0,0 +1,359 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// MaintenanceTask is a unit of periodic database maintenance.
+type MaintenanceTask struct {
+	// Name identifies the task in logs and history. Must be unique.
+	Name string
+
+	// Interval is the minimum time between runs of the task. It acts as the
+	// task's rate limit: a task never runs more often than this.
+	Interval time.Duration
+
+	// Run performs the maintenance.
+	Run func(ctx context.Context, db *sql.DB) error
+
+	// Quiesce pauses MaintenanceConfig.Gate while the task runs so that
+	// application writes don't interleave with it.
+	Quiesce bool
+}
+
+// OptimizeTask returns a task that runs PRAGMA optimize.
+func OptimizeTask(interval time.Duration) MaintenanceTask {
+	return execTask("optimize", interval, `PRAGMA optimize`)
+}
+
+// AnalyzeTask returns a task that runs ANALYZE.
+func AnalyzeTask(interval time.Duration) MaintenanceTask {
+	return execTask("analyze", interval, `ANALYZE`)
+}
+
+// CheckpointTask returns a task that checkpoints and truncates the WAL.
+func CheckpointTask(interval time.Duration) MaintenanceTask {
+	return execTask("checkpoint", interval, `PRAGMA wal_checkpoint(TRUNCATE)`)
+}
+
+// VacuumTask returns a task that runs VACUUM.
+func VacuumTask(interval time.Duration) MaintenanceTask {
+	t := execTask("vacuum", interval, `VACUUM`)
+	t.Quiesce = true
+	return t
+}
+
+// IncrementalVacuumTask returns a task that returns up to pages free
+// pages to the file system, or all of them if pages is 0. It does nothing
+// unless the database has auto_vacuum set to INCREMENTAL; unlike VACUUM it
+// doesn't rewrite the file, so it needn't quiesce writers.
+func IncrementalVacuumTask(interval time.Duration, pages int) MaintenanceTask {
+	query := incrementalVacuumSQL(pages)
+	return MaintenanceTask{
+		Name:     "incremental_vacuum",
+		Interval: interval,
+		Run: func(ctx context.Context, db *sql.DB) error {
+			conn, err := db.Conn(ctx)
+			if err != nil {
+				return err
+			}
+			defer conn.Close()
+			return execToCompletion(ctx, conn, query)
+		},
+	}
+}
+
+// BackupTask returns a task that runs BackupRotate into dir with policy on
+// each run.
+func BackupTask(dir string, interval time.Duration, policy RotationPolicy) MaintenanceTask {
+	return MaintenanceTask{
+		Name:     "backup",
+		Interval: interval,
+		Run: func(ctx context.Context, db *sql.DB) error {
+			_, err := BackupRotate(ctx, db, dir, policy)
+			return err
+		},
+	}
+}
+
+// DefaultMaintenanceTasks returns the tasks StartMaintenance schedules
+// when MaintenanceConfig.Tasks is empty: a WAL checkpoint every 15
+// minutes, and PRAGMA optimize and an incremental vacuum every hour.
+func DefaultMaintenanceTasks() []MaintenanceTask {
+	return []MaintenanceTask{
+		CheckpointTask(15 * time.Minute),
+		OptimizeTask(time.Hour),
+		IncrementalVacuumTask(time.Hour, 0),
+	}
+}
+
+// execTask returns a task that executes a single SQL statement.
+func execTask(name string, interval time.Duration, query string) MaintenanceTask {
+	return MaintenanceTask{
+		Name:     name,
+		Interval: interval,
+		Run: func(ctx context.Context, db *sql.DB) error {
+			_, err := db.ExecContext(ctx, query)
+			return err
+		},
+	}
+}
+
+// QuietWindow is a daily time range, in local time, during which maintenance
+// may run. Start and End are offsets from midnight. If End is before Start
+// the window wraps past midnight. The zero value means "any time".
+type QuietWindow struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+// contains returns true if t falls within the window.
+func (w QuietWindow) contains(t time.Time) bool {
+	if w.Start == w.End {
+		return true
+	}
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	offset := t.Sub(midnight)
+	if w.Start < w.End {
+		return offset >= w.Start && offset < w.End
+	}
+	return offset >= w.Start || offset < w.End
+}
+
+// MaintenanceConfig configures a Maintenance service.
+type MaintenanceConfig struct {
+	// Tasks to schedule.
+	Tasks []MaintenanceTask
+
+	// Window restricts when tasks may start. Default: any time.
+	Window QuietWindow
+
+	// Jitter adds a random delay of up to this duration to each task's
+	// interval so that many processes don't run maintenance in lockstep.
+	Jitter time.Duration
+
+	// TaskTimeout bounds a single task run. Default: 10m.
+	TaskTimeout time.Duration
+
+	// CheckInterval is how often the scheduler looks for due tasks.
+	// Default: 1m.
+	CheckInterval time.Duration
+
+	// HistorySize is the number of runs kept for History. Default: 100.
+	HistorySize int
+
+	// Gate, if set, is paused around tasks that have Quiesce set.
+	Gate *Gate
+
+	// Logger for operational logging. Uses slog.Default() if nil.
+	Logger *slog.Logger
+}
+
+// defaults returns a copy of cfg with default values applied.
+func (cfg MaintenanceConfig) defaults() MaintenanceConfig {
+	if cfg.TaskTimeout == 0 {
+		cfg.TaskTimeout = 10 * time.Minute
+	}
+	if cfg.CheckInterval == 0 {
+		cfg.CheckInterval = time.Minute
+	}
+	if cfg.HistorySize == 0 {
+		cfg.HistorySize = 100
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = slog.Default()
+	}
+	return cfg
+}
+
+// MaintenanceRun records a single execution of a maintenance task.
+type MaintenanceRun struct {
+	Task     string
+	Started  time.Time
+	Duration time.Duration
+	Err      error
+}
+
+// Maintenance runs maintenance tasks against a database on a schedule.
+// It is the single place where maintenance is scheduled and observed.
+type Maintenance struct {
+	db  *sql.DB
+	cfg MaintenanceConfig
+
+	mu      sync.Mutex
+	next    map[string]time.Time
+	history []MaintenanceRun
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+// NewMaintenance creates a maintenance service for db. Call Start to begin
+// running tasks in the background.
+func NewMaintenance(db *sql.DB, cfg MaintenanceConfig) (*Maintenance, error) {
+	cfg = cfg.defaults()
+
+	seen := make(map[string]bool, len(cfg.Tasks))
+	for _, t := range cfg.Tasks {
+		if t.Name == "" || t.Run == nil {
+			return nil, fmt.Errorf("maintenance task requires a name and a Run function")
+		}
+		if t.Interval <= 0 {
+			return nil, fmt.Errorf("maintenance task %q: interval must be positive", t.Name)
+		}
+		if seen[t.Name] {
+			return nil, fmt.Errorf("duplicate maintenance task %q", t.Name)
+		}
+		seen[t.Name] = true
+	}
+
+	m := &Maintenance{
+		db:   db,
+		cfg:  cfg,
+		next: make(map[string]time.Time, len(cfg.Tasks)),
+	}
+	now := time.Now()
+	for _, t := range cfg.Tasks {
+		m.next[t.Name] = now.Add(m.delay(t))
+	}
+	return m, nil
+}
+
+// StartMaintenance creates a maintenance service for db and starts it,
+// scheduling DefaultMaintenanceTasks if cfg.Tasks is empty. It runs until
+// ctx is cancelled or Stop is called.
+func StartMaintenance(ctx context.Context, db *sql.DB, cfg MaintenanceConfig) (*Maintenance, error) {
+	if len(cfg.Tasks) == 0 {
+		cfg.Tasks = DefaultMaintenanceTasks()
+	}
+	m, err := NewMaintenance(db, cfg)
+	if err != nil {
+		return nil, err
+	}
+	m.Start(ctx)
+	return m, nil
+}
+
+// Start runs the scheduler in a background goroutine until ctx is
+// cancelled or Stop is called. Calling Start on a running service is a no-op.
+func (m *Maintenance) Start(ctx context.Context) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.cancel != nil {
+		return
+	}
+
+	ctx, m.cancel = context.WithCancel(ctx)
+	m.done = make(chan struct{})
+	go m.loop(ctx, m.done)
+}
+
+// Stop halts the scheduler and waits for any running task to finish.
+func (m *Maintenance) Stop() {
+	m.mu.Lock()
+	cancel, done := m.cancel, m.done
+	m.cancel, m.done = nil, nil
+	m.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+}
+
+// RunNow runs the named task immediately, ignoring the window and rate
+// limit, and reschedules its next run.
+func (m *Maintenance) RunNow(ctx context.Context, name string) error {
+	for _, t := range m.cfg.Tasks {
+		if t.Name == name {
+			return m.run(ctx, t)
+		}
+	}
+	return fmt.Errorf("unknown maintenance task %q", name)
+}
+
+// History returns the most recent task runs, oldest first.
+func (m *Maintenance) History() []MaintenanceRun {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]MaintenanceRun(nil), m.history...)
+}
+
+// loop checks for due tasks every CheckInterval.
+func (m *Maintenance) loop(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(m.cfg.CheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if !m.cfg.Window.contains(now) {
+				continue
+			}
+			for _, t := range m.cfg.Tasks {
+				if ctx.Err() != nil {
+					return
+				}
+				m.mu.Lock()
+				due := !now.Before(m.next[t.Name])
+				m.mu.Unlock()
+				if due {
+					m.run(ctx, t)
+				}
+			}
+		}
+	}
+}
+
+// run executes a task, records it in history and schedules the next run.
+func (m *Maintenance) run(ctx context.Context, t MaintenanceTask) error {
+	ctx, cancel := context.WithTimeout(ctx, m.cfg.TaskTimeout)
+	defer cancel()
+
+	if t.Quiesce && m.cfg.Gate != nil {
+		if err := m.cfg.Gate.Pause(ctx); err != nil {
+			return fmt.Errorf("pause writes: %w", err)
+		}
+		defer m.cfg.Gate.Resume()
+	}
+
+	started := time.Now()
+	err := t.Run(ctx, m.db)
+	r := MaintenanceRun{Task: t.Name, Started: started, Duration: time.Since(started), Err: err}
+
+	if err != nil {
+		m.cfg.Logger.Warn("maintenance task failed", "task", t.Name, "error", err)
+	} else {
+		m.cfg.Logger.Debug("maintenance task completed", "task", t.Name, "duration", r.Duration)
+	}
+
+	m.mu.Lock()
+	m.next[t.Name] = started.Add(m.delay(t))
+	m.history = append(m.history, r)
+	if n := len(m.history) - m.cfg.HistorySize; n > 0 {
+		m.history = append(m.history[:0], m.history[n:]...)
+	}
+	m.mu.Unlock()
+
+	return err
+}
+
+// delay returns the task interval plus random jitter.
+func (m *Maintenance) delay(t MaintenanceTask) time.Duration {
+	d := t.Interval
+	if m.cfg.Jitter > 0 {
+		d += rand.N(m.cfg.Jitter)
+	}
+	return d
+}
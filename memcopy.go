@@ -0,0 +1,69 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"log/slog"
+)
+
+// OpenInMemoryCopy copies the persistent database at cfg.Path into a new
+// private in-memory database and opens that with cfg, giving read-mostly
+// workloads a fast working set isolated from the file. The file is opened
+// read-only and never modified; writes to the copy are lost when it is
+// closed. Migrations are applied to the copy unless cfg.SkipMigrations is
+// set.
+//
+// The copy is made with Dump, so the contents of virtual tables are not
+// copied. Unlike Open with ":memory:", it is allowed in production.
+func OpenInMemoryCopy(ctx context.Context, cfg Config) (*sql.DB, error) {
+	cfg = cfg.defaults()
+	if cfg.isMemory() || cfg.isRemote() {
+		return nil, fmt.Errorf("OpenInMemoryCopy requires a local persistent path")
+	}
+	cfg, err := applySymlinkPolicy(cfg)
+	if err != nil {
+		return nil, err
+	}
+	path := cfg.filePath()
+	if err := validatePersistentPath(path, cfg.pathPolicy()); err != nil {
+		return nil, err
+	}
+	if !fileExists(path) {
+		return nil, fmt.Errorf("%s: database file %w", path, ErrNotFound)
+	}
+
+	src, err := Open(ctx, Config{
+		Path:           hostPathStyle.uri(path) + "?mode=ro",
+		SkipMigrations: true,
+		DetectDrift:    DriftIgnore,
+		Driver:         cfg.Driver,
+		EncryptionKey:  cfg.EncryptionKey,
+		Logger:         slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", path, err)
+	}
+	var script bytes.Buffer
+	err = Dump(ctx, src, &script, DumpOptions{})
+	src.Close()
+	if err != nil {
+		return nil, fmt.Errorf("copy %s: %w", path, err)
+	}
+
+	cfg.Logger.Info("DB mode: in-memory copy", "path", cfg.Path)
+	cfg.Path = ":memory:"
+	cfg = cfg.isolateMemory()
+	cfg.FileMode = 0
+	cfg.seed = func(ctx context.Context, db *sql.DB) error {
+		if _, err := db.ExecContext(withInternal(ctx), script.String()); err != nil {
+			return fmt.Errorf("copy %s: %w", path, err)
+		}
+		return nil
+	}
+	return openAndMigrate(ctx, cfg)
+}
@@ -0,0 +1,126 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"sort"
+	"time"
+)
+
+// MergeFS combines several migration sources into a single fs.FS suitable
+// for Config.Migrations. Each source must hold its migrations at its root.
+// This lets library modules that own their own tables ship migrations
+// alongside the host application's.
+//
+// File names must be unique across sources; a collision is reported as an
+// error when the merged directory is read. Migration IDs must also be unique,
+// which is enforced by the usual duplicate ID check.
+func MergeFS(sources ...fs.FS) fs.FS {
+	return mergedFS(sources)
+}
+
+// mergedFS is the fs.FS returned by MergeFS.
+type mergedFS []fs.FS
+
+// Open opens the named file from the first source that contains it.
+// Opening "." returns the merged root directory.
+func (m mergedFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+	if name == "." {
+		entries, err := m.ReadDir(".")
+		if err != nil {
+			return nil, err
+		}
+		return &mergedDir{entries: entries}, nil
+	}
+	for _, src := range m {
+		f, err := src.Open(name)
+		if err == nil {
+			return f, nil
+		}
+	}
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
+
+// ReadDir returns the union of the named directory across all sources,
+// sorted by name. It fails if two sources contain the same file name.
+func (m mergedFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	var result []fs.DirEntry
+	seen := make(map[string]int)
+	found := false
+
+	for i, src := range m {
+		entries, err := fs.ReadDir(src, name)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		found = true
+		for _, e := range entries {
+			if j, ok := seen[e.Name()]; ok {
+				if e.IsDir() {
+					continue
+				}
+				return nil, fmt.Errorf("merge: %q found in sources %d and %d", e.Name(), j, i)
+			}
+			seen[e.Name()] = i
+			result = append(result, e)
+		}
+	}
+
+	if !found {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name() < result[j].Name()
+	})
+	return result, nil
+}
+
+// mergedDir is the root directory of a mergedFS.
+type mergedDir struct {
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *mergedDir) Stat() (fs.FileInfo, error) { return mergedDirInfo{}, nil }
+func (d *mergedDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: ".", Err: fs.ErrInvalid}
+}
+func (d *mergedDir) Close() error { return nil }
+
+// ReadDir implements fs.ReadDirFile.
+func (d *mergedDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	rest := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return rest, nil
+	}
+	if len(rest) == 0 {
+		return nil, io.EOF
+	}
+	if n > len(rest) {
+		n = len(rest)
+	}
+	d.offset += n
+	return rest[:n], nil
+}
+
+// mergedDirInfo describes the root directory of a mergedFS.
+type mergedDirInfo struct{}
+
+func (mergedDirInfo) Name() string       { return "." }
+func (mergedDirInfo) Size() int64        { return 0 }
+func (mergedDirInfo) Mode() fs.FileMode  { return fs.ModeDir | 0o555 }
+func (mergedDirInfo) ModTime() time.Time { return time.Time{} }
+func (mergedDirInfo) IsDir() bool        { return true }
+func (mergedDirInfo) Sys() any           { return nil }
@@ -5,13 +5,16 @@ package sqliteinit
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"io/fs"
 	"log/slog"
 	"regexp"
 	"sort"
 	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
 )
 
 // migrationScript represents a single migration file.
@@ -19,51 +22,107 @@ type migrationScript struct {
 	ID      int
 	Comment string
 	Path    string
+	Envs    []string // environments from "-- sqliteinit:env"; empty means all
+
+	// Deferrable is set by "-- sqliteinit:deferrable"; the migration may be
+	// finished in the background when Config.MigrationBudget runs out.
+	Deferrable bool
+}
+
+// appliesTo returns true if the script should be applied in env.
+func (s migrationScript) appliesTo(env string) bool {
+	if len(s.Envs) == 0 {
+		return true
+	}
+	for _, e := range s.Envs {
+		if strings.EqualFold(e, env) {
+			return true
+		}
+	}
+	return false
 }
 
 // reMigrationFile matches YYYYMMDDHHMMSS_comment.sql
 var reMigrationFile = regexp.MustCompile(`^(\d{14})_(.+)\.sql$`)
 
-// migrate applies pending migrations to the database.
-func migrate(ctx context.Context, db *sql.DB, cfg Config) error {
+// envDirective is the header comment that scopes a migration to environments.
+const envDirective = "-- sqliteinit:env"
+
+// parseEnvDirective scans the leading comment lines of a migration script
+// for an environment directive and returns the listed environments.
+// Returns nil if the script has no directive.
+func parseEnvDirective(sqlBytes []byte) []string {
+	for _, line := range strings.Split(string(sqlBytes), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "--") {
+			break
+		}
+		rest, ok := strings.CutPrefix(line, envDirective)
+		if !ok {
+			continue
+		}
+		var envs []string
+		for _, e := range strings.Split(rest, ",") {
+			if e = strings.TrimSpace(e); e != "" {
+				envs = append(envs, e)
+			}
+		}
+		return envs
+	}
+	return nil
+}
+
+// migrate applies pending migrations to the database. With
+// cfg.MigrationBudget set, it stops once the budget is spent if every
+// remaining migration is deferrable, and returns those for the caller to
+// apply in the background.
+func migrate(ctx context.Context, db *sql.DB, cfg Config) (deferred []migrationScript, err error) {
 	cfg.Logger.Debug("starting migration")
 
 	// Check current state
 	version, err := fetchSchemaVersion(ctx, db)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	needsInit := version == nil
 
-	// If uninitialized, apply the package's schema first
+	// If uninitialized, apply the package's schema first; otherwise bring
+	// a database created by an older release up to date.
 	if needsInit {
 		cfg.Logger.Debug("initializing schema")
+		start := time.Now()
 		if err := applySchemaInit(ctx, db, cfg); err != nil {
-			return fmt.Errorf("init schema: %w", err)
+			return nil, fmt.Errorf("init schema: %w", err)
 		}
+		cfg.emit(InitEvent{Duration: time.Since(start)})
+	} else if err := upgradeInfraSchema(ctx, db, cfg.Now()); err != nil {
+		return nil, fmt.Errorf("upgrade schema: %w", err)
 	}
 
 	// If no user migrations provided, we're done
 	if cfg.Migrations == nil {
-		return nil
+		return nil, nil
 	}
 
 	// List available migrations
 	scripts, err := listMigrationFiles(cfg.Migrations, cfg.Logger)
 	if err != nil {
-		return fmt.Errorf("list migrations: %w", err)
+		return nil, fmt.Errorf("list migrations: %w", err)
 	}
 
 	if len(scripts) == 0 {
 		cfg.Logger.Debug("no user migrations to apply")
-		return nil
+		return nil, nil
 	}
 
 	// Get currently applied migrations
 	applied, err := fetchAppliedMigrations(ctx, db)
 	if err != nil {
-		return fmt.Errorf("fetch applied: %w", err)
+		return nil, fmt.Errorf("fetch applied: %w", err)
 	}
 
 	appliedPaths := make(map[string]bool, len(applied))
@@ -71,22 +130,166 @@ func migrate(ctx context.Context, db *sql.DB, cfg Config) error {
 		appliedPaths[a.Path] = true
 	}
 
-	// Apply pending migrations
-	now := time.Now().UTC()
+	if err := verifyChecksums(cfg, scripts, applied); err != nil {
+		return nil, err
+	}
+
+	// Select pending migrations
+	env := cfg.environment()
+	var pending []migrationScript
 	for _, s := range scripts {
 		if appliedPaths[s.Path] {
 			continue
 		}
+		if !s.appliesTo(env) {
+			cfg.Logger.Debug("skipping migration for environment", "path", s.Path, "env", env)
+			continue
+		}
+		pending = append(pending, s)
+	}
+
+	// A migration may rewrite every page, so require room for a full copy
+	// of the database in the journal before starting.
+	if len(pending) != 0 && !cfg.isMemory() && !cfg.isRemote() {
+		if err := checkDiskSpace(cfg.filePath(), fileSize(cfg.filePath())); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(pending) != 0 && (cfg.RollbackPlanDir != "" || cfg.RollbackSnapshots != nil) {
+		if err := recordRollbackPlan(ctx, db, cfg, pending); err != nil {
+			return nil, fmt.Errorf("rollback plan: %w", err)
+		}
+	}
+
+	// Apply pending migrations
+	start := time.Now()
+	now := cfg.Now().UTC()
+	for i, s := range pending {
+		if cfg.MigrationBudget > 0 && time.Since(start) >= cfg.MigrationBudget && allDeferrable(pending[i:]) {
+			return pending[i:], nil
+		}
 
 		cfg.Logger.Debug("applying migration", "path", s.Path)
-		if err := applyMigration(ctx, db, cfg.Migrations, s, now); err != nil {
-			return fmt.Errorf("apply %s: %w", s.Path, err)
+		cfg.emit(MigrationStartEvent{ID: s.ID, Path: s.Path})
+		began := time.Now()
+		err := applyMigration(ctx, db, cfg, s, now)
+		elapsed := time.Since(began)
+		cfg.emit(MigrationFinishEvent{ID: s.ID, Path: s.Path, Duration: elapsed, Err: err})
+		if err != nil {
+			return nil, err
 		}
+		warnIfSlow(cfg, s, elapsed)
 	}
 
+	return nil, nil
+}
+
+// maxLoggedSQL is how much of a statement LogStatements logs.
+const maxLoggedSQL = 200
+
+// execScript executes a migration script in tx. A failing statement is
+// reported as a *statementError. With cfg.LogStatements, the statements are
+// executed one at a time and each is logged at debug level. Otherwise the
+// script runs in one call from a savepoint, and a failure is replayed
+// statement by statement to find the one at fault.
+func execScript(ctx context.Context, tx *sql.Tx, cfg Config, path, script string) error {
+	if cfg.LogStatements {
+		stmts := splitScript(script)
+		for i, st := range stmts {
+			cfg.Logger.Debug("migration statement", "path", path, "index", i+1, "of", len(stmts), "line", st.Line, "sql", truncateSQL(st.SQL, maxLoggedSQL))
+			if _, err := tx.ExecContext(ctx, st.SQL); err != nil {
+				return &statementError{index: i + 1, stmt: st, err: err}
+			}
+		}
+		return nil
+	}
+
+	if _, err := tx.ExecContext(ctx, `SAVEPOINT sqliteinit_script`); err != nil {
+		return err
+	}
+	_, err := tx.ExecContext(ctx, script)
+	if err == nil {
+		return nil
+	}
+	// Some errors abort the whole transaction; those can't be located.
+	if _, rerr := tx.ExecContext(ctx, `ROLLBACK TO sqliteinit_script`); rerr != nil {
+		return fmt.Errorf("exec: %w", err)
+	}
+	for i, st := range splitScript(script) {
+		if _, serr := tx.ExecContext(ctx, st.SQL); serr != nil {
+			return &statementError{index: i + 1, stmt: st, err: serr}
+		}
+	}
+	return fmt.Errorf("exec: %w", err)
+}
+
+// truncateSQL collapses whitespace in stmt and shortens it to at most max
+// bytes, marking a cut with "...".
+func truncateSQL(stmt string, max int) string {
+	stmt = strings.Join(strings.Fields(stmt), " ")
+	if len(stmt) <= max {
+		return stmt
+	}
+	cut := max - 3
+	for cut > 0 && !utf8.RuneStart(stmt[cut]) {
+		cut--
+	}
+	return stmt[:cut] + "..."
+}
+
+// warnIfSlow logs a warning if applying s took longer than
+// cfg.SlowMigrationThreshold.
+func warnIfSlow(cfg Config, s migrationScript, elapsed time.Duration) {
+	if cfg.SlowMigrationThreshold > 0 && elapsed > cfg.SlowMigrationThreshold {
+		cfg.Logger.Warn("slow migration", "id", s.ID, "path", s.Path, "elapsed", elapsed, "threshold", cfg.SlowMigrationThreshold)
+	}
+}
+
+// verifyChecksums compares the checksum recorded for each applied script
+// with the script's current contents, sending a ChecksumEvent for each. A
+// script that changed since it was applied fails with ErrChecksumMismatch
+// if cfg.VerifyChecksums is set and is logged as a warning otherwise.
+// Scripts applied before checksums were recorded are skipped.
+func verifyChecksums(cfg Config, scripts []migrationScript, applied []AppliedMigration) error {
+	recorded := make(map[string]AppliedMigration, len(applied))
+	for _, a := range applied {
+		if a.Checksum != "" {
+			recorded[a.Path] = a
+		}
+	}
+	for _, s := range scripts {
+		a, ok := recorded[s.Path]
+		if !ok {
+			continue
+		}
+		sqlBytes, err := fs.ReadFile(cfg.Migrations, s.Path)
+		if err != nil {
+			return fmt.Errorf("read %s: %w", s.Path, err)
+		}
+		e := ChecksumEvent{ID: s.ID, Path: s.Path, Recorded: a.Checksum, Current: checksum(sqlBytes)}
+		cfg.emit(e)
+		if e.Match() {
+			continue
+		}
+		if cfg.VerifyChecksums {
+			return fmt.Errorf("%s: %w: recorded %s, now %s", s.Path, ErrChecksumMismatch, e.Recorded, e.Current)
+		}
+		cfg.Logger.Warn("migration changed since it was applied", "path", s.Path, "recorded", e.Recorded, "current", e.Current)
+	}
 	return nil
 }
 
+// allDeferrable returns true if every script is deferrable.
+func allDeferrable(scripts []migrationScript) bool {
+	for _, s := range scripts {
+		if !s.Deferrable {
+			return false
+		}
+	}
+	return true
+}
+
 // applySchemaInit applies the package's internal schema initialization script.
 func applySchemaInit(ctx context.Context, db *sql.DB, cfg Config) error {
 	sqlBytes, err := fs.ReadFile(schemaFS, "schema.sql")
@@ -94,6 +297,7 @@ func applySchemaInit(ctx context.Context, db *sql.DB, cfg Config) error {
 		return fmt.Errorf("read schema.sql: %w", err)
 	}
 
+	start := time.Now()
 	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
@@ -105,13 +309,13 @@ func applySchemaInit(ctx context.Context, db *sql.DB, cfg Config) error {
 	}
 
 	// Record the init as migration ID 0
-	now := time.Now().UTC()
+	now := cfg.Now().UTC()
 	ts := now.Unix()
 
 	_, err = tx.ExecContext(ctx, `
-		INSERT INTO schema_migrations (id, comment, path, applied_at, created_at, updated_at)
-		VALUES (0, 'init', 'schema.sql', ?, ?, ?)
-	`, ts, ts, ts)
+		INSERT INTO schema_migrations (id, comment, path, applied_at, created_at, updated_at, duration_ms, checksum, applied_by)
+		VALUES (0, 'init', 'schema.sql', ?, ?, ?, ?, ?, ?)
+	`, ts, ts, ts, cfg.elapsedMS(start), checksum(sqlBytes), appliedBy(cfg))
 	if err != nil {
 		return fmt.Errorf("record init: %w", err)
 	}
@@ -131,30 +335,67 @@ func applySchemaInit(ctx context.Context, db *sql.DB, cfg Config) error {
 	return tx.Commit()
 }
 
-// applyMigration applies a single user migration script.
-func applyMigration(ctx context.Context, db *sql.DB, migrationsFS fs.FS, s migrationScript, now time.Time) error {
-	sqlBytes, err := fs.ReadFile(migrationsFS, s.Path)
+// applyMigration applies a single user migration script, returning a
+// *MigrationError if it fails. With cfg.StrictTables, the migration fails
+// if it creates a table that is not STRICT.
+func applyMigration(ctx context.Context, db *sql.DB, cfg Config, s migrationScript, now time.Time) error {
+	err := applyScript(ctx, db, cfg, s, now)
+	if err == nil {
+		return nil
+	}
+	merr := &MigrationError{ID: s.ID, Path: s.Path, Err: err}
+	var serr *statementError
+	if errors.As(err, &serr) {
+		merr.Statement = serr.index
+		merr.Line = serr.stmt.Line
+		merr.SQL = truncateSQL(serr.stmt.SQL, maxLoggedSQL)
+		merr.Err = serr.err
+	}
+	return merr
+}
+
+// applyScript does the work of applyMigration.
+func applyScript(ctx context.Context, db *sql.DB, cfg Config, s migrationScript, now time.Time) error {
+	sqlBytes, err := fs.ReadFile(cfg.Migrations, s.Path)
 	if err != nil {
 		return fmt.Errorf("read: %w", err)
 	}
 
+	start := time.Now()
 	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
 
+	var nonStrict []string
+	if cfg.StrictTables {
+		if nonStrict, err = nonStrictTables(ctx, tx); err != nil {
+			return err
+		}
+	}
+
 	// Execute the migration
-	if _, err := tx.ExecContext(ctx, string(sqlBytes)); err != nil {
-		return fmt.Errorf("exec: %w", err)
+	if err := execScript(ctx, tx, cfg, s.Path, string(sqlBytes)); err != nil {
+		return err
+	}
+
+	if cfg.StrictTables {
+		after, err := nonStrictTables(ctx, tx)
+		if err != nil {
+			return err
+		}
+		if err := checkStrictTables(nonStrict, after); err != nil {
+			return err
+		}
 	}
 
 	// Record the migration
 	ts := now.Unix()
 	_, err = tx.ExecContext(ctx, `
-		INSERT INTO schema_migrations (id, comment, path, applied_at, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?)
-	`, s.ID, s.Comment, s.Path, ts, ts, ts)
+		INSERT INTO schema_migrations (id, comment, path, applied_at, created_at, updated_at, duration_ms, checksum, applied_by)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, s.ID, s.Comment, s.Path, ts, ts, ts, cfg.elapsedMS(start), checksum(sqlBytes), appliedBy(cfg))
 	if err != nil {
 		return fmt.Errorf("record: %w", err)
 	}
@@ -193,6 +434,9 @@ func listMigrationFiles(migrationsFS fs.FS, logger *slog.Logger) ([]migrationScr
 		}
 
 		name := e.Name()
+		if isDownScript(name) {
+			continue
+		}
 		matches := reMigrationFile.FindStringSubmatch(name)
 		if matches == nil {
 			logger.Debug("skipping non-migration file", "name", name)
@@ -210,10 +454,17 @@ func listMigrationFiles(migrationsFS fs.FS, logger *slog.Logger) ([]migrationScr
 		}
 		seenIDs[id] = name
 
+		sqlBytes, err := fs.ReadFile(migrationsFS, name)
+		if err != nil {
+			return nil, fmt.Errorf("read %q: %w", name, err)
+		}
+
 		scripts = append(scripts, migrationScript{
-			ID:      id,
-			Comment: matches[2],
-			Path:    name,
+			ID:         id,
+			Comment:    matches[2],
+			Path:       name,
+			Envs:       parseEnvDirective(sqlBytes),
+			Deferrable: hasDirective(sqlBytes, deferrableDirective),
 		})
 	}
 
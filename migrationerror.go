@@ -0,0 +1,60 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"fmt"
+)
+
+// MigrationError is returned, wrapped, by Open and Create when a migration
+// fails, and by DeferredMigrations.Wait for a deferred migration. Use
+// errors.As to get it:
+//
+//	var merr *sqliteinit.MigrationError
+//	if errors.As(err, &merr) {
+//	    log.Printf("%s line %d: %v", merr.Path, merr.Line, merr.Err)
+//	}
+type MigrationError struct {
+	ID   int
+	Path string
+
+	// Statement is the 1-based index of the failing statement in the
+	// script, and Line the line it starts on. Both are 0 if the failure
+	// isn't tied to a statement, such as a STRICT table check.
+	Statement int
+	Line      int
+
+	// SQL is the failing statement, with whitespace collapsed and
+	// truncated to 200 bytes, or "".
+	SQL string
+
+	// Err is the underlying error, usually from the driver.
+	Err error
+}
+
+func (e *MigrationError) Error() string {
+	if e.Statement == 0 {
+		return fmt.Sprintf("apply %s: %v", e.Path, e.Err)
+	}
+	return fmt.Sprintf("apply %s: statement %d at line %d (%s): %v", e.Path, e.Statement, e.Line, e.SQL, e.Err)
+}
+
+func (e *MigrationError) Unwrap() error {
+	return e.Err
+}
+
+// statementError is a failure of one statement of a migration script, for
+// applyMigration to turn into a MigrationError.
+type statementError struct {
+	index int
+	stmt  scriptStatement
+	err   error
+}
+
+func (e *statementError) Error() string {
+	return fmt.Sprintf("statement %d: %v", e.index, e.err)
+}
+
+func (e *statementError) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,295 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// ModelMismatch describes a difference between a Go struct and the table
+// it is mapped to.
+type ModelMismatch struct {
+	Table   string
+	Column  string
+	Field   string
+	Problem string
+}
+
+// String formats the mismatch for logs and test failures.
+func (m ModelMismatch) String() string {
+	return fmt.Sprintf("%s.%s (field %s): %s", m.Table, m.Column, m.Field, m.Problem)
+}
+
+// CheckModel compares the exported fields of model that carry a `db` tag
+// against the live columns of table and returns any discrepancies: columns
+// that don't exist, Go types incompatible with the column's type affinity,
+// and nullable columns mapped to fields that can't hold NULL. Fields tagged
+// `db:"-"` and untagged fields are ignored; embedded structs are flattened.
+//
+// Run it at startup or in tests after migrations to catch structs that
+// weren't updated alongside a migration.
+func CheckModel(ctx context.Context, db *sql.DB, table string, model any) ([]ModelMismatch, error) {
+	t := reflect.TypeOf(model)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("model must be a struct or pointer to struct, got %T", model)
+	}
+
+	cols, err := tableColumns(ctx, db, table)
+	if err != nil {
+		return nil, err
+	}
+	if len(cols) == 0 {
+		return nil, fmt.Errorf("table %q %w", table, ErrNotFound)
+	}
+	byName := make(map[string]columnInfo, len(cols))
+	for _, c := range cols {
+		byName[strings.ToLower(c.Name)] = c
+	}
+
+	var result []ModelMismatch
+	for _, f := range modelFields(t) {
+		col, ok := byName[strings.ToLower(f.column)]
+		if !ok {
+			result = append(result, ModelMismatch{Table: table, Column: f.column, Field: f.name, Problem: "column does not exist"})
+			continue
+		}
+		goType, nullable := unwrapNullable(f.typ)
+		if !affinityAccepts(col.affinity(), goType) {
+			result = append(result, ModelMismatch{
+				Table: table, Column: col.Name, Field: f.name,
+				Problem: fmt.Sprintf("type mismatch: column %s, field %s", declOrNone(col.Type), f.typ),
+			})
+		}
+		if !col.NotNull && !nullable {
+			result = append(result, ModelMismatch{
+				Table: table, Column: col.Name, Field: f.name,
+				Problem: "column is nullable but field cannot hold NULL",
+			})
+		}
+	}
+	return result, nil
+}
+
+// columnInfo describes a table column as reported by PRAGMA table_xinfo.
+type columnInfo struct {
+	Name       string
+	Type       string
+	NotNull    bool
+	Default    sql.NullString
+	PrimaryKey int
+
+	// Generated is "VIRTUAL" or "STORED" for generated columns, else "".
+	Generated string
+
+	// Strict is true for columns of a STRICT table.
+	Strict bool
+}
+
+// affinity returns the column's type affinity. An ANY column of a STRICT
+// table stores values unchanged, so it has no affinity; elsewhere ANY is
+// an ordinary numeric type name.
+func (c columnInfo) affinity() affinity {
+	if c.isAny() {
+		return affinityBlob
+	}
+	return columnAffinity(c.Type)
+}
+
+// isAny returns true for an ANY column of a STRICT table.
+func (c columnInfo) isAny() bool {
+	return c.Strict && strings.EqualFold(c.Type, "ANY")
+}
+
+// tableColumns returns the columns of table in declaration order, or an
+// empty slice if the table doesn't exist. Generated columns are included;
+// hidden columns of virtual tables are not.
+func tableColumns(ctx context.Context, db *sql.DB, table string) ([]columnInfo, error) {
+	withoutRowID, strict, err := tableKind(ctx, db, table)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.QueryContext(ctx, `SELECT name, type, "notnull", dflt_value, pk, hidden FROM pragma_table_xinfo(?)`, table)
+	if err != nil {
+		return nil, fmt.Errorf("table_xinfo %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	var cols []columnInfo
+	pkCols := 0
+	for rows.Next() {
+		var c columnInfo
+		var hidden int
+		if err := rows.Scan(&c.Name, &c.Type, &c.NotNull, &c.Default, &c.PrimaryKey, &hidden); err != nil {
+			return nil, err
+		}
+		switch hidden {
+		case 1:
+			continue
+		case 2:
+			c.Generated = "VIRTUAL"
+		case 3:
+			c.Generated = "STORED"
+		}
+		c.Strict = strict
+		if c.PrimaryKey > 0 {
+			pkCols++
+			// Primary key columns of a WITHOUT ROWID table can never be NULL.
+			if withoutRowID {
+				c.NotNull = true
+			}
+		}
+		cols = append(cols, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// A lone INTEGER PRIMARY KEY of a rowid table is the rowid and can
+	// never be NULL. Part of a composite key, it is an ordinary column.
+	if !withoutRowID && pkCols == 1 {
+		for i, c := range cols {
+			if c.PrimaryKey > 0 && strings.EqualFold(c.Type, "INTEGER") {
+				cols[i].NotNull = true
+			}
+		}
+	}
+	return cols, nil
+}
+
+// tableKind reports whether table is a WITHOUT ROWID table and whether it
+// is a STRICT table. Both are false for views and missing tables.
+func tableKind(ctx context.Context, db *sql.DB, table string) (withoutRowID, strict bool, err error) {
+	err = db.QueryRowContext(ctx, `SELECT wr, strict FROM pragma_table_list(?) WHERE type = 'table'`, table).Scan(&withoutRowID, &strict)
+	if err == sql.ErrNoRows {
+		return false, false, nil
+	}
+	if err != nil {
+		return false, false, fmt.Errorf("table_list %s: %w", table, err)
+	}
+	return withoutRowID, strict, nil
+}
+
+// modelField is a struct field mapped to a column.
+type modelField struct {
+	name   string
+	column string
+	typ    reflect.Type
+}
+
+// modelFields returns the db-tagged fields of t, flattening embedded structs.
+func modelFields(t reflect.Type) []modelField {
+	var fields []modelField
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag, tagged := sf.Tag.Lookup("db")
+		name, _, _ := strings.Cut(tag, ",")
+		if sf.Anonymous && !tagged {
+			et := sf.Type
+			if et.Kind() == reflect.Pointer {
+				et = et.Elem()
+			}
+			if et.Kind() == reflect.Struct {
+				fields = append(fields, modelFields(et)...)
+			}
+			continue
+		}
+		if !sf.IsExported() || !tagged || name == "-" || name == "" {
+			continue
+		}
+		fields = append(fields, modelField{name: sf.Name, column: name, typ: sf.Type})
+	}
+	return fields
+}
+
+var (
+	timeType    = reflect.TypeOf(time.Time{})
+	scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+)
+
+// unwrapNullable returns the underlying value type of t and whether t can
+// hold NULL (pointers, sql.Null types and []byte).
+func unwrapNullable(t reflect.Type) (reflect.Type, bool) {
+	if t.Kind() == reflect.Pointer {
+		return t.Elem(), true
+	}
+	if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+		return t, true
+	}
+	if t.Kind() == reflect.Struct && strings.HasPrefix(t.Name(), "Null") && t.NumField() == 2 {
+		// sql.NullString, sql.NullInt64, sql.Null[T], and lookalikes
+		return t.Field(0).Type, true
+	}
+	return t, false
+}
+
+// affinity is a SQLite column type affinity.
+type affinity int
+
+const (
+	affinityNumeric affinity = iota
+	affinityInteger
+	affinityText
+	affinityBlob
+	affinityReal
+)
+
+// columnAffinity applies SQLite's rules for determining column affinity
+// from a declared type.
+func columnAffinity(declType string) affinity {
+	t := strings.ToUpper(declType)
+	switch {
+	case strings.Contains(t, "INT"):
+		return affinityInteger
+	case strings.Contains(t, "CHAR"), strings.Contains(t, "CLOB"), strings.Contains(t, "TEXT"):
+		return affinityText
+	case t == "" || strings.Contains(t, "BLOB"):
+		return affinityBlob
+	case strings.Contains(t, "REAL"), strings.Contains(t, "FLOA"), strings.Contains(t, "DOUB"):
+		return affinityReal
+	}
+	return affinityNumeric
+}
+
+// affinityAccepts returns true if values of Go type t are a reasonable
+// mapping for a column with affinity a.
+func affinityAccepts(a affinity, t reflect.Type) bool {
+	if t == timeType {
+		// Times are stored as Unix seconds or as text.
+		return a != affinityBlob && a != affinityReal
+	}
+	if a == affinityBlob {
+		return true // no affinity; anything goes
+	}
+	if reflect.PointerTo(t).Implements(scannerType) && t.Kind() == reflect.Struct {
+		return true // custom scanner; can't tell
+	}
+	switch t.Kind() {
+	case reflect.Bool, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return a == affinityInteger || a == affinityNumeric
+	case reflect.Float32, reflect.Float64:
+		return a == affinityReal || a == affinityNumeric || a == affinityInteger
+	case reflect.String:
+		return a == affinityText || a == affinityNumeric
+	case reflect.Slice:
+		return t.Elem().Kind() == reflect.Uint8 && a == affinityText
+	}
+	return true
+}
+
+// declOrNone returns the declared type, or "(none)" if empty.
+func declOrNone(declType string) string {
+	if declType == "" {
+		return "(none)"
+	}
+	return declType
+}
@@ -0,0 +1,42 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// CloseOptions controls CloseWithOptimize.
+type CloseOptions struct {
+	// Checkpoint checkpoints the WAL into the database file and truncates
+	// it before closing (see CheckpointTruncate), leaving a single file
+	// that is ready to copy.
+	Checkpoint bool
+}
+
+// CloseWithOptimize runs PRAGMA optimize, as SQLite recommends before
+// closing a long-lived connection, so the query planner's statistics are
+// refreshed for the tables the connection used, then closes db. It closes
+// db even if optimizing or checkpointing fails, and returns every error.
+//
+// PRAGMA optimize affects only the connection it runs on. Handles returned
+// by Open have a single connection, so for them that is all of it.
+func CloseWithOptimize(ctx context.Context, db *sql.DB, opts CloseOptions) error {
+	ctx = withInternal(ctx)
+	var errs []error
+	if _, err := db.ExecContext(ctx, `PRAGMA optimize`); err != nil {
+		errs = append(errs, fmt.Errorf("optimize: %w", err))
+	}
+	if opts.Checkpoint {
+		if _, err := Checkpoint(ctx, db, CheckpointTruncate); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := db.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("close: %w", err))
+	}
+	return errors.Join(errs...)
+}
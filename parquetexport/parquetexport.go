@@ -0,0 +1,205 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+// Package parquetexport streams SQLite query results to Parquet files for
+// ingestion into columnar analytics pipelines.
+//
+// It is a separate module so that applications which don't export to
+// Parquet don't depend on the Parquet encoder.
+package parquetexport
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// batchSize is the number of rows buffered before writing to the encoder.
+const batchSize = 1024
+
+// Export runs query on db and writes the result set to w as a Parquet file.
+// It returns the number of rows written. The query is cancelled if ctx is
+// done.
+//
+// Column types are derived from the declared SQLite types of the result
+// columns: INTEGER-affinity columns become INT64, REAL-affinity columns
+// DOUBLE, BLOB columns BYTE_ARRAY, and everything else UTF-8 strings. All
+// columns are optional so that NULLs round-trip.
+func Export(ctx context.Context, db *sql.DB, w io.Writer, query string, args ...any) (int64, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("query: %w", err)
+	}
+	defer rows.Close()
+
+	colTypes, err := rows.ColumnTypes()
+	if err != nil {
+		return 0, fmt.Errorf("column types: %w", err)
+	}
+
+	kinds := make([]kind, len(colTypes))
+	group := make(parquet.Group, len(colTypes))
+	for i, ct := range colTypes {
+		if _, ok := group[ct.Name()]; ok {
+			return 0, fmt.Errorf("duplicate column name %q", ct.Name())
+		}
+		kinds[i] = kindOf(ct.DatabaseTypeName())
+		group[ct.Name()] = parquet.Optional(kinds[i].node())
+	}
+	schema := parquet.NewSchema("row", group)
+
+	// Group fields are ordered by name; map each result column to its leaf.
+	leaf := make([]int, len(colTypes))
+	for li, path := range schema.Columns() {
+		for i, ct := range colTypes {
+			if ct.Name() == path[0] {
+				leaf[i] = li
+			}
+		}
+	}
+
+	pw := parquet.NewWriter(w, schema)
+
+	var written int64
+	batch := make([]parquet.Row, 0, batchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		if _, err := pw.WriteRows(batch); err != nil {
+			return fmt.Errorf("write rows: %w", err)
+		}
+		written += int64(len(batch))
+		batch = batch[:0]
+		return nil
+	}
+
+	values := make([]any, len(colTypes))
+	ptrs := make([]any, len(colTypes))
+	for i := range values {
+		ptrs[i] = &values[i]
+	}
+
+	for rows.Next() {
+		if err := rows.Scan(ptrs...); err != nil {
+			return written, fmt.Errorf("scan: %w", err)
+		}
+		row := make(parquet.Row, len(colTypes))
+		for i, v := range values {
+			pv, err := kinds[i].value(v)
+			if err != nil {
+				return written, fmt.Errorf("column %q: %w", colTypes[i].Name(), err)
+			}
+			if pv.IsNull() {
+				row[leaf[i]] = pv.Level(0, 0, leaf[i])
+			} else {
+				row[leaf[i]] = pv.Level(0, 1, leaf[i])
+			}
+		}
+		batch = append(batch, row)
+		if len(batch) == batchSize {
+			if err := flush(); err != nil {
+				return written, err
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return written, fmt.Errorf("rows: %w", err)
+	}
+	if err := flush(); err != nil {
+		return written, err
+	}
+
+	if err := pw.Close(); err != nil {
+		return written, fmt.Errorf("close writer: %w", err)
+	}
+	return written, nil
+}
+
+// kind is the Parquet physical representation chosen for a column.
+type kind int
+
+const (
+	kindString kind = iota
+	kindInt64
+	kindDouble
+	kindBytes
+)
+
+// kindOf maps a declared SQLite type to a Parquet kind using SQLite's
+// column affinity rules.
+func kindOf(declType string) kind {
+	t := strings.ToUpper(declType)
+	switch {
+	case strings.Contains(t, "INT"):
+		return kindInt64
+	case strings.Contains(t, "CHAR"), strings.Contains(t, "CLOB"), strings.Contains(t, "TEXT"):
+		return kindString
+	case t == "BLOB":
+		return kindBytes
+	case strings.Contains(t, "REAL"), strings.Contains(t, "FLOA"), strings.Contains(t, "DOUB"):
+		return kindDouble
+	}
+	return kindString
+}
+
+// node returns the Parquet schema node for k.
+func (k kind) node() parquet.Node {
+	switch k {
+	case kindInt64:
+		return parquet.Int(64)
+	case kindDouble:
+		return parquet.Leaf(parquet.DoubleType)
+	case kindBytes:
+		return parquet.Leaf(parquet.ByteArrayType)
+	}
+	return parquet.String()
+}
+
+// value converts a scanned SQLite value to a Parquet value of kind k.
+func (k kind) value(v any) (parquet.Value, error) {
+	if v == nil {
+		return parquet.Value{}, nil
+	}
+	switch k {
+	case kindInt64:
+		switch x := v.(type) {
+		case int64:
+			return parquet.Int64Value(x), nil
+		case float64:
+			return parquet.Int64Value(int64(x)), nil
+		case bool:
+			if x {
+				return parquet.Int64Value(1), nil
+			}
+			return parquet.Int64Value(0), nil
+		}
+	case kindDouble:
+		switch x := v.(type) {
+		case float64:
+			return parquet.DoubleValue(x), nil
+		case int64:
+			return parquet.DoubleValue(float64(x)), nil
+		}
+	case kindBytes:
+		switch x := v.(type) {
+		case []byte:
+			return parquet.ByteArrayValue(x), nil
+		case string:
+			return parquet.ByteArrayValue([]byte(x)), nil
+		}
+	default:
+		switch x := v.(type) {
+		case string:
+			return parquet.ByteArrayValue([]byte(x)), nil
+		case []byte:
+			return parquet.ByteArrayValue(x), nil
+		default:
+			return parquet.ByteArrayValue(fmt.Append(nil, x)), nil
+		}
+	}
+	return parquet.Value{}, fmt.Errorf("cannot convert %T to %v", v, k.node().Type())
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package parquetexport_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/mdhender/sqliteinit"
+	"github.com/mdhender/sqliteinit/parquetexport"
+	"github.com/parquet-go/parquet-go"
+	_ "modernc.org/sqlite"
+)
+
+// TestExport tests writing a query result to Parquet and reading it back.
+func TestExport(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := sqliteinit.Open(ctx, sqliteinit.Config{Path: ":memory:"})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+
+	_, err = db.ExecContext(ctx, `
+		CREATE TABLE items (id INTEGER, name TEXT, price REAL, data BLOB);
+		INSERT INTO items VALUES (1, 'apple', 0.5, x'01'), (2, NULL, 1.25, NULL), (3, 'cherry', NULL, x'0203');
+	`)
+	if err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+
+	var buf bytes.Buffer
+	n, err := parquetexport.Export(ctx, db, &buf, `SELECT id, name, price, data FROM items ORDER BY id`)
+	if err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if n != 3 {
+		t.Errorf("expected 3 rows written, got %d", n)
+	}
+
+	type item struct {
+		ID    *int64   `parquet:"id,optional"`
+		Name  *string  `parquet:"name,optional"`
+		Price *float64 `parquet:"price,optional"`
+		Data  []byte   `parquet:"data,optional"`
+	}
+	got, err := parquet.Read[item](bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("read back: %v", err)
+	}
+	if len(got) != 3 {
+		t.Fatalf("expected 3 rows read, got %d", len(got))
+	}
+	if got[0].Name == nil || *got[0].Name != "apple" || *got[0].ID != 1 {
+		t.Errorf("unexpected first row: %+v", got[0])
+	}
+	if got[1].Name != nil {
+		t.Errorf("expected NULL name in second row, got %q", *got[1].Name)
+	}
+	if got[2].Price != nil {
+		t.Errorf("expected NULL price in third row, got %v", *got[2].Price)
+	}
+}
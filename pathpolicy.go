@@ -0,0 +1,100 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// PathPolicy decides which file system paths may hold a persistent
+// database. Set Config.PathPolicy to relax or tighten the default rules,
+// e.g. to allow other extensions or require paths under a data directory.
+type PathPolicy interface {
+	// CheckPath returns an error if path is not acceptable. path has any
+	// "file:" URI prefix removed and is normalized for the host.
+	CheckPath(path string) error
+}
+
+// PathPolicyFunc adapts a function to a PathPolicy.
+type PathPolicyFunc func(path string) error
+
+// CheckPath calls f(path).
+func (f PathPolicyFunc) CheckPath(path string) error {
+	return f(path)
+}
+
+// DefaultPathPolicy requires an absolute path with a .db extension.
+// Custom policies can call it to add rules on top of the defaults. It is
+// the policy used when none is given: by the package-level Delete, Move,
+// Clone and FixPermissions, and when Config, BackupOptions,
+// SnapshotOptions or StandbyConfig leave their policy unset. Don't
+// reassign it; pass a policy to the functions that need another.
+var DefaultPathPolicy PathPolicy = ExtensionPolicy(".db")
+
+// ExtensionPolicy returns a policy that requires an absolute path ending
+// in one of exts. A missing leading dot is added.
+func ExtensionPolicy(exts ...string) PathPolicy {
+	p := extensionPolicy{}
+	for _, ext := range exts {
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+		p.exts = append(p.exts, ext)
+	}
+	return p
+}
+
+// extensionPolicy implements ExtensionPolicy.
+type extensionPolicy struct {
+	exts []string
+}
+
+func (p extensionPolicy) CheckPath(path string) error {
+	var errs []error
+	if !hostPathStyle.isAbs(path) {
+		errs = append(errs, fmt.Errorf("%s: persistent database path must be absolute", path))
+	}
+	if !slices.Contains(p.exts, filepath.Ext(path)) {
+		errs = append(errs, fmt.Errorf("%s: expected %s extension", path, strings.Join(p.exts, " or ")))
+	}
+	return errors.Join(errs...)
+}
+
+// orDefaultPolicy returns p, or DefaultPathPolicy if p is nil.
+func orDefaultPolicy(p PathPolicy) PathPolicy {
+	if p == nil {
+		return DefaultPathPolicy
+	}
+	return p
+}
+
+// Files works with database files by path, checking every path against
+// its PathPolicy. The package-level Delete, Move, Clone and FixPermissions
+// use a zero Files; Config.Files returns one with the Config's policy.
+type Files struct {
+	// PathPolicy checks each path. Default: DefaultPathPolicy.
+	PathPolicy PathPolicy
+}
+
+// Files returns a Files that checks paths against cfg's PathPolicy or
+// AllowedExtensions, so that the files Create and Open accept can also be
+// deleted, moved and cloned.
+func (cfg Config) Files() Files {
+	return Files{PathPolicy: cfg.pathPolicy()}
+}
+
+// pathPolicy returns the configured path policy: PathPolicy if set, else
+// an ExtensionPolicy for AllowedExtensions if set, else DefaultPathPolicy.
+func (cfg Config) pathPolicy() PathPolicy {
+	if cfg.PathPolicy != nil {
+		return cfg.PathPolicy
+	}
+	if len(cfg.AllowedExtensions) != 0 {
+		return ExtensionPolicy(cfg.AllowedExtensions...)
+	}
+	return DefaultPathPolicy
+}
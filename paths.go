@@ -0,0 +1,126 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"path"
+	"runtime"
+	"strings"
+)
+
+// pathStyle holds the file path rules of an operating system. The package
+// normalizes every persistent path through the host style so that
+// validation, Delete, Move and DSN construction agree on what a path means.
+// Styles are plain values so the rules for every OS can be tested on any
+// host.
+type pathStyle struct {
+	// windows selects drive letters, UNC shares, backslash separators and
+	// the \\?\ long-path prefix.
+	windows bool
+
+	// caseInsensitive makes paths differing only in case name the same
+	// file, as on default Windows and macOS file systems.
+	caseInsensitive bool
+}
+
+// hostPathStyle is the style of the operating system we are running on.
+var hostPathStyle = pathStyleFor(runtime.GOOS)
+
+// pathStyleFor returns the path style for a GOOS value.
+func pathStyleFor(goos string) pathStyle {
+	switch goos {
+	case "windows":
+		return pathStyle{windows: true, caseInsensitive: true}
+	case "darwin", "ios":
+		return pathStyle{caseInsensitive: true}
+	}
+	return pathStyle{}
+}
+
+// normalize returns the canonical form of p: cleaned, with native
+// separators, and on Windows with long-path prefixes removed, the drive
+// letter upper-cased and the leading slash that file: URIs put before a
+// drive letter ("/C:/data") dropped.
+func (s pathStyle) normalize(p string) string {
+	if !s.windows {
+		if p == "" {
+			return p
+		}
+		return path.Clean(p)
+	}
+
+	p = strings.ReplaceAll(p, "/", `\`)
+	switch {
+	case strings.HasPrefix(p, `\\?\UNC\`):
+		p = `\\` + p[len(`\\?\UNC\`):]
+	case strings.HasPrefix(p, `\\?\`):
+		p = p[len(`\\?\`):]
+	case len(p) >= 3 && p[0] == '\\' && isDriveLetter(p[1]) && p[2] == ':':
+		p = p[1:]
+	}
+
+	var prefix, rest string
+	switch {
+	case strings.HasPrefix(p, `\\`):
+		prefix, rest = `\\`, p[2:]
+	case len(p) >= 2 && isDriveLetter(p[0]) && p[1] == ':':
+		prefix, rest = strings.ToUpper(p[:1])+":", p[2:]
+	default:
+		rest = p
+	}
+	if rest == "" {
+		return prefix
+	}
+	cleaned := path.Clean(strings.ReplaceAll(rest, `\`, "/"))
+	return prefix + strings.ReplaceAll(cleaned, "/", `\`)
+}
+
+// isAbs reports whether p is absolute. On Windows that means a drive
+// letter followed by a separator, or a UNC path naming a server and share.
+func (s pathStyle) isAbs(p string) bool {
+	p = s.normalize(p)
+	if !s.windows {
+		return strings.HasPrefix(p, "/")
+	}
+	if rest, ok := strings.CutPrefix(p, `\\`); ok {
+		server, share, _ := strings.Cut(rest, `\`)
+		return server != "" && share != ""
+	}
+	return len(p) >= 3 && isDriveLetter(p[0]) && p[1] == ':' && p[2] == '\\'
+}
+
+// same reports whether a and b name the same path.
+func (s pathStyle) same(a, b string) bool {
+	a, b = s.normalize(a), s.normalize(b)
+	if s.caseInsensitive {
+		return strings.EqualFold(a, b)
+	}
+	return a == b
+}
+
+// uri returns a SQLite "file:" URI for the absolute path p, escaping the
+// characters SQLite treats specially.
+//
+//	/data/app.db            -> file:/data/app.db
+//	C:\data\app.db          -> file:///C:/data/app.db
+//	\\server\share\app.db   -> file:////server/share/app.db
+func (s pathStyle) uri(p string) string {
+	p = s.normalize(p)
+	if s.windows {
+		p = strings.ReplaceAll(p, `\`, "/")
+		if !strings.HasPrefix(p, "//") {
+			p = "/" + p
+		}
+		p = "//" + p
+	}
+	return "file:" + uriEscaper.Replace(p)
+}
+
+// uriEscaper percent-encodes the characters that end or escape the path
+// part of a SQLite URI.
+var uriEscaper = strings.NewReplacer("%", "%25", "?", "%3f", "#", "%23")
+
+// isDriveLetter reports whether c is an ASCII letter.
+func isDriveLetter(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
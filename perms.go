@@ -0,0 +1,138 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// defaultFileMode is the mode Create gives a database file when
+// Config.FileMode is zero: readable and writable by the owner only.
+const defaultFileMode fs.FileMode = 0o600
+
+// sidecarSuffixes are the files SQLite creates next to a database file.
+var sidecarSuffixes = []string{"-wal", "-shm", "-journal"}
+
+// createWithMode creates an empty database file with the given permission
+// bits. The mode is applied with chmod after creation so the process umask
+// doesn't loosen or tighten it. SQLite treats an empty file as a new
+// database.
+func createWithMode(path string, mode fs.FileMode) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode)
+	if err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Chmod(path, mode)
+}
+
+// createDirs creates dir and any missing parents with the given
+// permission bits. Like createWithMode it applies the mode with chmod, but
+// only to the directories it created. It returns the directories it
+// created, deepest first, even if it fails.
+func createDirs(dir string, mode fs.FileMode) (created []string, err error) {
+	var missing []string
+	for d := dir; ; d = filepath.Dir(d) {
+		if _, err := os.Stat(d); err == nil {
+			break
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		missing = append(missing, d)
+		if filepath.Dir(d) == d {
+			break
+		}
+	}
+	if err := os.MkdirAll(dir, mode); err != nil {
+		return missing, err
+	}
+	for _, d := range missing {
+		if err := os.Chmod(d, mode); err != nil {
+			return missing, err
+		}
+	}
+	return missing, nil
+}
+
+// removeDirs removes the directories made by createDirs, deepest first,
+// leaving any that aren't empty.
+func removeDirs(dirs []string) {
+	for _, d := range dirs {
+		os.Remove(d)
+	}
+}
+
+// applyFileMode sets mode on the database file and any sidecars that
+// exist. SQLite creates sidecars later with the main file's permissions,
+// but sidecars left by an earlier process keep whatever they were created
+// with, so they are corrected here too.
+func applyFileMode(path string, mode fs.FileMode) error {
+	for _, suffix := range append([]string{""}, sidecarSuffixes...) {
+		if err := os.Chmod(path+suffix, mode); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("chmod %s: %w", path+suffix, err)
+		}
+	}
+	return nil
+}
+
+// FixPermissions is Files{}.FixPermissions: it checks path against
+// DefaultPathPolicy.
+func FixPermissions(ctx context.Context, path string) error {
+	return Files{}.FixPermissions(ctx, path)
+}
+
+// FixPermissions makes the -wal, -shm and -journal files of the database
+// at path no more permissive than the database file itself, by giving
+// them its permission bits. It is a repair helper for files created by
+// other tools or before Config.FileMode was set; sidecars that don't
+// exist are skipped. path is checked against f.PathPolicy. ctx is checked
+// before each file.
+func (f Files) FixPermissions(ctx context.Context, path string) error {
+	if isMemoryPath(path) {
+		return fmt.Errorf("cannot fix permissions of in-memory database")
+	}
+	if isRemotePath(path) {
+		return fmt.Errorf("cannot fix permissions of remote database")
+	}
+	path = filePathOf(path)
+
+	if err := validatePersistentPath(path, orDefaultPolicy(f.PathPolicy)); err != nil {
+		return err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	mode := info.Mode().Perm()
+
+	for _, suffix := range sidecarSuffixes {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("fix permissions %s: %w", path, err)
+		}
+		name := path + suffix
+		sidecar, err := os.Lstat(name)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if !sidecar.Mode().IsRegular() {
+			return fmt.Errorf("%s: not a regular file", name)
+		}
+		if sidecar.Mode().Perm() == mode {
+			continue
+		}
+		if err := os.Chmod(name, mode); err != nil {
+			return fmt.Errorf("chmod %s: %w", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Pragma is a user-supplied SQLite pragma applied to every connection.
+// Name is the bare pragma name (e.g. "cache_size"), without any driver
+// prefix. Value is the literal pragma value (e.g. "-64000").
+type Pragma struct {
+	Name  string
+	Value string
+}
+
+var (
+	rePragmaName  = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+	rePragmaValue = regexp.MustCompile(`^[A-Za-z0-9_.+-]+$`)
+)
+
+// withExtraPragmas appends user pragmas to the built-in set. It is an
+// error for a user pragma to override a built-in one or to appear twice.
+func withExtraPragmas(builtin []Pragma, extra []Pragma) ([]Pragma, error) {
+	seen := make(map[string]bool, len(builtin)+len(extra))
+	for _, p := range builtin {
+		seen[p.Name] = true
+	}
+
+	result := append([]Pragma(nil), builtin...)
+	for _, p := range extra {
+		name := strings.ToLower(p.Name)
+		if !rePragmaName.MatchString(name) {
+			return nil, fmt.Errorf("pragma %q: invalid name", p.Name)
+		}
+		if !rePragmaValue.MatchString(p.Value) {
+			return nil, fmt.Errorf("pragma %s: invalid value %q", p.Name, p.Value)
+		}
+		if seen[name] {
+			return nil, fmt.Errorf("pragma %s: conflicts with a built-in or duplicate pragma", p.Name)
+		}
+		seen[name] = true
+		result = append(result, Pragma{Name: name, Value: p.Value})
+	}
+	return result, nil
+}
@@ -0,0 +1,150 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// NamedQuery is a query whose plan is tracked by CheckQueryPlans.
+// Args are bound when explaining; use representative values.
+type NamedQuery struct {
+	Name  string
+	Query string
+	Args  []any
+}
+
+// ExplainQueryPlan returns the EXPLAIN QUERY PLAN output for query as
+// indented lines, one per plan step.
+func ExplainQueryPlan(ctx context.Context, db *sql.DB, query string, args ...any) ([]string, error) {
+	rows, err := db.QueryContext(ctx, "EXPLAIN QUERY PLAN "+query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("explain: %w", err)
+	}
+	defer rows.Close()
+
+	depth := map[int]int{0: -1}
+	var lines []string
+	for rows.Next() {
+		var id, parent, notused int
+		var detail string
+		if err := rows.Scan(&id, &parent, &notused, &detail); err != nil {
+			return nil, err
+		}
+		depth[id] = depth[parent] + 1
+		lines = append(lines, strings.Repeat("  ", depth[id])+detail)
+	}
+	return lines, rows.Err()
+}
+
+// FormatQueryPlans explains each query and renders the plans in the golden
+// file format used by CheckQueryPlans, ordered by name.
+func FormatQueryPlans(ctx context.Context, db *sql.DB, queries []NamedQuery) (string, error) {
+	sorted := append([]NamedQuery(nil), queries...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+
+	var sb strings.Builder
+	for i, q := range sorted {
+		if i > 0 && q.Name == sorted[i-1].Name {
+			return "", fmt.Errorf("duplicate query name %q", q.Name)
+		}
+		plan, err := ExplainQueryPlan(ctx, db, q.Query, q.Args...)
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", q.Name, err)
+		}
+		fmt.Fprintf(&sb, "== %s\n", q.Name)
+		for _, line := range plan {
+			sb.WriteString(line)
+			sb.WriteByte('\n')
+		}
+	}
+	return sb.String(), nil
+}
+
+// CheckQueryPlans compares the current plans for queries against the golden
+// file at goldenPath and returns an error listing every query whose plan
+// changed, appeared or disappeared. If update is true the golden file is
+// rewritten instead. A missing golden file is an error unless update is set.
+//
+// Typical use in a test, run against a database opened with the
+// application's migrations:
+//
+//	var update = flag.Bool("update", false, "update golden files")
+//
+//	err := sqliteinit.CheckQueryPlans(ctx, db, queries, "testdata/plans.golden", *update)
+func CheckQueryPlans(ctx context.Context, db *sql.DB, queries []NamedQuery, goldenPath string, update bool) error {
+	got, err := FormatQueryPlans(ctx, db, queries)
+	if err != nil {
+		return err
+	}
+	if update {
+		return os.WriteFile(goldenPath, []byte(got), 0o644)
+	}
+
+	want, err := os.ReadFile(goldenPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("%s: golden file %w (run with update to create it)", goldenPath, ErrNotFound)
+		}
+		return err
+	}
+
+	return diffPlans(parsePlans(string(want)), parsePlans(got))
+}
+
+// parsePlans splits golden file contents into plans keyed by query name.
+func parsePlans(s string) map[string]string {
+	plans := make(map[string]string)
+	var name string
+	for _, line := range strings.Split(s, "\n") {
+		if n, ok := strings.CutPrefix(line, "== "); ok {
+			name = n
+			plans[name] = ""
+			continue
+		}
+		if name != "" && line != "" {
+			plans[name] += line + "\n"
+		}
+	}
+	return plans
+}
+
+// diffPlans returns an error describing differences between want and got.
+func diffPlans(want, got map[string]string) error {
+	names := make(map[string]bool)
+	for n := range want {
+		names[n] = true
+	}
+	for n := range got {
+		names[n] = true
+	}
+	sorted := make([]string, 0, len(names))
+	for n := range names {
+		sorted = append(sorted, n)
+	}
+	sort.Strings(sorted)
+
+	var sb strings.Builder
+	for _, n := range sorted {
+		w, inWant := want[n]
+		g, inGot := got[n]
+		switch {
+		case !inWant:
+			fmt.Fprintf(&sb, "%s: new query not in golden file\n", n)
+		case !inGot:
+			fmt.Fprintf(&sb, "%s: query in golden file no longer checked\n", n)
+		case w != g:
+			fmt.Fprintf(&sb, "%s: plan changed\n--- want\n%s+++ got\n%s", n, w, g)
+		}
+	}
+	if sb.Len() == 0 {
+		return nil
+	}
+	return fmt.Errorf("query plans differ from golden file:\n%s", sb.String())
+}
@@ -0,0 +1,245 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"time"
+)
+
+// ErrQueryTimeout is returned when a statement is interrupted because it
+// exceeded Config.QueryTimeout. Use errors.Is to test for it.
+var ErrQueryTimeout = errors.New("query timeout exceeded")
+
+// noQueryTimeoutKey marks a context whose statements are exempt from the
+// per-query timeout (e.g. migrations, which have their own budget).
+type noQueryTimeoutKey struct{}
+
+// withoutQueryTimeout returns a context exempt from the per-query timeout.
+func withoutQueryTimeout(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noQueryTimeoutKey{}, true)
+}
+
+// timeoutConnector opens connections that enforce a per-query timeout.
+type timeoutConnector struct {
+	next    driver.Connector
+	timeout time.Duration
+}
+
+func (c *timeoutConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.next.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &timeoutConn{Conn: conn, timeout: c.timeout}, nil
+}
+
+func (c *timeoutConnector) Driver() driver.Driver {
+	return c.next.Driver()
+}
+
+// timeoutConn wraps a driver connection, bounding each statement by timeout.
+type timeoutConn struct {
+	driver.Conn
+	timeout time.Duration
+}
+
+// bound derives a statement context carrying the per-query deadline.
+func (c *timeoutConn) bound(ctx context.Context) (context.Context, context.CancelFunc) {
+	if ctx.Value(noQueryTimeoutKey{}) != nil {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, c.timeout)
+}
+
+// timeoutErr converts an error caused by the per-query deadline into one
+// wrapping ErrQueryTimeout.
+func timeoutErr(parent, bounded context.Context, err error, timeout time.Duration) error {
+	if err == nil || parent.Err() != nil || !errors.Is(bounded.Err(), context.DeadlineExceeded) {
+		return err
+	}
+	return fmt.Errorf("%w (%s): %w", ErrQueryTimeout, timeout, err)
+}
+
+func (c *timeoutConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	bctx, cancel := c.bound(ctx)
+	defer cancel()
+	res, err := execer.ExecContext(bctx, query, args)
+	return res, timeoutErr(ctx, bctx, err, c.timeout)
+}
+
+func (c *timeoutConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	bctx, cancel := c.bound(ctx)
+	rows, err := queryer.QueryContext(bctx, query, args)
+	if err != nil {
+		cancel()
+		return nil, timeoutErr(ctx, bctx, err, c.timeout)
+	}
+	// The deadline covers iteration too; release it when the rows close.
+	return &timeoutRows{Rows: rows, parent: ctx, bounded: bctx, cancel: cancel, timeout: c.timeout}, nil
+}
+
+func (c *timeoutConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	var stmt driver.Stmt
+	var err error
+	if p, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		stmt, err = p.PrepareContext(ctx, query)
+	} else {
+		stmt, err = c.Conn.Prepare(query)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &timeoutStmt{Stmt: stmt, conn: c}, nil
+}
+
+func (c *timeoutConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	if b, ok := c.Conn.(driver.ConnBeginTx); ok {
+		return b.BeginTx(ctx, opts)
+	}
+	return c.Conn.Begin()
+}
+
+func (c *timeoutConn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *timeoutConn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *timeoutConn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+func (c *timeoutConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if n, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return n.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// timeoutStmt wraps a prepared statement, bounding each execution.
+type timeoutStmt struct {
+	driver.Stmt
+	conn *timeoutConn
+}
+
+func (s *timeoutStmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := s.Stmt.(driver.StmtExecContext)
+	if !ok {
+		return nil, fmt.Errorf("driver statement does not support ExecContext")
+	}
+	bctx, cancel := s.conn.bound(ctx)
+	defer cancel()
+	res, err := execer.ExecContext(bctx, args)
+	return res, timeoutErr(ctx, bctx, err, s.conn.timeout)
+}
+
+func (s *timeoutStmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := s.Stmt.(driver.StmtQueryContext)
+	if !ok {
+		return nil, fmt.Errorf("driver statement does not support QueryContext")
+	}
+	bctx, cancel := s.conn.bound(ctx)
+	rows, err := queryer.QueryContext(bctx, args)
+	if err != nil {
+		cancel()
+		return nil, timeoutErr(ctx, bctx, err, s.conn.timeout)
+	}
+	return &timeoutRows{Rows: rows, parent: ctx, bounded: bctx, cancel: cancel, timeout: s.conn.timeout}, nil
+}
+
+// timeoutRows releases the statement deadline when closed.
+type timeoutRows struct {
+	driver.Rows
+	parent, bounded context.Context
+	cancel          context.CancelFunc
+	timeout         time.Duration
+}
+
+func (r *timeoutRows) Next(dest []driver.Value) error {
+	return timeoutErr(r.parent, r.bounded, r.Rows.Next(dest), r.timeout)
+}
+
+func (r *timeoutRows) Close() error {
+	err := r.Rows.Close()
+	r.cancel()
+	return err
+}
+
+// The optional column type and result set interfaces are forwarded, so
+// that ColumnTypes reports what the driver knows. database/sql asserts
+// them on the wrapper, so each returns the value database/sql would use
+// when the driver's rows don't implement it.
+
+func (r *timeoutRows) ColumnTypeDatabaseTypeName(index int) string {
+	if rr, ok := r.Rows.(driver.RowsColumnTypeDatabaseTypeName); ok {
+		return rr.ColumnTypeDatabaseTypeName(index)
+	}
+	return ""
+}
+
+func (r *timeoutRows) ColumnTypeScanType(index int) reflect.Type {
+	if rr, ok := r.Rows.(driver.RowsColumnTypeScanType); ok {
+		return rr.ColumnTypeScanType(index)
+	}
+	return reflect.TypeFor[any]()
+}
+
+func (r *timeoutRows) ColumnTypeLength(index int) (length int64, ok bool) {
+	if rr, ok := r.Rows.(driver.RowsColumnTypeLength); ok {
+		return rr.ColumnTypeLength(index)
+	}
+	return 0, false
+}
+
+func (r *timeoutRows) ColumnTypeNullable(index int) (nullable, ok bool) {
+	if rr, ok := r.Rows.(driver.RowsColumnTypeNullable); ok {
+		return rr.ColumnTypeNullable(index)
+	}
+	return false, false
+}
+
+func (r *timeoutRows) ColumnTypePrecisionScale(index int) (precision, scale int64, ok bool) {
+	if rr, ok := r.Rows.(driver.RowsColumnTypePrecisionScale); ok {
+		return rr.ColumnTypePrecisionScale(index)
+	}
+	return 0, 0, false
+}
+
+func (r *timeoutRows) HasNextResultSet() bool {
+	if rr, ok := r.Rows.(driver.RowsNextResultSet); ok {
+		return rr.HasNextResultSet()
+	}
+	return false
+}
+
+func (r *timeoutRows) NextResultSet() error {
+	if rr, ok := r.Rows.(driver.RowsNextResultSet); ok {
+		return timeoutErr(r.parent, r.bounded, rr.NextResultSet(), r.timeout)
+	}
+	return io.EOF
+}
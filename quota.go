@@ -0,0 +1,138 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+)
+
+// quotaWarnRatio is the fraction of MaxDatabaseSize at which the database
+// is approaching its quota: Open logs a warning and sends a QuotaEvent, and
+// QuotaTask runs its reclaim function.
+const quotaWarnRatio = 0.9
+
+// defaultPageSize is SQLite's page size for new databases.
+const defaultPageSize = 4096
+
+// QuotaUsage is the size of a database measured against a quota.
+type QuotaUsage struct {
+	Size int64 // bytes used: page_count * page_size
+	Max  int64 // the quota in bytes
+}
+
+// Ratio returns the fraction of the quota in use.
+func (u QuotaUsage) Ratio() float64 {
+	return float64(u.Size) / float64(u.Max)
+}
+
+// Approaching reports whether the database is within 10% of its quota.
+func (u QuotaUsage) Approaching() bool {
+	return u.Ratio() >= quotaWarnRatio
+}
+
+// quotaPragma returns the max_page_count pragma that caps the database at
+// cfg.MaxDatabaseSize bytes. It is part of the per-connection pragmas
+// because the limit only applies to the connection that sets it. The page
+// size is read from the database file's header, or for a new or encrypted
+// file, taken from a page_size pragma in ExtraPragmas or SQLite's default.
+func (cfg Config) quotaPragma() (Pragma, bool) {
+	if cfg.MaxDatabaseSize <= 0 || cfg.isRemote() {
+		return Pragma{}, false
+	}
+	pageSize := int64(0)
+	if !cfg.isMemory() {
+		pageSize = filePageSize(cfg.filePath())
+	}
+	if pageSize == 0 {
+		pageSize = defaultPageSize
+		for _, p := range cfg.ExtraPragmas {
+			if n, err := strconv.ParseInt(p.Value, 10, 64); p.Name == "page_size" && err == nil && n > 0 {
+				pageSize = n
+			}
+		}
+	}
+	// checkQuota rejects a quota smaller than one page.
+	maxPages := max(cfg.MaxDatabaseSize/pageSize, 1)
+	return Pragma{Name: "max_page_count", Value: strconv.FormatInt(maxPages, 10)}, true
+}
+
+// filePageSize returns the page size recorded in the header of the
+// database file at path, or 0 if the file doesn't exist or its header
+// can't be read, as for an encrypted database.
+func filePageSize(path string) int64 {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+	var hdr [18]byte
+	if _, err := io.ReadFull(f, hdr[:]); err != nil || string(hdr[:16]) != "SQLite format 3\x00" {
+		return 0
+	}
+	// The header stores 65536 as 1.
+	size := int64(binary.BigEndian.Uint16(hdr[16:]))
+	if size == 1 {
+		size = 65536
+	}
+	return size
+}
+
+// quotaUsage measures db against a quota of maxSize bytes.
+func quotaUsage(ctx context.Context, db *sql.DB, maxSize int64) (QuotaUsage, error) {
+	var pageSize, pageCount int64
+	if err := db.QueryRowContext(ctx, `SELECT page_size, page_count FROM pragma_page_size, pragma_page_count`).Scan(&pageSize, &pageCount); err != nil {
+		return QuotaUsage{}, fmt.Errorf("read page_count: %w", err)
+	}
+	if maxSize < pageSize {
+		return QuotaUsage{}, fmt.Errorf("max database size %d is smaller than page size %d", maxSize, pageSize)
+	}
+	return QuotaUsage{Size: pageCount * pageSize, Max: maxSize}, nil
+}
+
+// checkQuota checks the database against cfg.MaxDatabaseSize when it is
+// opened, logging a warning and sending a QuotaEvent if it is approaching
+// the quota. The quota itself is enforced by quotaPragma.
+func checkQuota(ctx context.Context, db *sql.DB, cfg Config) error {
+	if cfg.MaxDatabaseSize <= 0 {
+		return nil
+	}
+	usage, err := quotaUsage(ctx, db, cfg.MaxDatabaseSize)
+	if err != nil {
+		return err
+	}
+	if usage.Approaching() {
+		cfg.Logger.Warn("database approaching size quota", "path", redactDSN(cfg.Path), "size", usage.Size, "max", usage.Max)
+		cfg.emit(QuotaEvent{Path: redactDSN(cfg.Path), Usage: usage})
+	}
+	return nil
+}
+
+// QuotaTask returns a maintenance task that measures the database against
+// a quota of maxSize bytes, normally Config.MaxDatabaseSize. When the
+// database is within 10% of the quota it calls reclaim, which should free
+// space, for example by deleting expired rows; free pages are reused by
+// later writes. With a nil reclaim the run fails instead, so that the
+// approaching quota shows in the maintenance history and logs.
+func QuotaTask(interval time.Duration, maxSize int64, reclaim func(ctx context.Context, db *sql.DB, usage QuotaUsage) error) MaintenanceTask {
+	return MaintenanceTask{
+		Name:     "quota",
+		Interval: interval,
+		Run: func(ctx context.Context, db *sql.DB) error {
+			usage, err := quotaUsage(ctx, db, maxSize)
+			if err != nil || !usage.Approaching() {
+				return err
+			}
+			if reclaim == nil {
+				return fmt.Errorf("database size %d is approaching its quota of %d", usage.Size, usage.Max)
+			}
+			return reclaim(ctx, db, usage)
+		},
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Restore replaces the database at cfg.Path with the backup at backupPath
+// and opens it with cfg, applying any migrations added since the backup was
+// taken. Close every handle to the database first.
+//
+// The backup is copied, with its -wal file if it has one, to a temporary
+// file beside cfg.Path and checked there; a backup ending in ".db.gz" or
+// ".db.zst" is decompressed instead. The copy must pass PRAGMA
+// integrity_check and have a readable schema version. Only then are the
+// database's -wal and -shm files removed, so a stale WAL can't be replayed
+// into the restored file, and the copy renamed into place. The backup
+// itself is left untouched. If the database doesn't exist yet it is
+// created. With cfg.Gate set, the gate is paused from the start of the
+// restore until the restored database is open.
+func Restore(ctx context.Context, backupPath string, cfg Config) (*sql.DB, error) {
+	cfg = cfg.defaults()
+	if cfg.isMemory() || cfg.isRemote() {
+		return nil, fmt.Errorf("Restore requires a local persistent path")
+	}
+	cfg, err := applySymlinkPolicy(cfg)
+	if err != nil {
+		return nil, err
+	}
+	path := cfg.filePath()
+	if err := validatePersistentPath(path, cfg.pathPolicy()); err != nil {
+		return nil, err
+	}
+	backupPath = filePathOf(backupPath)
+	if !fileExists(backupPath) {
+		return nil, fmt.Errorf("%s: backup file %w", backupPath, ErrNotFound)
+	}
+
+	if cfg.Gate != nil {
+		if err := cfg.Gate.Pause(ctx); err != nil {
+			return nil, fmt.Errorf("restore: %w", err)
+		}
+		defer cfg.Gate.Resume()
+	}
+
+	tmp := path + ".restore"
+	removeDatabaseFiles(tmp)
+	defer removeDatabaseFiles(tmp)
+
+	if compression, _ := compressionOf(backupPath); compression != CompressionNone {
+		if err := decompressFile(compression, backupPath, tmp); err != nil {
+			return nil, fmt.Errorf("restore: decompress backup: %w", err)
+		}
+	} else {
+		for _, suffix := range []string{"", "-wal"} {
+			if suffix != "" && !fileExists(backupPath+suffix) {
+				continue
+			}
+			if err := copyFile(backupPath+suffix, tmp+suffix); err != nil {
+				return nil, fmt.Errorf("restore: copy backup: %w", err)
+			}
+		}
+	}
+	version, err := checkRestore(ctx, cfg, tmp)
+	if err != nil {
+		return nil, fmt.Errorf("restore: %s: %w", backupPath, err)
+	}
+	if err := syncFile(tmp); err != nil {
+		return nil, fmt.Errorf("restore: %w", err)
+	}
+
+	for _, suffix := range []string{"-wal", "-shm"} {
+		if err := os.Remove(path + suffix); err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("restore: remove %s: %w", path+suffix, err)
+		}
+	}
+	if err := rename(tmp, path); err != nil {
+		return nil, fmt.Errorf("restore: %w", err)
+	}
+	syncDir(filepath.Dir(path))
+	cfg.Logger.Info("database restored", "path", cfg.Path, "backup", backupPath, "schema_version", version)
+
+	db, err := Open(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("restore: %w", err)
+	}
+	return db, nil
+}
+
+// checkRestore checks the copy of a backup at path, folding any WAL into
+// it, and returns its schema version.
+func checkRestore(ctx context.Context, cfg Config, path string) (int, error) {
+	ctx = withInternal(ctx)
+	dsn, _ := cfg.driver().BuildDSN(path, nil)
+	db, err := sql.Open(cfg.driver().Name(), dsn)
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+
+	violations, err := Verify(ctx, db, VerifyOptions{})
+	if err != nil {
+		return 0, err
+	}
+	for _, v := range violations {
+		if v.Check == "integrity" {
+			return 0, fmt.Errorf("integrity check: %s", v.Message)
+		}
+	}
+	version, err := fetchSchemaVersion(ctx, db)
+	if err != nil {
+		return 0, fmt.Errorf("read schema version: %w", err)
+	}
+	if version == nil {
+		return 0, fmt.Errorf("backup %w", ErrNotInitialized)
+	}
+	if _, err := db.ExecContext(ctx, `PRAGMA wal_checkpoint(TRUNCATE)`); err != nil {
+		return 0, fmt.Errorf("checkpoint: %w", err)
+	}
+	return *version, db.Close()
+}
+
+// copyFile copies the file at from to a new file at to.
+func copyFile(from, to string) error {
+	src, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dst, err := os.OpenFile(to, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
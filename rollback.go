@@ -0,0 +1,182 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// downSuffix ends the name of a migration's down script. The down script
+// for "20260101000001_users.sql" is "20260101000001_users.down.sql".
+// Down scripts are never applied by Open; they are only read to build
+// rollback plans.
+const downSuffix = ".down.sql"
+
+// isDownScript returns true if name is a down script.
+func isDownScript(name string) bool {
+	return strings.HasSuffix(name, downSuffix)
+}
+
+// downScriptPath returns the down script path for a migration path.
+func downScriptPath(path string) string {
+	return strings.TrimSuffix(path, ".sql") + downSuffix
+}
+
+// RollbackStep describes how to revert one pending migration.
+type RollbackStep struct {
+	// Path is the migration being reverted.
+	Path string
+
+	// DownPath is the down script for the migration, or empty if there is
+	// none and the migration can only be reverted from Snapshot.
+	DownPath string
+
+	// SQL is the content of the down script.
+	SQL string
+}
+
+// RollbackPlan describes how to revert the migrations that are pending on
+// a database, for review before they are applied.
+type RollbackPlan struct {
+	// Created is when the plan was built.
+	Created time.Time
+
+	// FromVersion is the schema version before the migrations are applied
+	// and the version the plan reverts to. It is 0 for a database that
+	// hasn't been initialized.
+	FromVersion int
+
+	// Steps revert the pending migrations, in the order to run them (the
+	// reverse of the order they are applied in).
+	Steps []RollbackStep
+
+	// Snapshot is the path of a copy of the database taken before the
+	// migrations were applied, or empty if none was taken.
+	Snapshot string
+}
+
+// Reversible returns true if every step has a down script.
+func (p *RollbackPlan) Reversible() bool {
+	for _, s := range p.Steps {
+		if s.DownPath == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// String renders the plan as a SQL script with a comment header, so it can
+// be reviewed and, if every step has a down script, run as is.
+func (p *RollbackPlan) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "-- Rollback plan created %s\n", p.Created.UTC().Format(time.RFC3339))
+	fmt.Fprintf(&b, "-- Reverts to schema version %d\n", p.FromVersion)
+	if p.Snapshot != "" {
+		fmt.Fprintf(&b, "-- Pre-migration snapshot: %s\n", p.Snapshot)
+	}
+	if !p.Reversible() {
+		b.WriteString("-- WARNING: some migrations have no down script; restore the snapshot instead\n")
+	}
+	for _, s := range p.Steps {
+		fmt.Fprintf(&b, "\n-- Revert %s\n", s.Path)
+		if s.DownPath == "" {
+			b.WriteString("-- (no down script)\n")
+			continue
+		}
+		fmt.Fprintf(&b, "-- From %s\n", s.DownPath)
+		b.WriteString(strings.TrimSpace(s.SQL))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// buildRollbackPlan builds the plan for reverting pending from fromVersion.
+func buildRollbackPlan(migrations fs.FS, fromVersion int, pending []migrationScript) (*RollbackPlan, error) {
+	plan := &RollbackPlan{Created: time.Now().UTC(), FromVersion: fromVersion}
+	for i := len(pending) - 1; i >= 0; i-- {
+		step := RollbackStep{Path: pending[i].Path}
+		down := downScriptPath(pending[i].Path)
+		sqlBytes, err := fs.ReadFile(migrations, down)
+		switch {
+		case err == nil:
+			step.DownPath, step.SQL = down, string(sqlBytes)
+		case !errors.Is(err, fs.ErrNotExist):
+			return nil, fmt.Errorf("read %s: %w", down, err)
+		}
+		plan.Steps = append(plan.Steps, step)
+	}
+	return plan, nil
+}
+
+// PlanRollback returns the rollback plan for the migrations in
+// cfg.Migrations that are pending on the database, without applying them.
+// The plan has no steps if nothing is pending.
+func PlanRollback(ctx context.Context, cfg Config) (*RollbackPlan, error) {
+	cfg = cfg.defaults()
+	status, err := Status(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Migrations == nil {
+		return &RollbackPlan{Created: time.Now().UTC(), FromVersion: status.SchemaVersion}, nil
+	}
+
+	scripts, err := listMigrationFiles(cfg.Migrations, cfg.Logger)
+	if err != nil {
+		return nil, fmt.Errorf("list migrations: %w", err)
+	}
+	isPending := make(map[string]bool, len(status.Pending))
+	for _, p := range status.Pending {
+		isPending[p] = true
+	}
+	var pending []migrationScript
+	for _, s := range scripts {
+		if isPending[s.Path] || !status.IsInitialized && s.appliesTo(cfg.environment()) {
+			pending = append(pending, s)
+		}
+	}
+	return buildRollbackPlan(cfg.Migrations, status.SchemaVersion, pending)
+}
+
+// recordRollbackPlan takes the pre-migration snapshot and writes the
+// rollback plan for pending, as configured by cfg.RollbackSnapshots and
+// cfg.RollbackPlanDir. It runs before any pending migration is applied.
+func recordRollbackPlan(ctx context.Context, db *sql.DB, cfg Config, pending []migrationScript) error {
+	version, err := fetchSchemaVersion(ctx, db)
+	if err != nil {
+		return err
+	}
+	var from int
+	if version != nil {
+		from = *version
+	}
+	plan, err := buildRollbackPlan(cfg.Migrations, from, pending)
+	if err != nil {
+		return err
+	}
+
+	if cfg.RollbackSnapshots != nil {
+		snap, err := cfg.RollbackSnapshots.Take(ctx, db)
+		if err != nil {
+			return fmt.Errorf("snapshot: %w", err)
+		}
+		plan.Snapshot = snap.Path
+	}
+
+	if cfg.RollbackPlanDir != "" {
+		name := filepath.Join(cfg.RollbackPlanDir, "rollback-"+plan.Created.Format(snapshotTimeFormat)+".sql")
+		if err := os.WriteFile(name, []byte(plan.String()), 0o644); err != nil {
+			return err
+		}
+		cfg.Logger.Info("wrote rollback plan", "path", name, "steps", len(plan.Steps), "reversible", plan.Reversible())
+	}
+	return nil
+}
@@ -0,0 +1,209 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// RoundTripTest describes the migrations CheckRoundTrip checks.
+type RoundTripTest struct {
+	// Migrations are the migrations to check, with their down scripts.
+	// Required.
+	Migrations fs.FS
+
+	// Config is the base configuration for every open, e.g. with
+	// StrictTables or Environment set. Path, Migrations, Seeds, the
+	// Required* checks, DetectDrift and MigrationBudget are set by
+	// CheckRoundTrip.
+	Config Config
+
+	// Dir is the directory for the scratch database. Default: a temporary
+	// directory, removed afterwards.
+	Dir string
+}
+
+// RoundTripReport summarizes a CheckRoundTrip run.
+type RoundTripReport struct {
+	// Checked lists the migrations that were applied, rolled back and
+	// applied again, in order.
+	Checked []string
+
+	// Irreversible lists the migrations that have no down script. They
+	// were applied but not rolled back.
+	Irreversible []string
+}
+
+// RoundTripError reports a migration whose round trip left a schema other
+// than the one expected.
+type RoundTripError struct {
+	// Path is the migration at fault.
+	Path string
+
+	// Step is "down" if the migration's down script didn't restore the
+	// schema from before the migration, or "reapply" if applying the
+	// migration again gave a different schema than the first time.
+	Step string
+
+	// Want and Got are the expected and actual schemas, as written by
+	// DumpSchema.
+	Want, Got string
+}
+
+func (e *RoundTripError) Error() string {
+	if e.Step == "down" {
+		return fmt.Sprintf("roundtrip: %s: down script doesn't restore the previous schema", e.Path)
+	}
+	return fmt.Sprintf("roundtrip: %s: applying again gives a different schema", e.Path)
+}
+
+// CheckRoundTrip takes a scratch database through every migration that
+// applies in the configured environment, in order. For each it applies
+// the migration, rolls it back with its down script, checks that the
+// schema is the one from before the migration, applies it again and checks
+// that the schema is the one from the first time. A migration without a
+// down script is only applied, and listed in RoundTripReport.Irreversible.
+//
+// CheckRoundTrip returns an error naming the first migration that failed,
+// a *RoundTripError if the failure was a schema mismatch, or nil. Use it in
+// a test, or through sqliteinittest.RoundTrip:
+//
+//	report, err := sqliteinit.CheckRoundTrip(ctx, sqliteinit.RoundTripTest{Migrations: migrations})
+func CheckRoundTrip(ctx context.Context, test RoundTripTest) (*RoundTripReport, error) {
+	if test.Migrations == nil {
+		return nil, fmt.Errorf("roundtrip: Migrations is required")
+	}
+	base := test.Config.defaults()
+	scripts, err := listMigrationFiles(test.Migrations, base.Logger)
+	if err != nil {
+		return nil, fmt.Errorf("roundtrip: migrations: %w", err)
+	}
+
+	dir := test.Dir
+	if dir == "" {
+		if dir, err = os.MkdirTemp("", "sqliteinit-roundtrip-"); err != nil {
+			return nil, fmt.Errorf("roundtrip: %w", err)
+		}
+		defer os.RemoveAll(dir)
+	}
+	base.Path = filepath.Join(dir, "roundtrip.db")
+	base.Seeds = nil
+	base.RequiredSchemaVersion = 0
+	base.RequiredSchemaFingerprint = ""
+	base.DetectDrift = DriftIgnore // rolling back changes the schema outside migrations
+	base.MigrationBudget = 0
+	base.SkipMigrations = false
+	if err := base.Files().Delete(ctx, base.Path); err != nil {
+		return nil, fmt.Errorf("roundtrip: %w", err)
+	}
+
+	through := func(id int) Config {
+		cfg := base
+		cfg.Migrations = migrationsThrough{fsys: test.Migrations, id: id}
+		return cfg
+	}
+	// schema opens the database with cfg, applying its pending migrations,
+	// and returns the schema.
+	schema := func(cfg Config) (string, error) {
+		db, err := Open(ctx, cfg)
+		if err != nil {
+			return "", err
+		}
+		defer db.Close()
+		var sb strings.Builder
+		if err := DumpSchema(ctx, db, &sb); err != nil {
+			return "", err
+		}
+		return sb.String(), db.Close()
+	}
+
+	if err := Create(ctx, through(0)); err != nil {
+		return nil, fmt.Errorf("roundtrip: create database: %w", err)
+	}
+	before, err := schema(through(0))
+	if err != nil {
+		return nil, fmt.Errorf("roundtrip: %w", err)
+	}
+
+	report := &RoundTripReport{}
+	version := 0
+	for _, s := range scripts {
+		if !s.appliesTo(base.environment()) {
+			continue
+		}
+		cfg := through(s.ID)
+		after, err := schema(cfg)
+		if err != nil {
+			return report, fmt.Errorf("roundtrip: %s: apply: %w", s.Path, err)
+		}
+		if _, err := fs.Stat(test.Migrations, downScriptPath(s.Path)); err != nil {
+			report.Irreversible = append(report.Irreversible, s.Path)
+			before, version = after, s.ID
+			continue
+		}
+
+		if err := rollBack(ctx, cfg, version, []migrationScript{s}); err != nil {
+			return report, fmt.Errorf("roundtrip: %s: roll back: %w", s.Path, err)
+		}
+		got, err := schema(through(version))
+		if err != nil {
+			return report, fmt.Errorf("roundtrip: %s: open rolled-back database: %w", s.Path, err)
+		}
+		if got != before {
+			return report, &RoundTripError{Path: s.Path, Step: "down", Want: before, Got: got}
+		}
+		if got, err = schema(cfg); err != nil {
+			return report, fmt.Errorf("roundtrip: %s: apply again: %w", s.Path, err)
+		}
+		if got != after {
+			return report, &RoundTripError{Path: s.Path, Step: "reapply", Want: after, Got: got}
+		}
+		report.Checked = append(report.Checked, s.Path)
+		before, version = after, s.ID
+	}
+	return report, nil
+}
+
+// migrationsThrough is a migrations filesystem that hides the migrations,
+// and their down scripts, after the one with ID id.
+type migrationsThrough struct {
+	fsys fs.FS
+	id   int
+}
+
+// hides returns true if name is a migration or down script after id.
+func (m migrationsThrough) hides(name string) bool {
+	matches := reMigrationFile.FindStringSubmatch(name)
+	if matches == nil {
+		return false
+	}
+	id, err := strconv.Atoi(matches[1])
+	return err == nil && id > m.id
+}
+
+func (m migrationsThrough) Open(name string) (fs.File, error) {
+	if m.hides(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return m.fsys.Open(name)
+}
+
+func (m migrationsThrough) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries, err := fs.ReadDir(m.fsys, name)
+	if err != nil || name != "." {
+		return entries, err
+	}
+	var kept []fs.DirEntry
+	for _, e := range entries {
+		if !m.hides(e.Name()) {
+			kept = append(kept, e)
+		}
+	}
+	return kept, nil
+}
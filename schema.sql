@@ -1,19 +1,25 @@
 -- sqliteinit infrastructure schema
 -- This script is owned by the sqliteinit package and creates the
--- schema_migrations and config tables used for migration tracking.
+-- schema_migrations and config tables used for migration tracking, and
+-- the flags table.
 
 PRAGMA foreign_keys = ON;
 PRAGMA busy_timeout = 5000;
 
 -- All timestamps are stored as Unix seconds in UTC.
 
+-- duration_ms, checksum and applied_by were added later; upgradeInfraSchema
+-- adds them to databases created before.
 CREATE TABLE schema_migrations (
-    id         INTEGER NOT NULL PRIMARY KEY,
-    comment    TEXT    NOT NULL,
-    path       TEXT    NOT NULL UNIQUE,
-    applied_at INTEGER NOT NULL,
-    created_at INTEGER NOT NULL,
-    updated_at INTEGER NOT NULL
+    id          INTEGER NOT NULL PRIMARY KEY,
+    comment     TEXT    NOT NULL,
+    path        TEXT    NOT NULL UNIQUE,
+    applied_at  INTEGER NOT NULL,
+    created_at  INTEGER NOT NULL,
+    updated_at  INTEGER NOT NULL,
+    duration_ms INTEGER NOT NULL DEFAULT 0,
+    checksum    TEXT    NOT NULL DEFAULT '',
+    applied_by  TEXT    NOT NULL DEFAULT ''
 );
 
 CREATE TABLE config (
@@ -23,9 +29,21 @@ CREATE TABLE config (
     updated_at INTEGER NOT NULL
 );
 
+-- Feature flags, read and written by FlagSet. Added in revision 3;
+-- upgradeInfraSchema creates it in databases created before.
+CREATE TABLE flags (
+    name       TEXT    NOT NULL PRIMARY KEY,
+    enabled    INTEGER NOT NULL DEFAULT 0,
+    created_at INTEGER NOT NULL,
+    updated_at INTEGER NOT NULL
+);
+
 -- Initial config rows. schema.version is updated by each migration.
 -- app.version and db.created_at are populated after init if AppVersion is set.
+-- schema.infra_version is the revision of this script and must match
+-- infraSchemaVersion.
 INSERT INTO config (key, value, created_at, updated_at)
 VALUES ('schema.version', '0', 0, 0),
+       ('schema.infra_version', '3', 0, 0),
        ('app.version', '', 0, 0),
        ('db.created_at', '', 0, 0);
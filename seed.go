@@ -0,0 +1,152 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// SeedReport summarizes a Seed run.
+type SeedReport struct {
+	// Applied lists the seed files applied by this run, in order.
+	Applied []string
+
+	// Changed lists seed files that were applied by an earlier run and
+	// have changed since. They are not applied again.
+	Changed []string
+}
+
+// seedOrderPrefix is the optional ordering prefix of a data seed's file
+// name, as in 010_users.csv.
+var seedOrderPrefix = regexp.MustCompile(`^[0-9]+_`)
+
+// Seed applies the fixtures in the top directory of fsys that haven't been
+// applied to db yet, in file name order, and records each in the
+// seed_runs table, so running it again is a no-op. It is what Open does
+// with Config.Seeds.
+//
+// A .sql file is executed as a script. A .csv or .jsonl file is loaded
+// into the table it is named after, less any numeric ordering prefix
+// (010_users.csv loads users), as ImportTable would, except that any row
+// that can't be imported fails the seed. Other files are ignored. Each
+// seed is applied in a transaction of its own, together with its record.
+// Seeds are tracked by file name; a seed that changed after it was applied
+// is listed in SeedReport.Changed and left alone.
+//
+// Seeds should only add data: tables they create aren't part of the
+// migration history.
+func Seed(ctx context.Context, db *sql.DB, fsys fs.FS) (*SeedReport, error) {
+	return seed(ctx, db, fsys, time.Now)
+}
+
+// seed does the work of Seed, recording seeds as applied at now().
+func seed(ctx context.Context, db *sql.DB, fsys fs.FS, now func() time.Time) (*SeedReport, error) {
+	ctx = withInternal(ctx)
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, fmt.Errorf("seed: %w", err)
+	}
+	if _, err := db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS seed_runs (
+		    name       TEXT    NOT NULL PRIMARY KEY,
+		    checksum   TEXT    NOT NULL,
+		    applied_at INTEGER NOT NULL
+		)
+	`); err != nil {
+		return nil, fmt.Errorf("seed: create seed_runs: %w", err)
+	}
+	applied, err := seedRuns(ctx, db)
+	if err != nil {
+		return nil, fmt.Errorf("seed: %w", err)
+	}
+
+	report := &SeedReport{}
+	for _, e := range entries {
+		name := e.Name()
+		ext := path.Ext(name)
+		if e.IsDir() || (ext != ".sql" && ext != ".csv" && ext != ".jsonl") {
+			continue
+		}
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return report, fmt.Errorf("seed: %w", err)
+		}
+		sum := sha256.Sum256(data)
+		checksum := hex.EncodeToString(sum[:])
+		if prev, ok := applied[name]; ok {
+			if prev != checksum {
+				report.Changed = append(report.Changed, name)
+			}
+			continue
+		}
+		if err := applySeed(ctx, db, name, data, checksum, now()); err != nil {
+			return report, fmt.Errorf("seed %s: %w", name, err)
+		}
+		report.Applied = append(report.Applied, name)
+	}
+	return report, nil
+}
+
+// seedRuns returns the checksums of the seeds recorded in db by name.
+func seedRuns(ctx context.Context, db *sql.DB) (map[string]string, error) {
+	rows, err := db.QueryContext(ctx, `SELECT name, checksum FROM seed_runs`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	applied := make(map[string]string)
+	for rows.Next() {
+		var name, checksum string
+		if err := rows.Scan(&name, &checksum); err != nil {
+			return nil, err
+		}
+		applied[name] = checksum
+	}
+	return applied, rows.Err()
+}
+
+// applySeed applies the seed file called name and records it.
+func applySeed(ctx context.Context, db *sql.DB, name string, data []byte, checksum string, now time.Time) error {
+	// Read the table's columns before the transaction takes the
+	// connection, which may be the handle's only one.
+	var table string
+	var cols []Column
+	var format DataFormat
+	if ext := path.Ext(name); ext != ".sql" {
+		table = seedOrderPrefix.ReplaceAllString(strings.TrimSuffix(name, ext), "")
+		var err error
+		if cols, err = Columns(ctx, db, table); err != nil {
+			return err
+		}
+		if ext == ".jsonl" {
+			format = FormatJSONLines
+		}
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if table == "" {
+		if _, err := tx.ExecContext(ctx, string(data)); err != nil {
+			return err
+		}
+	} else if _, err := importRows(ctx, db, tx, table, cols, bytes.NewReader(data), ImportOptions{Format: format}); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO seed_runs (name, checksum, applied_at) VALUES (?, ?, ?)`, name, checksum, now.UTC().Unix()); err != nil {
+		return fmt.Errorf("record: %w", err)
+	}
+	return tx.Commit()
+}
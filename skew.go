@@ -0,0 +1,213 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// SkewTest describes two releases of an application's migrations for
+// SimulateSkew.
+type SkewTest struct {
+	// Old and New are the migrations of the old and new release. Required.
+	Old, New fs.FS
+
+	// Config is the base configuration for every open, e.g. with
+	// StrictTables or Environment set. Path, Migrations and
+	// RequiredSchemaVersion are set by SimulateSkew.
+	Config Config
+
+	// Dir is the directory for the scratch database. Default: a temporary
+	// directory, removed afterwards.
+	Dir string
+}
+
+// SimulateSkew walks a scratch database through the upgrade and downgrade
+// sequence of a rolling deploy from Old to New and checks that the
+// package behaves as the application expects at every step:
+//
+//  1. the old release creates the database
+//  2. the new release upgrades it
+//  3. the old release, pinned to its schema version with
+//     RequiredSchemaVersion, refuses the upgraded database
+//  4. the old release, unpinned, opens it and applies nothing
+//  5. the new migrations are rolled back with their down scripts
+//  6. the pinned old release opens the rolled-back database
+//  7. the new release upgrades it again
+//
+// New must contain every migration in Old, and every migration only in
+// New must have a down script. SimulateSkew returns an error naming the
+// first step that failed, or nil. Use it in a test:
+//
+//	err := sqliteinit.SimulateSkew(ctx, sqliteinit.SkewTest{Old: v1, New: v2})
+func SimulateSkew(ctx context.Context, test SkewTest) error {
+	if test.Old == nil || test.New == nil {
+		return fmt.Errorf("skew: Old and New migrations are required")
+	}
+	base := test.Config.defaults()
+
+	oldScripts, err := listMigrationFiles(test.Old, base.Logger)
+	if err != nil {
+		return fmt.Errorf("skew: old migrations: %w", err)
+	}
+	newScripts, err := listMigrationFiles(test.New, base.Logger)
+	if err != nil {
+		return fmt.Errorf("skew: new migrations: %w", err)
+	}
+	added, err := addedMigrations(oldScripts, newScripts)
+	if err != nil {
+		return fmt.Errorf("skew: %w", err)
+	}
+	env := base.environment()
+	oldVersion, newVersion := latestVersion(oldScripts, env), latestVersion(newScripts, env)
+
+	dir := test.Dir
+	if dir == "" {
+		if dir, err = os.MkdirTemp("", "sqliteinit-skew-"); err != nil {
+			return fmt.Errorf("skew: %w", err)
+		}
+		defer os.RemoveAll(dir)
+	}
+	base.Path = filepath.Join(dir, "skew.db")
+	if err := base.Files().Delete(ctx, base.Path); err != nil {
+		return fmt.Errorf("skew: %w", err)
+	}
+
+	release := func(migrations fs.FS, pin int) Config {
+		cfg := base
+		cfg.Migrations = migrations
+		cfg.RequiredSchemaVersion = pin
+		return cfg
+	}
+	open := func(cfg Config) error {
+		db, err := Open(ctx, cfg)
+		if err != nil {
+			return err
+		}
+		return db.Close()
+	}
+
+	if err := Create(ctx, release(test.Old, oldVersion)); err != nil {
+		return fmt.Errorf("skew: old release creates database: %w", err)
+	}
+	if err := open(release(test.New, newVersion)); err != nil {
+		return fmt.Errorf("skew: new release upgrades database: %w", err)
+	}
+	if oldVersion != 0 && oldVersion != newVersion {
+		if err := open(release(test.Old, oldVersion)); err == nil {
+			return fmt.Errorf("skew: pinned old release opened upgraded database: want %v", ErrSchemaVersionMismatch)
+		} else if !errors.Is(err, ErrSchemaVersionMismatch) {
+			return fmt.Errorf("skew: pinned old release opens upgraded database: %w", err)
+		}
+	}
+	status, err := Status(ctx, release(test.Old, 0))
+	if err == nil && len(status.Pending) != 0 {
+		err = fmt.Errorf("would apply %v", status.Pending)
+	}
+	if err == nil {
+		err = open(release(test.Old, 0))
+	}
+	if err != nil {
+		return fmt.Errorf("skew: unpinned old release opens upgraded database: %w", err)
+	}
+	if err := rollBack(ctx, release(test.New, 0), oldVersion, added); err != nil {
+		return fmt.Errorf("skew: roll back new migrations: %w", err)
+	}
+	if err := open(release(test.Old, oldVersion)); err != nil {
+		return fmt.Errorf("skew: pinned old release opens rolled-back database: %w", err)
+	}
+	if err := open(release(test.New, newVersion)); err != nil {
+		return fmt.Errorf("skew: new release upgrades rolled-back database: %w", err)
+	}
+	return nil
+}
+
+// addedMigrations returns the scripts in newScripts that are not in
+// oldScripts, or an error if newScripts drops one of oldScripts.
+func addedMigrations(oldScripts, newScripts []migrationScript) ([]migrationScript, error) {
+	inNew := make(map[string]bool, len(newScripts))
+	for _, s := range newScripts {
+		inNew[s.Path] = true
+	}
+	inOld := make(map[string]bool, len(oldScripts))
+	for _, s := range oldScripts {
+		if !inNew[s.Path] {
+			return nil, fmt.Errorf("new release drops migration %s", s.Path)
+		}
+		inOld[s.Path] = true
+	}
+	var added []migrationScript
+	for _, s := range newScripts {
+		if !inOld[s.Path] {
+			added = append(added, s)
+		}
+	}
+	return added, nil
+}
+
+// latestVersion returns the schema version a database has after scripts
+// are applied in env: the ID of the last one that applies, or 0.
+func latestVersion(scripts []migrationScript, env string) int {
+	version := 0
+	for _, s := range scripts {
+		if s.appliesTo(env) {
+			version = s.ID
+		}
+	}
+	return version
+}
+
+// rollBack reverts the applied migrations among added with their down
+// scripts, removes them from schema_migrations and resets the schema
+// version to version, all in one transaction.
+func rollBack(ctx context.Context, cfg Config, version int, added []migrationScript) error {
+	var applied []migrationScript
+	for _, s := range added {
+		if s.appliesTo(cfg.environment()) {
+			applied = append(applied, s)
+		}
+	}
+	plan, err := buildRollbackPlan(cfg.Migrations, version, applied)
+	if err != nil {
+		return err
+	}
+	for _, step := range plan.Steps {
+		if step.DownPath == "" {
+			return fmt.Errorf("%s: no down script", step.Path)
+		}
+	}
+
+	cfg.SkipMigrations = true
+	db, err := Open(ctx, cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	ctx = withInternal(ctx)
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	ts := cfg.Now().Unix()
+	for _, step := range plan.Steps {
+		if _, err := tx.ExecContext(ctx, step.SQL); err != nil {
+			return fmt.Errorf("%s: %w", step.DownPath, err)
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE path = ?`, step.Path); err != nil {
+			return fmt.Errorf("%s: %w", step.Path, err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE config SET value = ?, updated_at = ? WHERE key = 'schema.version'`, strconv.Itoa(version), ts); err != nil {
+		return fmt.Errorf("reset schema.version: %w", err)
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,105 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+)
+
+// SnapshotOptions controls ExportSnapshot.
+type SnapshotOptions struct {
+	// StripInternal drops the package's schema_migrations, config,
+	// table_access, flags and seed_runs tables from the snapshot.
+	StripInternal bool
+
+	// Driver used to open the snapshot when stripping. Default: DefaultDriver.
+	Driver Driver
+
+	// PathPolicy checks destPath. Default: DefaultPathPolicy.
+	PathPolicy PathPolicy
+}
+
+// ExportSnapshot writes a compacted copy of db to destPath and marks it
+// read-only (mode 0444), for handing to analytics and BI tools without
+// risking writes to the operational file. destPath is checked against
+// opts.PathPolicy and must not already exist. The snapshot is built in a
+// temporary file beside destPath and renamed into place, so destPath never
+// holds a partial snapshot.
+func ExportSnapshot(ctx context.Context, db *sql.DB, destPath string, opts SnapshotOptions) error {
+	if err := validatePersistentPath(destPath, orDefaultPolicy(opts.PathPolicy)); err != nil {
+		return err
+	}
+	if fileExists(destPath) {
+		return fmt.Errorf("%s: file %w", destPath, ErrAlreadyExists)
+	}
+	if err := checkDiskSpace(destPath, databaseSize(ctx, db)); err != nil {
+		return err
+	}
+
+	// Build the snapshot beside destPath and rename it into place, so a
+	// failure never leaves a partial snapshot at destPath. VACUUM INTO
+	// refuses to overwrite, so clear out a failed attempt first.
+	tmp := destPath + ".tmp"
+	if err := os.Remove(tmp); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("snapshot: %w", err)
+	}
+	defer os.Remove(tmp)
+
+	if _, err := db.ExecContext(ctx, `VACUUM INTO ?`, tmp); err != nil {
+		return fmt.Errorf("vacuum into %s: %w", destPath, err)
+	}
+
+	if opts.StripInternal {
+		if err := stripInternalTables(ctx, opts.Driver, tmp); err != nil {
+			return fmt.Errorf("strip internal tables: %w", err)
+		}
+	}
+
+	if err := os.Chmod(tmp, 0o444); err != nil {
+		return fmt.Errorf("mark read-only: %w", err)
+	}
+	if err := os.Rename(tmp, destPath); err != nil {
+		return fmt.Errorf("snapshot: %w", err)
+	}
+	return nil
+}
+
+// stripInternalTables drops the package-owned tables from the database at
+// path and compacts it.
+func stripInternalTables(ctx context.Context, drv Driver, path string) error {
+	if drv == nil {
+		drv = DefaultDriver
+	}
+	dsn, _ := drv.BuildDSN(path, nil)
+	db, err := sql.Open(drv.Name(), dsn)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	for _, name := range internalTables {
+		if _, err := db.ExecContext(ctx, `DROP TABLE IF EXISTS `+name); err != nil {
+			return err
+		}
+	}
+	if _, err := db.ExecContext(ctx, `VACUUM`); err != nil {
+		return err
+	}
+	return db.Close()
+}
+
+// databaseSize returns the size in bytes of the main database of db, or 0
+// if it can't be determined.
+func databaseSize(ctx context.Context, db *sql.DB) int64 {
+	var pageSize, pageCount int64
+	if err := db.QueryRowContext(ctx, `PRAGMA page_size`).Scan(&pageSize); err != nil {
+		return 0
+	}
+	if err := db.QueryRowContext(ctx, `PRAGMA page_count`).Scan(&pageCount); err != nil {
+		return 0
+	}
+	return pageSize * pageCount
+}
@@ -4,8 +4,12 @@ package sqliteinit
 
 import (
 	"context"
+	"crypto/rand"
 	"database/sql"
 	"embed"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"io/fs"
 	"log/slog"
@@ -13,6 +17,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
@@ -23,42 +28,329 @@ var schemaFS embed.FS
 type Config struct {
 	// Path to database file. Use ":memory:" for in-memory databases.
 	// Persistent paths must be absolute and have a .db extension.
+	// Path may also be a SQLite "file:" URI with query parameters
+	// (e.g. "file:/data/app.db?mode=rwc"); the package's pragmas are
+	// appended to the URI's parameters and the file name is validated
+	// like a plain path. A "libsql://" (or http, https, ws, wss) URL opens
+	// a remote libSQL database such as Turso; see AuthToken.
 	Path string
 
+	// AuthToken is the auth token for a remote libSQL Path. It is added to
+	// the connection URL and never logged.
+	AuthToken string
+
 	// Migrations is an embedded filesystem containing application migration
 	// scripts. Optional - if nil, only infrastructure tables are created.
 	// Scripts must be named YYYYMMDDHHMMSS_comment.sql.
 	Migrations fs.FS
 
+	// Seeds holds fixtures, .sql scripts and .csv or .jsonl table data,
+	// applied with Seed after migrations on every Open, for test and demo
+	// environments that need known data without adding it to the
+	// migration history. Each file is applied once, so existing data is
+	// left alone. Skipped with SkipMigrations. Default: nil (no seeds).
+	Seeds fs.FS
+
 	// Logger for operational logging. Uses slog.Default() if nil.
 	Logger *slog.Logger
 
+	// OnEvent, if set, is called for lifecycle events: Open finishing, the
+	// package's tables being created, each migration starting and
+	// finishing, each applied migration's checksum being verified, and the
+	// handle being closed. See Event for the payloads. It is called
+	// synchronously, so it should return quickly, and from the background
+	// goroutine for deferred migrations, so it must be safe for concurrent
+	// use.
+	OnEvent func(Event)
+
 	// ProductionEnvVar is the environment variable checked to determine
 	// production mode. If the variable equals "production" (case-insensitive),
 	// in-memory databases are rejected unless AllowMemoryInProduction is true.
 	// Default: "ENV".
 	ProductionEnvVar string
 
+	// IsProduction reports whether the process is running in production.
+	// If set, it replaces the ProductionEnvVar check for the in-memory
+	// guard, for platforms that signal the environment some other way.
+	// Default: nil (use ProductionEnvVar).
+	IsProduction func() bool
+
+	// Environment names the current deployment environment (e.g. "dev",
+	// "test", "production"). Migrations with a "-- sqliteinit:env" header
+	// are applied only when their list includes this value. If empty, the
+	// value of ProductionEnvVar is used.
+	Environment string
+
 	// AllowMemoryInProduction permits :memory: databases when the production
 	// environment variable is set. Default: false.
 	AllowMemoryInProduction bool
 
+	// IsolatedMemory gives each Open of ":memory:" a database of its own,
+	// under a random name (file:sqliteinit-<random>?mode=memory&cache=shared),
+	// instead of the one shared by every ":memory:" handle in the process,
+	// so parallel tests don't see each other's tables. OpenOrCreate and
+	// OpenInMemoryCopy honor it too; it is ignored for other paths.
+	// Default: false.
+	IsolatedMemory bool
+
+	// Now returns the current time for the timestamps the package writes:
+	// applied_at and the other columns of schema_migrations, the config
+	// rows it sets, and seed_runs. Set it to a fixed clock in tests to
+	// assert exact values. Durations are still measured with the real
+	// clock. Default: time.Now.
+	Now func() time.Time
+
+	// Deterministic makes initialization reproducible, so that creating a
+	// database twice from the same migrations, seeds and configuration
+	// gives byte-identical files, as content-addressed build caches need.
+	// Timestamps come from a fixed clock (the Unix epoch, unless Now is
+	// set), durations and applied_by are recorded as 0 and "" (or the
+	// AppVersion), MigrationBudget is ignored so every migration is applied
+	// before Open returns, and IsolatedMemory names databases by a counter
+	// rather than at random. Default: false.
+	Deterministic bool
+
 	// SkipMigrations disables automatic migration on Open.
 	// By default, migrations run automatically.
 	SkipMigrations bool
 
+	// ReadConns is the size of the read pool opened by OpenDB for
+	// persistent databases. Default: 4.
+	ReadConns int
+
+	// PathPolicy decides which paths may hold a persistent database.
+	// Default: nil (DefaultPathPolicy: absolute path with .db extension).
+	PathPolicy PathPolicy
+
+	// AllowedExtensions lists the file extensions accepted for a
+	// persistent path, e.g. []string{".sqlite3"}. Ignored if PathPolicy is
+	// set. Default: nil (DefaultPathPolicy, which allows ".db").
+	AllowedExtensions []string
+
+	// ForbidSymlinks rejects a persistent Path that is, or runs through, a
+	// symbolic link. Default: false (links are followed).
+	ForbidSymlinks bool
+
+	// ResolveSymlinks resolves every symbolic link in a persistent Path
+	// when opening and uses the real path from then on, so the DSN, the
+	// read pool and the WAL sidecars all refer to where the bytes live.
+	// Mutually exclusive with ForbidSymlinks. Default: false.
+	ResolveSymlinks bool
+
+	// IdleClose, if positive, makes a DB returned by OpenDB checkpoint and
+	// close the database file after this long without use, and reopen it
+	// on the next ReadTx or WriteTx. This releases file handles for desktop
+	// apps and sync-friendly folders. Persistent databases only.
+	// Default: 0 (never close).
+	IdleClose time.Duration
+
+	// Gate, if set, quiesces application writes around operations that
+	// need a stable file: DB.WriteTx enters it, and Restore and Rekey
+	// pause it while they replace or rewrite the database. Share it with
+	// MaintenanceConfig.Gate to cover Quiesce tasks too. Default: nil.
+	Gate *Gate
+
+	// Driver selects the SQLite driver at runtime. Default: DefaultDriver
+	// (Modernc, or Mattn when built with -tags mattn).
+	Driver Driver
+
+	// BusyTimeout is how long a connection waits on a locked database
+	// before failing with SQLITE_BUSY (the busy_timeout pragma). Open also
+	// retries, with backoff, initialization and migration steps that still
+	// fail with SQLITE_BUSY. Default: 5s.
+	BusyTimeout time.Duration
+
+	// CacheSizeKB sets the page cache size per connection in KiB (the
+	// cache_size pragma). Default: 0 (SQLite's default, about 2 MiB).
+	CacheSizeKB int
+
+	// MmapSize sets the maximum number of bytes of the database file that
+	// are memory-mapped (the mmap_size pragma). Persistent databases only.
+	// Default: 0 (no memory mapping).
+	MmapSize int64
+
+	// WALAutocheckpoint is the WAL size, in pages, at which a commit
+	// triggers an automatic checkpoint (the wal_autocheckpoint pragma). A
+	// negative value disables automatic checkpoints. Persistent databases
+	// only. Default: 0 (SQLite's default of 1000 pages).
+	WALAutocheckpoint int
+
+	// JournalSizeLimit caps, in bytes, the size the WAL is truncated back
+	// to after a checkpoint (the journal_size_limit pragma), so a burst of
+	// writes doesn't leave a multi-gigabyte WAL behind. -1 means no limit.
+	// Persistent databases only. Default: 0 (SQLite's default, no limit).
+	JournalSizeLimit int64
+
+	// FileMode, if non-zero, sets the permission bits of the database file
+	// and its -wal, -shm and -journal sidecars, regardless of the process
+	// umask. Create creates the file with this mode and Open re-applies it
+	// to the file and any existing sidecars. Persistent databases only.
+	// Default: 0, which makes Create use 0600, and SQLite gives the
+	// sidecars the same; Open then leaves permissions alone.
+	FileMode os.FileMode
+
+	// DirMode, if non-zero, makes Create create the database's missing
+	// parent directories with these permission bits, regardless of the
+	// process umask. Persistent databases only. Default: 0 (the directory
+	// must exist).
+	DirMode os.FileMode
+
+	// EncryptionKey, if set, unlocks (or, for a new file, encrypts) the
+	// database. It is issued on every connection before any other
+	// statement. The driver must implement KeyedDriver (e.g. SQLCipher);
+	// other drivers fail to open rather than silently writing plaintext.
+	EncryptionKey string
+
+	// Extensions lists SQLite extension libraries (e.g. mod_spatialite)
+	// loaded on every connection before migrations run. The driver's
+	// connections must support loading extensions, as mattn/go-sqlite3's
+	// do; other drivers (including modernc) fail to open. The entry point
+	// is derived from the file name, as SQLite does. Default: nil.
+	Extensions []string
+
+	// Functions are Go SQL functions registered on every connection before
+	// migrations run, e.g. a regexp implementation for the REGEXP
+	// operator. modernc registers them for the whole process; mattn
+	// registers them per connection. Default: nil.
+	Functions []Func
+
+	// Attach lists secondary databases attached to every connection
+	// before migrations run, so migrations and queries can refer to their
+	// tables by alias (e.g. "analytics.events"). Local databases only.
+	// Default: nil.
+	Attach []AttachSpec
+
+	// ExtraPragmas are appended to the built-in pragmas for the database
+	// mode. A pragma that overrides a built-in one is rejected.
+	ExtraPragmas []Pragma
+
 	// MigrationTimeout bounds migration execution time. Default: 90s.
 	MigrationTimeout time.Duration
 
+	// LogStatements executes migrations one statement at a time, logging
+	// each at debug level with its index, line and SQL (truncated to 200
+	// bytes). Failing statements are located either way; see
+	// MigrationError.
+	LogStatements bool
+
+	// VerifyChecksums makes Open fail with ErrChecksumMismatch if the script
+	// of an applied migration changed since it was applied. Otherwise the
+	// change is only logged as a warning. Migrations applied before
+	// checksums were recorded aren't checked.
+	VerifyChecksums bool
+
+	// SlowMigrationThreshold is how long a migration may take before a
+	// warning with its ID and elapsed time is logged. Every migration's
+	// duration is recorded in schema_migrations either way. A negative
+	// value disables the warning. Default: 5s.
+	SlowMigrationThreshold time.Duration
+
+	// RollbackPlanDir, if set, is an existing directory where Open writes
+	// a rollback plan (see PlanRollback) before applying pending
+	// migrations. Down scripts are read from files named like the
+	// migration with a ".down.sql" suffix. Default: "" (no plan).
+	RollbackPlanDir string
+
+	// RollbackSnapshots, if set, receives a snapshot of the database
+	// before pending migrations are applied; the rollback plan refers to
+	// it. Default: nil (no snapshot).
+	RollbackSnapshots *SnapshotCatalog
+
+	// MigrationBudget, if positive, bounds how long Open spends applying
+	// migrations. Once it is spent, if every remaining pending migration
+	// is marked "-- sqliteinit:deferrable", Open returns the handle and
+	// applies them in the background; use Deferred to follow progress.
+	// Ignored when RequiredSchemaVersion is set. Default: 0 (no budget).
+	MigrationBudget time.Duration
+
+	// QueryTimeout, if positive, bounds every statement run on the returned
+	// handle. A statement that exceeds it is interrupted and fails with an
+	// error wrapping ErrQueryTimeout. Migrations are exempt; they are bounded
+	// by MigrationTimeout. Default: 0 (no limit).
+	QueryTimeout time.Duration
+
+	// StrictTables makes a migration fail if it creates a table that is not
+	// a STRICT table, enforcing typed columns. SQLite has no pragma that
+	// makes STRICT the default, so this is checked after each migration.
+	// With DetectDrift set, Open also reports existing tables that are not
+	// STRICT, such as ones created before the option was turned on or
+	// outside the migrations, as drift; NonStrictTables lists them.
+	// Default: false.
+	StrictTables bool
+
+	// Audit, if set, records which tables the application's statements
+	// read and write, sampled, so unused tables can be found. See
+	// AccessAudit. Default: nil (no auditing).
+	Audit *AccessAudit
+
+	// DevStrict turns silent foot-guns into immediate errors wrapping
+	// ErrMisuse, for use in development and tests: writes on read-only
+	// handles, statements without a cancellable context, transactions used
+	// from another goroutine, and schema changes outside migrations.
+	// It adds overhead to every statement. Default: false.
+	DevStrict bool
+
 	// AppVersion is written to the config table after initialization.
 	// Leave empty to skip writing app metadata.
 	AppVersion string
 
+	// MaxDatabaseSize, if positive, caps the database size in bytes by
+	// setting the max_page_count pragma on every connection. Writes past
+	// the cap fail with "database or disk is full". Open logs a warning and
+	// sends a QuotaEvent when the database is within 10% of the cap; run a
+	// QuotaTask to monitor it while the database is open. Default: 0
+	// (unlimited).
+	MaxDatabaseSize int64
+
+	// AdoptForeign makes Open leave a foreign database alone: one that has
+	// tables but not the package's config and schema_migrations tables.
+	// Instead of initializing it and running migrations, Open returns a
+	// read-only handle for inspection. Call Adopt to install the
+	// infrastructure tables and take ownership. Default: false (a foreign
+	// database is initialized like an empty one).
+	AdoptForeign bool
+
+	// DetectDrift makes Open check whether the schema was changed outside
+	// the migrations since the last Open at the same schema version, by
+	// comparing a fingerprint of the schema stored in the config table,
+	// and with StrictTables set, whether any table is not STRICT.
+	// DriftWarn logs drift and accepts the schema; DriftFail fails Open
+	// with ErrSchemaDrift. Default: DriftIgnore.
+	DetectDrift DriftPolicy
+
+	// AutoVacuum sets the auto_vacuum mode of a database made by Create.
+	// It can only be set cheaply before the first table is created; use
+	// EnableIncrementalVacuum to switch an existing database. Persistent
+	// databases only. Default: AutoVacuumNone (the file never shrinks
+	// unless vacuumed).
+	AutoVacuum AutoVacuum
+
+	// IntegrityCheckOnOpen makes Open check the database with
+	// PRAGMA quick_check (IntegrityCheckQuick) or integrity_check
+	// (IntegrityCheckFull) before migrating, failing with a
+	// *CorruptionError if problems are found, so a corrupt file isn't
+	// migrated further. Default: IntegrityCheckOff.
+	IntegrityCheckOnOpen IntegrityCheck
+
 	// RequiredSchemaVersion, if non-zero, causes Open to verify that the
 	// database schema version exactly matches this value after any migrations
 	// are applied. Returns an error if the versions don't match.
 	// Useful for catching schema/code mismatches at startup.
 	RequiredSchemaVersion int
+
+	// RequiredSchemaFingerprint, if set, causes Open to verify that the
+	// schema's fingerprint (see SchemaFingerprint) matches this value after
+	// any migrations are applied. It catches binaries and databases whose
+	// schemas diverged even though the numeric versions match.
+	RequiredSchemaFingerprint string
+
+	// closeNotifier, set by openAndMigrate when OnEvent is set, sends a
+	// CloseEvent when the opened handle is closed.
+	closeNotifier *closeNotifier
+
+	// seed, set by Create and OpenInMemoryCopy, prepares a newly opened
+	// database before it is initialized and migrated.
+	seed func(ctx context.Context, db *sql.DB) error
 }
 
 // defaults returns a copy of cfg with default values applied.
@@ -72,33 +364,118 @@ func (cfg Config) defaults() Config {
 	if cfg.MigrationTimeout == 0 {
 		cfg.MigrationTimeout = 90 * time.Second
 	}
+	if cfg.SlowMigrationThreshold == 0 {
+		cfg.SlowMigrationThreshold = 5 * time.Second
+	}
+	if cfg.ReadConns == 0 {
+		cfg.ReadConns = 4
+	}
+	if cfg.BusyTimeout == 0 {
+		cfg.BusyTimeout = 5 * time.Second
+	}
+	if cfg.Deterministic {
+		if cfg.Now == nil {
+			cfg.Now = func() time.Time { return time.Unix(0, 0) }
+		}
+		cfg.MigrationBudget = 0
+	}
+	if cfg.Now == nil {
+		cfg.Now = time.Now
+	}
 	return cfg
 }
 
-// isProduction returns true if the production environment variable is set.
+// elapsedMS returns the milliseconds since start for the duration_ms
+// columns, or 0 in deterministic mode.
+func (cfg Config) elapsedMS(start time.Time) int64 {
+	if cfg.Deterministic {
+		return 0
+	}
+	return time.Since(start).Milliseconds()
+}
+
+// isProduction returns true if the process is running in production, as
+// reported by IsProduction or, if that is nil, the production environment
+// variable.
 func (cfg Config) isProduction() bool {
+	if cfg.IsProduction != nil {
+		return cfg.IsProduction()
+	}
 	return strings.EqualFold(os.Getenv(cfg.ProductionEnvVar), "production")
 }
 
+// productionSource describes how production mode was detected, for error
+// messages.
+func (cfg Config) productionSource() string {
+	if cfg.IsProduction != nil {
+		return "Config.IsProduction"
+	}
+	return cfg.ProductionEnvVar + "=production"
+}
+
+// environment returns the effective environment name used to select
+// environment-scoped migrations.
+func (cfg Config) environment() string {
+	if cfg.Environment != "" {
+		return cfg.Environment
+	}
+	return os.Getenv(cfg.ProductionEnvVar)
+}
+
 // isMemory returns true if Path indicates an in-memory database.
 func (cfg Config) isMemory() bool {
-	return cfg.Path == ":memory:" || strings.HasPrefix(cfg.Path, "file::memory:")
+	return isMemoryPath(cfg.Path)
+}
+
+// isolatedMemorySeq numbers the in-memory databases isolateMemory names
+// in deterministic mode.
+var isolatedMemorySeq atomic.Uint64
+
+// isolateMemory returns cfg with a ":memory:" Path replaced by a uniquely
+// named in-memory database if IsolatedMemory is set.
+func (cfg Config) isolateMemory() Config {
+	if cfg.IsolatedMemory && cfg.Path == ":memory:" {
+		var b [8]byte
+		if cfg.Deterministic {
+			binary.BigEndian.PutUint64(b[:], isolatedMemorySeq.Add(1))
+		} else {
+			rand.Read(b[:])
+		}
+		cfg.Path = "file:sqliteinit-" + hex.EncodeToString(b[:]) + "?mode=memory&cache=shared"
+	}
+	return cfg
+}
+
+// isRemote returns true if Path is a remote libSQL URL.
+func (cfg Config) isRemote() bool {
+	return isRemotePath(cfg.Path)
+}
+
+// filePath returns the file system path of the database, resolving
+// "file:" URIs.
+func (cfg Config) filePath() string {
+	return filePathOf(cfg.Path)
 }
 
-// MigrationStatus describes the current schema state.
+// MigrationStatus describes the current schema state. Its JSON encoding
+// is stable; see MarshalJSON.
 type MigrationStatus struct {
-	SchemaVersion int
-	Applied       []AppliedMigration
-	Pending       []string
-	IsInitialized bool
+	SchemaVersion int                `json:"schema_version"`
+	InfraVersion  int                `json:"infra_version"` // revision of the package's own tables
+	Applied       []AppliedMigration `json:"applied"`
+	Pending       []string           `json:"pending"`
+	IsInitialized bool               `json:"is_initialized"`
 }
 
 // AppliedMigration describes a migration that has been applied.
 type AppliedMigration struct {
-	ID        int
-	Comment   string
-	Path      string
-	AppliedAt time.Time
+	ID        int           `json:"id"`
+	Comment   string        `json:"comment"`
+	Path      string        `json:"path"`
+	AppliedAt time.Time     `json:"applied_at"`
+	Duration  time.Duration `json:"-"`          // encoded as duration_ms
+	Checksum  string        `json:"checksum"`   // SHA-256 of the script, hex
+	AppliedBy string        `json:"applied_by"` // "user@host (app version)"
 }
 
 // Open opens a database and optionally applies migrations.
@@ -106,47 +483,163 @@ type AppliedMigration struct {
 // For persistent databases, it opens an existing file (use Create for new files).
 func Open(ctx context.Context, cfg Config) (*sql.DB, error) {
 	cfg = cfg.defaults()
+	cfg, err := applySymlinkPolicy(cfg)
+	if err != nil {
+		return nil, err
+	}
 
 	if cfg.isMemory() {
-		return openMemory(ctx, cfg)
+		return openMemory(ctx, cfg.isolateMemory())
+	}
+	if cfg.isRemote() {
+		return openRemote(ctx, cfg)
 	}
 	return openPersistent(ctx, cfg)
 }
 
 // Create creates a new persistent database file and applies migrations.
-// Returns an error if the file already exists.
-func Create(ctx context.Context, cfg Config) error {
+// Returns an error if the file already exists. If creation fails or ctx is
+// cancelled, the partial file and its sidecars are removed, as are any
+// directories made for it with DirMode.
+func Create(ctx context.Context, cfg Config) (err error) {
 	cfg = cfg.defaults()
 
 	if cfg.isMemory() {
 		return fmt.Errorf("Create requires a persistent path, not :memory:")
 	}
+	if cfg.isRemote() {
+		return fmt.Errorf("Create does not apply to remote databases; create it on the server and use Open")
+	}
 
-	if err := validatePersistentPath(cfg.Path); err != nil {
+	if cfg.DirMode != 0 {
+		// Check the name before creating directories for it.
+		if err := cfg.pathPolicy().CheckPath(hostPathStyle.normalize(cfg.filePath())); err != nil {
+			return err
+		}
+		var created []string
+		created, err = createDirs(filepath.Dir(cfg.filePath()), cfg.DirMode)
+		defer func() {
+			if err != nil {
+				removeDirs(created)
+			}
+		}()
+		if err != nil {
+			return fmt.Errorf("create %s: %w", cfg.filePath(), err)
+		}
+	}
+
+	if cfg, err = applySymlinkPolicy(cfg); err != nil {
+		return err
+	}
+
+	if err := validatePersistentPath(cfg.filePath(), cfg.pathPolicy()); err != nil {
 		return err
 	}
 
-	if fileExists(cfg.Path) {
-		return fmt.Errorf("%s: file already exists", cfg.Path)
+	if fileExists(cfg.filePath()) {
+		return fmt.Errorf("%s: file %w", cfg.filePath(), ErrAlreadyExists)
+	}
+
+	if err := checkDiskSpace(cfg.filePath(), 0); err != nil {
+		return err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
 	}
 
 	cfg.Logger.Info("creating database", "path", cfg.Path)
 
-	db, err := openAndMigrate(ctx, cfg, persistentPragmas)
+	mode := cfg.FileMode
+	if mode == 0 {
+		mode = defaultFileMode
+	}
+	if err := createWithMode(cfg.filePath(), mode); err != nil {
+		return fmt.Errorf("create %s: %w", cfg.filePath(), err)
+	}
+
+	if cfg.AutoVacuum != AutoVacuumNone {
+		// Switching to WAL already wrote the file header, so the setting
+		// takes a VACUUM, which is instant while the file has no tables.
+		pragma := `PRAGMA auto_vacuum = ` + cfg.AutoVacuum.pragmaValue()
+		cfg.seed = func(ctx context.Context, db *sql.DB) error {
+			for _, stmt := range []string{pragma, `VACUUM`} {
+				if _, err := db.ExecContext(withInternal(ctx), stmt); err != nil {
+					return fmt.Errorf("auto_vacuum: %w", err)
+				}
+			}
+			return nil
+		}
+	}
+
+	db, err := openAndMigrate(ctx, cfg)
+	if err == nil {
+		err = db.Close()
+	}
 	if err != nil {
+		// The file didn't exist before; don't leave a partial one behind.
+		removeDatabaseFiles(cfg.filePath())
 		return err
 	}
-	return db.Close()
+	return nil
 }
 
-// Delete removes a database file and its WAL sidecar files.
-// Returns nil if the file does not exist.
+// OpenOrCreate opens a persistent database, creating it first if the file
+// does not exist, along with its directory if DirMode is set. It reports
+// whether the database was created.
+// In-memory databases are always new, so created is true for them.
+func OpenOrCreate(ctx context.Context, cfg Config) (db *sql.DB, created bool, err error) {
+	cfg = cfg.defaults()
+	if cfg, err = applySymlinkPolicy(cfg); err != nil {
+		return nil, false, err
+	}
+
+	if cfg.isMemory() {
+		db, err = openMemory(ctx, cfg.isolateMemory())
+		return db, err == nil, err
+	}
+	if cfg.isRemote() {
+		db, err = openRemote(ctx, cfg)
+		return db, false, err
+	}
+
+	// Create and openPersistent check the path; Create only after making
+	// a missing directory for it with DirMode.
+	if !fileExists(cfg.filePath()) {
+		if err := Create(ctx, cfg); err != nil {
+			return nil, false, err
+		}
+		created = true
+	}
+
+	db, err = openPersistent(ctx, cfg)
+	if err != nil {
+		return nil, false, err
+	}
+	return db, created, nil
+}
+
+// Delete removes a database file and its WAL sidecar files, checking path
+// against DefaultPathPolicy. It is Files{}.Delete; use Config.Files to
+// delete a file under another policy.
 func Delete(ctx context.Context, path string) error {
-	if path == ":memory:" || strings.HasPrefix(path, "file::memory:") {
+	return Files{}.Delete(ctx, path)
+}
+
+// Delete removes a database file and its WAL sidecar files.
+// Returns nil if the file does not exist. path is checked against
+// f.PathPolicy. ctx is checked before each file is removed; if it is
+// cancelled, Delete stops and returns its error.
+func (f Files) Delete(ctx context.Context, path string) error {
+	if isMemoryPath(path) {
 		return fmt.Errorf("cannot delete in-memory database")
 	}
+	if isRemotePath(path) {
+		return fmt.Errorf("cannot delete remote database")
+	}
+	path = filePathOf(path)
 
-	if err := validatePersistentPath(path); err != nil {
+	if err := validatePersistentPath(path, orDefaultPolicy(f.PathPolicy)); err != nil {
 		return err
 	}
 
@@ -154,9 +647,28 @@ func Delete(ctx context.Context, path string) error {
 		return nil
 	}
 
+	// SQLite follows a symlinked database path and creates the sidecars
+	// next to the real file, so delete the real files, then the link.
+	if isSymlink(path) {
+		real, err := realPath(path)
+		if err != nil {
+			return err
+		}
+		if err := f.Delete(ctx, real); err != nil {
+			return err
+		}
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("delete %s: %w", path, err)
+		}
+		return nil
+	}
+
 	// WAL mode creates sidecar files
 	var firstErr error
 	for _, suffix := range []string{"", "-shm", "-wal"} {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("delete %s: %w", path, err)
+		}
 		name := path + suffix
 		if !fileExists(name) {
 			continue
@@ -186,7 +698,76 @@ func Delete(ctx context.Context, path string) error {
 	return nil
 }
 
+// Move renames a database file and its WAL sidecar files, checking both
+// paths against DefaultPathPolicy. It is Files{}.Move; use Config.Files to
+// move a file under another policy.
+func Move(ctx context.Context, from, to string) error {
+	return Files{}.Move(ctx, from, to)
+}
+
+// Move renames a database file and its WAL sidecar files. Close every
+// handle to the database first. The destination must be a valid persistent
+// path that doesn't exist; a rename that only changes case is allowed on
+// case-insensitive file systems. Both paths are checked against
+// f.PathPolicy. ctx is checked before each file is moved. If a file can't
+// be moved, the files already moved are moved back, so the database is
+// never left split between the two paths.
+func (f Files) Move(ctx context.Context, from, to string) error {
+	if isMemoryPath(from) || isMemoryPath(to) || isRemotePath(from) || isRemotePath(to) {
+		return fmt.Errorf("Move requires local persistent paths")
+	}
+	from, to = filePathOf(from), filePathOf(to)
+	policy := orDefaultPolicy(f.PathPolicy)
+	if err := validatePersistentPath(from, policy); err != nil {
+		return err
+	}
+	if err := validatePersistentPath(to, policy); err != nil {
+		return err
+	}
+	if !fileExists(from) {
+		return fmt.Errorf("%s: database file %w", from, ErrNotFound)
+	}
+	if from == to {
+		return nil
+	}
+	if fileExists(to) && !hostPathStyle.same(from, to) {
+		return fmt.Errorf("%s: file %w", to, ErrAlreadyExists)
+	}
+
+	// Move the sidecars first so that a WAL holding committed data is never
+	// separated from a database that has already moved.
+	var moved []string
+	undo := func(err error) error {
+		for i := len(moved) - 1; i >= 0; i-- {
+			if rerr := rename(to+moved[i], from+moved[i]); rerr != nil {
+				return fmt.Errorf("%w; moving %s back: %w", err, to+moved[i], rerr)
+			}
+		}
+		return err
+	}
+	for _, suffix := range []string{"-wal", "-shm", ""} {
+		if err := ctx.Err(); err != nil {
+			return undo(fmt.Errorf("move %s: %w", from, err))
+		}
+		if suffix != "" && !fileExists(from+suffix) {
+			continue
+		}
+		if err := rename(from+suffix, to+suffix); err != nil {
+			return undo(fmt.Errorf("move %s: %w", from+suffix, err))
+		}
+		moved = append(moved, suffix)
+	}
+	return nil
+}
+
+// rename is os.Rename. Tests replace it to make a rename fail.
+var rename = os.Rename
+
 // Status returns the current migration status without modifying the database.
+// For an in-memory database, Path must name a shared one (":memory:", or a
+// "file:" URI with mode=memory&cache=shared) that is still open elsewhere in
+// the process; Status reports an uninitialized database otherwise. Use
+// StatusFromDB for a private in-memory database.
 func Status(ctx context.Context, cfg Config) (*MigrationStatus, error) {
 	cfg = cfg.defaults()
 	cfg.SkipMigrations = true // don't migrate when checking status
@@ -195,11 +776,33 @@ func Status(ctx context.Context, cfg Config) (*MigrationStatus, error) {
 	var err error
 
 	if cfg.isMemory() {
-		// For memory DBs, we can't check status of a non-existent DB
-		return nil, fmt.Errorf("cannot check status of in-memory database")
+		// A private in-memory database can only be seen through the
+		// handle that created it; a shared one can be reopened.
+		if !isSharedMemoryPath(cfg.Path) {
+			return nil, fmt.Errorf("cannot check status of private in-memory database (use StatusFromDB or a cache=shared URI)")
+		}
+		db, err = openMemory(ctx, cfg)
+		if err != nil {
+			return nil, err
+		}
+		defer db.Close()
+		return getStatus(ctx, db, cfg)
 	}
 
-	if !fileExists(cfg.Path) {
+	if cfg.isRemote() {
+		db, err = openRemote(ctx, cfg)
+		if err != nil {
+			return nil, err
+		}
+		defer db.Close()
+		return getStatus(ctx, db, cfg)
+	}
+
+	if cfg, err = applySymlinkPolicy(cfg); err != nil {
+		return nil, err
+	}
+
+	if !fileExists(cfg.filePath()) {
 		return &MigrationStatus{IsInitialized: false}, nil
 	}
 
@@ -212,38 +815,81 @@ func Status(ctx context.Context, cfg Config) (*MigrationStatus, error) {
 	return getStatus(ctx, db, cfg)
 }
 
+// StatusFromDB returns the migration status of an open handle, such as one
+// returned by Open, without modifying the database. It works for in-memory
+// databases, which Status can't reopen. migrations may be nil, in which case
+// Pending is empty. Environment-scoped migrations are selected using the
+// ENV environment variable, as with a default Config.
+func StatusFromDB(ctx context.Context, db *sql.DB, migrations fs.FS) (*MigrationStatus, error) {
+	cfg := Config{Migrations: migrations}.defaults()
+	return getStatus(withInternal(ctx), db, cfg)
+}
+
 // openMemory opens an in-memory database.
 func openMemory(ctx context.Context, cfg Config) (*sql.DB, error) {
 	if cfg.isProduction() && !cfg.AllowMemoryInProduction {
-		return nil, fmt.Errorf("in-memory database not allowed in production (%s=production)", cfg.ProductionEnvVar)
+		return nil, fmt.Errorf("%w (%s)", ErrMemoryInProduction, cfg.productionSource())
 	}
 
 	cfg.Logger.Info("DB mode: in-memory")
-	return openAndMigrate(ctx, cfg, memoryPragmas)
+	return openAndMigrate(ctx, cfg)
 }
 
 // openPersistent opens an existing persistent database.
 func openPersistent(ctx context.Context, cfg Config) (*sql.DB, error) {
-	if err := validatePersistentPath(cfg.Path); err != nil {
+	if err := validatePersistentPath(cfg.filePath(), cfg.pathPolicy()); err != nil {
 		return nil, err
 	}
 
-	if !fileExists(cfg.Path) {
-		return nil, fmt.Errorf("%s: database file not found (use Create to make a new database)", cfg.Path)
+	if !fileExists(cfg.filePath()) {
+		return nil, fmt.Errorf("%s: database file %w (use Create to make a new database)", cfg.filePath(), ErrNotFound)
 	}
 
 	cfg.Logger.Info("DB mode: persistent", "path", cfg.Path)
-	return openAndMigrate(ctx, cfg, persistentPragmas)
+	return openAndMigrate(ctx, cfg)
+}
+
+// openRemote opens a remote libSQL database.
+func openRemote(ctx context.Context, cfg Config) (*sql.DB, error) {
+	cfg.Logger.Info("DB mode: remote", "url", redactDSN(cfg.Path))
+	return openAndMigrate(ctx, cfg)
+}
+
+// openAndMigrate opens a database with the driver's pragmas for the
+// database mode and runs migrations, sending an OpenEvent, and arranging
+// for a CloseEvent, if cfg.OnEvent is set. With a MigrationBudget the
+// handle also gets a closeNotifier, so that deferred migrations can be
+// forgotten when it is closed.
+func openAndMigrate(ctx context.Context, cfg Config) (*sql.DB, error) {
+	if cfg.OnEvent == nil && cfg.MigrationBudget <= 0 {
+		return openMigrated(ctx, cfg)
+	}
+	start := time.Now()
+	cfg.closeNotifier = &closeNotifier{}
+	db, err := openMigrated(ctx, cfg)
+	if cfg.OnEvent == nil {
+		return db, err
+	}
+	path := redactDSN(cfg.Path)
+	if err == nil {
+		cfg.closeNotifier.arm(cfg, path)
+	}
+	cfg.emit(OpenEvent{Path: path, Duration: time.Since(start), Err: err})
+	return db, err
 }
 
-// openAndMigrate opens a database with the given pragmas and runs migrations.
-func openAndMigrate(ctx context.Context, cfg Config, pragmas []pragma) (*sql.DB, error) {
-	dsn := buildDSN(cfg.Path, pragmas)
-	cfg.Logger.Debug("opening database", "dsn", dsn)
+// openMigrated does the work of openAndMigrate.
+func openMigrated(ctx context.Context, cfg Config) (*sql.DB, error) {
+	ctx = withInternal(ctx)
 
-	db, err := sql.Open("sqlite", dsn)
+	pragmas, err := withExtraPragmas(cfg.builtinPragmas(), cfg.ExtraPragmas)
 	if err != nil {
-		return nil, fmt.Errorf("sql.Open: %w", err)
+		return nil, err
+	}
+
+	db, err := openHandle(ctx, cfg, pragmas, false)
+	if err != nil {
+		return nil, err
 	}
 
 	// Ensure cleanup on error
@@ -254,23 +900,87 @@ func openAndMigrate(ctx context.Context, cfg Config, pragmas []pragma) (*sql.DB,
 		}
 	}()
 
-	// SQLite works best with limited connections
-	db.SetMaxOpenConns(1)
-	db.SetMaxIdleConns(1)
+	if cfg.seed != nil {
+		if err := cfg.seed(ctx, db); err != nil {
+			return nil, err
+		}
+	}
 
-	if err := db.PingContext(ctx); err != nil {
-		return nil, fmt.Errorf("ping: %w", err)
+	// Inspect a foreign database through a read-only handle instead of
+	// taking ownership of it.
+	foreign := false
+	if cfg.AdoptForeign {
+		if foreign, err = isForeign(ctx, db); err != nil {
+			return nil, err
+		}
+	}
+	if foreign {
+		cfg.Logger.Warn("foreign database opened read-only; use Adopt to initialize it", "path", redactDSN(cfg.Path))
+		ro, err := openHandle(ctx, cfg, append(pragmas, readOnlyPragma), true)
+		if err != nil {
+			return nil, err
+		}
+		db.Close()
+		db = ro
+	}
+
+	if err := checkIntegrity(ctx, db, cfg); err != nil {
+		return nil, err
+	}
+
+	if cfg.FileMode != 0 && !cfg.isMemory() && !cfg.isRemote() {
+		if err := applyFileMode(cfg.filePath(), cfg.FileMode); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := checkQuota(ctx, db, cfg); err != nil {
+		return nil, fmt.Errorf("quota: %w", err)
+	}
+
+	if cfg.DetectDrift != DriftIgnore && !foreign {
+		if err := checkDrift(ctx, db, cfg.StrictTables); err != nil {
+			if cfg.DetectDrift == DriftFail || !errors.Is(err, ErrSchemaDrift) {
+				return nil, err
+			}
+			cfg.Logger.Warn("schema drift detected; accepting current schema", "error", err)
+		}
 	}
 
-	if !cfg.SkipMigrations {
-		migCtx, cancel := context.WithTimeout(ctx, cfg.MigrationTimeout)
+	var deferred []migrationScript
+	if !cfg.SkipMigrations && !foreign {
+		migCtx, cancel := context.WithTimeout(withoutQueryTimeout(ctx), cfg.MigrationTimeout)
 		defer cancel()
 
-		if err := migrate(migCtx, db, cfg); err != nil {
+		// A required schema version can't be verified while migrations
+		// are still running, so apply everything up front.
+		migCfg := cfg
+		if cfg.RequiredSchemaVersion != 0 {
+			migCfg.MigrationBudget = 0
+		}
+		err := retryBusy(migCtx, cfg.Logger, "migrate", func() error {
+			var err error
+			deferred, err = migrate(migCtx, db, migCfg)
+			return err
+		})
+		if err != nil {
 			return nil, fmt.Errorf("migrate: %w", err)
 		}
 	}
 
+	if cfg.Seeds != nil && !cfg.SkipMigrations && !foreign {
+		report, err := seed(ctx, db, cfg.Seeds, cfg.Now)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range report.Applied {
+			cfg.Logger.Info("applied seed", "name", name)
+		}
+		for _, name := range report.Changed {
+			cfg.Logger.Warn("seed changed since it was applied; not applying it again", "name", name)
+		}
+	}
+
 	// Verify schema version if required
 	if cfg.RequiredSchemaVersion != 0 {
 		version, err := fetchSchemaVersion(ctx, db)
@@ -278,24 +988,88 @@ func openAndMigrate(ctx context.Context, cfg Config, pragmas []pragma) (*sql.DB,
 			return nil, fmt.Errorf("fetch schema version: %w", err)
 		}
 		if version == nil {
-			return nil, fmt.Errorf("schema version check failed: database not initialized")
+			return nil, fmt.Errorf("schema version check failed: %w", ErrNotInitialized)
 		}
 		if *version != cfg.RequiredSchemaVersion {
-			return nil, fmt.Errorf("schema version mismatch: required %d, found %d", cfg.RequiredSchemaVersion, *version)
+			return nil, fmt.Errorf("%w: required %d, found %d", ErrSchemaVersionMismatch, cfg.RequiredSchemaVersion, *version)
 		}
 	}
 
+	if cfg.RequiredSchemaFingerprint != "" {
+		fingerprint, err := schemaFingerprint(ctx, db)
+		if err != nil {
+			return nil, fmt.Errorf("schema fingerprint: %w", err)
+		}
+		if fingerprint != cfg.RequiredSchemaFingerprint {
+			return nil, fmt.Errorf("%w: schema fingerprint mismatch: required %s, found %s", ErrSchemaVersionMismatch, cfg.RequiredSchemaFingerprint, fingerprint)
+		}
+	}
+
+	if !cfg.SkipMigrations && !foreign {
+		if err := recordFingerprint(ctx, db, cfg.Now()); isReadOnly(err) {
+			cfg.Logger.Warn("database is read-only; schema fingerprint not recorded")
+		} else if err != nil {
+			return nil, fmt.Errorf("record schema fingerprint: %w", err)
+		}
+	}
+
+	if len(deferred) != 0 {
+		startDeferred(db, cfg, deferred)
+	}
+
 	success = true
 	return db, nil
 }
 
-// validatePersistentPath checks that a path is valid for a persistent database.
-func validatePersistentPath(path string) error {
-	if !filepath.IsAbs(path) {
-		return fmt.Errorf("%s: persistent database path must be absolute", path)
+// openHandle opens a single-connection handle with pragmas applied and
+// checks that the database can be reached. readOnly marks the handle for
+// DevStrict.
+func openHandle(ctx context.Context, cfg Config, pragmas []Pragma, readOnly bool) (*sql.DB, error) {
+	path := cfg.Path
+	if cfg.isRemote() {
+		path = withAuthToken(path, cfg.AuthToken)
 	}
-	if filepath.Ext(path) != ".db" {
-		return fmt.Errorf("%s: expected .db extension", path)
+	dsn, postPragmas := cfg.driver().BuildDSN(path, pragmas)
+	cfg.Logger.Debug("opening database", "dsn", redactDSN(dsn))
+
+	db, err := openDB(dsn, postPragmas, cfg, readOnly)
+	if err != nil {
+		return nil, fmt.Errorf("sql.Open: %w", err)
+	}
+
+	// SQLite works best with limited connections
+	db.SetMaxOpenConns(1)
+	db.SetMaxIdleConns(1)
+
+	// The ping opens the connection, which runs the post pragmas.
+	err = retryBusy(ctx, cfg.Logger, "open", func() error {
+		if err := db.PingContext(ctx); err != nil {
+			return fmt.Errorf("ping: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// removeDatabaseFiles removes a database file and its sidecars, ignoring
+// errors. It is used to clean up after a failed Create.
+func removeDatabaseFiles(path string) {
+	for _, suffix := range append([]string{""}, sidecarSuffixes...) {
+		os.Remove(path + suffix)
+	}
+}
+
+// validatePersistentPath checks that a path is valid for a persistent
+// database: it must satisfy policy, and must not be a directory or be in a
+// directory that doesn't exist.
+func validatePersistentPath(path string, policy PathPolicy) error {
+	path = hostPathStyle.normalize(path)
+	if err := policy.CheckPath(path); err != nil {
+		return err
 	}
 	if isDirectory(path) {
 		return fmt.Errorf("%s: path is a directory", path)
@@ -349,6 +1123,9 @@ func getStatus(ctx context.Context, db *sql.DB, cfg Config) (*MigrationStatus, e
 
 	status.IsInitialized = true
 	status.SchemaVersion = *version
+	if status.InfraVersion, err = fetchInfraVersion(ctx, db); err != nil {
+		return nil, err
+	}
 
 	// Get applied migrations
 	applied, err := fetchAppliedMigrations(ctx, db)
@@ -369,8 +1146,9 @@ func getStatus(ctx context.Context, db *sql.DB, cfg Config) (*MigrationStatus, e
 			appliedPaths[a.Path] = true
 		}
 
+		env := cfg.environment()
 		for _, s := range scripts {
-			if !appliedPaths[s.Path] {
+			if !appliedPaths[s.Path] && s.appliesTo(env) {
 				status.Pending = append(status.Pending, s.Path)
 			}
 		}
@@ -399,26 +1177,7 @@ func fetchSchemaVersion(ctx context.Context, db *sql.DB) (*int, error) {
 
 // fetchAppliedMigrations returns all applied migrations in order.
 func fetchAppliedMigrations(ctx context.Context, db *sql.DB) ([]AppliedMigration, error) {
-	rows, err := db.QueryContext(ctx, `SELECT id, comment, path, applied_at FROM schema_migrations ORDER BY path`)
-	if err != nil {
-		if isNoSuchTable(err) {
-			return nil, nil
-		}
-		return nil, err
-	}
-	defer rows.Close()
-
-	var result []AppliedMigration
-	for rows.Next() {
-		var m AppliedMigration
-		var appliedAt int64
-		if err := rows.Scan(&m.ID, &m.Comment, &m.Path, &appliedAt); err != nil {
-			return nil, err
-		}
-		m.AppliedAt = time.Unix(appliedAt, 0).UTC()
-		result = append(result, m)
-	}
-	return result, rows.Err()
+	return queryApplied(ctx, db, `ORDER BY path`)
 }
 
 // isNoSuchTable checks if an error indicates a missing table.
@@ -428,3 +1187,12 @@ func isNoSuchTable(err error) bool {
 	}
 	return strings.Contains(err.Error(), "no such table")
 }
+
+// isReadOnly returns true if err is a write rejected by a read-only
+// database.
+func isReadOnly(err error) bool {
+	if err == nil {
+		return false
+	}
+	return strings.Contains(err.Error(), "readonly database")
+}
@@ -3,12 +3,29 @@
 package sqliteinit_test
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"database/sql"
+	"database/sql/driver"
 	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"io/fs"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"reflect"
+	"regexp"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
+	"testing/fstest"
 	"time"
 
 	"github.com/mdhender/sqliteinit"
@@ -21,6 +38,60 @@ var validMigrationsFS embed.FS
 //go:embed testdata/invalid/*.sql
 var invalidMigrationsFS embed.FS
 
+//go:embed testdata/envscoped/*.sql
+var envScopedMigrationsFS embed.FS
+
+//go:embed testdata/plugin/*.sql
+var pluginMigrationsFS embed.FS
+
+//go:embed testdata/strict/*.sql
+var strictMigrationsFS embed.FS
+
+//go:embed testdata/deferred/*.sql
+var deferredMigrationsFS embed.FS
+
+//go:embed testdata/rollback/*.sql
+var rollbackMigrationsFS embed.FS
+
+//go:embed testdata/advanced/*.sql
+var advancedMigrationsFS embed.FS
+
+// strictMigrations returns a sub-filesystem rooted at the STRICT table migrations directory.
+func strictMigrations() fs.FS {
+	sub, err := fs.Sub(strictMigrationsFS, "testdata/strict")
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
+
+// deferredMigrations returns a sub-filesystem rooted at the deferred migrations directory.
+func deferredMigrations() fs.FS {
+	sub, err := fs.Sub(deferredMigrationsFS, "testdata/deferred")
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
+
+// rollbackMigrations returns a sub-filesystem rooted at the rollback migrations directory.
+func rollbackMigrations() fs.FS {
+	sub, err := fs.Sub(rollbackMigrationsFS, "testdata/rollback")
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
+
+// advancedMigrations returns a sub-filesystem rooted at the advanced schema migrations directory.
+func advancedMigrations() fs.FS {
+	sub, err := fs.Sub(advancedMigrationsFS, "testdata/advanced")
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
+
 // validMigrations returns a sub-filesystem rooted at the valid migrations directory.
 func validMigrations() fs.FS {
 	sub, err := fs.Sub(validMigrationsFS, "testdata/valid")
@@ -39,6 +110,24 @@ func invalidMigrations() fs.FS {
 	return sub
 }
 
+// envScopedMigrations returns a sub-filesystem rooted at the environment-scoped migrations directory.
+func envScopedMigrations() fs.FS {
+	sub, err := fs.Sub(envScopedMigrationsFS, "testdata/envscoped")
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
+
+// pluginMigrations returns a sub-filesystem rooted at the plugin migrations directory.
+func pluginMigrations() fs.FS {
+	sub, err := fs.Sub(pluginMigrationsFS, "testdata/plugin")
+	if err != nil {
+		panic(err)
+	}
+	return sub
+}
+
 // TestOpen_Memory tests opening an in-memory database.
 func TestOpen_Memory(t *testing.T) {
 	ctx := context.Background()
@@ -128,6 +217,33 @@ func TestOpen_Memory_ProductionAllowed(t *testing.T) {
 	db.Close()
 }
 
+// TestOpen_Memory_ProductionDetector tests that IsProduction replaces the
+// environment variable check.
+func TestOpen_Memory_ProductionDetector(t *testing.T) {
+	os.Setenv("TEST_ENV", "production")
+	defer os.Unsetenv("TEST_ENV")
+
+	ctx := context.Background()
+
+	db, err := sqliteinit.Open(ctx, sqliteinit.Config{
+		Path:             ":memory:",
+		ProductionEnvVar: "TEST_ENV",
+		IsProduction:     func() bool { return false },
+	})
+	if err != nil {
+		t.Fatalf("Open should succeed when IsProduction reports false: %v", err)
+	}
+	db.Close()
+
+	_, err = sqliteinit.Open(ctx, sqliteinit.Config{
+		Path:         ":memory:",
+		IsProduction: func() bool { return true },
+	})
+	if err == nil || !strings.Contains(err.Error(), "Config.IsProduction") {
+		t.Fatalf("expected production error naming Config.IsProduction, got %v", err)
+	}
+}
+
 // TestOpen_Memory_AppVersion tests that AppVersion is written to config.
 func TestOpen_Memory_AppVersion(t *testing.T) {
 	ctx := context.Background()
@@ -366,6 +482,71 @@ func TestStatus_Uninitialized(t *testing.T) {
 	}
 }
 
+// TestStatusFromDB tests getting migration status from an open in-memory
+// handle.
+func TestStatusFromDB(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := sqliteinit.Open(ctx, sqliteinit.Config{Path: ":memory:"})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+
+	status, err := sqliteinit.StatusFromDB(ctx, db, validMigrations())
+	if err != nil {
+		t.Fatalf("StatusFromDB failed: %v", err)
+	}
+	if !status.IsInitialized {
+		t.Error("expected IsInitialized=true")
+	}
+	if len(status.Pending) != 2 {
+		t.Errorf("expected 2 pending migrations, got %v", status.Pending)
+	}
+
+	if status, err = sqliteinit.StatusFromDB(ctx, db, nil); err != nil {
+		t.Fatalf("StatusFromDB failed: %v", err)
+	}
+	if len(status.Applied) != 1 || len(status.Pending) != 0 {
+		t.Errorf("expected only the init migration, got %+v", status)
+	}
+
+}
+
+// TestStatus_SharedMemory tests getting migration status of a shared
+// in-memory database that is open elsewhere.
+func TestStatus_SharedMemory(t *testing.T) {
+	ctx := context.Background()
+	cfg := sqliteinit.Config{Path: "file:statusmem?mode=memory&cache=shared", Migrations: validMigrations()}
+
+	db, err := sqliteinit.Open(ctx, cfg)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+
+	status, err := sqliteinit.Status(ctx, cfg)
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	if !status.IsInitialized || len(status.Applied) != 3 || len(status.Pending) != 0 {
+		t.Errorf("expected 3 applied and none pending, got %+v", status)
+	}
+
+	// Status must not have migrated a database nobody holds open.
+	status, err = sqliteinit.Status(ctx, sqliteinit.Config{Path: "file:statusempty?mode=memory&cache=shared"})
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	if status.IsInitialized {
+		t.Error("expected unopened shared memory database to be uninitialized")
+	}
+
+	if _, err := sqliteinit.Status(ctx, sqliteinit.Config{Path: "file:private?mode=memory"}); err == nil {
+		t.Error("expected Status to reject a private in-memory database")
+	}
+}
+
 // TestMigrate_DuplicateID tests that duplicate migration IDs are rejected.
 func TestMigrate_DuplicateID(t *testing.T) {
 	ctx := context.Background()
@@ -521,3 +702,5211 @@ func TestOpen_SkipMigrations(t *testing.T) {
 		t.Errorf("expected only init migration (1), got %d", count)
 	}
 }
+
+// TestMigrate_EnvironmentScoped tests that env-scoped migrations apply only in matching environments.
+func TestMigrate_EnvironmentScoped(t *testing.T) {
+	ctx := context.Background()
+
+	for _, tc := range []struct {
+		env   string
+		users int
+	}{
+		{env: "test", users: 2},
+		{env: "DEV", users: 2},
+		{env: "production", users: 0},
+		{env: "", users: 0},
+	} {
+		t.Run(tc.env, func(t *testing.T) {
+			db, err := sqliteinit.Open(ctx, sqliteinit.Config{
+				Path:                    ":memory:",
+				Migrations:              envScopedMigrations(),
+				Environment:             tc.env,
+				ProductionEnvVar:        "SQLITEINIT_TEST_UNSET_ENV",
+				AllowMemoryInProduction: true,
+			})
+			if err != nil {
+				t.Fatalf("Open failed: %v", err)
+			}
+			defer db.Close()
+
+			var count int
+			if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM users`).Scan(&count); err != nil {
+				t.Fatalf("count users: %v", err)
+			}
+			if count != tc.users {
+				t.Errorf("expected %d users, got %d", tc.users, count)
+			}
+		})
+	}
+}
+
+// TestStatus_EnvironmentScoped tests that Status omits migrations scoped to other environments.
+func TestStatus_EnvironmentScoped(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "test.db")
+
+	if err := sqliteinit.Create(ctx, sqliteinit.Config{Path: path}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	status, err := sqliteinit.Status(ctx, sqliteinit.Config{
+		Path:        path,
+		Migrations:  envScopedMigrations(),
+		Environment: "production",
+	})
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	if len(status.Pending) != 1 {
+		t.Errorf("expected 1 pending migration, got %v", status.Pending)
+	}
+}
+
+// TestOpen_MaxDatabaseSize tests that writes beyond the size quota are rejected.
+func TestOpen_MaxDatabaseSize(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := sqliteinit.Open(ctx, sqliteinit.Config{
+		Path:            ":memory:",
+		MaxDatabaseSize: 64 * 1024,
+	})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+
+	if _, err := db.ExecContext(ctx, `CREATE TABLE blobs (data BLOB)`); err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+	for i := 0; i < 32; i++ {
+		if _, err = db.ExecContext(ctx, `INSERT INTO blobs (data) VALUES (randomblob(8192))`); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		t.Fatal("expected insert to fail once the quota was reached")
+	}
+}
+
+// TestQuota tests that the size quota holds on every connection of a file
+// database and that approaching it sends a QuotaEvent and runs QuotaTask.
+func TestQuota(t *testing.T) {
+	ctx := context.Background()
+	cfg := sqliteinit.Config{Path: filepath.Join(t.TempDir(), "app.db"), MaxDatabaseSize: 128 * 1024}
+	if err := sqliteinit.Create(ctx, cfg); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	db, err := sqliteinit.Open(ctx, cfg)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	// Every statement gets a new connection, which must have the cap too.
+	db.SetMaxIdleConns(0)
+	if _, err := db.ExecContext(ctx, `CREATE TABLE blobs (data BLOB)`); err != nil {
+		t.Fatalf("create table: %v", err)
+	}
+	for i := 0; i < 32; i++ {
+		if _, err = db.ExecContext(ctx, `INSERT INTO blobs (data) VALUES (randomblob(8192))`); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		t.Fatal("expected insert to fail once the quota was reached")
+	}
+
+	var reclaimed sqliteinit.QuotaUsage
+	m, err := sqliteinit.NewMaintenance(db, sqliteinit.MaintenanceConfig{Tasks: []sqliteinit.MaintenanceTask{
+		sqliteinit.QuotaTask(time.Hour, cfg.MaxDatabaseSize, func(ctx context.Context, db *sql.DB, usage sqliteinit.QuotaUsage) error {
+			reclaimed = usage
+			_, err := db.ExecContext(ctx, `DELETE FROM blobs`)
+			return err
+		}),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.RunNow(ctx, "quota"); err != nil {
+		t.Fatalf("quota task failed: %v", err)
+	}
+	if reclaimed.Max != cfg.MaxDatabaseSize || !reclaimed.Approaching() {
+		t.Errorf("expected reclaim near the quota, got %+v", reclaimed)
+	}
+	db.Close()
+
+	var events []sqliteinit.QuotaEvent
+	cfg.OnEvent = func(e sqliteinit.Event) {
+		if e, ok := e.(sqliteinit.QuotaEvent); ok {
+			events = append(events, e)
+		}
+	}
+	db, err = sqliteinit.Open(ctx, cfg)
+	if err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	db.Close()
+	if len(events) != 1 || events[0].Usage.Max != cfg.MaxDatabaseSize {
+		t.Errorf("expected one QuotaEvent, got %+v", events)
+	}
+
+	db, err = sqliteinit.Open(ctx, sqliteinit.Config{Path: cfg.Path})
+	if err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	defer db.Close()
+	m, err = sqliteinit.NewMaintenance(db, sqliteinit.MaintenanceConfig{Tasks: []sqliteinit.MaintenanceTask{
+		sqliteinit.QuotaTask(time.Hour, cfg.MaxDatabaseSize, nil),
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.RunNow(ctx, "quota"); err == nil {
+		t.Error("expected a QuotaTask without reclaim to fail near the quota")
+	}
+}
+
+// TestMergeFS tests applying migrations merged from several sources.
+func TestMergeFS(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := sqliteinit.Open(ctx, sqliteinit.Config{
+		Path:       ":memory:",
+		Migrations: sqliteinit.MergeFS(validMigrations(), pluginMigrations()),
+	})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+
+	var count int
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM schema_migrations`).Scan(&count); err != nil {
+		t.Fatalf("query migrations count: %v", err)
+	}
+	if count != 4 {
+		t.Errorf("expected 4 migrations (init + 3 user), got %d", count)
+	}
+}
+
+// TestMergeFS_Collision tests that the same file in two sources is rejected.
+func TestMergeFS_Collision(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := sqliteinit.Open(ctx, sqliteinit.Config{
+		Path:       ":memory:",
+		Migrations: sqliteinit.MergeFS(validMigrations(), validMigrations()),
+	})
+	if err == nil {
+		t.Fatal("expected error for colliding migration files")
+	}
+}
+
+// TestMaintenance tests that scheduled tasks run and are recorded in history.
+func TestMaintenance(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := sqliteinit.Open(ctx, sqliteinit.Config{Path: ":memory:"})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+
+	m, err := sqliteinit.NewMaintenance(db, sqliteinit.MaintenanceConfig{
+		Tasks:         []sqliteinit.MaintenanceTask{sqliteinit.OptimizeTask(time.Millisecond)},
+		CheckInterval: time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("NewMaintenance failed: %v", err)
+	}
+
+	m.Start(ctx)
+	deadline := time.Now().Add(5 * time.Second)
+	for len(m.History()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	m.Stop()
+
+	history := m.History()
+	if len(history) == 0 {
+		t.Fatal("expected at least one maintenance run")
+	}
+	if history[0].Task != "optimize" || history[0].Err != nil {
+		t.Errorf("unexpected run: %+v", history[0])
+	}
+
+	if err := m.RunNow(ctx, "missing"); err == nil {
+		t.Error("RunNow should fail for unknown task")
+	}
+}
+
+// TestStartMaintenance tests running maintenance tasks in the background.
+func TestStartMaintenance(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	cfg := sqliteinit.Config{Path: filepath.Join(dir, "app.db"), Migrations: validMigrations()}
+	if err := sqliteinit.Create(ctx, cfg); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	db, err := sqliteinit.Open(ctx, cfg)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+
+	backups := filepath.Join(dir, "backups")
+	if err := os.Mkdir(backups, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	m, err := sqliteinit.StartMaintenance(ctx, db, sqliteinit.MaintenanceConfig{
+		Tasks: []sqliteinit.MaintenanceTask{
+			sqliteinit.IncrementalVacuumTask(time.Hour, 10),
+			sqliteinit.BackupTask(backups, time.Hour, sqliteinit.RotationPolicy{Options: sqliteinit.BackupOptions{Verify: true}}),
+		},
+		CheckInterval: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("StartMaintenance failed: %v", err)
+	}
+	defer m.Stop()
+
+	for _, name := range []string{"incremental_vacuum", "backup"} {
+		if err := m.RunNow(ctx, name); err != nil {
+			t.Errorf("RunNow(%q) failed: %v", name, err)
+		}
+	}
+	matches, _ := filepath.Glob(filepath.Join(backups, "backup-*.db"))
+	if len(matches) != 1 {
+		t.Errorf("expected one backup, got %v", matches)
+	}
+
+	// With no tasks, the defaults are scheduled.
+	d, err := sqliteinit.StartMaintenance(ctx, db, sqliteinit.MaintenanceConfig{CheckInterval: time.Hour})
+	if err != nil {
+		t.Fatalf("StartMaintenance failed: %v", err)
+	}
+	defer d.Stop()
+	for _, task := range sqliteinit.DefaultMaintenanceTasks() {
+		if err := d.RunNow(ctx, task.Name); err != nil {
+			t.Errorf("RunNow(%q) failed: %v", task.Name, err)
+		}
+	}
+}
+
+// TestGate tests pausing and resuming write traffic.
+func TestGate(t *testing.T) {
+	ctx := context.Background()
+	var g sqliteinit.Gate
+
+	release, err := g.Enter(ctx)
+	if err != nil {
+		t.Fatalf("Enter failed: %v", err)
+	}
+
+	// Pause must wait for the in-flight writer
+	paused := make(chan error)
+	go func() { paused <- g.Pause(ctx) }()
+	select {
+	case <-paused:
+		t.Fatal("Pause returned before writer released")
+	case <-time.After(10 * time.Millisecond):
+	}
+	release()
+	if err := <-paused; err != nil {
+		t.Fatalf("Pause failed: %v", err)
+	}
+
+	// New writers block until Resume
+	entered := make(chan struct{})
+	go func() {
+		release, err := g.Enter(ctx)
+		if err == nil {
+			release()
+		}
+		close(entered)
+	}()
+	select {
+	case <-entered:
+		t.Fatal("Enter succeeded while paused")
+	case <-time.After(10 * time.Millisecond):
+	}
+	g.Resume()
+	<-entered
+}
+
+// TestGate_FailFast tests that Enter errors while paused when FailFast is set.
+func TestGate_FailFast(t *testing.T) {
+	ctx := context.Background()
+	g := sqliteinit.Gate{FailFast: true}
+
+	if err := g.Pause(ctx); err != nil {
+		t.Fatalf("Pause failed: %v", err)
+	}
+	if _, err := g.Enter(ctx); !errors.Is(err, sqliteinit.ErrGateClosed) {
+		t.Errorf("expected ErrGateClosed, got %v", err)
+	}
+	g.Resume()
+	release, err := g.Enter(ctx)
+	if err != nil {
+		t.Fatalf("Enter after Resume failed: %v", err)
+	}
+	release()
+}
+
+// TestGate_Restore tests that DB.WriteTx waits while Restore holds
+// Config.Gate.
+func TestGate_Restore(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	var gate sqliteinit.Gate
+	cfg := sqliteinit.Config{Path: filepath.Join(dir, "app.db"), Migrations: validMigrations(), Gate: &gate}
+	if err := sqliteinit.Create(ctx, cfg); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	src, err := sqliteinit.Open(ctx, cfg)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	backup := filepath.Join(dir, "backup.db")
+	if err := sqliteinit.Backup(ctx, src, backup, sqliteinit.BackupOptions{}); err != nil {
+		t.Fatalf("Backup failed: %v", err)
+	}
+	src.Close()
+
+	other := cfg
+	other.Path = filepath.Join(dir, "other.db")
+	if err := sqliteinit.Create(ctx, other); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	db, err := sqliteinit.OpenDB(ctx, other)
+	if err != nil {
+		t.Fatalf("OpenDB failed: %v", err)
+	}
+	defer db.Close()
+
+	// Hold Restore just before it swaps the file in.
+	swapping, proceed := make(chan struct{}), make(chan struct{})
+	defer sqliteinit.SetRename(func(from, to string) error {
+		close(swapping)
+		<-proceed
+		return os.Rename(from, to)
+	})()
+	restored := make(chan error, 1)
+	go func() {
+		db, err := sqliteinit.Restore(ctx, backup, cfg)
+		if err == nil {
+			db.Close()
+		}
+		restored <- err
+	}()
+	<-swapping
+
+	wrote := make(chan error, 1)
+	go func() {
+		wrote <- db.WriteTx(ctx, func(tx *sql.Tx) error {
+			_, err := tx.Exec(`INSERT INTO users (email, name, created_at) VALUES ('a@example.com', 'A', 0)`)
+			return err
+		})
+	}()
+	select {
+	case err := <-wrote:
+		t.Fatalf("WriteTx ran while Restore held the gate: %v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(proceed)
+	if err := <-restored; err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if err := <-wrote; err != nil {
+		t.Fatalf("WriteTx failed: %v", err)
+	}
+	if gate.Paused() {
+		t.Error("expected Restore to resume the gate")
+	}
+}
+
+// TestOpen_ExtraPragmas tests that user pragmas are applied.
+func TestOpen_ExtraPragmas(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := sqliteinit.Open(ctx, sqliteinit.Config{
+		Path: ":memory:",
+		ExtraPragmas: []sqliteinit.Pragma{
+			{Name: "cache_size", Value: "-4000"},
+			{Name: "secure_delete", Value: "ON"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+
+	var cacheSize int
+	if err := db.QueryRowContext(ctx, `PRAGMA cache_size`).Scan(&cacheSize); err != nil {
+		t.Fatalf("query cache_size: %v", err)
+	}
+	if cacheSize != -4000 {
+		t.Errorf("expected cache_size -4000, got %d", cacheSize)
+	}
+}
+
+// TestOpen_ExtraPragmas_Conflict tests that overriding a built-in pragma is rejected.
+func TestOpen_ExtraPragmas_Conflict(t *testing.T) {
+	ctx := context.Background()
+
+	_, err := sqliteinit.Open(ctx, sqliteinit.Config{
+		Path:         ":memory:",
+		ExtraPragmas: []sqliteinit.Pragma{{Name: "foreign_keys", Value: "OFF"}},
+	})
+	if err == nil {
+		t.Fatal("expected error for pragma conflicting with built-in")
+	}
+}
+
+// TestOpen_QueryTimeout tests that runaway statements are interrupted and
+// that the timeout wrapper keeps the declared column types.
+func TestOpen_QueryTimeout(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "test.db")
+
+	if err := sqliteinit.Create(ctx, sqliteinit.Config{Path: path, Migrations: validMigrations()}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	db, err := sqliteinit.Open(ctx, sqliteinit.Config{
+		Path:         path,
+		Migrations:   validMigrations(),
+		QueryTimeout: 50 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+
+	// Short statements are unaffected
+	if _, err := db.ExecContext(ctx, `INSERT INTO users (email, name, created_at) VALUES ('a@example.com', 'A', 0)`); err != nil {
+		t.Fatalf("insert failed: %v", err)
+	}
+
+	var n int
+	err = db.QueryRowContext(ctx, `
+		WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c)
+		SELECT COUNT(*) FROM c
+	`).Scan(&n)
+	if !errors.Is(err, sqliteinit.ErrQueryTimeout) {
+		t.Fatalf("expected ErrQueryTimeout, got %v", err)
+	}
+
+	// The connection is usable afterward
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM users`).Scan(&n); err != nil {
+		t.Fatalf("query after timeout failed: %v", err)
+	}
+	// Column types pass through the timeout wrapper
+	rows, err := db.QueryContext(ctx, `SELECT email, created_at FROM users`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := []string{types[0].DatabaseTypeName(), types[1].DatabaseTypeName()}; got[0] != "TEXT" || got[1] != "INTEGER" {
+		t.Errorf("expected declared types TEXT and INTEGER, got %v", got)
+	}
+}
+
+// TestExportSnapshot tests exporting a read-only snapshot.
+func TestExportSnapshot(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	dest := filepath.Join(dir, "snapshot.db")
+
+	db, err := sqliteinit.Open(ctx, sqliteinit.Config{
+		Path:       ":memory:",
+		Migrations: validMigrations(),
+	})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+
+	err = sqliteinit.ExportSnapshot(ctx, db, dest, sqliteinit.SnapshotOptions{StripInternal: true})
+	if err != nil {
+		t.Fatalf("ExportSnapshot failed: %v", err)
+	}
+
+	info, err := os.Stat(dest)
+	if err != nil {
+		t.Fatalf("stat snapshot: %v", err)
+	}
+	if info.Mode().Perm()&0o222 != 0 {
+		t.Errorf("snapshot should be read-only, got mode %v", info.Mode())
+	}
+
+	// Internal tables are stripped, so the snapshot isn't a managed database
+	status, err := sqliteinit.Status(ctx, sqliteinit.Config{Path: dest})
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	if status.IsInitialized {
+		t.Error("expected stripped snapshot to be uninitialized")
+	}
+
+	// Exporting over an existing file fails
+	if err := sqliteinit.ExportSnapshot(ctx, db, dest, sqliteinit.SnapshotOptions{}); err == nil {
+		t.Error("expected error exporting over existing file")
+	}
+
+	// A failed export leaves nothing behind, and a leftover temporary file
+	// doesn't block the next one.
+	failed := filepath.Join(dir, "failed.db")
+	if err := os.WriteFile(failed+".tmp", []byte("partial"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	unregistered := sqliteinit.SnapshotOptions{StripInternal: true, Driver: sqliteinit.Mattn}
+	if err := sqliteinit.ExportSnapshot(ctx, db, failed, unregistered); err == nil {
+		t.Fatal("expected export with an unregistered driver to fail")
+	}
+	for _, name := range []string{failed, failed + ".tmp"} {
+		if _, err := os.Stat(name); !os.IsNotExist(err) {
+			t.Errorf("expected %s to be removed, got %v", filepath.Base(name), err)
+		}
+	}
+	if err := sqliteinit.ExportSnapshot(ctx, db, failed, sqliteinit.SnapshotOptions{}); err != nil {
+		t.Errorf("ExportSnapshot after a failed export failed: %v", err)
+	}
+}
+
+// TestBackup tests taking an online backup of an open database.
+func TestBackup(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	cfg := sqliteinit.Config{Path: filepath.Join(dir, "app.db"), Migrations: validMigrations()}
+	if err := sqliteinit.Create(ctx, cfg); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	db, err := sqliteinit.Open(ctx, cfg)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`INSERT INTO users (email, name, created_at) VALUES ('a@example.com', 'A', 0)`); err != nil {
+		t.Fatal(err)
+	}
+
+	dest := filepath.Join(dir, "backup.db")
+	if err := sqliteinit.Backup(ctx, db, dest, sqliteinit.BackupOptions{Verify: true}); err != nil {
+		t.Fatalf("Backup failed: %v", err)
+	}
+	if _, err := os.Stat(dest + ".tmp"); !os.IsNotExist(err) {
+		t.Errorf("expected the temporary file to be removed, got %v", err)
+	}
+
+	// The backup is a complete, migrated database.
+	restored, err := sqliteinit.Open(ctx, sqliteinit.Config{Path: dest, Migrations: validMigrations()})
+	if err != nil {
+		t.Fatalf("Open backup failed: %v", err)
+	}
+	defer restored.Close()
+	var n int
+	if err := restored.QueryRow(`SELECT count(*) FROM users`).Scan(&n); err != nil || n != 1 {
+		t.Errorf("expected 1 user in the backup, got %d (%v)", n, err)
+	}
+
+	if err := sqliteinit.Backup(ctx, db, dest, sqliteinit.BackupOptions{}); !errors.Is(err, sqliteinit.ErrAlreadyExists) {
+		t.Errorf("expected ErrAlreadyExists, got %v", err)
+	}
+	if err := sqliteinit.Backup(ctx, db, "backup.db", sqliteinit.BackupOptions{}); err == nil {
+		t.Error("expected error for a relative destination")
+	}
+}
+
+// TestRestore tests restoring a database from a backup.
+func TestRestore(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	v1 := fstest.MapFS{
+		"20260101000001_items.sql": &fstest.MapFile{Data: []byte("CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT);\n")},
+	}
+	v2 := fstest.MapFS{
+		"20260101000001_items.sql": v1["20260101000001_items.sql"],
+		"20260101000002_price.sql": &fstest.MapFile{Data: []byte("ALTER TABLE items ADD COLUMN price INTEGER;\n")},
+	}
+
+	cfg := sqliteinit.Config{Path: filepath.Join(dir, "app.db"), Migrations: v1}
+	if err := sqliteinit.Create(ctx, cfg); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	db, err := sqliteinit.Open(ctx, cfg)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if _, err := db.Exec(`INSERT INTO items (name) VALUES ('kept')`); err != nil {
+		t.Fatal(err)
+	}
+	backup := filepath.Join(dir, "backup.db")
+	if err := sqliteinit.Backup(ctx, db, backup, sqliteinit.BackupOptions{}); err != nil {
+		t.Fatalf("Backup failed: %v", err)
+	}
+	if _, err := db.Exec(`INSERT INTO items (name) VALUES ('lost')`); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	// Restoring rolls back the data and applies the newer migration.
+	cfg.Migrations = v2
+	db, err = sqliteinit.Restore(ctx, backup, cfg)
+	if err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	var names []string
+	rows, err := db.Query(`SELECT name FROM items WHERE price IS NULL ORDER BY id`)
+	if err != nil {
+		t.Fatalf("query restored database: %v", err)
+	}
+	for rows.Next() {
+		var name string
+		rows.Scan(&name)
+		names = append(names, name)
+	}
+	rows.Close()
+	if !reflect.DeepEqual(names, []string{"kept"}) {
+		t.Errorf("expected only the backed-up row, got %v", names)
+	}
+	db.Close()
+	if _, err := os.Stat(backup); err != nil {
+		t.Error("expected the backup to be left in place")
+	}
+
+	// A corrupt backup is rejected and the database left alone.
+	corrupt := filepath.Join(dir, "corrupt.db")
+	if err := os.WriteFile(corrupt, bytes.Repeat([]byte("x"), 8192), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sqliteinit.Restore(ctx, corrupt, cfg); err == nil {
+		t.Error("expected error restoring a corrupt backup")
+	}
+	status, err := sqliteinit.Status(ctx, cfg)
+	if err != nil || status.SchemaVersion != 20260101000002 {
+		t.Errorf("expected the database to be untouched, got %+v (%v)", status, err)
+	}
+
+	// So is a database without the package's tables.
+	empty := filepath.Join(dir, "empty.db")
+	if err := os.WriteFile(empty, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sqliteinit.Restore(ctx, empty, cfg); !errors.Is(err, sqliteinit.ErrNotInitialized) {
+		t.Errorf("expected ErrNotInitialized, got %v", err)
+	}
+	if _, err := sqliteinit.Restore(ctx, filepath.Join(dir, "missing.db"), cfg); !errors.Is(err, sqliteinit.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+// TestOpenInMemoryCopy tests opening an in-memory copy of a database file.
+func TestOpenInMemoryCopy(t *testing.T) {
+	ctx := context.Background()
+	// Characters that end or escape the path part of a URI.
+	dir := filepath.Join(t.TempDir(), "a#b?c%d")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	v1 := fstest.MapFS{
+		"20260101000001_items.sql": &fstest.MapFile{Data: []byte("CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT);\n")},
+	}
+	v2 := fstest.MapFS{
+		"20260101000001_items.sql": v1["20260101000001_items.sql"],
+		"20260101000002_price.sql": &fstest.MapFile{Data: []byte("ALTER TABLE items ADD COLUMN price INTEGER;\n")},
+	}
+	cfg := sqliteinit.Config{Path: filepath.Join(dir, "app.db"), Migrations: v1}
+	if err := sqliteinit.Create(ctx, cfg); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	db, err := sqliteinit.Open(ctx, cfg)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if _, err := db.Exec(`INSERT INTO items (name) VALUES ('a'), ('b')`); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+	before, err := os.ReadFile(cfg.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg.Migrations = v2
+	mem, err := sqliteinit.OpenInMemoryCopy(ctx, cfg)
+	if err != nil {
+		t.Fatalf("OpenInMemoryCopy failed: %v", err)
+	}
+	defer mem.Close()
+	var n int
+	if err := mem.QueryRow(`SELECT count(*) FROM items WHERE price IS NULL`).Scan(&n); err != nil || n != 2 {
+		t.Errorf("expected 2 migrated rows in the copy, got %d (%v)", n, err)
+	}
+	if _, err := mem.Exec(`DELETE FROM items`); err != nil {
+		t.Fatalf("write to copy failed: %v", err)
+	}
+
+	// The file is untouched, migrations included.
+	after, err := os.ReadFile(cfg.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(before, after) {
+		t.Error("expected the database file to be unchanged")
+	}
+
+	if _, err := sqliteinit.OpenInMemoryCopy(ctx, sqliteinit.Config{Path: filepath.Join(dir, "missing.db")}); !errors.Is(err, sqliteinit.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+// TestClone tests cloning a database to a new file.
+func TestClone(t *testing.T) {
+	ctx := context.Background()
+	// Characters that end or escape the path part of a URI.
+	dir := filepath.Join(t.TempDir(), "a#b?c%d")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	src := filepath.Join(dir, "app.db")
+	cfg := sqliteinit.Config{Path: src, Migrations: validMigrations()}
+	if err := sqliteinit.Create(ctx, cfg); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	// The source stays open, with its latest rows still in the WAL.
+	db, err := sqliteinit.Open(ctx, cfg)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`INSERT INTO users (email, name, created_at) VALUES ('a@example.com', 'A', 0)`); err != nil {
+		t.Fatal(err)
+	}
+
+	dst := filepath.Join(dir, "clone.db")
+	if err := sqliteinit.Clone(ctx, src, dst); err != nil {
+		t.Fatalf("Clone failed: %v", err)
+	}
+	clone, err := sqliteinit.Open(ctx, sqliteinit.Config{Path: dst, Migrations: validMigrations()})
+	if err != nil {
+		t.Fatalf("Open clone failed: %v", err)
+	}
+	defer clone.Close()
+	var n int
+	if err := clone.QueryRow(`SELECT count(*) FROM users`).Scan(&n); err != nil || n != 1 {
+		t.Errorf("expected 1 user in the clone, got %d (%v)", n, err)
+	}
+
+	if err := sqliteinit.Clone(ctx, src, dst); !errors.Is(err, sqliteinit.ErrAlreadyExists) {
+		t.Errorf("expected ErrAlreadyExists, got %v", err)
+	}
+	if err := sqliteinit.Clone(ctx, src, src); !errors.Is(err, sqliteinit.ErrAlreadyExists) {
+		t.Errorf("cloning onto itself: expected ErrAlreadyExists, got %v", err)
+	}
+	if err := sqliteinit.Clone(ctx, filepath.Join(dir, "missing.db"), filepath.Join(dir, "new.db")); !errors.Is(err, sqliteinit.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	if err := sqliteinit.Clone(ctx, src, "relative.db"); err == nil {
+		t.Error("expected error for a relative destination")
+	}
+}
+
+// TestVacuum tests full and incremental vacuuming.
+func TestVacuum(t *testing.T) {
+	ctx := context.Background()
+	db, err := sqliteinit.Open(ctx, sqliteinit.Config{Path: ":memory:"})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+
+	freelist := func() int {
+		t.Helper()
+		var n int
+		if err := db.QueryRow(`PRAGMA freelist_count`).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+	fill := func() {
+		t.Helper()
+		for _, stmt := range []string{
+			`CREATE TABLE IF NOT EXISTS blobs (data BLOB)`,
+			`WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < 200) INSERT INTO blobs SELECT randomblob(4000) FROM n`,
+			`DELETE FROM blobs`,
+		} {
+			if _, err := db.Exec(stmt); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	fill()
+	if freelist() == 0 {
+		t.Fatal("expected free pages after deleting rows")
+	}
+	if err := sqliteinit.Vacuum(ctx, db, sqliteinit.VacuumIncremental(0)); err == nil {
+		t.Error("expected incremental vacuum to require auto_vacuum=INCREMENTAL")
+	}
+	if err := sqliteinit.Vacuum(ctx, db, sqliteinit.VacuumFull); err != nil {
+		t.Fatalf("VacuumFull failed: %v", err)
+	}
+	if n := freelist(); n != 0 {
+		t.Errorf("expected no free pages after VacuumFull, got %d", n)
+	}
+
+	if _, err := db.Exec(`PRAGMA auto_vacuum = INCREMENTAL`); err != nil {
+		t.Fatal(err)
+	}
+	if err := sqliteinit.Vacuum(ctx, db, sqliteinit.VacuumFull); err != nil {
+		t.Fatalf("VacuumFull failed: %v", err)
+	}
+	fill()
+	before := freelist()
+	if err := sqliteinit.Vacuum(ctx, db, sqliteinit.VacuumIncremental(10)); err != nil {
+		t.Fatalf("VacuumIncremental failed: %v", err)
+	}
+	if n := freelist(); n != before-10 {
+		t.Errorf("expected %d free pages after freeing 10, got %d", before-10, n)
+	}
+	if err := sqliteinit.Vacuum(ctx, db, sqliteinit.VacuumIncremental(0)); err != nil {
+		t.Fatalf("VacuumIncremental failed: %v", err)
+	}
+	if n := freelist(); n != 0 {
+		t.Errorf("expected no free pages, got %d", n)
+	}
+
+	dest := filepath.Join(t.TempDir(), "compact.db")
+	if err := sqliteinit.Vacuum(ctx, db, sqliteinit.VacuumInto(dest)); err != nil {
+		t.Fatalf("VacuumInto failed: %v", err)
+	}
+	if _, err := os.Stat(dest); err != nil {
+		t.Errorf("expected %s to exist: %v", dest, err)
+	}
+}
+
+// TestCheckpoint tests checkpointing the WAL of a database.
+func TestCheckpoint(t *testing.T) {
+	ctx := context.Background()
+	cfg := sqliteinit.Config{Path: filepath.Join(t.TempDir(), "app.db"), Migrations: validMigrations(), WALAutocheckpoint: -1}
+	if err := sqliteinit.Create(ctx, cfg); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	db, err := sqliteinit.Open(ctx, cfg)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`INSERT INTO users (email, name, created_at) VALUES ('a@example.com', 'A', 0)`); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := sqliteinit.Checkpoint(ctx, db, sqliteinit.CheckpointPassive)
+	if err != nil {
+		t.Fatalf("Checkpoint failed: %v", err)
+	}
+	if r.Busy || r.Checkpointed == 0 || r.Remaining != 0 {
+		t.Errorf("expected every frame checkpointed, got %+v", r)
+	}
+
+	r, err = sqliteinit.Checkpoint(ctx, db, sqliteinit.CheckpointTruncate)
+	if err != nil {
+		t.Fatalf("Checkpoint failed: %v", err)
+	}
+	if r != (sqliteinit.CheckpointResult{}) {
+		t.Errorf("expected an empty WAL after truncating, got %+v", r)
+	}
+	if info, err := os.Stat(cfg.Path + "-wal"); err != nil || info.Size() != 0 {
+		t.Errorf("expected a truncated WAL file, got %v", err)
+	}
+
+	if _, err := sqliteinit.Checkpoint(ctx, db, sqliteinit.CheckpointMode(9)); err == nil {
+		t.Error("expected error for an unknown mode")
+	}
+}
+
+// TestIntegrityCheckOnOpen tests that Open runs the configured integrity
+// check and rejects a damaged database.
+func TestIntegrityCheckOnOpen(t *testing.T) {
+	ctx := context.Background()
+	cfg := sqliteinit.Config{Path: filepath.Join(t.TempDir(), "app.db"), Migrations: validMigrations()}
+	if err := sqliteinit.Create(ctx, cfg); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	cfg.IntegrityCheckOnOpen = sqliteinit.IntegrityCheckFull
+	db, err := sqliteinit.Open(ctx, cfg)
+	if err != nil {
+		t.Fatalf("Open of a sound database failed: %v", err)
+	}
+	db.Close()
+
+	// Redefine an index so its entries no longer match the table.
+	raw, err := sql.Open("sqlite", cfg.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, stmt := range []string{
+		`CREATE TABLE numbers (n INTEGER)`,
+		`CREATE INDEX idx_numbers ON numbers (n)`,
+		`INSERT INTO numbers VALUES (1), (2), (3)`,
+		`PRAGMA writable_schema = ON`,
+		`UPDATE sqlite_master SET sql = 'CREATE INDEX idx_numbers ON numbers (-n)' WHERE name = 'idx_numbers'`,
+	} {
+		if _, err := raw.Exec(stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+	raw.Close()
+
+	_, err = sqliteinit.Open(ctx, cfg)
+	var cerr *sqliteinit.CorruptionError
+	if !errors.Is(err, sqliteinit.ErrCorrupt) || !errors.As(err, &cerr) {
+		t.Fatalf("expected a *CorruptionError, got %v", err)
+	}
+	if len(cerr.Violations) == 0 || cerr.Path != cfg.Path {
+		t.Errorf("expected a report for %s, got %+v", cfg.Path, cerr)
+	}
+
+	cfg.IntegrityCheckOnOpen = sqliteinit.IntegrityCheckOff
+	db, err = sqliteinit.Open(ctx, cfg)
+	if err != nil {
+		t.Fatalf("Open without the check failed: %v", err)
+	}
+	db.Close()
+}
+
+// TestAutoVacuum tests that AutoVacuum is applied to new databases.
+func TestAutoVacuum(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	autoVacuum := func(db *sql.DB) int {
+		t.Helper()
+		var n int
+		if err := db.QueryRow(`PRAGMA auto_vacuum`).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	for _, tc := range []struct {
+		mode sqliteinit.AutoVacuum
+		want int
+	}{
+		{sqliteinit.AutoVacuumNone, 0},
+		{sqliteinit.AutoVacuumFull, 1},
+		{sqliteinit.AutoVacuumIncremental, 2},
+	} {
+		cfg := sqliteinit.Config{Path: filepath.Join(dir, fmt.Sprintf("mode%d.db", tc.mode)), Migrations: validMigrations(), AutoVacuum: tc.mode}
+		if err := sqliteinit.Create(ctx, cfg); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		db, err := sqliteinit.Open(ctx, cfg)
+		if err != nil {
+			t.Fatalf("Open failed: %v", err)
+		}
+		if got := autoVacuum(db); got != tc.want {
+			t.Errorf("AutoVacuum %d: expected auto_vacuum %d, got %d", tc.mode, tc.want, got)
+		}
+		db.Close()
+	}
+
+	// An existing database is switched with a VACUUM.
+	db, err := sqliteinit.Open(ctx, sqliteinit.Config{Path: filepath.Join(dir, "mode0.db")})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+	for range 2 {
+		if err := sqliteinit.EnableIncrementalVacuum(ctx, db); err != nil {
+			t.Fatalf("EnableIncrementalVacuum failed: %v", err)
+		}
+		if got := autoVacuum(db); got != 2 {
+			t.Errorf("expected auto_vacuum 2, got %d", got)
+		}
+	}
+	if err := sqliteinit.Vacuum(ctx, db, sqliteinit.VacuumIncremental(0)); err != nil {
+		t.Errorf("VacuumIncremental failed: %v", err)
+	}
+
+	if err := (sqliteinit.Config{Path: ":memory:", AutoVacuum: sqliteinit.AutoVacuumFull}).Validate(); err == nil {
+		t.Error("expected Validate to reject AutoVacuum for an in-memory database")
+	}
+}
+
+// TestCloseWithOptimize tests that closing with OptimizeOnClose runs
+// PRAGMA optimize.
+func TestCloseWithOptimize(t *testing.T) {
+	ctx := context.Background()
+	cfg := sqliteinit.Config{Path: filepath.Join(t.TempDir(), "app.db"), Migrations: validMigrations(), WALAutocheckpoint: -1}
+	if err := sqliteinit.Create(ctx, cfg); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	db, err := sqliteinit.Open(ctx, cfg)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if _, err := db.Exec(`INSERT INTO users (email, name, created_at) VALUES ('a@example.com', 'A', 0)`); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := sqliteinit.CloseWithOptimize(ctx, db, sqliteinit.CloseOptions{Checkpoint: true}); err != nil {
+		t.Fatalf("CloseWithOptimize failed: %v", err)
+	}
+	if err := db.Ping(); err == nil {
+		t.Error("expected the handle to be closed")
+	}
+	if info, err := os.Stat(cfg.Path + "-wal"); err == nil && info.Size() != 0 {
+		t.Errorf("expected an empty or no WAL file, got %d bytes", info.Size())
+	}
+
+	// Closing an already closed handle still reports the failure.
+	if err := sqliteinit.CloseWithOptimize(ctx, db, sqliteinit.CloseOptions{}); err == nil {
+		t.Error("expected error optimizing a closed handle")
+	}
+}
+
+// TestCompact tests compacting a database.
+func TestCompact(t *testing.T) {
+	ctx := context.Background()
+	cfg := sqliteinit.Config{Path: filepath.Join(t.TempDir(), "app.db"), Migrations: validMigrations()}
+	if err := sqliteinit.Create(ctx, cfg); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	db, err := sqliteinit.Open(ctx, cfg)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	for _, stmt := range []string{
+		`CREATE TABLE blobs (data BLOB)`,
+		`WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < 200) INSERT INTO blobs SELECT randomblob(4000) FROM n`,
+		`PRAGMA wal_checkpoint(TRUNCATE)`,
+		`DELETE FROM blobs`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db.Close()
+
+	r, err := sqliteinit.Compact(ctx, cfg)
+	if err != nil {
+		t.Fatalf("Compact failed: %v", err)
+	}
+	if r.Path != cfg.Path || r.Reclaimed < 500_000 || r.Reclaimed != r.SizeBefore-r.SizeAfter {
+		t.Errorf("expected at least 500 KB reclaimed from %s, got %+v", cfg.Path, r)
+	}
+	info, err := os.Stat(cfg.Path)
+	if err != nil || info.Size() != r.SizeAfter {
+		t.Errorf("expected the file to be %d bytes, got %v (%v)", r.SizeAfter, info, err)
+	}
+
+	if _, err := sqliteinit.Compact(ctx, sqliteinit.Config{Path: ":memory:"}); err == nil {
+		t.Error("expected error compacting an in-memory database")
+	}
+}
+
+// TestBackupRotate tests that BackupRotate prunes old backups by count and age.
+func TestBackupRotate(t *testing.T) {
+	ctx := context.Background()
+	db, err := sqliteinit.Open(ctx, sqliteinit.Config{Path: ":memory:", Migrations: validMigrations()})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+
+	dir := t.TempDir()
+	now := time.Now().UTC()
+	existing := []string{
+		"backup-" + now.Add(-72*time.Hour).Format("20060102T150405Z") + ".db", // too old
+		"backup-" + now.Add(-3*time.Hour).Format("20060102T150405Z") + ".db",  // beyond Keep
+		"backup-" + now.Add(-2*time.Hour).Format("20060102T150405Z") + ".db",
+		"backup-" + now.Add(-time.Hour).Format("20060102T150405Z") + ".db",
+		"backup-latest.db", // not a rotated backup
+		"notes.db",
+	}
+	for _, name := range existing {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	path, err := sqliteinit.BackupRotate(ctx, db, dir, sqliteinit.RotationPolicy{Keep: 3, MaxAge: 24 * time.Hour})
+	if err != nil {
+		t.Fatalf("BackupRotate failed: %v", err)
+	}
+	if filepath.Dir(path) != dir || !strings.HasPrefix(filepath.Base(path), "backup-") {
+		t.Errorf("unexpected backup path %s", path)
+	}
+
+	var got []string
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		got = append(got, e.Name())
+	}
+	want := append(append([]string(nil), existing[2:]...), filepath.Base(path))
+	slices.Sort(want)
+	if !slices.Equal(got, want) {
+		t.Errorf("expected %v to remain, got %v", want, got)
+	}
+
+	if _, err := sqliteinit.BackupRotate(ctx, db, dir, sqliteinit.RotationPolicy{Keep: -1}); err == nil {
+		t.Error("expected error for a negative Keep")
+	}
+}
+
+// TestCompressedBackup tests taking and restoring a compressed backup.
+func TestCompressedBackup(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	db, err := sqliteinit.Open(ctx, sqliteinit.Config{Path: ":memory:", Migrations: validMigrations()})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`INSERT INTO users (email, name, created_at) VALUES ('a@example.com', 'A', 0)`); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		ext   string
+		magic []byte
+	}{
+		{".db.gz", []byte{0x1f, 0x8b}},
+	} {
+		backup := filepath.Join(dir, "backup"+tc.ext)
+		if err := sqliteinit.Backup(ctx, db, backup, sqliteinit.BackupOptions{Verify: true}); err != nil {
+			t.Fatalf("Backup %s failed: %v", tc.ext, err)
+		}
+		data, err := os.ReadFile(backup)
+		if err != nil || !bytes.HasPrefix(data, tc.magic) {
+			t.Errorf("%s: expected a compressed file, got %v", tc.ext, err)
+		}
+
+		restored, err := sqliteinit.Restore(ctx, backup, sqliteinit.Config{
+			Path:       filepath.Join(dir, "restored"+strings.ReplaceAll(tc.ext, ".", "_")+".db"),
+			Migrations: validMigrations(),
+		})
+		if err != nil {
+			t.Fatalf("Restore %s failed: %v", tc.ext, err)
+		}
+		var n int
+		if err := restored.QueryRow(`SELECT count(*) FROM users`).Scan(&n); err != nil || n != 1 {
+			t.Errorf("%s: expected 1 restored user, got %d (%v)", tc.ext, n, err)
+		}
+		restored.Close()
+	}
+
+	rotated := filepath.Join(dir, "rotated")
+	if err := os.Mkdir(rotated, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	path, err := sqliteinit.BackupRotate(ctx, db, rotated, sqliteinit.RotationPolicy{Keep: 1, Compression: sqliteinit.CompressionGzip})
+	if err != nil || !strings.HasSuffix(path, ".db.gz") {
+		t.Errorf("expected a .db.gz backup, got %q (%v)", path, err)
+	}
+	if err := sqliteinit.Backup(ctx, db, filepath.Join(dir, "backup.gz"), sqliteinit.BackupOptions{}); err == nil {
+		t.Error("expected error for a .gz name without .db")
+	}
+
+	// Zstandard needs the sqliteinitzstd codec, which this module doesn't import.
+	zst := filepath.Join(dir, "backup.db.zst")
+	if err := sqliteinit.Backup(ctx, db, zst, sqliteinit.BackupOptions{}); err == nil || !strings.Contains(err.Error(), "sqliteinitzstd") {
+		t.Errorf("expected an unregistered zstd codec to be reported, got %v", err)
+	}
+	assertNoDatabaseFiles(t, zst)
+	assertNoDatabaseFiles(t, filepath.Join(dir, "backup.db.tmp"))
+}
+
+// TestOpenDB tests the reader/writer split handle.
+func TestOpenDB(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "test.db")
+
+	cfg := sqliteinit.Config{Path: path, Migrations: validMigrations()}
+	if err := sqliteinit.Create(ctx, cfg); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	db, err := sqliteinit.OpenDB(ctx, cfg)
+	if err != nil {
+		t.Fatalf("OpenDB failed: %v", err)
+	}
+	defer db.Close()
+
+	err = db.WriteTx(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `INSERT INTO users (email, name, created_at) VALUES ('a@example.com', 'A', 0)`)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("WriteTx failed: %v", err)
+	}
+
+	var count int
+	err = db.ReadTx(ctx, func(tx *sql.Tx) error {
+		return tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM users`).Scan(&count)
+	})
+	if err != nil {
+		t.Fatalf("ReadTx failed: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("expected 1 user, got %d", count)
+	}
+
+	// The read pool rejects writes
+	if _, err := db.Reader.ExecContext(ctx, `DELETE FROM users`); err == nil {
+		t.Error("expected write on read pool to fail")
+	}
+}
+
+// TestImportDump_MySQL tests importing a mysqldump file.
+func TestImportDump_MySQL(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := sqliteinit.Open(ctx, sqliteinit.Config{Path: ":memory:"})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+
+	f, err := os.Open("testdata/dumps/mysql.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	report, err := sqliteinit.ImportDump(ctx, db, f, sqliteinit.ImportOptions{Dialect: sqliteinit.DialectMySQL})
+	if err != nil {
+		t.Fatalf("ImportDump failed: %v", err)
+	}
+	if len(report.Untranslatable) != 1 {
+		t.Errorf("expected 1 untranslatable statement (procedure), got %+v", report.Untranslatable)
+	}
+
+	var email string
+	if err := db.QueryRowContext(ctx, `SELECT email FROM users WHERE id = 1`).Scan(&email); err != nil {
+		t.Fatalf("query users: %v", err)
+	}
+	if email != "o'brien@example.com" {
+		t.Errorf("expected unescaped email, got %q", email)
+	}
+
+	// AUTO_INCREMENT columns become rowid aliases
+	if _, err := db.ExecContext(ctx, `INSERT INTO users (email) VALUES ('new@example.com')`); err != nil {
+		t.Fatalf("insert without id: %v", err)
+	}
+}
+
+// TestImportDump_Postgres tests importing a pg_dump file with COPY blocks.
+func TestImportDump_Postgres(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := sqliteinit.Open(ctx, sqliteinit.Config{Path: ":memory:"})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+
+	f, err := os.Open("testdata/dumps/postgres.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	report, err := sqliteinit.ImportDump(ctx, db, f, sqliteinit.ImportOptions{Dialect: sqliteinit.DialectPostgres})
+	if err != nil {
+		t.Fatalf("ImportDump failed: %v", err)
+	}
+	// The function and the ADD CONSTRAINT can't be translated
+	if len(report.Untranslatable) != 2 {
+		t.Errorf("expected 2 untranslatable statements, got %+v", report.Untranslatable)
+	}
+
+	var count int
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM users WHERE bio = 'likes'||char(9)||'tabs'`).Scan(&count); err != nil {
+		t.Fatalf("query users: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("expected COPY data with escapes to be imported, got %d rows", count)
+	}
+}
+
+// TestImportDump_PostgresLongRow tests that a COPY row longer than 64 MiB
+// is converted rather than silently truncating the dump.
+func TestImportDump_PostgresLongRow(t *testing.T) {
+	big := strings.Repeat("x", 65<<20)
+	dump := "COPY public.notes (id, body) FROM stdin;\n1\t" + big + "\n2\tshort\n\\.\n"
+	script, err := sqliteinit.PostgresPrepass(dump)
+	if err != nil {
+		t.Fatalf("prepass failed: %v", err)
+	}
+	if want := "INSERT INTO notes (id, body) VALUES ('2', 'short');"; !strings.Contains(script, want) || !strings.Contains(script, big) {
+		t.Errorf("expected both rows to be converted, got %d bytes", len(script))
+	}
+}
+
+// TestImportDump_Strict tests that strict mode fails on untranslatable statements.
+func TestImportDump_Strict(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := sqliteinit.Open(ctx, sqliteinit.Config{Path: ":memory:"})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+
+	f, err := os.Open("testdata/dumps/postgres.sql")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	_, err = sqliteinit.ImportDump(ctx, db, f, sqliteinit.ImportOptions{Dialect: sqliteinit.DialectPostgres, Strict: true})
+	if err == nil {
+		t.Fatal("expected strict import to fail")
+	}
+
+	// Nothing is committed when the import fails
+	var count int
+	db.QueryRowContext(ctx, `SELECT COUNT(*) FROM sqlite_master WHERE name = 'users'`).Scan(&count)
+	if count != 0 {
+		t.Error("expected failed import to be rolled back")
+	}
+}
+
+// TestOpenDB_Metadata tests the metadata recorded on the DB handle.
+func TestOpenDB_Metadata(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := sqliteinit.OpenDB(ctx, sqliteinit.Config{
+		Path:         ":memory:",
+		Migrations:   validMigrations(),
+		ExtraPragmas: []sqliteinit.Pragma{{Name: "cache_size", Value: "-4000"}},
+	})
+	if err != nil {
+		t.Fatalf("OpenDB failed: %v", err)
+	}
+	defer db.Close()
+
+	if db.Path() != ":memory:" {
+		t.Errorf("expected path :memory:, got %q", db.Path())
+	}
+	if db.SchemaVersion() != 20260101000002 {
+		t.Errorf("expected schema version 20260101000002, got %d", db.SchemaVersion())
+	}
+	if len(db.Applied()) != 3 {
+		t.Errorf("expected 3 applied migrations, got %d", len(db.Applied()))
+	}
+	found := false
+	for _, p := range db.Pragmas() {
+		if p.Name == "cache_size" && p.Value == "-4000" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("expected cache_size in pragmas, got %v", db.Pragmas())
+	}
+
+	status, err := db.Status(ctx)
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	if len(status.Pending) != 0 {
+		t.Errorf("expected no pending migrations, got %v", status.Pending)
+	}
+}
+
+// TestOpenOrCreate tests creating a missing database and reopening it.
+func TestOpenOrCreate(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	cfg := sqliteinit.Config{
+		Path:       filepath.Join(dir, "test.db"),
+		Migrations: validMigrations(),
+	}
+
+	db, created, err := sqliteinit.OpenOrCreate(ctx, cfg)
+	if err != nil {
+		t.Fatalf("first OpenOrCreate failed: %v", err)
+	}
+	db.Close()
+	if !created {
+		t.Error("expected created=true for missing file")
+	}
+
+	db, created, err = sqliteinit.OpenOrCreate(ctx, cfg)
+	if err != nil {
+		t.Fatalf("second OpenOrCreate failed: %v", err)
+	}
+	db.Close()
+	if created {
+		t.Error("expected created=false for existing file")
+	}
+
+	// Path validation still applies
+	if _, _, err := sqliteinit.OpenOrCreate(ctx, sqliteinit.Config{Path: "relative.db"}); err == nil {
+		t.Error("expected error for relative path")
+	}
+}
+
+// TestOpenOrCreate_DirMode tests that OpenOrCreate makes a missing parent
+// directory with DirMode, and fails without it.
+func TestOpenOrCreate_DirMode(t *testing.T) {
+	ctx := context.Background()
+	dir := filepath.Join(t.TempDir(), "data", "app")
+	cfg := sqliteinit.Config{Path: filepath.Join(dir, "app.db"), Migrations: validMigrations()}
+
+	if _, _, err := sqliteinit.OpenOrCreate(ctx, cfg); err == nil {
+		t.Fatal("expected OpenOrCreate to fail without the directory")
+	}
+
+	cfg.DirMode = 0o750
+	db, created, err := sqliteinit.OpenOrCreate(ctx, cfg)
+	if err != nil {
+		t.Fatalf("OpenOrCreate failed: %v", err)
+	}
+	db.Close()
+	if !created {
+		t.Error("expected created=true for missing file")
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		t.Errorf("expected %s to be created, got %v", dir, err)
+	}
+}
+
+// TestCheckModel tests comparing Go structs against the live schema.
+func TestCheckModel(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := sqliteinit.Open(ctx, sqliteinit.Config{
+		Path:       ":memory:",
+		Migrations: validMigrations(),
+	})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+
+	type timestamps struct {
+		CreatedAt int64 `db:"created_at"`
+	}
+	type goodUser struct {
+		ID    int64  `db:"id"`
+		Email string `db:"email"`
+		Name  string `db:"name"`
+		timestamps
+		Cache string `db:"-"`
+	}
+	mismatches, err := sqliteinit.CheckModel(ctx, db, "users", goodUser{})
+	if err != nil {
+		t.Fatalf("CheckModel failed: %v", err)
+	}
+	if len(mismatches) != 0 {
+		t.Errorf("expected no mismatches, got %v", mismatches)
+	}
+
+	type staleUser struct {
+		ID       int64  `db:"id"`
+		Email    int64  `db:"email"`
+		Nickname string `db:"nickname"`
+	}
+	mismatches, err = sqliteinit.CheckModel(ctx, db, "users", &staleUser{})
+	if err != nil {
+		t.Fatalf("CheckModel failed: %v", err)
+	}
+	if len(mismatches) != 2 {
+		t.Errorf("expected 2 mismatches (type, missing column), got %v", mismatches)
+	}
+
+	if _, err := sqliteinit.CheckModel(ctx, db, "nope", goodUser{}); err == nil {
+		t.Error("expected error for missing table")
+	}
+}
+
+// TestOpen_FileURI tests that file: URI paths with query parameters are accepted.
+func TestOpen_FileURI(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "test.db")
+
+	err := sqliteinit.Create(ctx, sqliteinit.Config{
+		Path:       "file:" + path + "?mode=rwc",
+		Migrations: validMigrations(),
+	})
+	if err != nil {
+		t.Fatalf("Create with URI failed: %v", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("database file should exist: %v", err)
+	}
+
+	db, err := sqliteinit.Open(ctx, sqliteinit.Config{
+		Path:       "file://" + path + "?mode=ro",
+		Migrations: validMigrations(),
+	})
+	if err != nil {
+		t.Fatalf("Open with read-only URI failed: %v", err)
+	}
+	defer db.Close()
+
+	if _, err := db.ExecContext(ctx, `INSERT INTO users (email, name, created_at) VALUES ('a@example.com', 'A', 0)`); err == nil {
+		t.Error("expected write to fail with mode=ro")
+	}
+
+	// URI file names are validated like plain paths
+	if _, err := sqliteinit.Open(ctx, sqliteinit.Config{Path: "file:relative.db?mode=rwc"}); err == nil {
+		t.Error("expected error for relative URI path")
+	}
+}
+
+// TestOpen_NamedMemoryURI tests opening a named shared-cache in-memory database.
+func TestOpen_NamedMemoryURI(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := sqliteinit.Open(ctx, sqliteinit.Config{
+		Path:       "file:named_memdb?mode=memory&cache=shared",
+		Migrations: validMigrations(),
+	})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+
+	var count int
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM schema_migrations`).Scan(&count); err != nil {
+		t.Fatalf("query migrations: %v", err)
+	}
+	if count != 3 {
+		t.Errorf("expected 3 migrations, got %d", count)
+	}
+}
+
+// TestGenerateGo tests generating Go constants and structs from the schema.
+func TestGenerateGo(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := sqliteinit.Open(ctx, sqliteinit.Config{
+		Path:       ":memory:",
+		Migrations: validMigrations(),
+	})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+
+	var buf bytes.Buffer
+	err = sqliteinit.GenerateGo(ctx, db, &buf, sqliteinit.CodegenOptions{Package: "store", Structs: true})
+	if err != nil {
+		t.Fatalf("GenerateGo failed: %v", err)
+	}
+
+	src := buf.String()
+	for _, want := range []string{
+		"package store",
+		`TableUsers = "users"`,
+		`PostsUserID    = "user_id"`,
+		"type PostsRow struct",
+		"CreatedAt int64  `db:\"created_at\"`",
+	} {
+		if !strings.Contains(src, want) {
+			t.Errorf("generated source missing %q:\n%s", want, src)
+		}
+	}
+	if strings.Contains(src, "SchemaMigrations") {
+		t.Error("internal tables should be excluded by default")
+	}
+}
+
+// TestCheckQueryPlans tests golden-file query plan regression checks.
+func TestCheckQueryPlans(t *testing.T) {
+	ctx := context.Background()
+	golden := filepath.Join(t.TempDir(), "plans.golden")
+
+	db, err := sqliteinit.Open(ctx, sqliteinit.Config{
+		Path:       ":memory:",
+		Migrations: validMigrations(),
+	})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+
+	queries := []sqliteinit.NamedQuery{
+		{Name: "user_by_email", Query: `SELECT id FROM users WHERE email = ?`, Args: []any{"a@example.com"}},
+		{Name: "posts_by_user", Query: `SELECT id FROM posts WHERE user_id = ?`, Args: []any{1}},
+	}
+
+	if err := sqliteinit.CheckQueryPlans(ctx, db, queries, golden, false); err == nil {
+		t.Fatal("expected error for missing golden file")
+	}
+	if err := sqliteinit.CheckQueryPlans(ctx, db, queries, golden, true); err != nil {
+		t.Fatalf("update golden failed: %v", err)
+	}
+	if err := sqliteinit.CheckQueryPlans(ctx, db, queries, golden, false); err != nil {
+		t.Fatalf("plans should match golden: %v", err)
+	}
+
+	// Adding an index changes the posts_by_user plan
+	if _, err := db.ExecContext(ctx, `CREATE INDEX posts_user_id ON posts (user_id)`); err != nil {
+		t.Fatalf("create index: %v", err)
+	}
+	err = sqliteinit.CheckQueryPlans(ctx, db, queries, golden, false)
+	if err == nil || !strings.Contains(err.Error(), "posts_by_user: plan changed") {
+		t.Fatalf("expected plan change for posts_by_user, got %v", err)
+	}
+}
+
+// recordingDriver wraps a Driver and records the DSNs it builds.
+type recordingDriver struct {
+	sqliteinit.Driver
+	dsns []string
+}
+
+func (d *recordingDriver) BuildDSN(path string, pragmas []sqliteinit.Pragma) (string, []sqliteinit.Pragma) {
+	dsn, post := d.Driver.BuildDSN(path, pragmas)
+	d.dsns = append(d.dsns, dsn)
+	return dsn, post
+}
+
+// TestOpen_Driver tests selecting a driver at runtime via Config.Driver.
+func TestOpen_Driver(t *testing.T) {
+	ctx := context.Background()
+	drv := &recordingDriver{Driver: sqliteinit.Modernc}
+
+	db, err := sqliteinit.Open(ctx, sqliteinit.Config{Path: ":memory:", Driver: drv})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	db.Close()
+
+	if len(drv.dsns) != 1 || !strings.Contains(drv.dsns[0], "_pragma=foreign_keys(ON)") {
+		t.Errorf("expected a modernc DSN, got %v", drv.dsns)
+	}
+}
+
+// TestMattnDriver_BuildDSN tests the mattn DSN dialect.
+func TestMattnDriver_BuildDSN(t *testing.T) {
+	dsn, post := sqliteinit.Mattn.BuildDSN("/data/app.db", []sqliteinit.Pragma{
+		{Name: "foreign_keys", Value: "1"},
+		{Name: "journal_mode", Value: "WAL"},
+		{Name: "mmap_size", Value: "268435456"},
+	})
+	if dsn != "file:/data/app.db?_foreign_keys=1&_journal_mode=WAL" {
+		t.Errorf("unexpected DSN %q", dsn)
+	}
+	if len(post) != 1 || post[0].Name != "mmap_size" {
+		t.Errorf("expected mmap_size to be applied after open, got %v", post)
+	}
+	if sqliteinit.Mattn.Name() != "sqlite3" {
+		t.Errorf("expected driver name sqlite3, got %q", sqliteinit.Mattn.Name())
+	}
+}
+
+// TestSnapshotCatalog tests taking snapshots and opening them by time.
+func TestSnapshotCatalog(t *testing.T) {
+	ctx := context.Background()
+	catalog := &sqliteinit.SnapshotCatalog{Dir: t.TempDir(), Retain: 2}
+
+	db, err := sqliteinit.Open(ctx, sqliteinit.Config{
+		Path:       ":memory:",
+		Migrations: validMigrations(),
+	})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+
+	before := time.Now().Add(-time.Hour)
+	var snaps []sqliteinit.Snapshot
+	for i := 0; i < 3; i++ {
+		if _, err := db.ExecContext(ctx, `INSERT INTO users (email, name, created_at) VALUES (?, 'u', 0)`, fmt.Sprintf("u%d@example.com", i)); err != nil {
+			t.Fatalf("insert: %v", err)
+		}
+		snap, err := catalog.Take(ctx, db)
+		if err != nil {
+			t.Fatalf("Take failed: %v", err)
+		}
+		snaps = append(snaps, snap)
+	}
+
+	list, err := catalog.List()
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(list) != 2 {
+		t.Fatalf("expected 2 retained snapshots, got %d", len(list))
+	}
+
+	// The snapshot as of the second Take has two users
+	asOf, snap, err := catalog.OpenAsOf(ctx, snaps[1].Taken)
+	if err != nil {
+		t.Fatalf("OpenAsOf failed: %v", err)
+	}
+	defer asOf.Close()
+	if snap.Path != snaps[1].Path {
+		t.Errorf("expected snapshot %s, got %s", snaps[1].Path, snap.Path)
+	}
+	var count int
+	if err := asOf.QueryRowContext(ctx, `SELECT COUNT(*) FROM users`).Scan(&count); err != nil {
+		t.Fatalf("query snapshot: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("expected 2 users in snapshot, got %d", count)
+	}
+
+	if _, _, err := catalog.OpenAsOf(ctx, before); !errors.Is(err, sqliteinit.ErrNoSnapshot) {
+		t.Errorf("expected ErrNoSnapshot, got %v", err)
+	}
+}
+
+// TestStandby tests copying a standby and promoting it.
+func TestStandby(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	cfg := sqliteinit.Config{
+		Path:       filepath.Join(dir, "primary.db"),
+		Migrations: validMigrations(),
+	}
+
+	db, _, err := sqliteinit.OpenOrCreate(ctx, cfg)
+	if err != nil {
+		t.Fatalf("OpenOrCreate failed: %v", err)
+	}
+	defer db.Close()
+
+	r, err := sqliteinit.NewStandby(db, sqliteinit.StandbyConfig{
+		StandbyPath: filepath.Join(dir, "standby.db"),
+		Interval:    time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("NewStandby failed: %v", err)
+	}
+
+	if _, err := db.ExecContext(ctx, `INSERT INTO users (email, name, created_at) VALUES ('a@example.com', 'a', 0)`); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	if err := r.CopyNow(ctx); err != nil {
+		t.Fatalf("CopyNow failed: %v", err)
+	}
+	if r.LastCopy().IsZero() {
+		t.Error("expected LastCopy to be set")
+	}
+
+	// Changes after the last copy are picked up by the final copy in Promote
+	if _, err := db.ExecContext(ctx, `INSERT INTO users (email, name, created_at) VALUES ('b@example.com', 'b', 0)`); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+
+	promoted, err := r.Promote(ctx, cfg)
+	if err != nil {
+		t.Fatalf("Promote failed: %v", err)
+	}
+	defer promoted.Close()
+
+	var count int
+	if err := promoted.QueryRowContext(ctx, `SELECT COUNT(*) FROM users`).Scan(&count); err != nil {
+		t.Fatalf("query standby: %v", err)
+	}
+	if count != 2 {
+		t.Errorf("expected 2 users on promoted standby, got %d", count)
+	}
+
+	if err := r.CopyNow(ctx); !errors.Is(err, sqliteinit.ErrPromoted) {
+		t.Errorf("expected ErrPromoted after Promote, got %v", err)
+	}
+}
+
+// TestBundle tests bundling a database and restoring it elsewhere.
+func TestBundle(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	cfg := sqliteinit.Config{
+		Path:       filepath.Join(dir, "app.db"),
+		Migrations: validMigrations(),
+		AppVersion: "1.2.3",
+	}
+
+	db, _, err := sqliteinit.OpenOrCreate(ctx, cfg)
+	if err != nil {
+		t.Fatalf("OpenOrCreate failed: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, `INSERT INTO users (email, name, created_at) VALUES ('a@example.com', 'a', 0)`); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	db.Close()
+
+	var buf bytes.Buffer
+	if err := sqliteinit.Bundle(ctx, cfg, &buf); err != nil {
+		t.Fatalf("Bundle failed: %v", err)
+	}
+
+	restoreCfg := cfg
+	restoreCfg.Path = filepath.Join(dir, "restored.db")
+	manifest, err := sqliteinit.Unbundle(ctx, bytes.NewReader(buf.Bytes()), restoreCfg)
+	if err != nil {
+		t.Fatalf("Unbundle failed: %v", err)
+	}
+	if manifest.SchemaVersion != 20260101000002 || manifest.Migrations != 3 {
+		t.Errorf("unexpected manifest: %+v", manifest)
+	}
+
+	restored, err := sqliteinit.Open(ctx, restoreCfg)
+	if err != nil {
+		t.Fatalf("Open restored failed: %v", err)
+	}
+	defer restored.Close()
+
+	var email, appVersion string
+	if err := restored.QueryRowContext(ctx, `SELECT email FROM users`).Scan(&email); err != nil {
+		t.Fatalf("query users: %v", err)
+	}
+	if err := restored.QueryRowContext(ctx, `SELECT value FROM config WHERE key = 'app.version'`).Scan(&appVersion); err != nil {
+		t.Fatalf("query config: %v", err)
+	}
+	if email != "a@example.com" || appVersion != "1.2.3" {
+		t.Errorf("restored email=%q app.version=%q", email, appVersion)
+	}
+
+	// Restoring over an existing file fails
+	if _, err := sqliteinit.Unbundle(ctx, bytes.NewReader(buf.Bytes()), restoreCfg); err == nil {
+		t.Error("expected error restoring over existing file")
+	}
+}
+
+// TestOpenDB_IdleClose tests that the file is closed while idle and
+// reopened on next use.
+func TestOpenDB_IdleClose(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "test.db")
+	if err := sqliteinit.Create(ctx, sqliteinit.Config{Path: path}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	db, err := sqliteinit.OpenDB(ctx, sqliteinit.Config{
+		Path:       path,
+		Migrations: validMigrations(),
+		IdleClose:  50 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatalf("OpenDB failed: %v", err)
+	}
+	defer db.Close()
+
+	err = db.WriteTx(ctx, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `INSERT INTO users (email, name, created_at) VALUES ('a@example.com', 'a', 0)`)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("WriteTx failed: %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for !db.Idle() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !db.Idle() {
+		t.Fatal("expected database to be closed while idle")
+	}
+	if _, err := os.Stat(path + "-wal"); !os.IsNotExist(err) {
+		t.Error("expected WAL file to be removed after idle close")
+	}
+
+	var count int
+	err = db.ReadTx(ctx, func(tx *sql.Tx) error {
+		return tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM users`).Scan(&count)
+	})
+	if err != nil {
+		t.Fatalf("ReadTx after idle failed: %v", err)
+	}
+	if count != 1 || db.Idle() {
+		t.Errorf("expected reopened database with 1 user, got count=%d idle=%v", count, db.Idle())
+	}
+
+	if _, err := sqliteinit.OpenDB(ctx, sqliteinit.Config{Path: ":memory:", IdleClose: time.Second}); err == nil {
+		t.Error("expected error for IdleClose on in-memory database")
+	}
+}
+
+// remoteStubDriver stands in for a libSQL driver by recording the DSN it is
+// given and opening an in-memory modernc database instead.
+type remoteStubDriver struct {
+	dsn *string
+}
+
+func (remoteStubDriver) Name() string                       { return "sqlite" }
+func (remoteStubDriver) MemoryPragmas() []sqliteinit.Pragma { return sqliteinit.LibSQL.MemoryPragmas() }
+func (remoteStubDriver) PersistentPragmas() []sqliteinit.Pragma {
+	return sqliteinit.LibSQL.PersistentPragmas()
+}
+func (d remoteStubDriver) BuildDSN(path string, pragmas []sqliteinit.Pragma) (string, []sqliteinit.Pragma) {
+	*d.dsn = path
+	return "file:remotestub?mode=memory&cache=shared", pragmas
+}
+
+// TestOpen_Remote tests that libSQL URLs are opened with the auth token
+// and migrated, and that the token is not logged.
+func TestOpen_Remote(t *testing.T) {
+	ctx := context.Background()
+	var dsn string
+	var logs bytes.Buffer
+
+	cfg := sqliteinit.Config{
+		Path:       "libsql://app-org.turso.io",
+		AuthToken:  "s3cret",
+		Migrations: validMigrations(),
+		Driver:     remoteStubDriver{dsn: &dsn},
+		Logger:     slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})),
+	}
+
+	db, err := sqliteinit.Open(ctx, cfg)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+
+	if dsn != "libsql://app-org.turso.io?authToken=s3cret" {
+		t.Errorf("unexpected DSN %q", dsn)
+	}
+	if strings.Contains(logs.String(), "s3cret") {
+		t.Error("auth token was logged")
+	}
+
+	var count int
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM schema_migrations`).Scan(&count); err != nil {
+		t.Fatalf("query migrations: %v", err)
+	}
+	if count == 0 {
+		t.Error("expected migrations to be applied")
+	}
+
+	if err := sqliteinit.Create(ctx, cfg); err == nil {
+		t.Error("expected Create to reject a remote path")
+	}
+	if err := sqliteinit.Delete(ctx, cfg.Path); err == nil {
+		t.Error("expected Delete to reject a remote path")
+	}
+}
+
+// TestWatcher tests that commits from another connection and file
+// replacement are detected.
+func TestWatcher(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	cfg := sqliteinit.Config{
+		Path:       filepath.Join(dir, "test.db"),
+		Migrations: validMigrations(),
+	}
+
+	db, _, err := sqliteinit.OpenOrCreate(ctx, cfg)
+	if err != nil {
+		t.Fatalf("OpenOrCreate failed: %v", err)
+	}
+	defer db.Close()
+
+	var events []sqliteinit.ChangeEvent
+	w, err := sqliteinit.NewWatcher(ctx, cfg, sqliteinit.WatchConfig{
+		OnChange: func(e sqliteinit.ChangeEvent) { events = append(events, e) },
+	})
+	if err != nil {
+		t.Fatalf("NewWatcher failed: %v", err)
+	}
+	defer w.Close()
+
+	if changed, err := w.Check(ctx); err != nil || changed {
+		t.Fatalf("expected no change, got changed=%v err=%v", changed, err)
+	}
+
+	if _, err := db.ExecContext(ctx, `INSERT INTO users (email, name, created_at) VALUES ('a@example.com', 'a', 0)`); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	if changed, err := w.Check(ctx); err != nil || !changed {
+		t.Fatalf("expected change after insert, got changed=%v err=%v", changed, err)
+	}
+
+	// Replace the file with a fresh database
+	other := filepath.Join(dir, "other.db")
+	if err := sqliteinit.Create(ctx, sqliteinit.Config{Path: other}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if err := os.Rename(other, cfg.Path); err != nil {
+		t.Fatalf("rename: %v", err)
+	}
+	if changed, err := w.Check(ctx); err != nil || !changed {
+		t.Fatalf("expected change after replace, got changed=%v err=%v", changed, err)
+	}
+
+	if len(events) != 2 || events[0].Replaced || !events[1].Replaced {
+		t.Errorf("unexpected events: %+v", events)
+	}
+}
+
+// postPragmaDriver is Modernc, except that it runs cache_size after
+// opening a connection, as drivers do with pragmas they can't put in
+// their DSN.
+type postPragmaDriver struct{}
+
+func (postPragmaDriver) Name() string { return sqliteinit.Modernc.Name() }
+func (postPragmaDriver) MemoryPragmas() []sqliteinit.Pragma {
+	return sqliteinit.Modernc.MemoryPragmas()
+}
+func (postPragmaDriver) PersistentPragmas() []sqliteinit.Pragma {
+	return sqliteinit.Modernc.PersistentPragmas()
+}
+func (postPragmaDriver) BuildDSN(path string, pragmas []sqliteinit.Pragma) (string, []sqliteinit.Pragma) {
+	var in, post []sqliteinit.Pragma
+	for _, p := range pragmas {
+		if p.Name == "cache_size" {
+			post = append(post, p)
+		} else {
+			in = append(in, p)
+		}
+	}
+	dsn, more := sqliteinit.Modernc.BuildDSN(path, in)
+	return dsn, append(post, more...)
+}
+
+// TestOpenDB_PostPragmas tests that post pragmas run on every connection
+// of the read pool, not just the first.
+func TestOpenDB_PostPragmas(t *testing.T) {
+	ctx := context.Background()
+	cfg := sqliteinit.Config{Path: filepath.Join(t.TempDir(), "app.db"), Migrations: validMigrations(), Driver: postPragmaDriver{}, CacheSizeKB: 1234, ReadConns: 3}
+	if err := sqliteinit.Create(ctx, cfg); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	db, err := sqliteinit.OpenDB(ctx, cfg)
+	if err != nil {
+		t.Fatalf("OpenDB failed: %v", err)
+	}
+	defer db.Close()
+
+	for i := range cfg.ReadConns {
+		conn, err := db.Reader.Conn(ctx) // held, so each is a new connection
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		var cacheSize int
+		if err := conn.QueryRowContext(ctx, `PRAGMA cache_size`).Scan(&cacheSize); err != nil {
+			t.Fatal(err)
+		}
+		if cacheSize != -1234 {
+			t.Errorf("reader connection %d: expected cache_size -1234, got %d", i, cacheSize)
+		}
+	}
+}
+
+// keyedStubDriver stands in for an encrypting driver. Its "key" records the
+// key in user_version so the test can observe that it ran on connect.
+type keyedStubDriver struct{}
+
+func (keyedStubDriver) Name() string                       { return sqliteinit.Modernc.Name() }
+func (keyedStubDriver) MemoryPragmas() []sqliteinit.Pragma { return sqliteinit.Modernc.MemoryPragmas() }
+func (keyedStubDriver) PersistentPragmas() []sqliteinit.Pragma {
+	return sqliteinit.Modernc.PersistentPragmas()
+}
+func (keyedStubDriver) BuildDSN(path string, pragmas []sqliteinit.Pragma) (string, []sqliteinit.Pragma) {
+	return sqliteinit.Modernc.BuildDSN(path, pragmas)
+}
+func (keyedStubDriver) KeyPragmas(key string) []sqliteinit.Pragma {
+	return []sqliteinit.Pragma{{Name: "user_version", Value: key}}
+}
+func (keyedStubDriver) RekeyPragmas(newKey string) []sqliteinit.Pragma {
+	return []sqliteinit.Pragma{{Name: "user_version", Value: newKey}}
+}
+
+// TestEncryptionKey tests that key pragmas run on connect, that drivers
+// without encryption support are rejected, and Rekey.
+func TestEncryptionKey(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "test.db")
+
+	if err := sqliteinit.Create(ctx, sqliteinit.Config{Path: path, EncryptionKey: "7"}); err == nil {
+		t.Fatal("expected error for EncryptionKey with a driver lacking encryption support")
+	}
+
+	cfg := sqliteinit.Config{Path: path, EncryptionKey: "7", Driver: keyedStubDriver{}}
+	if err := sqliteinit.Create(ctx, cfg); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if err := sqliteinit.Rekey(ctx, cfg, "9"); err != nil {
+		t.Fatalf("Rekey failed: %v", err)
+	}
+
+	// Open without the key to observe what the last connection wrote
+	db, err := sqliteinit.Open(ctx, sqliteinit.Config{Path: path})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+	var v int
+	if err := db.QueryRowContext(ctx, `PRAGMA user_version`).Scan(&v); err != nil {
+		t.Fatalf("user_version: %v", err)
+	}
+	if v != 9 {
+		t.Errorf("expected rekey to set 9, got %d", v)
+	}
+}
+
+// TestOpen_BusyRetry tests that migration waits out a lock held by another
+// connection longer than BusyTimeout.
+func TestOpen_BusyRetry(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "test.db")
+	if err := sqliteinit.Create(ctx, sqliteinit.Config{Path: path}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	locker, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatalf("sql.Open: %v", err)
+	}
+	defer locker.Close()
+	conn, err := locker.Conn(ctx)
+	if err != nil {
+		t.Fatalf("Conn: %v", err)
+	}
+	if _, err := conn.ExecContext(ctx, `BEGIN EXCLUSIVE`); err != nil {
+		t.Fatalf("BEGIN EXCLUSIVE: %v", err)
+	}
+	// Release the lock once Open reports that it is retrying.
+	release := sync.OnceFunc(func() {
+		conn.ExecContext(ctx, `COMMIT`)
+		conn.Close()
+	})
+
+	db, err := sqliteinit.Open(ctx, sqliteinit.Config{
+		Path:        path,
+		Migrations:  validMigrations(),
+		BusyTimeout: 10 * time.Millisecond,
+		Logger:      slog.New(cancelOnMessage{msg: "database busy, retrying", cancel: release}),
+	})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+
+	var busy int
+	if err := db.QueryRowContext(ctx, `PRAGMA busy_timeout`).Scan(&busy); err != nil {
+		t.Fatalf("busy_timeout: %v", err)
+	}
+	if busy != 10 {
+		t.Errorf("expected busy_timeout 10, got %d", busy)
+	}
+}
+
+// TestOpenDB_DevStrict tests that DevStrict rejects common misuse.
+func TestOpenDB_DevStrict(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	path := filepath.Join(t.TempDir(), "test.db")
+	if err := sqliteinit.Create(ctx, sqliteinit.Config{Path: path}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	db, err := sqliteinit.OpenDB(ctx, sqliteinit.Config{
+		Path:       path,
+		Migrations: validMigrations(),
+		DevStrict:  true,
+	})
+	if err != nil {
+		t.Fatalf("OpenDB failed: %v", err)
+	}
+	defer db.Close()
+
+	insert := `INSERT INTO users (email, name, created_at) VALUES ('a@example.com', 'a', 0)`
+	if _, err := db.Writer.ExecContext(ctx, insert); err != nil {
+		t.Fatalf("expected insert with context to succeed: %v", err)
+	}
+	if _, err := db.Writer.ExecContext(ctx, `CREATE TEMP TABLE scratch (x)`); err != nil {
+		t.Fatalf("expected temp table to be allowed: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		run  func() error
+	}{
+		{"write on reader", func() error {
+			_, err := db.Reader.ExecContext(ctx, insert)
+			return err
+		}},
+		{"no context", func() error {
+			_, err := db.Writer.Exec(`SELECT 1`)
+			return err
+		}},
+		{"schema change", func() error {
+			_, err := db.Writer.ExecContext(ctx, `CREATE TABLE extra (id INTEGER)`)
+			return err
+		}},
+		{"cross-goroutine tx", func() error {
+			tx, err := db.Writer.BeginTx(ctx, nil)
+			if err != nil {
+				return err
+			}
+			defer tx.Rollback()
+			errc := make(chan error, 1)
+			go func() {
+				_, err := tx.ExecContext(ctx, `SELECT 1`)
+				errc <- err
+			}()
+			return <-errc
+		}},
+	}
+	for _, tt := range tests {
+		if err := tt.run(); !errors.Is(err, sqliteinit.ErrMisuse) {
+			t.Errorf("%s: expected ErrMisuse, got %v", tt.name, err)
+		}
+	}
+}
+
+// TestOpen_WALControls tests that the WAL tuning fields become pragmas on
+// persistent databases.
+func TestOpen_WALControls(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "test.db")
+	cfg := sqliteinit.Config{
+		Path:              path,
+		WALAutocheckpoint: 500,
+		JournalSizeLimit:  64 << 20,
+	}
+
+	db, _, err := sqliteinit.OpenOrCreate(ctx, cfg)
+	if err != nil {
+		t.Fatalf("OpenOrCreate failed: %v", err)
+	}
+	defer db.Close()
+
+	var autocheckpoint int
+	var limit int64
+	if err := db.QueryRowContext(ctx, `PRAGMA wal_autocheckpoint`).Scan(&autocheckpoint); err != nil {
+		t.Fatalf("wal_autocheckpoint: %v", err)
+	}
+	if err := db.QueryRowContext(ctx, `PRAGMA journal_size_limit`).Scan(&limit); err != nil {
+		t.Fatalf("journal_size_limit: %v", err)
+	}
+	if autocheckpoint != 500 || limit != 64<<20 {
+		t.Errorf("got wal_autocheckpoint=%d journal_size_limit=%d", autocheckpoint, limit)
+	}
+
+	// Overriding them through ExtraPragmas is a conflict
+	cfg.ExtraPragmas = []sqliteinit.Pragma{{Name: "wal_autocheckpoint", Value: "10"}}
+	if _, err := cfg.Pragmas(); err == nil {
+		t.Error("expected conflict between WALAutocheckpoint and ExtraPragmas")
+	}
+}
+
+// TestOpen_CacheAndMmap tests that CacheSizeKB and MmapSize become pragmas.
+func TestOpen_CacheAndMmap(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "test.db")
+
+	db, _, err := sqliteinit.OpenOrCreate(ctx, sqliteinit.Config{
+		Path:        path,
+		CacheSizeKB: 8192,
+		MmapSize:    1 << 20,
+	})
+	if err != nil {
+		t.Fatalf("OpenOrCreate failed: %v", err)
+	}
+	defer db.Close()
+
+	var cacheSize, mmapSize int64
+	if err := db.QueryRowContext(ctx, `PRAGMA cache_size`).Scan(&cacheSize); err != nil {
+		t.Fatalf("cache_size: %v", err)
+	}
+	if err := db.QueryRowContext(ctx, `PRAGMA mmap_size`).Scan(&mmapSize); err != nil {
+		t.Fatalf("mmap_size: %v", err)
+	}
+	if cacheSize != -8192 || mmapSize != 1<<20 {
+		t.Errorf("got cache_size=%d mmap_size=%d", cacheSize, mmapSize)
+	}
+
+	// mattn takes cache_size in the DSN and mmap_size after open
+	pragmas, err := sqliteinit.Config{Path: path, Driver: sqliteinit.Mattn, CacheSizeKB: 8192, MmapSize: 1 << 20}.Pragmas()
+	if err != nil {
+		t.Fatalf("Pragmas failed: %v", err)
+	}
+	dsn, post := sqliteinit.Mattn.BuildDSN(path, pragmas)
+	if !strings.Contains(dsn, "_cache_size=-8192") {
+		t.Errorf("expected cache_size in mattn DSN: %s", dsn)
+	}
+	if len(post) != 1 || post[0].Name != "mmap_size" {
+		t.Errorf("expected mmap_size as a post pragma, got %+v", post)
+	}
+}
+
+// cancelOnMessage is a slog handler that cancels a context when a record
+// with the given message is logged.
+type cancelOnMessage struct {
+	msg    string
+	cancel context.CancelFunc
+}
+
+func (h cancelOnMessage) Enabled(context.Context, slog.Level) bool { return true }
+func (h cancelOnMessage) WithAttrs([]slog.Attr) slog.Handler       { return h }
+func (h cancelOnMessage) WithGroup(string) slog.Handler            { return h }
+func (h cancelOnMessage) Handle(_ context.Context, r slog.Record) error {
+	if r.Message == h.msg {
+		h.cancel()
+	}
+	return nil
+}
+
+// assertNoDatabaseFiles fails the test if path or any sidecar exists.
+func assertNoDatabaseFiles(t *testing.T, path string) {
+	t.Helper()
+	for _, suffix := range []string{"", "-shm", "-wal", "-journal"} {
+		if _, err := os.Stat(path + suffix); !os.IsNotExist(err) {
+			t.Errorf("%s%s should not exist", path, suffix)
+		}
+	}
+}
+
+// TestCreate_Cancelled tests that a cancelled Create leaves no files behind.
+func TestCreate_Cancelled(t *testing.T) {
+	dir := t.TempDir()
+
+	// Cancelled before starting
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	path := filepath.Join(dir, "before.db")
+	if err := sqliteinit.Create(ctx, sqliteinit.Config{Path: path}); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	assertNoDatabaseFiles(t, path)
+
+	// Cancelled while applying migrations
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	path = filepath.Join(dir, "during.db")
+	err := sqliteinit.Create(ctx, sqliteinit.Config{
+		Path:       path,
+		Migrations: validMigrations(),
+		Logger:     slog.New(cancelOnMessage{msg: "applying migration", cancel: cancel}),
+	})
+	if err == nil {
+		t.Fatal("expected error from cancelled Create")
+	}
+	assertNoDatabaseFiles(t, path)
+
+	// Failed migration
+	path = filepath.Join(dir, "failed.db")
+	if err := sqliteinit.Create(context.Background(), sqliteinit.Config{Path: path, Migrations: invalidMigrations()}); err == nil {
+		t.Fatal("expected error from invalid migrations")
+	}
+	assertNoDatabaseFiles(t, path)
+}
+
+// TestDelete_Cancelled tests that a cancelled Delete leaves the file alone.
+func TestDelete_Cancelled(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	if err := sqliteinit.Create(context.Background(), sqliteinit.Config{Path: path}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := sqliteinit.Delete(ctx, path); !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("database should still exist: %v", err)
+	}
+}
+
+// TestOpen_StrictTables tests that migrations creating non-STRICT tables
+// are rejected and that NonStrictTables reports them.
+func TestOpen_StrictTables(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := sqliteinit.Open(ctx, sqliteinit.Config{
+		Path:         ":memory:",
+		Migrations:   strictMigrations(),
+		StrictTables: true,
+	})
+	if err != nil {
+		t.Fatalf("Open with STRICT migrations failed: %v", err)
+	}
+	tables, err := sqliteinit.NonStrictTables(ctx, db)
+	if err != nil {
+		t.Fatalf("NonStrictTables failed: %v", err)
+	}
+	if len(tables) != 0 {
+		t.Errorf("expected no non-STRICT tables, got %v", tables)
+	}
+	db.Close()
+
+	_, err = sqliteinit.Open(ctx, sqliteinit.Config{
+		Path:         ":memory:",
+		Migrations:   validMigrations(),
+		StrictTables: true,
+	})
+	if err == nil || !strings.Contains(err.Error(), "users") {
+		t.Errorf("expected error naming non-STRICT table users, got %v", err)
+	}
+
+	db, err = sqliteinit.Open(ctx, sqliteinit.Config{Path: ":memory:", Migrations: validMigrations()})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+	tables, err = sqliteinit.NonStrictTables(ctx, db)
+	if err != nil {
+		t.Fatalf("NonStrictTables failed: %v", err)
+	}
+	if strings.Join(tables, ",") != "posts,users" {
+		t.Errorf("expected posts,users, got %v", tables)
+	}
+
+	// Tables created before StrictTables was set are drift.
+	cfg := sqliteinit.Config{Path: filepath.Join(t.TempDir(), "app.db"), Migrations: validMigrations()}
+	if err := sqliteinit.Create(ctx, cfg); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	cfg.StrictTables = true
+	cfg.DetectDrift = sqliteinit.DriftFail
+	if _, err := sqliteinit.Open(ctx, cfg); !errors.Is(err, sqliteinit.ErrSchemaDrift) || !strings.Contains(err.Error(), "posts, users") {
+		t.Errorf("expected drift naming posts and users, got %v", err)
+	}
+	cfg.DetectDrift = sqliteinit.DriftWarn
+	db, err = sqliteinit.Open(ctx, cfg)
+	if err != nil {
+		t.Fatalf("Open with DriftWarn failed: %v", err)
+	}
+	db.Close()
+}
+
+// TestPathStyles tests path normalization, absolute path detection,
+// comparison and URI construction for each OS family.
+func TestPathStyles(t *testing.T) {
+	tests := []struct {
+		goos    string
+		path    string
+		want    string
+		abs     bool
+		wantURI string
+	}{
+		{"linux", "/data/./app.db", "/data/app.db", true, "file:/data/app.db"},
+		{"linux", "/data/x/../app.db", "/data/app.db", true, "file:/data/app.db"},
+		{"linux", "data/app.db", "data/app.db", false, ""},
+		{"linux", "/data/what?#%.db", "/data/what?#%.db", true, "file:/data/what%3f%23%25.db"},
+		{"darwin", "/Users/me/app.db", "/Users/me/app.db", true, "file:/Users/me/app.db"},
+		{"windows", `c:\data\app.db`, `C:\data\app.db`, true, "file:///C:/data/app.db"},
+		{"windows", `C:/data/sub/../app.db`, `C:\data\app.db`, true, "file:///C:/data/app.db"},
+		{"windows", `/C:/data/app.db`, `C:\data\app.db`, true, "file:///C:/data/app.db"},
+		{"windows", `\\?\C:\data\app.db`, `C:\data\app.db`, true, "file:///C:/data/app.db"},
+		{"windows", `\\server\share\app.db`, `\\server\share\app.db`, true, "file:////server/share/app.db"},
+		{"windows", `\\?\UNC\server\share\app.db`, `\\server\share\app.db`, true, "file:////server/share/app.db"},
+		{"windows", `C:app.db`, `C:app.db`, false, ""},
+		{"windows", `\data\app.db`, `\data\app.db`, false, ""},
+		{"windows", `\\server`, `\\server`, false, ""},
+	}
+	for _, tt := range tests {
+		if got := sqliteinit.NormalizePathFor(tt.goos, tt.path); got != tt.want {
+			t.Errorf("%s normalize(%q) = %q, want %q", tt.goos, tt.path, got, tt.want)
+		}
+		if got := sqliteinit.IsAbsPathFor(tt.goos, tt.path); got != tt.abs {
+			t.Errorf("%s isAbs(%q) = %v, want %v", tt.goos, tt.path, got, tt.abs)
+		}
+		if tt.abs {
+			if got := sqliteinit.FileURIFor(tt.goos, tt.path); got != tt.wantURI {
+				t.Errorf("%s uri(%q) = %q, want %q", tt.goos, tt.path, got, tt.wantURI)
+			}
+		}
+	}
+
+	same := []struct {
+		goos string
+		a, b string
+		want bool
+	}{
+		{"linux", "/data/App.db", "/data/app.db", false},
+		{"darwin", "/data/App.db", "/data/app.db", true},
+		{"windows", `C:\Data\App.db`, `c:/data/app.db`, true},
+		{"windows", `\\?\C:\data\app.db`, `C:\data\app.db`, true},
+	}
+	for _, tt := range same {
+		if got := sqliteinit.SamePathFor(tt.goos, tt.a, tt.b); got != tt.want {
+			t.Errorf("%s same(%q, %q) = %v, want %v", tt.goos, tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+// TestMove tests moving a database with its WAL, and that a failed move
+// is undone.
+func TestMove(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	from := filepath.Join(dir, "from.db")
+	to := filepath.Join(dir, "to.db")
+
+	db, _, err := sqliteinit.OpenOrCreate(ctx, sqliteinit.Config{Path: from, Migrations: validMigrations()})
+	if err != nil {
+		t.Fatalf("OpenOrCreate failed: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, `INSERT INTO users (email, name, created_at) VALUES ('a@example.com', 'a', 0)`); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	db.Close()
+
+	if err := sqliteinit.Move(ctx, from, to); err != nil {
+		t.Fatalf("Move failed: %v", err)
+	}
+	assertNoDatabaseFiles(t, from)
+
+	db, err = sqliteinit.Open(ctx, sqliteinit.Config{Path: to})
+	if err != nil {
+		t.Fatalf("Open after Move failed: %v", err)
+	}
+	defer db.Close()
+	var count int
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM users`).Scan(&count); err != nil || count != 1 {
+		t.Errorf("expected 1 user after move, got %d (%v)", count, err)
+	}
+
+	if err := sqliteinit.Create(ctx, sqliteinit.Config{Path: from}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if err := sqliteinit.Move(ctx, from, to); err == nil {
+		t.Error("expected error moving onto an existing database")
+	}
+
+	// If the database file can't be moved, the sidecars moved before it
+	// are moved back.
+	split := filepath.Join(dir, "split.db")
+	for _, suffix := range []string{"-wal", "-shm"} {
+		if err := os.WriteFile(from+suffix, []byte("sidecar"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	restore := sqliteinit.SetRename(func(oldPath, newPath string) error {
+		if oldPath == from {
+			return errors.New("simulated failure")
+		}
+		return os.Rename(oldPath, newPath)
+	})
+	err = sqliteinit.Move(ctx, from, split)
+	restore()
+	if err == nil || !strings.Contains(err.Error(), "simulated failure") {
+		t.Errorf("expected the simulated failure, got %v", err)
+	}
+	for _, suffix := range []string{"", "-wal", "-shm"} {
+		if _, err := os.Stat(from + suffix); err != nil {
+			t.Errorf("expected %s to be moved back: %v", from+suffix, err)
+		}
+	}
+	assertNoDatabaseFiles(t, split)
+}
+
+// TestConfig_Validate tests that Validate reports every problem at once.
+func TestConfig_Validate(t *testing.T) {
+	if err := (sqliteinit.Config{Path: ":memory:", Migrations: validMigrations()}).Validate(); err != nil {
+		t.Errorf("expected valid config, got %v", err)
+	}
+
+	err := sqliteinit.Config{
+		Path:         "relative/app.sqlite",
+		AuthToken:    "token",
+		QueryTimeout: -time.Second,
+		ExtraPragmas: []sqliteinit.Pragma{{Name: "journal_mode", Value: "DELETE"}},
+		Migrations:   invalidMigrations(),
+	}.Validate()
+	if err == nil {
+		t.Fatal("expected validation errors")
+	}
+	for _, want := range []string{"must be absolute", ".db extension", "AuthToken", "QueryTimeout", "ExtraPragmas"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in error:\n%v", want, err)
+		}
+	}
+
+	// The default BusyTimeout is not held against a short QueryTimeout,
+	// but one the caller set is.
+	if err := (sqliteinit.Config{Path: ":memory:", QueryTimeout: time.Second}).Validate(); err != nil {
+		t.Errorf("expected a short QueryTimeout alone to be valid, got %v", err)
+	}
+	if err := (sqliteinit.Config{Path: ":memory:", QueryTimeout: time.Second, BusyTimeout: 2 * time.Second}).Validate(); err == nil || !strings.Contains(err.Error(), "BusyTimeout") {
+		t.Errorf("expected BusyTimeout exceeding QueryTimeout to be reported, got %v", err)
+	}
+}
+
+// TestSymlinkPolicy tests ForbidSymlinks, ResolveSymlinks, and Delete
+// through a symlinked file.
+func TestSymlinkPolicy(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	realDir := filepath.Join(dir, "real")
+	linkDir := filepath.Join(dir, "link")
+	if err := os.Mkdir(realDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(realDir, linkDir); err != nil {
+		t.Skipf("symlinks not supported: %v", err)
+	}
+	viaLink := filepath.Join(linkDir, "app.db")
+
+	if err := sqliteinit.Create(ctx, sqliteinit.Config{Path: viaLink, ForbidSymlinks: true}); err == nil {
+		t.Error("expected ForbidSymlinks to reject a path through a symlink")
+	}
+
+	db, _, err := sqliteinit.OpenOrCreate(ctx, sqliteinit.Config{Path: viaLink, ResolveSymlinks: true})
+	if err != nil {
+		t.Fatalf("OpenOrCreate with ResolveSymlinks failed: %v", err)
+	}
+	var file string
+	if err := db.QueryRowContext(ctx, `SELECT file FROM pragma_database_list WHERE name = 'main'`).Scan(&file); err != nil {
+		t.Fatalf("database_list: %v", err)
+	}
+	db.Close()
+	realPath, _ := filepath.EvalSymlinks(filepath.Join(realDir, "app.db"))
+	if file != realPath {
+		t.Errorf("expected database opened at %s, got %s", realPath, file)
+	}
+
+	// Deleting through a symlinked file removes the real file and the link
+	fileLink := filepath.Join(dir, "alias.db")
+	if err := os.Symlink(filepath.Join(realDir, "app.db"), fileLink); err != nil {
+		t.Fatal(err)
+	}
+	if err := sqliteinit.Delete(ctx, fileLink); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	assertNoDatabaseFiles(t, filepath.Join(realDir, "app.db"))
+	if _, err := os.Lstat(fileLink); !os.IsNotExist(err) {
+		t.Error("expected symlink to be removed")
+	}
+}
+
+// TestFileMode tests that FileMode is applied to the database and its
+// sidecars despite the umask, and that FixPermissions repairs sidecars.
+func TestFileMode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Unix permission bits")
+	}
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "app.db")
+
+	db, _, err := sqliteinit.OpenOrCreate(ctx, sqliteinit.Config{Path: path, FileMode: 0o600})
+	if err != nil {
+		t.Fatalf("OpenOrCreate failed: %v", err)
+	}
+	defer db.Close()
+	for _, name := range []string{path, path + "-wal", path + "-shm"} {
+		info, err := os.Stat(name)
+		if err != nil {
+			t.Fatalf("stat %s: %v", name, err)
+		}
+		if got := info.Mode().Perm(); got != 0o600 {
+			t.Errorf("%s: expected mode 0600, got %04o", filepath.Base(name), got)
+		}
+	}
+
+	if err := os.Chmod(path+"-wal", 0o666); err != nil {
+		t.Fatal(err)
+	}
+	if err := sqliteinit.FixPermissions(ctx, path); err != nil {
+		t.Fatalf("FixPermissions failed: %v", err)
+	}
+	info, err := os.Stat(path + "-wal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := info.Mode().Perm(); got != 0o600 {
+		t.Errorf("expected repaired -wal mode 0600, got %04o", got)
+	}
+}
+
+// TestFileMode_Defaults tests that Create makes owner-only files without a
+// FileMode, and creates missing directories with DirMode, removing them if
+// it fails.
+func TestFileMode_Defaults(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Unix permission bits")
+	}
+	ctx := context.Background()
+	root := t.TempDir()
+	dir := filepath.Join(root, "var", "lib")
+	path := filepath.Join(dir, "app.db")
+
+	cfg := sqliteinit.Config{Path: path, Migrations: validMigrations()}
+	if err := sqliteinit.Create(ctx, cfg); err == nil {
+		t.Fatal("expected Create to fail without the directory")
+	}
+
+	// A failed Create removes the directories it made.
+	failing := sqliteinit.Config{Path: path, Migrations: invalidMigrations(), DirMode: 0o750}
+	if err := sqliteinit.Create(ctx, failing); err == nil {
+		t.Fatal("expected Create to fail with invalid migrations")
+	}
+	if _, err := os.Stat(filepath.Join(root, "var")); !os.IsNotExist(err) {
+		t.Errorf("expected the directories made by the failed Create to be removed, got %v", err)
+	}
+
+	cfg.DirMode = 0o750
+	if err := sqliteinit.Create(ctx, cfg); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	for _, d := range []string{filepath.Join(root, "var"), dir} {
+		info, err := os.Stat(d)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := info.Mode().Perm(); got != 0o750 {
+			t.Errorf("%s: expected mode 0750, got %04o", d, got)
+		}
+	}
+
+	db, err := sqliteinit.Open(ctx, sqliteinit.Config{Path: path, Migrations: validMigrations()})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+	for _, name := range []string{path, path + "-wal", path + "-shm"} {
+		info, err := os.Stat(name)
+		if err != nil {
+			t.Fatalf("stat %s: %v", name, err)
+		}
+		if got := info.Mode().Perm(); got != 0o600 {
+			t.Errorf("%s: expected mode 0600, got %04o", filepath.Base(name), got)
+		}
+	}
+}
+
+// TestOpen_MigrationBudget tests that deferrable migrations left over when
+// the budget is spent are applied in the background.
+func TestOpen_MigrationBudget(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := sqliteinit.Open(ctx, sqliteinit.Config{
+		Path:            ":memory:",
+		Migrations:      deferredMigrations(),
+		MigrationBudget: time.Nanosecond,
+	})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+
+	// The non-deferrable migration must be applied before Open returns
+	var tables int
+	if err := db.QueryRowContext(ctx, `SELECT count(*) FROM sqlite_master WHERE name = 'items'`).Scan(&tables); err != nil {
+		t.Fatal(err)
+	}
+	if tables != 1 {
+		t.Fatal("expected items table to exist when Open returns")
+	}
+
+	d := sqliteinit.Deferred(db)
+	if d == nil {
+		t.Fatal("expected deferred migrations")
+	}
+	if err := d.Wait(ctx); err != nil {
+		t.Fatalf("deferred migrations failed: %v", err)
+	}
+	if remaining := d.Remaining(); len(remaining) != 0 {
+		t.Errorf("expected no remaining migrations, got %v", remaining)
+	}
+
+	var rows int
+	if err := db.QueryRowContext(ctx, `SELECT count(*) FROM items`).Scan(&rows); err != nil {
+		t.Fatal(err)
+	}
+	if rows != 1000 {
+		t.Errorf("expected 1000 backfilled rows, got %d", rows)
+	}
+
+	// Closing the handle forgets its deferred migrations.
+	db.Close()
+	if sqliteinit.Deferred(db) != nil {
+		t.Error("expected no deferred migrations for a closed handle")
+	}
+
+	// Without a budget, everything is applied up front
+	path := filepath.Join(t.TempDir(), "app.db")
+	db2, _, err := sqliteinit.OpenOrCreate(ctx, sqliteinit.Config{Path: path, Migrations: deferredMigrations()})
+	if err != nil {
+		t.Fatalf("OpenOrCreate failed: %v", err)
+	}
+	defer db2.Close()
+	if sqliteinit.Deferred(db2) != nil {
+		t.Error("expected no deferred migrations without a budget")
+	}
+}
+
+// TestPathPolicy tests that a custom PathPolicy replaces the default path
+// rules in Create, Open and Validate.
+func TestPathPolicy(t *testing.T) {
+	ctx := context.Background()
+	dataDir := t.TempDir()
+	policy := sqliteinit.PathPolicyFunc(func(path string) error {
+		if !strings.HasPrefix(path, dataDir+string(filepath.Separator)) {
+			return fmt.Errorf("%s: must be under %s", path, dataDir)
+		}
+		if filepath.Ext(path) != ".sqlite3" {
+			return fmt.Errorf("%s: expected .sqlite3 extension", path)
+		}
+		return nil
+	})
+
+	path := filepath.Join(dataDir, "app.sqlite3")
+	if err := sqliteinit.Create(ctx, sqliteinit.Config{Path: path}); err == nil {
+		t.Error("expected default policy to reject .sqlite3")
+	}
+	if err := sqliteinit.Create(ctx, sqliteinit.Config{Path: path, PathPolicy: policy}); err != nil {
+		t.Fatalf("Create with custom policy failed: %v", err)
+	}
+	db, err := sqliteinit.Open(ctx, sqliteinit.Config{Path: path, PathPolicy: policy})
+	if err != nil {
+		t.Fatalf("Open with custom policy failed: %v", err)
+	}
+	db.Close()
+
+	outside := filepath.Join(t.TempDir(), "app.sqlite3")
+	if err := sqliteinit.Create(ctx, sqliteinit.Config{Path: outside, PathPolicy: policy}); err == nil {
+		t.Error("expected custom policy to reject a path outside the data directory")
+	}
+	err = sqliteinit.Config{Path: outside, PathPolicy: policy}.Validate()
+	if err == nil || !strings.Contains(err.Error(), "must be under") {
+		t.Errorf("expected Validate to report the policy violation, got %v", err)
+	}
+}
+
+// TestAllowedExtensions tests that AllowedExtensions replaces the .db
+// requirement, for Config.Files and the options of the other file
+// functions too.
+func TestAllowedExtensions(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "app.sqlite3")
+	cfg := sqliteinit.Config{Path: path, AllowedExtensions: []string{".sqlite3"}}
+
+	db, created, err := sqliteinit.OpenOrCreate(ctx, cfg)
+	if err != nil {
+		t.Fatalf("OpenOrCreate failed: %v", err)
+	}
+	db.Close()
+	if !created {
+		t.Error("expected database to be created")
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate failed: %v", err)
+	}
+
+	files := cfg.Files()
+	moved := filepath.Join(filepath.Dir(path), "moved.sqlite3")
+	if err := sqliteinit.Move(ctx, path, moved); err == nil {
+		t.Error("expected Move to reject .sqlite3 under the default policy")
+	}
+	if err := files.Move(ctx, path, moved); err != nil {
+		t.Fatalf("Move failed: %v", err)
+	}
+	clone := filepath.Join(filepath.Dir(path), "clone.sqlite3")
+	if err := files.Clone(ctx, moved, clone); err != nil {
+		t.Fatalf("Clone failed: %v", err)
+	}
+	if err := files.FixPermissions(ctx, clone); err != nil {
+		t.Fatalf("FixPermissions failed: %v", err)
+	}
+
+	db, err = sqliteinit.Open(ctx, sqliteinit.Config{Path: moved, AllowedExtensions: cfg.AllowedExtensions})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+	policy := sqliteinit.ExtensionPolicy(".sqlite3")
+	backup := filepath.Join(filepath.Dir(path), "backup.sqlite3")
+	if err := sqliteinit.Backup(ctx, db, backup, sqliteinit.BackupOptions{}); err == nil {
+		t.Error("expected Backup to reject .sqlite3 under the default policy")
+	}
+	if err := sqliteinit.Backup(ctx, db, backup, sqliteinit.BackupOptions{PathPolicy: policy}); err != nil {
+		t.Fatalf("Backup failed: %v", err)
+	}
+	snapshot := filepath.Join(filepath.Dir(path), "snapshot.sqlite3")
+	if err := sqliteinit.ExportSnapshot(ctx, db, snapshot, sqliteinit.SnapshotOptions{PathPolicy: policy}); err != nil {
+		t.Fatalf("ExportSnapshot failed: %v", err)
+	}
+	if _, err := sqliteinit.NewStandby(db, sqliteinit.StandbyConfig{StandbyPath: filepath.Join(filepath.Dir(path), "standby.sqlite3"), PathPolicy: policy}); err != nil {
+		t.Fatalf("NewStandby failed: %v", err)
+	}
+
+	if err := sqliteinit.Delete(ctx, clone); err == nil {
+		t.Error("expected Delete to reject .sqlite3 under the default policy")
+	}
+	if err := files.Delete(ctx, clone); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	assertNoDatabaseFiles(t, clone)
+}
+
+// TestRollbackPlan tests that rollback plans list down scripts in reverse
+// order and are written, with a snapshot, before migrations are applied.
+func TestRollbackPlan(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.db")
+	if err := sqliteinit.Create(ctx, sqliteinit.Config{Path: path}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	cfg := sqliteinit.Config{Path: path, Migrations: rollbackMigrations()}
+	plan, err := sqliteinit.PlanRollback(ctx, cfg)
+	if err != nil {
+		t.Fatalf("PlanRollback failed: %v", err)
+	}
+	if len(plan.Steps) != 2 || plan.Steps[0].Path != "20260101000002_price.sql" || plan.Steps[1].Path != "20260101000001_items.sql" {
+		t.Fatalf("expected steps in reverse order, got %+v", plan.Steps)
+	}
+	if plan.Steps[0].DownPath != "" || plan.Steps[1].DownPath != "20260101000001_items.down.sql" {
+		t.Errorf("unexpected down scripts: %+v", plan.Steps)
+	}
+	if plan.Reversible() {
+		t.Error("expected plan with a missing down script to be irreversible")
+	}
+
+	planDir := filepath.Join(dir, "plans")
+	snapDir := filepath.Join(dir, "snapshots")
+	for _, d := range []string{planDir, snapDir} {
+		if err := os.Mkdir(d, 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cfg.RollbackPlanDir = planDir
+	cfg.RollbackSnapshots = &sqliteinit.SnapshotCatalog{Dir: snapDir}
+	db, err := sqliteinit.Open(ctx, cfg)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	db.Close()
+
+	files, _ := filepath.Glob(filepath.Join(planDir, "rollback-*.sql"))
+	if len(files) != 1 {
+		t.Fatalf("expected one rollback plan file, got %v", files)
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"DROP TABLE items;", "Pre-migration snapshot: " + snapDir, "no down script"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("expected plan to contain %q:\n%s", want, data)
+		}
+	}
+
+	// The down script is not applied as a migration
+	status, err := sqliteinit.Status(ctx, sqliteinit.Config{Path: path, Migrations: rollbackMigrations()})
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	if len(status.Pending) != 0 || len(status.Applied) != 3 {
+		t.Errorf("expected 2 migrations applied after init, got %+v", status)
+	}
+}
+
+// extStubConn records the extensions it is asked to load. It accepts only
+// the entry point derived from the file name.
+type extStubConn struct {
+	driver.Conn
+	loaded *[]string
+}
+
+func (c extStubConn) LoadExtension(lib, entry string) error {
+	if entry != "sqlite3_modspatialite_init" {
+		return fmt.Errorf("%s: no entry point %s", lib, entry)
+	}
+	*c.loaded = append(*c.loaded, lib+":"+entry)
+	return nil
+}
+
+// extStubDriver wraps the modernc driver with extStubConn connections.
+type extStubDriver struct {
+	driver.Driver
+	loaded *[]string
+}
+
+func (d extStubDriver) Open(name string) (driver.Conn, error) {
+	conn, err := d.Driver.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return extStubConn{Conn: conn, loaded: d.loaded}, nil
+}
+
+var extStubLoaded []string
+
+func init() {
+	probe, _ := sql.Open("sqlite", ":memory:")
+	sql.Register("sqliteinit-extstub", extStubDriver{Driver: probe.Driver(), loaded: &extStubLoaded})
+	probe.Close()
+}
+
+// extStubDriverConfig names the extension stub driver for Config.Driver.
+type extStubDriverConfig struct{ sqliteinit.Driver }
+
+func (extStubDriverConfig) Name() string { return "sqliteinit-extstub" }
+
+// TestExtensions tests that extensions are loaded on every connection with
+// the derived entry point, and that drivers without support are rejected.
+func TestExtensions(t *testing.T) {
+	ctx := context.Background()
+	lib := filepath.Join(t.TempDir(), "mod_spatialite.so")
+
+	_, err := sqliteinit.Open(ctx, sqliteinit.Config{Path: ":memory:", Extensions: []string{lib}})
+	if err == nil || !strings.Contains(err.Error(), "does not support loading extensions") {
+		t.Fatalf("expected unsupported driver error, got %v", err)
+	}
+
+	extStubLoaded = nil
+	db, err := sqliteinit.Open(ctx, sqliteinit.Config{
+		Path:       ":memory:",
+		Driver:     extStubDriverConfig{sqliteinit.Modernc},
+		Extensions: []string{lib},
+	})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+	if len(extStubLoaded) == 0 || extStubLoaded[0] != lib+":sqlite3_modspatialite_init" {
+		t.Errorf("expected extension loaded with derived entry point, got %v", extStubLoaded)
+	}
+}
+
+// TestAccessedTables tests the SQL scan used by the access audit.
+func TestAccessedTables(t *testing.T) {
+	tests := []struct {
+		query         string
+		reads, writes []string
+	}{
+		{`SELECT * FROM users WHERE id = ?`, []string{"users"}, nil},
+		{`SELECT u.name FROM main.users AS u JOIN "posts" p ON p.user_id = u.id`, []string{"users", "posts"}, nil},
+		{`SELECT 1 FROM a x, b, [c d]`, []string{"a", "b", "c d"}, nil},
+		{`INSERT INTO logs (msg) SELECT msg FROM staging`, []string{"staging"}, []string{"logs"}},
+		{`INSERT INTO t (k) VALUES (1) ON CONFLICT (k) DO UPDATE SET n = n + 1`, nil, []string{"t"}},
+		{`UPDATE OR IGNORE users SET name = 'FROM x' WHERE id = 1`, nil, []string{"users"}},
+		{`DELETE FROM sessions WHERE id IN (SELECT id FROM expired)`, []string{"expired"}, []string{"sessions"}},
+		{`WITH r AS (SELECT id FROM src) DELETE FROM dst WHERE id IN r`, []string{"src"}, []string{"dst"}},
+		{`-- FROM comment
+		  SELECT count(*) FROM /* JOIN hidden */ items`, []string{"items"}, nil},
+	}
+	for _, tt := range tests {
+		reads, writes := sqliteinit.AccessedTables(tt.query)
+		if fmt.Sprint(reads) != fmt.Sprint(tt.reads) || fmt.Sprint(writes) != fmt.Sprint(tt.writes) {
+			t.Errorf("%s:\n got reads %v writes %v\nwant reads %v writes %v", tt.query, reads, writes, tt.reads, tt.writes)
+		}
+	}
+}
+
+// TestAccessAudit tests that sampled accesses are recorded through the
+// connector, flushed, and used to report untouched tables.
+func TestAccessAudit(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	audit := &sqliteinit.AccessAudit{SampleRate: 1}
+
+	db, err := sqliteinit.Open(ctx, sqliteinit.Config{
+		Path:       ":memory:",
+		Migrations: validMigrations(),
+		Audit:      audit,
+	})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+
+	if _, err := db.ExecContext(ctx, `INSERT INTO users (email, name, created_at) VALUES ('a@example.com', 'A', 0)`); err != nil {
+		t.Fatal(err)
+	}
+	var n int
+	if err := db.QueryRowContext(ctx, `SELECT count(*) FROM users`).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+
+	tables, err := audit.Tables(ctx, db)
+	if err != nil {
+		t.Fatalf("Tables failed: %v", err)
+	}
+	byName := make(map[string]sqliteinit.TableAccess)
+	for _, ta := range tables {
+		byName[ta.Table] = ta
+	}
+	if _, ok := byName["table_access"]; ok {
+		t.Error("expected the audit's own table to be omitted")
+	}
+	users := byName["users"]
+	if users.Reads != 1 || users.Writes != 1 || users.LastRead.IsZero() {
+		t.Errorf("unexpected users access: %+v", users)
+	}
+	if posts, ok := byName["posts"]; !ok || posts.Reads != 0 || posts.Writes != 0 {
+		t.Errorf("expected posts listed as never accessed, got %+v", posts)
+	}
+
+	// Nothing is untouched for a day when the audit just started
+	untouched, err := audit.Untouched(ctx, db, 24*time.Hour)
+	if err != nil {
+		t.Fatalf("Untouched failed: %v", err)
+	}
+	if len(untouched) != 0 {
+		t.Errorf("expected no untouched tables, got %v", untouched)
+	}
+	untouched, err = audit.Untouched(ctx, db, 0)
+	if err != nil {
+		t.Fatalf("Untouched failed: %v", err)
+	}
+	if !slices.Contains(untouched, "posts") {
+		t.Errorf("expected posts untouched, got %v", untouched)
+	}
+}
+
+// TestFindDeadObjects tests that tables and indexes unused by a query
+// corpus are reported and rendered as a drop migration.
+func TestFindDeadObjects(t *testing.T) {
+	ctx := context.Background()
+	db, err := sqliteinit.Open(ctx, sqliteinit.Config{Path: ":memory:", Migrations: validMigrations()})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+
+	for _, stmt := range []string{
+		`CREATE INDEX idx_posts_user ON posts (user_id)`,
+		`CREATE INDEX idx_posts_created ON posts (created_at)`,
+		`CREATE INDEX idx_users_name ON users (name)`,
+	} {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := sqliteinit.FindDeadObjects(ctx, db, sqliteinit.DeadObjectOptions{}); err == nil {
+		t.Error("expected error without evidence")
+	}
+
+	objects, err := sqliteinit.FindDeadObjects(ctx, db, sqliteinit.DeadObjectOptions{
+		Queries: []sqliteinit.NamedQuery{
+			{Name: "posts by user", Query: `SELECT title FROM posts WHERE user_id = ?`, Args: []any{1}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("FindDeadObjects failed: %v", err)
+	}
+	var got []string
+	for _, o := range objects {
+		got = append(got, o.Type+" "+o.Name)
+	}
+	want := []string{"table users", "index idx_posts_created"}
+	if !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	migration := sqliteinit.DropMigration(objects)
+	for _, s := range []string{`DROP TABLE IF EXISTS "users";`, `DROP INDEX IF EXISTS "idx_posts_created";`} {
+		if !strings.Contains(migration, s) {
+			t.Errorf("expected migration to contain %s:\n%s", s, migration)
+		}
+	}
+}
+
+// productAggregate multiplies its integer arguments.
+type productAggregate struct{ product int64 }
+
+func (a *productAggregate) Step(args []any) error {
+	a.product *= args[0].(int64)
+	return nil
+}
+
+func (a *productAggregate) Value() (any, error) { return a.product, nil }
+
+// TestFunctions tests that Go functions are available to migrations and
+// queries, and that drivers without support are rejected.
+func TestFunctions(t *testing.T) {
+	ctx := context.Background()
+	fns := []sqliteinit.Func{
+		{
+			Name: "regexp", NArgs: 2, Deterministic: true,
+			Scalar: func(args []any) (any, error) {
+				re, err := regexp.Compile(args[0].(string))
+				if err != nil {
+					return nil, err
+				}
+				return re.MatchString(fmt.Sprint(args[1])), nil
+			},
+		},
+		{
+			Name: "product", NArgs: 1,
+			NewAggregate: func() sqliteinit.Aggregate { return &productAggregate{product: 1} },
+		},
+	}
+
+	db, err := sqliteinit.Open(ctx, sqliteinit.Config{Path: ":memory:", Functions: fns})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+
+	var match bool
+	if err := db.QueryRowContext(ctx, `SELECT 'slug-123' REGEXP '^[a-z]+-[0-9]+$'`).Scan(&match); err != nil {
+		t.Fatalf("REGEXP failed: %v", err)
+	}
+	if !match {
+		t.Error("expected REGEXP to match")
+	}
+	var product int64
+	if err := db.QueryRowContext(ctx, `SELECT product(value) FROM json_each('[2, 3, 7]')`).Scan(&product); err != nil {
+		t.Fatalf("aggregate failed: %v", err)
+	}
+	if product != 42 {
+		t.Errorf("expected product 42, got %d", product)
+	}
+
+	_, err = sqliteinit.Open(ctx, sqliteinit.Config{
+		Path:      ":memory:",
+		Driver:    extStubDriverConfig{sqliteinit.Modernc},
+		Functions: fns,
+	})
+	if err == nil || !strings.Contains(err.Error(), "does not support custom functions") {
+		t.Errorf("expected unsupported driver error, got %v", err)
+	}
+}
+
+// TestAttach tests that secondary databases are attached to every
+// connection, including the read pool, and that ReadOnly is honored.
+func TestAttach(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	analytics := filepath.Join(dir, "analytics.db")
+	archive := filepath.Join(dir, "archive.db")
+	for _, p := range []string{analytics, archive} {
+		if err := sqliteinit.Create(ctx, sqliteinit.Config{Path: p}); err != nil {
+			t.Fatalf("Create %s failed: %v", p, err)
+		}
+	}
+
+	path := filepath.Join(dir, "app.db")
+	if err := sqliteinit.Create(ctx, sqliteinit.Config{Path: path}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	db, err := sqliteinit.OpenDB(ctx, sqliteinit.Config{
+		Path: path,
+		Attach: []sqliteinit.AttachSpec{
+			{Path: analytics, Alias: "analytics"},
+			{Path: archive, Alias: "archive", ReadOnly: true},
+		},
+	})
+	if err != nil {
+		t.Fatalf("OpenDB failed: %v", err)
+	}
+	defer db.Close()
+
+	err = db.WriteTx(ctx, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `CREATE TABLE analytics.events (name TEXT)`); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, `INSERT INTO analytics.events VALUES ('signup')`)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("write to attached database failed: %v", err)
+	}
+	err = db.ReadTx(ctx, func(tx *sql.Tx) error {
+		var name string
+		return tx.QueryRowContext(ctx, `SELECT name FROM analytics.events`).Scan(&name)
+	})
+	if err != nil {
+		t.Fatalf("read pool can't see attached database: %v", err)
+	}
+
+	if _, err := db.Writer.ExecContext(ctx, `CREATE TABLE archive.old (x)`); err == nil {
+		t.Error("expected write to read-only attached database to fail")
+	}
+
+	_, err = sqliteinit.Open(ctx, sqliteinit.Config{
+		Path:   ":memory:",
+		Attach: []sqliteinit.AttachSpec{{Path: analytics, Alias: "main"}},
+	})
+	if err == nil {
+		t.Error("expected reserved alias to be rejected")
+	}
+}
+
+// TestAdvancedSchema tests that the schema tooling models WITHOUT ROWID and
+// STRICT tables, generated columns, composite keys, and partial and
+// expression indexes.
+func TestAdvancedSchema(t *testing.T) {
+	ctx := context.Background()
+	db, err := sqliteinit.Open(ctx, sqliteinit.Config{Path: ":memory:", Migrations: advancedMigrations()})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+
+	t.Run("CheckModel", func(t *testing.T) {
+		type tag struct {
+			Slug  string         `db:"slug"`
+			Label sql.NullString `db:"label"`
+		}
+		type event struct {
+			ID      int64          `db:"id"`
+			Kind    string         `db:"kind"`
+			Payload []byte         `db:"payload"`
+			Day     sql.NullString `db:"day"`
+			Upper   sql.NullString `db:"upper"`
+		}
+		for table, model := range map[string]any{"tags": tag{}, "events": event{}} {
+			mismatches, err := sqliteinit.CheckModel(ctx, db, table, model)
+			if err != nil {
+				t.Fatalf("CheckModel %s failed: %v", table, err)
+			}
+			if len(mismatches) != 0 {
+				t.Errorf("%s: expected no mismatches, got %v", table, mismatches)
+			}
+		}
+
+		// Columns of a composite primary key are not the rowid.
+		type membership struct {
+			GroupID int64 `db:"group_id"`
+		}
+		mismatches, err := sqliteinit.CheckModel(ctx, db, "memberships", membership{})
+		if err != nil {
+			t.Fatalf("CheckModel failed: %v", err)
+		}
+		if len(mismatches) != 1 || !strings.Contains(mismatches[0].Problem, "nullable") {
+			t.Errorf("expected nullable mismatch for group_id, got %v", mismatches)
+		}
+	})
+
+	t.Run("GenerateGo", func(t *testing.T) {
+		var buf bytes.Buffer
+		if err := sqliteinit.GenerateGo(ctx, db, &buf, sqliteinit.CodegenOptions{Package: "store", Structs: true}); err != nil {
+			t.Fatalf("GenerateGo failed: %v", err)
+		}
+		src := buf.String()
+		for _, re := range []string{
+			`EventsDay\s+= "day"`,
+			`Day\s+sql.NullString\s+` + "`db:\"day\"`" + `\s+// generated \(virtual\)`,
+			`Upper\s+sql.NullString\s+` + "`db:\"upper\"`" + `\s+// generated \(stored\)`,
+			`Payload\s+any\s`,
+			`Slug\s+string\s`,
+			`GroupID\s+sql.NullInt64\s`,
+		} {
+			if !regexp.MustCompile(re).MatchString(src) {
+				t.Errorf("generated source does not match %s:\n%s", re, src)
+			}
+		}
+	})
+
+	t.Run("FindDeadObjects", func(t *testing.T) {
+		objects, err := sqliteinit.FindDeadObjects(ctx, db, sqliteinit.DeadObjectOptions{
+			Queries: []sqliteinit.NamedQuery{
+				{Name: "live events", Query: `SELECT id FROM events WHERE kind = ? AND deleted = 0`, Args: []any{"x"}},
+				{Name: "tags by label", Query: `SELECT slug FROM tags WHERE lower(label) = ?`, Args: []any{"x"}},
+			},
+		})
+		if err != nil {
+			t.Fatalf("FindDeadObjects failed: %v", err)
+		}
+		for _, o := range objects {
+			if o.Type == "index" {
+				t.Errorf("expected partial and expression indexes to be used, got %s %s", o.Name, o.Reason)
+			}
+		}
+	})
+}
+
+// TestAdoptForeign tests that a database created by something else is
+// opened read-only with AdoptForeign and initialized only by Adopt.
+func TestAdoptForeign(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "foreign.db")
+
+	raw, err := sql.Open("sqlite", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := raw.ExecContext(ctx, `CREATE TABLE legacy (id INTEGER PRIMARY KEY, note TEXT); INSERT INTO legacy (note) VALUES ('kept')`); err != nil {
+		t.Fatal(err)
+	}
+	raw.Close()
+
+	cfg := sqliteinit.Config{Path: path, Migrations: validMigrations(), AdoptForeign: true}
+	db, err := sqliteinit.Open(ctx, cfg)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	var note string
+	if err := db.QueryRowContext(ctx, `SELECT note FROM legacy`).Scan(&note); err != nil || note != "kept" {
+		t.Errorf("expected to read legacy table, got %q, %v", note, err)
+	}
+	if _, err := db.ExecContext(ctx, `INSERT INTO legacy (note) VALUES ('new')`); err == nil {
+		t.Error("expected write to foreign database to fail")
+	}
+	db.Close()
+
+	status, err := sqliteinit.Status(ctx, cfg)
+	if err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	if status.IsInitialized {
+		t.Error("expected foreign database to be left uninitialized")
+	}
+
+	if err := sqliteinit.Adopt(ctx, cfg); err != nil {
+		t.Fatalf("Adopt failed: %v", err)
+	}
+	if status, err = sqliteinit.Status(ctx, cfg); err != nil {
+		t.Fatalf("Status failed: %v", err)
+	}
+	if !status.IsInitialized || len(status.Pending) == 0 {
+		t.Errorf("expected initialized database with pending migrations, got %+v", status)
+	}
+
+	db, err = sqliteinit.Open(ctx, cfg)
+	if err != nil {
+		t.Fatalf("Open after Adopt failed: %v", err)
+	}
+	defer db.Close()
+	if _, err := db.ExecContext(ctx, `INSERT INTO users (email, name, created_at) VALUES ('a@example.com', 'A', 0)`); err != nil {
+		t.Errorf("expected migrated, writable database: %v", err)
+	}
+	if err := sqliteinit.Adopt(ctx, cfg); err != nil {
+		t.Errorf("Adopt of an initialized database failed: %v", err)
+	}
+
+	if err := (sqliteinit.Config{Path: ":memory:", AdoptForeign: true}).Validate(); err == nil {
+		t.Error("expected AdoptForeign on :memory: to be rejected")
+	}
+}
+
+// TestSimulateSkew tests the rolling-deploy simulation against releases
+// that can and can't be rolled back.
+func TestSimulateSkew(t *testing.T) {
+	ctx := context.Background()
+	file := func(sql string) *fstest.MapFile { return &fstest.MapFile{Data: []byte(sql)} }
+	v1 := fstest.MapFS{
+		"20260101000001_items.sql": file(`CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT NOT NULL);`),
+	}
+	v2 := fstest.MapFS{
+		"20260101000001_items.sql":      v1["20260101000001_items.sql"],
+		"20260101000002_price.sql":      file(`ALTER TABLE items ADD COLUMN price REAL NOT NULL DEFAULT 0;`),
+		"20260101000002_price.down.sql": file(`ALTER TABLE items DROP COLUMN price;`),
+	}
+
+	if err := sqliteinit.SimulateSkew(ctx, sqliteinit.SkewTest{Old: v1, New: v2, Dir: t.TempDir()}); err != nil {
+		t.Fatalf("SimulateSkew failed: %v", err)
+	}
+
+	for name, tc := range map[string]struct {
+		old, new fstest.MapFS
+		want     string
+	}{
+		"no down script": {
+			old: v1,
+			new: fstest.MapFS{
+				"20260101000001_items.sql": v2["20260101000001_items.sql"],
+				"20260101000002_price.sql": v2["20260101000002_price.sql"],
+			},
+			want: "no down script",
+		},
+		"down script that doesn't revert": {
+			old: v1,
+			new: fstest.MapFS{
+				"20260101000001_items.sql":      v2["20260101000001_items.sql"],
+				"20260101000002_price.sql":      v2["20260101000002_price.sql"],
+				"20260101000002_price.down.sql": file(`SELECT 1;`),
+			},
+			want: "upgrades rolled-back database",
+		},
+		"dropped migration": {
+			old:  v2,
+			new:  v1,
+			want: "drops migration",
+		},
+	} {
+		err := sqliteinit.SimulateSkew(ctx, sqliteinit.SkewTest{Old: tc.old, New: tc.new, Dir: t.TempDir()})
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: expected error containing %q, got %v", name, tc.want, err)
+		}
+	}
+}
+
+// TestMigrationStatus_JSON tests the stable JSON encoding of a status.
+func TestMigrationStatus_JSON(t *testing.T) {
+	status := sqliteinit.MigrationStatus{
+		SchemaVersion: 20260101000001,
+		Applied: []sqliteinit.AppliedMigration{{
+			ID: 20260101000001, Comment: "users", Path: "20260101000001_users.sql",
+			AppliedAt: time.Date(2026, 1, 1, 9, 30, 0, 500, time.FixedZone("EST", -5*3600)),
+		}},
+		IsInitialized: true,
+	}
+	got, err := json.Marshal(status)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	want := `{"schema_version":20260101000001,"infra_version":0,"applied":[{"id":20260101000001,"comment":"users",` +
+		`"path":"20260101000001_users.sql","checksum":"","applied_by":"","applied_at":"2026-01-01T14:30:00Z","duration_ms":0}],` +
+		`"pending":[],"is_initialized":true}`
+	if string(got) != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, got)
+	}
+
+	var decoded sqliteinit.MigrationStatus
+	if err := json.Unmarshal(got, &decoded); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	if !decoded.Applied[0].AppliedAt.Equal(time.Date(2026, 1, 1, 14, 30, 0, 0, time.UTC)) {
+		t.Errorf("expected applied_at to round-trip, got %v", decoded.Applied[0].AppliedAt)
+	}
+
+	if got, _ := json.Marshal(sqliteinit.MigrationStatus{}); string(got) != `{"schema_version":0,"infra_version":0,"applied":[],"pending":[],"is_initialized":false}` {
+		t.Errorf("expected empty arrays for an uninitialized status, got %s", got)
+	}
+}
+
+// TestDumpSchema tests that the schema dump is sorted, stable and can be
+// replayed to recreate the schema.
+func TestDumpSchema(t *testing.T) {
+	ctx := context.Background()
+	db, err := sqliteinit.Open(ctx, sqliteinit.Config{Path: "file:dumpschema?mode=memory&cache=shared", Migrations: advancedMigrations()})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+	for _, stmt := range []string{
+		"CREATE VIEW live_events AS SELECT id, kind FROM events WHERE deleted = 0   \r\n",
+		"CREATE TRIGGER tags_touch AFTER UPDATE ON tags BEGIN SELECT 1; END",
+		"CREATE VIRTUAL TABLE notes USING fts5(body)",
+	} {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var dump bytes.Buffer
+	if err := sqliteinit.DumpSchema(ctx, db, &dump); err != nil {
+		t.Fatalf("DumpSchema failed: %v", err)
+	}
+	got := dump.String()
+
+	var order []string
+	for _, m := range regexp.MustCompile(`(?m)^CREATE (?:VIRTUAL )?(\w+) (\w+)`).FindAllStringSubmatch(got, -1) {
+		order = append(order, m[1]+" "+m[2])
+	}
+	want := []string{
+		"TABLE config", "TABLE events", "TABLE flags", "TABLE memberships", "TABLE notes", "TABLE schema_migrations", "TABLE tags",
+		"INDEX idx_events_live", "INDEX idx_tags_lower",
+		"VIEW live_events",
+		"TRIGGER tags_touch",
+	}
+	if !slices.Equal(order, want) {
+		t.Errorf("expected objects %v, got %v\n%s", want, order, got)
+	}
+	if !strings.Contains(got, "WHERE deleted = 0;\n") || strings.Contains(got, "\r") {
+		t.Errorf("expected normalized statements:\n%s", got)
+	}
+
+	// Replaying the dump recreates the same schema.
+	replica, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer replica.Close()
+	replica.SetMaxOpenConns(1)
+	if _, err := replica.ExecContext(ctx, got); err != nil {
+		t.Fatalf("replay failed: %v", err)
+	}
+	var again bytes.Buffer
+	if err := sqliteinit.DumpSchema(ctx, replica, &again); err != nil {
+		t.Fatalf("DumpSchema failed: %v", err)
+	}
+	if again.String() != got {
+		t.Errorf("replayed schema differs:\n%s\nwant:\n%s", again.String(), got)
+	}
+}
+
+// TestDump tests dumping a database as SQL.
+func TestDump(t *testing.T) {
+	ctx := context.Background()
+	db, err := sqliteinit.Open(ctx, sqliteinit.Config{Path: ":memory:"})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+	for _, stmt := range []string{
+		`CREATE TABLE items (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT, price REAL, data BLOB, upper_name TEXT GENERATED ALWAYS AS (upper(name)))`,
+		`CREATE TABLE other (id INTEGER PRIMARY KEY)`,
+		`CREATE INDEX idx_items_name ON items (name)`,
+		`INSERT INTO items (name, price, data) VALUES ('it''s', 0.1, x'00ff'), (NULL, 1e300, NULL), ('two' || char(10) || 'lines', -2.5, '')`,
+		`DELETE FROM items WHERE id = 2`,
+		`INSERT INTO other (id) VALUES (7)`,
+	} {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var dump bytes.Buffer
+	if err := sqliteinit.Dump(ctx, db, &dump, sqliteinit.DumpOptions{}); err != nil {
+		t.Fatalf("Dump failed: %v", err)
+	}
+	got := dump.String()
+	for _, want := range []string{
+		"PRAGMA foreign_keys=OFF;\nBEGIN TRANSACTION;\n",
+		`INSERT INTO "items"("id","name","price","data") VALUES(1,'it''s',0.1,X'00FF');`,
+		`INSERT INTO "other" VALUES(7);`,
+		`INSERT INTO sqlite_sequence VALUES('items',3);`,
+		"COMMIT;\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("expected dump to contain %q:\n%s", want, got)
+		}
+	}
+
+	// Loading the dump into an empty database recreates the same database.
+	replica, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer replica.Close()
+	replica.SetMaxOpenConns(1)
+	if _, err := replica.ExecContext(ctx, got); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	var again bytes.Buffer
+	if err := sqliteinit.Dump(ctx, replica, &again, sqliteinit.DumpOptions{}); err != nil {
+		t.Fatalf("Dump failed: %v", err)
+	}
+	if again.String() != got {
+		t.Errorf("reloaded dump differs:\n%s\nwant:\n%s", again.String(), got)
+	}
+
+	dump.Reset()
+	if err := sqliteinit.Dump(ctx, db, &dump, sqliteinit.DumpOptions{SchemaOnly: true, Tables: []string{"items"}}); err != nil {
+		t.Fatalf("Dump failed: %v", err)
+	}
+	got = dump.String()
+	if strings.Contains(got, "INSERT") || strings.Contains(got, "other") || !strings.Contains(got, "CREATE INDEX idx_items_name") {
+		t.Errorf("expected only the items schema:\n%s", got)
+	}
+	if err := sqliteinit.Dump(ctx, db, &dump, sqliteinit.DumpOptions{Tables: []string{"missing"}}); !errors.Is(err, sqliteinit.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+// TestDiff tests that manual schema edits are reported against the schema
+// the migrations produce.
+func TestDiff(t *testing.T) {
+	ctx := context.Background()
+	db, err := sqliteinit.Open(ctx, sqliteinit.Config{Path: "file:diff?mode=memory&cache=shared", Migrations: advancedMigrations()})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+
+	changes, err := sqliteinit.Diff(ctx, db, advancedMigrations())
+	if err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+	if len(changes) != 0 {
+		t.Fatalf("expected no changes after migrating, got %v", changes)
+	}
+
+	for _, stmt := range []string{
+		`CREATE TABLE scratch (x)`,
+		`ALTER TABLE tags ADD COLUMN color TEXT`,
+		`DROP INDEX idx_tags_lower`,
+		`DROP INDEX idx_events_live`,
+		`CREATE INDEX idx_events_live ON events (kind)`,
+		`CREATE INDEX idx_manual ON events (deleted)`,
+	} {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if changes, err = sqliteinit.Diff(ctx, db, advancedMigrations()); err != nil {
+		t.Fatalf("Diff failed: %v", err)
+	}
+	var got []string
+	for _, c := range changes {
+		got = append(got, c.Kind+" "+c.Type+" "+c.Name)
+	}
+	want := []string{
+		"added table scratch",
+		"added column tags.color",
+		"changed index idx_events_live",
+		"added index idx_manual",
+		"removed index idx_tags_lower",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("expected %v, got %v", want, changes)
+	}
+}
+
+// TestDetectDrift tests that schema changes made outside migrations are
+// caught at Open.
+func TestDetectDrift(t *testing.T) {
+	ctx := context.Background()
+	cfg := sqliteinit.Config{
+		Path:        filepath.Join(t.TempDir(), "drift.db"),
+		Migrations:  validMigrations(),
+		DetectDrift: sqliteinit.DriftFail,
+	}
+	if err := sqliteinit.Create(ctx, cfg); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	db, err := sqliteinit.Open(ctx, cfg)
+	if err != nil {
+		t.Fatalf("Open without drift failed: %v", err)
+	}
+	if _, err := db.ExecContext(ctx, `CREATE INDEX idx_manual ON posts (title)`); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	if _, err := sqliteinit.Open(ctx, cfg); !errors.Is(err, sqliteinit.ErrSchemaDrift) {
+		t.Fatalf("expected ErrSchemaDrift, got %v", err)
+	}
+
+	var logs bytes.Buffer
+	warn := cfg
+	warn.DetectDrift = sqliteinit.DriftWarn
+	warn.Logger = slog.New(slog.NewTextHandler(&logs, nil))
+	db, err = sqliteinit.Open(ctx, warn)
+	if err != nil {
+		t.Fatalf("Open with DriftWarn failed: %v", err)
+	}
+	db.Close()
+	if !strings.Contains(logs.String(), "schema drift detected") {
+		t.Errorf("expected drift warning, got %q", logs.String())
+	}
+
+	// DriftWarn accepted the schema as the new baseline.
+	db, err = sqliteinit.Open(ctx, cfg)
+	if err != nil {
+		t.Fatalf("Open after accepting drift failed: %v", err)
+	}
+	db.Close()
+}
+
+// TestHistory tests paging through the migration history and upgrading
+// databases whose history predates the metadata columns.
+func TestHistory(t *testing.T) {
+	ctx := context.Background()
+	cfg := sqliteinit.Config{Path: filepath.Join(t.TempDir(), "history.db"), Migrations: validMigrations(), AppVersion: "1.0"}
+	db, _, err := sqliteinit.OpenOrCreate(ctx, cfg)
+	if err != nil {
+		t.Fatalf("OpenOrCreate failed: %v", err)
+	}
+
+	history, err := sqliteinit.History(ctx, db, sqliteinit.HistoryFilter{})
+	if err != nil {
+		t.Fatalf("History failed: %v", err)
+	}
+	var paths []string
+	for _, m := range history {
+		paths = append(paths, m.Path)
+	}
+	if want := []string{"20260101000002_posts.sql", "20260101000001_users.sql", "schema.sql"}; !slices.Equal(paths, want) {
+		t.Fatalf("expected %v, got %v", want, paths)
+	}
+	script, _ := fs.ReadFile(validMigrations(), "20260101000002_posts.sql")
+	if sum := sha256.Sum256(script); history[0].Checksum != hex.EncodeToString(sum[:]) {
+		t.Errorf("expected checksum of the script, got %q", history[0].Checksum)
+	}
+	if !strings.HasSuffix(history[0].AppliedBy, "(app 1.0)") {
+		t.Errorf("expected applied_by with app version, got %q", history[0].AppliedBy)
+	}
+
+	page, err := sqliteinit.History(ctx, db, sqliteinit.HistoryFilter{Limit: 1, Offset: 1})
+	if err != nil || len(page) != 1 || page[0].Path != "20260101000001_users.sql" {
+		t.Errorf("expected second entry, got %v, %v", page, err)
+	}
+	if later, err := sqliteinit.History(ctx, db, sqliteinit.HistoryFilter{Since: time.Now().Add(time.Hour)}); err != nil || len(later) != 0 {
+		t.Errorf("expected no entries after now, got %v, %v", later, err)
+	}
+
+	// A database created before the metadata columns existed still has a
+	// history, and gets the columns on its next migration.
+	for _, col := range []string{"duration_ms", "checksum", "applied_by"} {
+		if _, err := db.ExecContext(ctx, `ALTER TABLE schema_migrations DROP COLUMN `+col); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := db.ExecContext(ctx, `DELETE FROM config WHERE key = 'schema.infra_version'`); err != nil {
+		t.Fatal(err)
+	}
+	if history, err = sqliteinit.History(ctx, db, sqliteinit.HistoryFilter{}); err != nil || len(history) != 3 || history[0].Checksum != "" {
+		t.Errorf("expected history without metadata, got %v, %v", history, err)
+	}
+	db.Close()
+
+	db, err = sqliteinit.Open(ctx, cfg)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+	if _, err := db.ExecContext(ctx, `SELECT checksum, applied_by, duration_ms FROM schema_migrations`); err != nil {
+		t.Errorf("expected metadata columns to be restored: %v", err)
+	}
+}
+
+// TestConfigTable tests reading and writing application keys in the
+// config table, and that the package's keys are protected.
+func TestConfigTable(t *testing.T) {
+	ctx := context.Background()
+	db, err := sqliteinit.Open(ctx, sqliteinit.Config{Path: ":memory:", Migrations: validMigrations()})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+
+	if _, err := sqliteinit.GetConfig(ctx, db, "theme"); !errors.Is(err, sqliteinit.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+	for _, value := range []string{"dark", "light"} {
+		if err := sqliteinit.SetConfig(ctx, db, "theme", value); err != nil {
+			t.Fatalf("SetConfig failed: %v", err)
+		}
+		if got, err := sqliteinit.GetConfig(ctx, db, "theme"); err != nil || got != value {
+			t.Errorf("expected %q, got %q, %v", value, got, err)
+		}
+	}
+	if err := sqliteinit.DeleteConfig(ctx, db, "theme"); err != nil {
+		t.Fatalf("DeleteConfig failed: %v", err)
+	}
+	if _, err := sqliteinit.GetConfig(ctx, db, "theme"); !errors.Is(err, sqliteinit.ErrNotFound) {
+		t.Errorf("expected ErrNotFound after delete, got %v", err)
+	}
+	if err := sqliteinit.DeleteConfig(ctx, db, "theme"); err != nil {
+		t.Errorf("expected deleting a missing key to succeed, got %v", err)
+	}
+
+	if v, err := sqliteinit.GetConfig(ctx, db, "schema.version"); err != nil || v != "20260101000002" {
+		t.Errorf("expected to read schema.version, got %q, %v", v, err)
+	}
+	for _, key := range []string{"schema.version", "app.version", "db.created_at", "audit.started_at", ""} {
+		if err := sqliteinit.SetConfig(ctx, db, key, "x"); err == nil {
+			t.Errorf("expected SetConfig(%q) to be rejected", key)
+		}
+		if err := sqliteinit.DeleteConfig(ctx, db, key); err == nil {
+			t.Errorf("expected DeleteConfig(%q) to be rejected", key)
+		}
+	}
+}
+
+// TestGetConfigAs tests parsing config values into Go types.
+func TestGetConfigAs(t *testing.T) {
+	ctx := context.Background()
+	db, err := sqliteinit.Open(ctx, sqliteinit.Config{Path: ":memory:", Migrations: validMigrations(), AppVersion: "2.0"})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+
+	if v, err := sqliteinit.GetConfigAs[int](ctx, db, "schema.version"); err != nil || v != 20260101000002 {
+		t.Errorf("expected schema.version as int, got %d, %v", v, err)
+	}
+	if v, err := sqliteinit.GetConfigAs[time.Time](ctx, db, "db.created_at"); err != nil || time.Since(v) > time.Minute {
+		t.Errorf("expected db.created_at as a recent time, got %v, %v", v, err)
+	}
+
+	for key, value := range map[string]string{"enabled": "true", "started": "2026-01-02T03:04:05Z", "count": "x"} {
+		if err := sqliteinit.SetConfig(ctx, db, key, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if v, err := sqliteinit.GetConfigAs[bool](ctx, db, "enabled"); err != nil || !v {
+		t.Errorf("expected true, got %v, %v", v, err)
+	}
+	if v, err := sqliteinit.GetConfigAs[time.Time](ctx, db, "started"); err != nil || !v.Equal(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)) {
+		t.Errorf("expected RFC 3339 time, got %v, %v", v, err)
+	}
+	if v, err := sqliteinit.GetConfigAs[string](ctx, db, "count"); err != nil || v != "x" {
+		t.Errorf("expected string, got %q, %v", v, err)
+	}
+	if v, err := sqliteinit.GetConfigAs[int](ctx, db, "count"); err == nil || errors.Is(err, sqliteinit.ErrNotFound) || v != 0 {
+		t.Errorf("expected parse error and zero value, got %d, %v", v, err)
+	}
+	if v, err := sqliteinit.GetConfigAs[int](ctx, db, "missing"); !errors.Is(err, sqliteinit.ErrNotFound) || v != 0 {
+		t.Errorf("expected ErrNotFound and zero value, got %d, %v", v, err)
+	}
+}
+
+// TestFlags tests setting, reading and listing feature flags.
+func TestFlags(t *testing.T) {
+	ctx := context.Background()
+	db, err := sqliteinit.Open(ctx, sqliteinit.Config{Path: ":memory:", Migrations: validMigrations()})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+
+	flags := sqliteinit.Flags(db)
+	if on, err := flags.Enabled(ctx, "beta"); err != nil || on {
+		t.Errorf("expected unset flag to be disabled, got %v, %v", on, err)
+	}
+	if all, err := flags.List(ctx); err != nil || len(all) != 0 {
+		t.Errorf("expected no flags, got %v, %v", all, err)
+	}
+
+	if err := flags.Set(ctx, "beta", true); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if err := flags.Set(ctx, "alpha", false); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if on, err := flags.Enabled(ctx, "beta"); err != nil || !on {
+		t.Errorf("expected beta enabled, got %v, %v", on, err)
+	}
+	if on, err := flags.Enabled(ctx, "gamma"); err != nil || on {
+		t.Errorf("expected unknown flag disabled, got %v, %v", on, err)
+	}
+	if err := flags.Set(ctx, "beta", false); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if on, _ := flags.Enabled(ctx, "beta"); on {
+		t.Error("expected beta disabled after Set(false)")
+	}
+
+	all, err := flags.List(ctx)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(all) != 2 || all[0].Name != "alpha" || all[1].Name != "beta" || all[1].Enabled || all[1].UpdatedAt.IsZero() {
+		t.Errorf("unexpected flags: %+v", all)
+	}
+	if err := flags.Set(ctx, "", true); err == nil {
+		t.Error("expected empty flag name to be rejected")
+	}
+}
+
+// TestInfo tests the summary returned by Info.
+func TestInfo(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "info.db")
+	cfg := sqliteinit.Config{Path: path, Migrations: validMigrations()}
+	if err := sqliteinit.Create(ctx, cfg); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	db, err := sqliteinit.Open(ctx, cfg)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+
+	info, err := sqliteinit.Info(ctx, db)
+	if err != nil {
+		t.Fatalf("Info failed: %v", err)
+	}
+	if info.Path != path {
+		t.Errorf("expected path %q, got %q", path, info.Path)
+	}
+	if info.FileSize <= 0 || info.PageSize <= 0 || info.PageCount <= 0 {
+		t.Errorf("expected non-zero sizes, got %+v", info)
+	}
+	if info.JournalMode != "wal" {
+		t.Errorf("expected wal journal mode, got %q", info.JournalMode)
+	}
+	if !info.ForeignKeys {
+		t.Error("expected foreign keys on")
+	}
+	if !strings.HasPrefix(info.SQLiteVersion, "3.") {
+		t.Errorf("unexpected SQLite version %q", info.SQLiteVersion)
+	}
+
+	mem, err := sqliteinit.Open(ctx, sqliteinit.Config{Path: ":memory:", Migrations: validMigrations()})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer mem.Close()
+	if info, err := sqliteinit.Info(ctx, mem); err != nil || info.Path != "" || info.FileSize != 0 {
+		t.Errorf("expected in-memory info without a file, got %+v, %v", info, err)
+	}
+}
+
+// TestVerify tests that Verify reports integrity and foreign key violations.
+func TestVerify(t *testing.T) {
+	ctx := context.Background()
+	db, err := sqliteinit.Open(ctx, sqliteinit.Config{Path: ":memory:", Migrations: validMigrations()})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+
+	for _, quick := range []bool{false, true} {
+		if violations, err := sqliteinit.Verify(ctx, db, sqliteinit.VerifyOptions{Quick: quick}); err != nil || len(violations) != 0 {
+			t.Errorf("Quick=%v: expected a clean database, got %v, %v", quick, violations, err)
+		}
+	}
+
+	// Write an orphaned post with enforcement off.
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		t.Fatalf("Conn failed: %v", err)
+	}
+	for _, stmt := range []string{
+		`PRAGMA foreign_keys = OFF`,
+		`INSERT INTO posts (id, user_id, title, body, created_at) VALUES (7, 99, 't', 'b', 0)`,
+		`PRAGMA foreign_keys = ON`,
+	} {
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+	conn.Close()
+
+	violations, err := sqliteinit.Verify(ctx, db, sqliteinit.VerifyOptions{Quick: true})
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if len(violations) != 1 {
+		t.Fatalf("expected 1 violation, got %v", violations)
+	}
+	v := violations[0]
+	if v.Check != "foreign_key" || v.Table != "posts" || v.RowID != 7 || v.Parent != "users" {
+		t.Errorf("unexpected violation: %+v", v)
+	}
+	if !strings.Contains(v.String(), "posts row 7") {
+		t.Errorf("unexpected String: %s", v)
+	}
+}
+
+// TestRequiredSchemaFingerprint tests that Open rejects a database whose
+// schema doesn't match RequiredSchemaFingerprint.
+func TestRequiredSchemaFingerprint(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "fingerprint.db")
+	cfg := sqliteinit.Config{Path: path, Migrations: validMigrations()}
+	if err := sqliteinit.Create(ctx, cfg); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	db, err := sqliteinit.Open(ctx, cfg)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	fingerprint, err := sqliteinit.SchemaFingerprint(ctx, db)
+	if err != nil {
+		t.Fatalf("SchemaFingerprint failed: %v", err)
+	}
+	if stored, err := sqliteinit.GetConfig(ctx, db, "schema.fingerprint"); err != nil || stored != fingerprint {
+		t.Errorf("expected stored fingerprint %q, got %q, %v", fingerprint, stored, err)
+	}
+	// Change the schema without changing the version.
+	if _, err := db.ExecContext(ctx, `CREATE INDEX idx_posts_title ON posts (title)`); err != nil {
+		t.Fatalf("create index: %v", err)
+	}
+	db.Close()
+
+	cfg.RequiredSchemaFingerprint = fingerprint
+	if db, err := sqliteinit.Open(ctx, cfg); err == nil {
+		db.Close()
+		t.Fatal("expected Open to fail on a fingerprint mismatch")
+	} else if !strings.Contains(err.Error(), "schema fingerprint mismatch") {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	cfg.RequiredSchemaFingerprint = "not-a-fingerprint"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "RequiredSchemaFingerprint") {
+		t.Errorf("expected Validate to reject the fingerprint, got %v", err)
+	}
+}
+
+// TestHealthCheck tests the report returned by HealthCheck.
+func TestHealthCheck(t *testing.T) {
+	ctx := context.Background()
+	db, err := sqliteinit.Open(ctx, sqliteinit.Config{Path: ":memory:", Migrations: validMigrations()})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+
+	h, err := sqliteinit.HealthCheck(ctx, db, validMigrations())
+	if err != nil {
+		t.Fatalf("HealthCheck failed: %v", err)
+	}
+	if !h.Ready() || h.SchemaVersion != 20260101000002 || h.Pending != 0 || h.LastMigrationAt.IsZero() || h.PingLatency <= 0 {
+		t.Errorf("unexpected health: %+v", h)
+	}
+
+	// A migration the database hasn't seen makes it not ready.
+	extra := fstest.MapFS{
+		"20260101000001_users.sql":    {Data: []byte(`CREATE TABLE users (id INTEGER PRIMARY KEY);`)},
+		"20260101000002_posts.sql":    {Data: []byte(`CREATE TABLE posts (id INTEGER PRIMARY KEY);`)},
+		"20260101000003_comments.sql": {Data: []byte(`CREATE TABLE comments (id INTEGER PRIMARY KEY);`)},
+	}
+	if h, err := sqliteinit.HealthCheck(ctx, db, extra); err != nil || h.Ready() || h.Pending != 1 {
+		t.Errorf("expected 1 pending migration, got %+v, %v", h, err)
+	}
+
+	data, err := json.Marshal(h)
+	if err != nil {
+		t.Fatalf("Marshal failed: %v", err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal failed: %v", err)
+	}
+	for _, key := range []string{"initialized", "schema_version", "pending", "ready", "last_migration_at", "ping_latency_us"} {
+		if _, ok := decoded[key]; !ok {
+			t.Errorf("expected %q in %s", key, data)
+		}
+	}
+
+	db.Close()
+	if _, err := sqliteinit.HealthCheck(ctx, db, validMigrations()); err == nil {
+		t.Error("expected HealthCheck on a closed database to fail")
+	}
+}
+
+// TestInfraVersion tests that the revision of the package's own tables is
+// recorded and reported.
+func TestInfraVersion(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "infra.db")
+	cfg := sqliteinit.Config{Path: path, Migrations: validMigrations()}
+	if err := sqliteinit.Create(ctx, cfg); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	db, err := sqliteinit.Open(ctx, cfg)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	status, err := sqliteinit.StatusFromDB(ctx, db, validMigrations())
+	if err != nil || status.InfraVersion != sqliteinit.InfraSchemaVersion {
+		t.Errorf("expected infra version %d, got %+v, %v", sqliteinit.InfraSchemaVersion, status, err)
+	}
+	if info, err := sqliteinit.Info(ctx, db); err != nil || info.InfraVersion != sqliteinit.InfraSchemaVersion {
+		t.Errorf("expected Info infra version %d, got %+v, %v", sqliteinit.InfraSchemaVersion, info, err)
+	}
+
+	// Simulate a database from a release that predates the revision and
+	// the migration metadata columns.
+	for _, stmt := range []string{
+		`DELETE FROM config WHERE key = 'schema.infra_version'`,
+		`ALTER TABLE schema_migrations DROP COLUMN duration_ms`,
+		`ALTER TABLE schema_migrations DROP COLUMN checksum`,
+		`ALTER TABLE schema_migrations DROP COLUMN applied_by`,
+		`DROP TABLE flags`,
+	} {
+		if _, err := db.ExecContext(ctx, stmt); err != nil {
+			t.Fatalf("%s: %v", stmt, err)
+		}
+	}
+	if status, err := sqliteinit.StatusFromDB(ctx, db, nil); err != nil || status.InfraVersion != 1 {
+		t.Errorf("expected infra version 1, got %+v, %v", status, err)
+	}
+	db.Close()
+
+	db, err = sqliteinit.Open(ctx, cfg)
+	if err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	if status, err := sqliteinit.StatusFromDB(ctx, db, nil); err != nil || status.InfraVersion != sqliteinit.InfraSchemaVersion {
+		t.Errorf("expected upgrade to %d, got %+v, %v", sqliteinit.InfraSchemaVersion, status, err)
+	}
+	var n int
+	if err := db.QueryRowContext(ctx, `SELECT count(*) FROM pragma_table_info('schema_migrations') WHERE name = 'applied_by'`).Scan(&n); err != nil || n != 1 {
+		t.Errorf("expected applied_by to be restored, got %d, %v", n, err)
+	}
+	if err := sqliteinit.Flags(db).Set(ctx, "beta", true); err != nil {
+		t.Errorf("expected the flags table to be restored, got %v", err)
+	}
+
+	// A newer revision than this release knows is refused.
+	if _, err := db.ExecContext(ctx, `UPDATE config SET value = '99' WHERE key = 'schema.infra_version'`); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	db.Close()
+	if db, err := sqliteinit.Open(ctx, cfg); err == nil {
+		db.Close()
+		t.Error("expected Open to refuse a newer infrastructure revision")
+	}
+}
+
+// TestIntrospection tests listing tables, columns and indexes.
+func TestIntrospection(t *testing.T) {
+	ctx := context.Background()
+	db, err := sqliteinit.Open(ctx, sqliteinit.Config{Path: ":memory:", Migrations: advancedMigrations()})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+
+	tables, err := sqliteinit.Tables(ctx, db)
+	if err != nil {
+		t.Fatalf("Tables failed: %v", err)
+	}
+	want := []sqliteinit.Table{
+		{Name: "events", Strict: true},
+		{Name: "memberships"},
+		{Name: "tags", WithoutRowID: true},
+	}
+	if !reflect.DeepEqual(tables, want) {
+		t.Errorf("expected tables %+v, got %+v", want, tables)
+	}
+
+	cols, err := sqliteinit.Columns(ctx, db, "events")
+	if err != nil {
+		t.Fatalf("Columns failed: %v", err)
+	}
+	if len(cols) != 6 {
+		t.Fatalf("expected 6 columns, got %+v", cols)
+	}
+	if c := cols[0]; c.Name != "id" || c.PrimaryKey != 1 || !c.NotNull {
+		t.Errorf("unexpected id column: %+v", c)
+	}
+	if c := cols[4]; c.Name != "upper" || c.Generated != "STORED" {
+		t.Errorf("unexpected upper column: %+v", c)
+	}
+	if c := cols[5]; c.Name != "deleted" || c.Default.String != "0" || !c.NotNull {
+		t.Errorf("unexpected deleted column: %+v", c)
+	}
+	if _, err := sqliteinit.Columns(ctx, db, "missing"); !errors.Is(err, sqliteinit.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a missing table, got %v", err)
+	}
+
+	indexes, err := sqliteinit.Indexes(ctx, db, "tags")
+	if err != nil {
+		t.Fatalf("Indexes failed: %v", err)
+	}
+	if len(indexes) != 2 {
+		t.Fatalf("expected 2 indexes, got %+v", indexes)
+	}
+	if idx := indexes[0]; idx.Name != "idx_tags_lower" || idx.Origin != "c" || !reflect.DeepEqual(idx.Columns, []string{""}) {
+		t.Errorf("unexpected expression index: %+v", idx)
+	}
+	if idx := indexes[1]; idx.Origin != "pk" || !idx.Unique || !reflect.DeepEqual(idx.Columns, []string{"slug"}) {
+		t.Errorf("unexpected primary key index: %+v", idx)
+	}
+	if indexes, err := sqliteinit.Indexes(ctx, db, "events"); err != nil || len(indexes) != 1 || !indexes[0].Partial {
+		t.Errorf("expected one partial index, got %+v, %v", indexes, err)
+	}
+	if indexes, err := sqliteinit.Indexes(ctx, db, "memberships"); err != nil || len(indexes) != 1 || !reflect.DeepEqual(indexes[0].Columns, []string{"group_id", "user_id"}) {
+		t.Errorf("expected the composite key index, got %+v, %v", indexes, err)
+	}
+	if _, err := sqliteinit.Indexes(ctx, db, "missing"); !errors.Is(err, sqliteinit.ErrNotFound) {
+		t.Errorf("expected ErrNotFound for a missing table, got %v", err)
+	}
+}
+
+// TestExportERD tests exporting the schema as an entity relationship diagram.
+func TestExportERD(t *testing.T) {
+	ctx := context.Background()
+	db, err := sqliteinit.Open(ctx, sqliteinit.Config{Path: ":memory:", Migrations: validMigrations()})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+
+	var dot bytes.Buffer
+	if err := sqliteinit.ExportERD(ctx, db, &dot, sqliteinit.FormatDOT); err != nil {
+		t.Fatalf("ExportERD(DOT) failed: %v", err)
+	}
+	for _, want := range []string{
+		"digraph schema {",
+		`"posts" [label=<`,
+		`<td port="user_id" align="left">user_id INTEGER</td>`,
+		`<td port="id" align="left">id INTEGER PK</td>`,
+		`"posts":"user_id" -> "users":"id";`,
+	} {
+		if !strings.Contains(dot.String(), want) {
+			t.Errorf("expected DOT to contain %q:\n%s", want, dot.String())
+		}
+	}
+	if strings.Contains(dot.String(), "schema_migrations") {
+		t.Errorf("expected internal tables to be left out:\n%s", dot.String())
+	}
+
+	var mermaid bytes.Buffer
+	if err := sqliteinit.ExportERD(ctx, db, &mermaid, sqliteinit.FormatMermaid); err != nil {
+		t.Fatalf("ExportERD(Mermaid) failed: %v", err)
+	}
+	want := `erDiagram
+    posts {
+        INTEGER id PK
+        INTEGER user_id FK
+        TEXT title
+        TEXT body
+        INTEGER created_at
+    }
+    users {
+        INTEGER id PK
+        TEXT email
+        TEXT name
+        INTEGER created_at
+    }
+    users ||--o{ posts : "user_id"
+`
+	if mermaid.String() != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, mermaid.String())
+	}
+
+	if err := sqliteinit.ExportERD(ctx, db, &bytes.Buffer{}, sqliteinit.ERDFormat(99)); err == nil {
+		t.Error("expected an unknown format to be rejected")
+	}
+}
+
+// TestOnEvent tests that OnEvent is called for lifecycle events.
+func TestOnEvent(t *testing.T) {
+	ctx := context.Background()
+	var mu sync.Mutex
+	var events []sqliteinit.Event
+	cfg := sqliteinit.Config{
+		Path:       filepath.Join(t.TempDir(), "events.db"),
+		Migrations: validMigrations(),
+		OnEvent: func(e sqliteinit.Event) {
+			mu.Lock()
+			defer mu.Unlock()
+			events = append(events, e)
+		},
+	}
+	if err := sqliteinit.Create(ctx, cfg); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	kinds := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		var kinds []string
+		for _, e := range events {
+			switch e := e.(type) {
+			case sqliteinit.OpenEvent:
+				kinds = append(kinds, fmt.Sprintf("open err=%v", e.Err))
+			case sqliteinit.InitEvent:
+				kinds = append(kinds, "init")
+			case sqliteinit.MigrationStartEvent:
+				kinds = append(kinds, "start "+e.Path)
+			case sqliteinit.MigrationFinishEvent:
+				kinds = append(kinds, fmt.Sprintf("finish %s err=%v", e.Path, e.Err))
+			case sqliteinit.ChecksumEvent:
+				kinds = append(kinds, fmt.Sprintf("checksum %s match=%v", e.Path, e.Match()))
+			case sqliteinit.CloseEvent:
+				kinds = append(kinds, "close")
+			}
+		}
+		events = nil
+		return kinds
+	}
+
+	want := []string{
+		"init",
+		"start 20260101000001_users.sql",
+		"finish 20260101000001_users.sql err=<nil>",
+		"start 20260101000002_posts.sql",
+		"finish 20260101000002_posts.sql err=<nil>",
+		"open err=<nil>",
+		"close",
+	}
+	if got := kinds(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Create: expected %q, got %q", want, got)
+	}
+
+	db, err := sqliteinit.Open(ctx, cfg)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	want = []string{
+		"checksum 20260101000001_users.sql match=true",
+		"checksum 20260101000002_posts.sql match=true",
+		"open err=<nil>",
+	}
+	if got := kinds(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Open: expected %q, got %q", want, got)
+	}
+	db.Close()
+	if got := kinds(); !reflect.DeepEqual(got, []string{"close"}) {
+		t.Errorf("Close: expected a close event, got %q", got)
+	}
+
+	// An edited script is reported.
+	edited := fstest.MapFS{
+		"20260101000001_users.sql": {Data: []byte(`CREATE TABLE users (id INTEGER PRIMARY KEY);`)},
+	}
+	cfg.Migrations = edited
+	db, err = sqliteinit.Open(ctx, cfg)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	db.Close()
+	if got := kinds(); len(got) == 0 || got[0] != "checksum 20260101000001_users.sql match=false" {
+		t.Errorf("expected a checksum mismatch, got %q", got)
+	}
+}
+
+// TestSlowMigrationThreshold tests that migrations slower than
+// SlowMigrationThreshold are reported.
+func TestSlowMigrationThreshold(t *testing.T) {
+	ctx := context.Background()
+	for _, tc := range []struct {
+		threshold time.Duration
+		warnings  int
+	}{
+		{time.Nanosecond, 2},
+		{-1, 0},
+		{0, 0}, // the 5s default
+	} {
+		var logs bytes.Buffer
+		db, err := sqliteinit.Open(ctx, sqliteinit.Config{
+			Path:                   ":memory:",
+			Migrations:             validMigrations(),
+			Logger:                 slog.New(slog.NewTextHandler(&logs, nil)),
+			SlowMigrationThreshold: tc.threshold,
+		})
+		if err != nil {
+			t.Fatalf("Open failed: %v", err)
+		}
+		db.Close()
+		if n := strings.Count(logs.String(), `msg="slow migration"`); n != tc.warnings {
+			t.Errorf("threshold %v: expected %d warnings, got %d:\n%s", tc.threshold, tc.warnings, n, logs.String())
+		}
+		if tc.warnings != 0 && !strings.Contains(logs.String(), "id=20260101000001") {
+			t.Errorf("expected the migration ID in the warning:\n%s", logs.String())
+		}
+	}
+}
+
+// TestLogStatements tests that LogStatements logs each migration statement.
+func TestLogStatements(t *testing.T) {
+	ctx := context.Background()
+	migrations := fstest.MapFS{
+		"20260101000001_items.sql": {Data: []byte(`
+-- three statements, the last one broken
+CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT);
+INSERT INTO items (name) VALUES ('a;b');
+INSERT INTO missing (name) VALUES ('c');
+`)},
+	}
+	var logs bytes.Buffer
+	_, err := sqliteinit.Open(ctx, sqliteinit.Config{
+		Path:          ":memory:",
+		Migrations:    migrations,
+		Logger:        slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})),
+		LogStatements: true,
+	})
+	if err == nil {
+		t.Fatal("expected the migration to fail")
+	}
+	if !strings.Contains(err.Error(), "statement 3 at line 5 (INSERT INTO missing (name) VALUES ('c'))") {
+		t.Errorf("expected the error to name the failing statement, got %v", err)
+	}
+	if n := strings.Count(logs.String(), `msg="migration statement"`); n != 3 {
+		t.Errorf("expected 3 logged statements, got %d:\n%s", n, logs.String())
+	}
+	if !strings.Contains(logs.String(), "index=2 of=3") {
+		t.Errorf("expected statement indexes in the log:\n%s", logs.String())
+	}
+
+	// Without LogStatements, the failing statement is still located.
+	_, err = sqliteinit.Open(ctx, sqliteinit.Config{Path: ":memory:", Migrations: migrations})
+	if err == nil || !strings.Contains(err.Error(), "statement 3 at line 5") {
+		t.Errorf("expected the failing statement to be located, got %v", err)
+	}
+}
+
+// TestMigrationError tests the details carried by a MigrationError.
+func TestMigrationError(t *testing.T) {
+	ctx := context.Background()
+	migrations := fstest.MapFS{
+		"20260101000001_users.sql": &fstest.MapFile{Data: []byte("CREATE TABLE users (id INTEGER PRIMARY KEY);\n")},
+		"20260101000002_bad.sql": &fstest.MapFile{Data: []byte(`-- seed users
+INSERT INTO users (id) VALUES (1);
+
+/* this one fails */
+INSERT INTO users (id)
+  VALUES (1);
+`)},
+	}
+
+	check := func(name string, err error) {
+		t.Helper()
+		var merr *sqliteinit.MigrationError
+		if !errors.As(err, &merr) {
+			t.Fatalf("%s: expected a *MigrationError, got %v", name, err)
+		}
+		if merr.ID != 20260101000002 || merr.Path != "20260101000002_bad.sql" {
+			t.Errorf("%s: got migration %d %q", name, merr.ID, merr.Path)
+		}
+		if merr.Statement != 2 || merr.Line != 5 {
+			t.Errorf("%s: got statement %d at line %d, want 2 at line 5", name, merr.Statement, merr.Line)
+		}
+		if merr.SQL != "INSERT INTO users (id) VALUES (1)" {
+			t.Errorf("%s: got SQL %q", name, merr.SQL)
+		}
+		if merr.Err == nil || !strings.Contains(merr.Err.Error(), "UNIQUE") {
+			t.Errorf("%s: expected the driver's UNIQUE error, got %v", name, merr.Err)
+		}
+	}
+
+	_, err := sqliteinit.Open(ctx, sqliteinit.Config{Path: ":memory:", Migrations: migrations})
+	check("Open", err)
+	err = sqliteinit.Create(ctx, sqliteinit.Config{Path: filepath.Join(t.TempDir(), "app.db"), Migrations: migrations})
+	check("Create", err)
+}
+
+// TestSentinelErrors tests that failures wrap the package's sentinel errors.
+func TestSentinelErrors(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.db")
+	cfg := sqliteinit.Config{Path: path, Migrations: validMigrations()}
+
+	if _, err := sqliteinit.Open(ctx, cfg); !errors.Is(err, sqliteinit.ErrNotFound) {
+		t.Errorf("Open missing file: expected ErrNotFound, got %v", err)
+	}
+	if err := sqliteinit.Move(ctx, path, filepath.Join(dir, "moved.db")); !errors.Is(err, sqliteinit.ErrNotFound) {
+		t.Errorf("Move missing file: expected ErrNotFound, got %v", err)
+	}
+	if err := sqliteinit.Create(ctx, cfg); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if err := sqliteinit.Create(ctx, cfg); !errors.Is(err, sqliteinit.ErrAlreadyExists) {
+		t.Errorf("Create twice: expected ErrAlreadyExists, got %v", err)
+	}
+
+	pinned := cfg
+	pinned.RequiredSchemaVersion = 1
+	if _, err := sqliteinit.Open(ctx, pinned); !errors.Is(err, sqliteinit.ErrSchemaVersionMismatch) {
+		t.Errorf("expected ErrSchemaVersionMismatch, got %v", err)
+	}
+	pinned = cfg
+	pinned.SkipMigrations = true
+	pinned.RequiredSchemaVersion = 1
+	pinned.Path = filepath.Join(dir, "empty.db")
+	if err := os.WriteFile(pinned.Path, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sqliteinit.Open(ctx, pinned); !errors.Is(err, sqliteinit.ErrNotInitialized) {
+		t.Errorf("expected ErrNotInitialized, got %v", err)
+	}
+
+	prod := sqliteinit.Config{Path: ":memory:", IsProduction: func() bool { return true }}
+	if _, err := sqliteinit.Open(ctx, prod); !errors.Is(err, sqliteinit.ErrMemoryInProduction) {
+		t.Errorf("Open: expected ErrMemoryInProduction, got %v", err)
+	}
+	if err := prod.Validate(); !errors.Is(err, sqliteinit.ErrMemoryInProduction) {
+		t.Errorf("Validate: expected ErrMemoryInProduction, got %v", err)
+	}
+
+	edited := cfg
+	edited.Migrations = fstest.MapFS{
+		"20260101000001_users.sql": {Data: []byte(`CREATE TABLE users (id INTEGER PRIMARY KEY);`)},
+	}
+	edited.VerifyChecksums = true
+	if _, err := sqliteinit.Open(ctx, edited); !errors.Is(err, sqliteinit.ErrChecksumMismatch) {
+		t.Errorf("expected ErrChecksumMismatch, got %v", err)
+	}
+	edited.VerifyChecksums = false
+	if db, err := sqliteinit.Open(ctx, edited); err != nil {
+		t.Errorf("expected an edited script to only warn without VerifyChecksums, got %v", err)
+	} else {
+		db.Close()
+	}
+}
+
+// memTarget is a BackupTarget that keeps backups in memory, like an object
+// storage bucket would.
+type memTarget struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func (m *memTarget) Put(ctx context.Context, name string, r io.Reader) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.objects[name] = data
+	return nil
+}
+
+func (m *memTarget) List(ctx context.Context) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var names []string
+	for name := range m.objects {
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+func (m *memTarget) Delete(ctx context.Context, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.objects, name)
+	return nil
+}
+
+// TestBackupTarget tests backing up to a BackupTarget.
+func TestBackupTarget(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	db, err := sqliteinit.Open(ctx, sqliteinit.Config{Path: ":memory:", Migrations: validMigrations()})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`INSERT INTO users (email, name, created_at) VALUES ('a@example.com', 'A', 0)`); err != nil {
+		t.Fatal(err)
+	}
+
+	old := "backup-" + time.Now().UTC().Add(-48*time.Hour).Format("20060102T150405Z") + ".db.gz"
+	target := &memTarget{objects: map[string][]byte{old: nil, "other.txt": nil}}
+	name, err := sqliteinit.BackupRotateTo(ctx, db, target, sqliteinit.RotationPolicy{
+		Keep:        1,
+		Compression: sqliteinit.CompressionGzip,
+		Options:     sqliteinit.BackupOptions{Verify: true, TempDir: dir},
+	})
+	if err != nil {
+		t.Fatalf("BackupRotateTo failed: %v", err)
+	}
+	names, _ := target.List(ctx)
+	slices.Sort(names)
+	if want := []string{name, "other.txt"}; !slices.Equal(names, want) {
+		t.Errorf("expected %v in the target, got %v", want, names)
+	}
+	if !bytes.HasPrefix(target.objects[name], []byte{0x1f, 0x8b}) {
+		t.Errorf("expected %s to be gzip compressed", name)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("expected the staging directory to be cleaned up, found %d entries", len(entries))
+	}
+
+	// The stored object restores like a local backup.
+	local := filepath.Join(dir, name)
+	if err := os.WriteFile(local, target.objects[name], 0o600); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := sqliteinit.Restore(ctx, local, sqliteinit.Config{Path: filepath.Join(dir, "restored.db"), Migrations: validMigrations()})
+	if err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	var n int
+	if err := restored.QueryRow(`SELECT count(*) FROM users`).Scan(&n); err != nil || n != 1 {
+		t.Errorf("expected 1 restored user, got %d (%v)", n, err)
+	}
+	restored.Close()
+
+	if err := sqliteinit.BackupTo(ctx, db, target, "backup.sql", sqliteinit.BackupOptions{}); err == nil {
+		t.Error("expected error for a name without .db")
+	}
+
+	fsTarget := sqliteinit.DirTarget{Dir: dir}
+	if err := sqliteinit.BackupTo(ctx, db, fsTarget, "copy.db", sqliteinit.BackupOptions{}); err != nil {
+		t.Fatalf("BackupTo DirTarget failed: %v", err)
+	}
+	if err := fsTarget.Put(ctx, "copy.db", strings.NewReader("x")); !errors.Is(err, sqliteinit.ErrAlreadyExists) {
+		t.Errorf("expected ErrAlreadyExists, got %v", err)
+	}
+	if err := fsTarget.Put(ctx, "../escape.db", strings.NewReader("x")); err == nil {
+		t.Error("expected error for a name with a path separator")
+	}
+}
+
+// TestExportTable tests exporting a table as CSV and JSON lines.
+func TestExportTable(t *testing.T) {
+	ctx := context.Background()
+	db, err := sqliteinit.Open(ctx, sqliteinit.Config{Path: ":memory:", Migrations: validMigrations()})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`
+		CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT, price REAL, data BLOB, note TEXT, seen DATETIME);
+		INSERT INTO items VALUES (1, 'plain', 1.5, x'00ff', NULL, '2026-03-01 02:00:00');
+		INSERT INTO items VALUES (2, 'say "hi", bye', 2, NULL, '', NULL);
+	`); err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	n, err := sqliteinit.ExportTable(ctx, db, "items", &buf, sqliteinit.FormatCSV)
+	if err != nil || n != 2 {
+		t.Fatalf("ExportTable csv: got %d rows, %v", n, err)
+	}
+	want := "id,name,price,data,note,seen\r\n" +
+		"1,plain,1.5,AP8=,,2026-03-01T02:00:00Z\r\n" +
+		"2,\"say \"\"hi\"\", bye\",2,,\"\",\r\n"
+	if buf.String() != want {
+		t.Errorf("unexpected CSV:\n%q\nwant\n%q", buf.String(), want)
+	}
+
+	buf.Reset()
+	if _, err := sqliteinit.ExportTable(ctx, db, "items", &buf, sqliteinit.FormatJSONLines); err != nil {
+		t.Fatalf("ExportTable jsonl: %v", err)
+	}
+	want = `{"id":1,"name":"plain","price":1.5,"data":"AP8=","note":null,"seen":"2026-03-01T02:00:00Z"}` + "\n" +
+		`{"id":2,"name":"say \"hi\", bye","price":2,"data":null,"note":"","seen":null}` + "\n"
+	if buf.String() != want {
+		t.Errorf("unexpected JSON lines:\n%s\nwant\n%s", buf.String(), want)
+	}
+
+	if _, err := sqliteinit.ExportTable(ctx, db, "missing", &buf, sqliteinit.FormatCSV); !errors.Is(err, sqliteinit.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+// TestImportTable tests importing CSV and JSON lines into a table.
+func TestImportTable(t *testing.T) {
+	ctx := context.Background()
+	db, err := sqliteinit.Open(ctx, sqliteinit.Config{Path: ":memory:", Migrations: validMigrations()})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+	if _, err := db.Exec(`
+		CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT NOT NULL, price REAL, data BLOB, note TEXT, doubled REAL AS (price * 2));
+		INSERT INTO items (id, name, price, data, note) VALUES (1, 'plain', 1.5, x'00ff', NULL);
+		INSERT INTO items (id, name, price, data, note) VALUES (2, 'say "hi",
+bye', 2, NULL, '');
+	`); err != nil {
+		t.Fatal(err)
+	}
+	dump := func() string {
+		var buf bytes.Buffer
+		if _, err := sqliteinit.ExportTable(ctx, db, "items", &buf, sqliteinit.FormatJSONLines); err != nil {
+			t.Fatal(err)
+		}
+		return buf.String()
+	}
+	want := dump()
+
+	for _, format := range []sqliteinit.DataFormat{sqliteinit.FormatCSV, sqliteinit.FormatJSONLines} {
+		var buf bytes.Buffer
+		if _, err := sqliteinit.ExportTable(ctx, db, "items", &buf, format); err != nil {
+			t.Fatal(err)
+		}
+		report, err := sqliteinit.ImportTable(ctx, db, "items", &buf, sqliteinit.ImportOptions{Format: format, BatchSize: 1, Truncate: true})
+		if err != nil {
+			t.Fatalf("ImportTable %v failed: %v", format, err)
+		}
+		if report.Rows != 2 || len(report.Errors) != 0 {
+			t.Errorf("%v: expected 2 rows and no errors, got %+v", format, report)
+		}
+		if got := dump(); got != want {
+			t.Errorf("%v: round trip changed the table:\n%s\nwant\n%s", format, got, want)
+		}
+	}
+
+	csv := "id,name,data\r\n" +
+		"3,three,\r\n" +
+		"1,duplicate,\r\n" + // primary key conflict
+		"4,four,not base64!\r\n" +
+		"5\r\n" +
+		"\r\n" +
+		"6,,\r\n" // NULL name
+	report, err := sqliteinit.ImportTable(ctx, db, "items", strings.NewReader(csv), sqliteinit.ImportOptions{})
+	if err != nil {
+		t.Fatalf("ImportTable failed: %v", err)
+	}
+	var rows []int64
+	for _, e := range report.Errors {
+		rows = append(rows, e.Row)
+	}
+	if report.Rows != 1 || !slices.Equal(rows, []int64{2, 3, 4, 5}) {
+		t.Errorf("expected 1 row and errors in rows 2-5, got %d rows and %v", report.Rows, report.Errors)
+	}
+
+	jsonl := `{"id": 7, "name": "seven", "extra": true}` + "\n" + `{"id": 8, "name": "eight", "note": {"a": [1]}}`
+	report, err = sqliteinit.ImportTable(ctx, db, "items", strings.NewReader(jsonl), sqliteinit.ImportOptions{Format: sqliteinit.FormatJSONLines})
+	if err != nil || report.Rows != 1 || len(report.Errors) != 1 {
+		t.Fatalf("expected 1 row and 1 error, got %+v (%v)", report, err)
+	}
+	var note string
+	if err := db.QueryRow(`SELECT note FROM items WHERE id = 8`).Scan(&note); err != nil || note != `{"a":[1]}` {
+		t.Errorf("expected the object stored as JSON text, got %q (%v)", note, err)
+	}
+
+	if _, err := sqliteinit.ImportTable(ctx, db, "items", strings.NewReader("id,bogus\r\n"), sqliteinit.ImportOptions{}); err == nil {
+		t.Error("expected error for an unknown column in the header")
+	}
+	if _, err := sqliteinit.ImportTable(ctx, db, "missing", strings.NewReader(""), sqliteinit.ImportOptions{}); !errors.Is(err, sqliteinit.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+// TestCopyData tests copying rows from one database to another.
+func TestCopyData(t *testing.T) {
+	ctx := context.Background()
+	schema := `
+		CREATE TABLE z_tenants (id INTEGER PRIMARY KEY, name TEXT);
+		CREATE TABLE a_users (
+			id INTEGER PRIMARY KEY,
+			tenant_id INTEGER NOT NULL REFERENCES z_tenants(id),
+			manager_id INTEGER REFERENCES a_users(id),
+			joined DATETIME,
+			legacy TEXT
+		);
+	`
+	dir := t.TempDir()
+	srcCfg := sqliteinit.Config{Path: filepath.Join(dir, "src.db"), Migrations: validMigrations()}
+	if err := sqliteinit.Create(ctx, srcCfg); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	src, err := sqliteinit.Open(ctx, srcCfg)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer src.Close()
+	if _, err := src.Exec(schema + `
+		INSERT INTO z_tenants VALUES (1, 'one'), (2, 'two');
+		INSERT INTO a_users VALUES (10, 1, NULL, '2026-03-01 02:00:00', 'x'), (11, 1, 10, NULL, 'y'), (20, 2, NULL, NULL, 'z');
+	`); err != nil {
+		t.Fatal(err)
+	}
+
+	dstCfg := sqliteinit.Config{Path: filepath.Join(dir, "dst.db"), Migrations: validMigrations()}
+	if err := sqliteinit.Create(ctx, dstCfg); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	dst, err := sqliteinit.Open(ctx, dstCfg)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer dst.Close()
+	// The destination's schema has moved on: legacy is gone, plan is new.
+	dstSchema := strings.Replace(schema, "legacy TEXT", "plan TEXT NOT NULL DEFAULT 'free'", 1)
+	if _, err := dst.Exec(dstSchema); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := sqliteinit.CopyData(ctx, src, dst, []string{"a_users", "z_tenants"}, sqliteinit.CopyOptions{
+		Where: map[string]string{"z_tenants": "id = 1", "a_users": "tenant_id = 1"},
+	})
+	if err != nil {
+		t.Fatalf("CopyData failed: %v", err)
+	}
+	if !slices.Equal(report.Tables, []string{"z_tenants", "a_users"}) {
+		t.Errorf("expected parents first, got %v", report.Tables)
+	}
+	if report.Rows["z_tenants"] != 1 || report.Rows["a_users"] != 2 {
+		t.Errorf("unexpected row counts %v", report.Rows)
+	}
+	var joined, plan string
+	if err := dst.QueryRow(`SELECT CAST(joined AS TEXT), plan FROM a_users WHERE id = 10`).Scan(&joined, &plan); err != nil {
+		t.Fatal(err)
+	}
+	if joined != "2026-03-01 02:00:00" || plan != "free" {
+		t.Errorf("expected the stored text and the default, got %q and %q", joined, plan)
+	}
+
+	report, err = sqliteinit.CopyData(ctx, src, dst, nil, sqliteinit.CopyOptions{Truncate: true})
+	if err != nil {
+		t.Fatalf("CopyData all tables failed: %v", err)
+	}
+	if report.Rows["a_users"] != 3 {
+		t.Errorf("expected 3 users after truncating, got %v", report.Rows)
+	}
+	if _, ok := report.Rows["users"]; !ok {
+		t.Errorf("expected every source table to be copied, got %v", report.Tables)
+	}
+
+	if _, err := dst.Exec(`DROP TABLE a_users`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sqliteinit.CopyData(ctx, src, dst, []string{"a_users"}, sqliteinit.CopyOptions{}); !errors.Is(err, sqliteinit.ErrNotFound) {
+		t.Errorf("expected ErrNotFound, got %v", err)
+	}
+}
+
+// TestIsolatedMemory tests that IsolatedMemory gives each Open its own
+// database, while plain ":memory:" handles share one.
+func TestIsolatedMemory(t *testing.T) {
+	ctx := context.Background()
+	open := func(isolated bool) *sql.DB {
+		db, err := sqliteinit.Open(ctx, sqliteinit.Config{Path: ":memory:", IsolatedMemory: isolated, Migrations: validMigrations()})
+		if err != nil {
+			t.Fatalf("Open failed: %v", err)
+		}
+		t.Cleanup(func() { db.Close() })
+		return db
+	}
+	count := func(db *sql.DB) int {
+		var n int
+		if err := db.QueryRow(`SELECT count(*) FROM users`).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	for i := range 3 {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			t.Parallel()
+			db := open(true)
+			if _, err := db.Exec(`INSERT INTO users (email, name, created_at) VALUES ('a@example.com', 'A', 0)`); err != nil {
+				t.Fatalf("insert: %v", err)
+			}
+			if n := count(db); n != 1 {
+				t.Errorf("expected only this test's user, got %d", n)
+			}
+		})
+	}
+
+	// OpenOrCreate honors IsolatedMemory too.
+	var created [2]*sql.DB
+	for i := range created {
+		db, _, err := sqliteinit.OpenOrCreate(ctx, sqliteinit.Config{Path: ":memory:", IsolatedMemory: true, Migrations: validMigrations()})
+		if err != nil {
+			t.Fatalf("OpenOrCreate failed: %v", err)
+		}
+		t.Cleanup(func() { db.Close() })
+		created[i] = db
+	}
+	if _, err := created[0].Exec(`INSERT INTO users (email, name, created_at) VALUES ('c@example.com', 'C', 0)`); err != nil {
+		t.Fatal(err)
+	}
+	if n := count(created[1]); n != 0 {
+		t.Errorf("expected isolated OpenOrCreate handles not to share a database, got %d users", n)
+	}
+
+	shared1, shared2 := open(false), open(false)
+	if _, err := shared1.Exec(`INSERT INTO users (email, name, created_at) VALUES ('b@example.com', 'B', 0)`); err != nil {
+		t.Fatal(err)
+	}
+	if n := count(shared2); n != 1 {
+		t.Errorf("expected plain :memory: handles to share a database, got %d users", n)
+	}
+}
+
+// TestSeeds tests that seeds are applied once, in order, and that a
+// failing seed leaves nothing behind.
+func TestSeeds(t *testing.T) {
+	ctx := context.Background()
+	seeds := fstest.MapFS{
+		"010_users.csv":   {Data: []byte("id,email,name,created_at\n1,a@example.com,A,0\n2,b@example.com,B,0\n")},
+		"020_posts.sql":   {Data: []byte("INSERT INTO posts (user_id, title, body, created_at) VALUES (1, 'Hello', '', 0);\n")},
+		"030_posts.jsonl": {Data: []byte(`{"user_id": 2, "title": "Again", "body": "", "created_at": 0}` + "\n")},
+		"README.md":       {Data: []byte("not a seed")},
+	}
+	cfg := sqliteinit.Config{Path: filepath.Join(t.TempDir(), "app.db"), Migrations: validMigrations(), Seeds: seeds}
+	if err := sqliteinit.Create(ctx, cfg); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	count := func(db *sql.DB, table string) int {
+		var n int
+		if err := db.QueryRow(`SELECT count(*) FROM ` + table).Scan(&n); err != nil {
+			t.Fatal(err)
+		}
+		return n
+	}
+
+	// Opening again doesn't apply the seeds twice.
+	db, err := sqliteinit.Open(ctx, cfg)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+	if users, posts := count(db, "users"), count(db, "posts"); users != 2 || posts != 2 {
+		t.Errorf("expected 2 users and 2 posts, got %d and %d", users, posts)
+	}
+
+	seeds["020_posts.sql"] = &fstest.MapFile{Data: []byte("INSERT INTO posts (user_id, title, body, created_at) VALUES (1, 'Changed', '', 0);\n")}
+	seeds["040_users.csv"] = &fstest.MapFile{Data: []byte("id,email,name,created_at\n3,c@example.com,C,0\n4,a@example.com,duplicate,0\n")}
+	report, err := sqliteinit.Seed(ctx, db, seeds)
+	if err == nil || !strings.Contains(err.Error(), "040_users.csv") {
+		t.Errorf("expected the duplicate email to fail 040_users.csv, got %v", err)
+	}
+	if report == nil || !slices.Equal(report.Changed, []string{"020_posts.sql"}) || len(report.Applied) != 0 {
+		t.Errorf("expected 020_posts.sql to be reported as changed, got %+v", report)
+	}
+	if n := count(db, "users"); n != 2 {
+		t.Errorf("expected the failed seed to be rolled back, got %d users", n)
+	}
+
+	delete(seeds, "040_users.csv")
+	if report, err := sqliteinit.Seed(ctx, db, seeds); err != nil || len(report.Applied) != 0 {
+		t.Errorf("expected nothing to apply, got %+v (%v)", report, err)
+	}
+	tables, err := sqliteinit.Tables(ctx, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tbl := range tables {
+		if tbl.Name == "seed_runs" {
+			t.Error("expected seed_runs to be an internal table")
+		}
+	}
+
+	// A stripped snapshot doesn't carry the seed bookkeeping.
+	dest := filepath.Join(t.TempDir(), "snapshot.db")
+	if err := sqliteinit.ExportSnapshot(ctx, db, dest, sqliteinit.SnapshotOptions{StripInternal: true}); err != nil {
+		t.Fatalf("ExportSnapshot failed: %v", err)
+	}
+	snap, err := sql.Open("sqlite", "file:"+filepath.ToSlash(dest)+"?mode=ro")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer snap.Close()
+	var n int
+	if err := snap.QueryRow(`SELECT count(*) FROM sqlite_schema WHERE name = 'seed_runs'`).Scan(&n); err != nil || n != 0 {
+		t.Errorf("expected seed_runs to be stripped from the snapshot, got %d (%v)", n, err)
+	}
+	if users := count(snap, "users"); users != 2 {
+		t.Errorf("expected the seeded users in the snapshot, got %d", users)
+	}
+}
+
+// TestConfigNow tests that the timestamps the package records come from
+// Config.Now.
+func TestConfigNow(t *testing.T) {
+	ctx := context.Background()
+	fixed := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	seeds := fstest.MapFS{
+		"users.csv": {Data: []byte("id,email,name,created_at\n1,a@example.com,A,0\n")},
+	}
+	db, err := sqliteinit.Open(ctx, sqliteinit.Config{
+		Path:           ":memory:",
+		IsolatedMemory: true,
+		Migrations:     validMigrations(),
+		Seeds:          seeds,
+		Now:            func() time.Time { return fixed },
+	})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+
+	for _, q := range []string{
+		`SELECT DISTINCT applied_at FROM schema_migrations`,
+		`SELECT DISTINCT updated_at FROM config WHERE key = 'schema.version'`,
+		`SELECT DISTINCT applied_at FROM seed_runs`,
+	} {
+		rows, err := db.Query(q)
+		if err != nil {
+			t.Fatalf("%s: %v", q, err)
+		}
+		var got []int64
+		for rows.Next() {
+			var ts int64
+			if err := rows.Scan(&ts); err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, ts)
+		}
+		rows.Close()
+		if !slices.Equal(got, []int64{fixed.Unix()}) {
+			t.Errorf("%s: expected only %d, got %v", q, fixed.Unix(), got)
+		}
+	}
+}
+
+// TestDeterministic tests that deterministic mode creates byte-identical
+// database files.
+func TestDeterministic(t *testing.T) {
+	ctx := context.Background()
+	create := func(deterministic bool, now func() time.Time) []byte {
+		cfg := sqliteinit.Config{
+			Path:          filepath.Join(t.TempDir(), "app.db"),
+			Migrations:    validMigrations(),
+			Seeds:         fstest.MapFS{"users.csv": {Data: []byte("id,email,name,created_at\n1,a@example.com,A,0\n")}},
+			AppVersion:    "1.2.3",
+			Deterministic: deterministic,
+			Now:           now,
+		}
+		if err := sqliteinit.Create(ctx, cfg); err != nil {
+			t.Fatalf("Create failed: %v", err)
+		}
+		// Open records the schema fingerprint, which Create does not.
+		db, err := sqliteinit.Open(ctx, cfg)
+		if err != nil {
+			t.Fatalf("Open failed: %v", err)
+		}
+		if err := db.Close(); err != nil {
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile(cfg.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+
+	first := create(true, nil)
+	if second := create(true, nil); !bytes.Equal(first, second) {
+		t.Error("expected deterministic runs to produce identical files")
+	}
+	later := func() time.Time { return time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC) }
+	if nondeterministic := create(false, later); bytes.Equal(first, nondeterministic) {
+		t.Error("expected a run with a different clock to differ")
+	}
+
+	err := sqliteinit.Config{Path: ":memory:", Deterministic: true, Audit: &sqliteinit.AccessAudit{}}.Validate()
+	if err == nil || !strings.Contains(err.Error(), "Deterministic") {
+		t.Errorf("expected Validate to reject Audit in deterministic mode, got %v", err)
+	}
+}
+
+// TestCheckRoundTrip tests that migrations without a down script are
+// reported as irreversible and that a down script that doesn't undo its
+// migration is caught.
+func TestCheckRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	migrations := fstest.MapFS{
+		"20260101000001_items.sql":      {Data: []byte("CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT NOT NULL);\n")},
+		"20260101000001_items.down.sql": {Data: []byte("DROP TABLE items;\n")},
+		"20260101000002_seed.sql":       {Data: []byte("INSERT INTO items (name) VALUES ('a');\n")},
+		"20260101000003_tags.sql":       {Data: []byte("CREATE TABLE tags (id INTEGER PRIMARY KEY);\n")},
+		"20260101000003_tags.down.sql":  {Data: []byte("DROP TABLE tags;\n")},
+	}
+	report, err := sqliteinit.CheckRoundTrip(ctx, sqliteinit.RoundTripTest{Migrations: migrations, Dir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("CheckRoundTrip failed: %v", err)
+	}
+	if !slices.Equal(report.Checked, []string{"20260101000001_items.sql", "20260101000003_tags.sql"}) {
+		t.Errorf("unexpected checked migrations %v", report.Checked)
+	}
+	if !slices.Equal(report.Irreversible, []string{"20260101000002_seed.sql"}) {
+		t.Errorf("unexpected irreversible migrations %v", report.Irreversible)
+	}
+
+	// A down script that leaves the table behind.
+	migrations["20260101000003_tags.down.sql"] = &fstest.MapFile{Data: []byte("SELECT 1;\n")}
+	_, err = sqliteinit.CheckRoundTrip(ctx, sqliteinit.RoundTripTest{Migrations: migrations, Dir: t.TempDir()})
+	var rerr *sqliteinit.RoundTripError
+	if !errors.As(err, &rerr) || rerr.Path != "20260101000003_tags.sql" || rerr.Step != "down" {
+		t.Errorf("expected a down RoundTripError for 20260101000003_tags.sql, got %v", err)
+	}
+}
+
+// TestDiskSpacePreflight tests that Backup and Vacuum refuse to start when
+// the file system is short of space, and run when it isn't.
+func TestDiskSpacePreflight(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	cfg := sqliteinit.Config{Path: filepath.Join(dir, "app.db"), Migrations: validMigrations()}
+	if err := sqliteinit.Create(ctx, cfg); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	db, err := sqliteinit.Open(ctx, cfg)
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+
+	dest := filepath.Join(dir, "backup.db")
+	restore := sqliteinit.SetFreeSpace(4096)
+	err = sqliteinit.Backup(ctx, db, dest, sqliteinit.BackupOptions{})
+	restore()
+	if !errors.Is(err, sqliteinit.ErrInsufficientSpace) {
+		t.Errorf("expected ErrInsufficientSpace, got %v", err)
+	}
+	if _, err := os.Stat(dest); !os.IsNotExist(err) {
+		t.Errorf("expected no backup to be written, got %v", err)
+	}
+
+	restore = sqliteinit.SetFreeSpace(4096)
+	err = sqliteinit.Vacuum(ctx, db, sqliteinit.VacuumFull)
+	restore()
+	if !errors.Is(err, sqliteinit.ErrInsufficientSpace) {
+		t.Errorf("Vacuum: expected ErrInsufficientSpace, got %v", err)
+	}
+
+	defer sqliteinit.SetFreeSpace(1 << 40)()
+	if err := sqliteinit.Backup(ctx, db, dest, sqliteinit.BackupOptions{}); err != nil {
+		t.Errorf("expected Backup to run with enough space, got %v", err)
+	}
+	if err := sqliteinit.Vacuum(ctx, db, sqliteinit.VacuumFull); err != nil {
+		t.Errorf("expected Vacuum to run with enough space, got %v", err)
+	}
+}
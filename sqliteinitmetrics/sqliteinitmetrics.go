@@ -0,0 +1,145 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+// Package sqliteinitmetrics exposes the migration state and size of a
+// database managed by sqliteinit as Prometheus metrics, so dashboards can
+// alert on stuck migrations without custom glue.
+//
+// It is a separate module so that applications which don't use
+// Prometheus don't depend on the client library.
+package sqliteinitmetrics
+
+import (
+	"context"
+	"database/sql"
+	"io/fs"
+	"time"
+
+	"github.com/mdhender/sqliteinit"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Options configures a Collector.
+type Options struct {
+	// Namespace prefixes the metric names. Default: "sqliteinit".
+	Namespace string
+
+	// Labels are added to every metric, e.g. {"db": "orders"} when several
+	// databases are collected.
+	Labels prometheus.Labels
+
+	// Timeout bounds the queries run by each scrape. Default: 5 seconds.
+	Timeout time.Duration
+}
+
+// Collector is a prometheus.Collector that reads the migration state and
+// size of a database on each scrape:
+//
+//   - sqliteinit_schema_version: ID of the last applied migration
+//   - sqliteinit_migrations_applied: number of applied migrations
+//   - sqliteinit_migrations_pending: number of migrations not yet applied
+//   - sqliteinit_last_migration_duration_seconds: how long the most
+//     recently applied migration took
+//   - sqliteinit_last_migration_timestamp_seconds: when it was applied
+//   - sqliteinit_db_size_bytes: size of the database file
+//   - sqliteinit_wal_size_bytes: size of the -wal file
+//
+// The migration counts leave out the package's own init entry. Sizes are
+// 0 for in-memory databases. If the database can't be read, the scrape
+// reports an error for every metric.
+type Collector struct {
+	db         *sql.DB
+	migrations fs.FS
+	timeout    time.Duration
+
+	schemaVersion *prometheus.Desc
+	applied       *prometheus.Desc
+	pending       *prometheus.Desc
+	lastDuration  *prometheus.Desc
+	lastTimestamp *prometheus.Desc
+	dbSize        *prometheus.Desc
+	walSize       *prometheus.Desc
+}
+
+// NewCollector returns a collector for db, whose pending migrations are
+// counted against migrations. Register it with a prometheus.Registerer.
+func NewCollector(db *sql.DB, migrations fs.FS, opts Options) *Collector {
+	if opts.Namespace == "" {
+		opts.Namespace = "sqliteinit"
+	}
+	if opts.Timeout == 0 {
+		opts.Timeout = 5 * time.Second
+	}
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(opts.Namespace, "", name), help, nil, opts.Labels)
+	}
+	return &Collector{
+		db:         db,
+		migrations: migrations,
+		timeout:    opts.Timeout,
+
+		schemaVersion: desc("schema_version", "ID of the last applied migration."),
+		applied:       desc("migrations_applied", "Number of applied migrations."),
+		pending:       desc("migrations_pending", "Number of migrations not yet applied."),
+		lastDuration:  desc("last_migration_duration_seconds", "How long the most recently applied migration took."),
+		lastTimestamp: desc("last_migration_timestamp_seconds", "When the most recently applied migration was applied, as a Unix timestamp."),
+		dbSize:        desc("db_size_bytes", "Size of the database file in bytes."),
+		walSize:       desc("wal_size_bytes", "Size of the write-ahead log in bytes."),
+	}
+}
+
+// descs returns every metric the collector reports.
+func (c *Collector) descs() []*prometheus.Desc {
+	return []*prometheus.Desc{c.schemaVersion, c.applied, c.pending, c.lastDuration, c.lastTimestamp, c.dbSize, c.walSize}
+}
+
+// Describe implements prometheus.Collector.
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range c.descs() {
+		ch <- d
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	status, err := sqliteinit.StatusFromDB(ctx, c.db, c.migrations)
+	var info *sqliteinit.DBInfo
+	if err == nil {
+		info, err = sqliteinit.Info(ctx, c.db)
+	}
+	if err != nil {
+		for _, d := range c.descs() {
+			ch <- prometheus.NewInvalidMetric(d, err)
+		}
+		return
+	}
+
+	var applied int
+	var last sqliteinit.AppliedMigration
+	for _, m := range status.Applied {
+		if m.ID == 0 {
+			continue // the package's own init
+		}
+		applied++
+		if m.AppliedAt.After(last.AppliedAt) || (m.AppliedAt.Equal(last.AppliedAt) && m.ID > last.ID) {
+			last = m
+		}
+	}
+	var lastTimestamp float64
+	if !last.AppliedAt.IsZero() {
+		lastTimestamp = float64(last.AppliedAt.Unix())
+	}
+
+	gauge := func(d *prometheus.Desc, v float64) {
+		ch <- prometheus.MustNewConstMetric(d, prometheus.GaugeValue, v)
+	}
+	gauge(c.schemaVersion, float64(status.SchemaVersion))
+	gauge(c.applied, float64(applied))
+	gauge(c.pending, float64(len(status.Pending)))
+	gauge(c.lastDuration, last.Duration.Seconds())
+	gauge(c.lastTimestamp, lastTimestamp)
+	gauge(c.dbSize, float64(info.FileSize))
+	gauge(c.walSize, float64(info.WALSize))
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinitmetrics_test
+
+import (
+	"context"
+	"embed"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/mdhender/sqliteinit"
+	"github.com/mdhender/sqliteinit/sqliteinitmetrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	_ "modernc.org/sqlite"
+)
+
+//go:embed testdata/*.sql
+var migrationsFS embed.FS
+
+// TestCollector tests the metrics reported for a migrated database, and
+// that a closed database reports errors instead of stale values.
+func TestCollector(t *testing.T) {
+	ctx := context.Background()
+	migrations, err := fs.Sub(migrationsFS, "testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := sqliteinit.Open(ctx, sqliteinit.Config{Path: ":memory:", Migrations: migrations})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+
+	c := sqliteinitmetrics.NewCollector(db, migrations, sqliteinitmetrics.Options{Labels: prometheus.Labels{"db": "test"}})
+	reg := prometheus.NewPedanticRegistry()
+	if err := reg.Register(c); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+
+	want := `
+# HELP sqliteinit_migrations_applied Number of applied migrations.
+# TYPE sqliteinit_migrations_applied gauge
+sqliteinit_migrations_applied{db="test"} 1
+# HELP sqliteinit_migrations_pending Number of migrations not yet applied.
+# TYPE sqliteinit_migrations_pending gauge
+sqliteinit_migrations_pending{db="test"} 0
+# HELP sqliteinit_schema_version ID of the last applied migration.
+# TYPE sqliteinit_schema_version gauge
+sqliteinit_schema_version{db="test"} 2.0260101000001e+13
+# HELP sqliteinit_db_size_bytes Size of the database file in bytes.
+# TYPE sqliteinit_db_size_bytes gauge
+sqliteinit_db_size_bytes{db="test"} 0
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(want),
+		"sqliteinit_migrations_applied", "sqliteinit_migrations_pending", "sqliteinit_schema_version", "sqliteinit_db_size_bytes"); err != nil {
+		t.Error(err)
+	}
+	if n, err := testutil.GatherAndCount(reg); err != nil || n != 7 {
+		t.Errorf("expected 7 metrics, got %d, %v", n, err)
+	}
+
+	db.Close()
+	if _, err := reg.Gather(); err == nil {
+		t.Error("expected a scrape of a closed database to fail")
+	}
+}
@@ -0,0 +1,6 @@
+-- Test migration: create users table
+
+CREATE TABLE users (
+    id   INTEGER PRIMARY KEY,
+    name TEXT NOT NULL
+);
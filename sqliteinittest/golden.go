@@ -0,0 +1,112 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinittest
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mdhender/sqliteinit"
+)
+
+// AssertSchema compares db's schema, as written by sqliteinit.DumpSchema,
+// with the golden file at goldenFile and fails t with a line diff if they
+// differ, so schema changes show up as reviewable diffs in pull requests.
+//
+// If the test binary defines a boolean -update flag and it is set, the
+// golden file, and its directory, are written instead. The package doesn't
+// define the flag itself, since most test packages already do:
+//
+//	var update = flag.Bool("update", false, "update golden files")
+func AssertSchema(t testing.TB, db *sql.DB, goldenFile string) {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := sqliteinit.DumpSchema(context.Background(), db, &buf); err != nil {
+		t.Fatalf("sqliteinittest: dump schema: %v", err)
+	}
+	got := buf.String()
+
+	if updating() {
+		if err := os.MkdirAll(filepath.Dir(goldenFile), 0o755); err != nil {
+			t.Fatalf("sqliteinittest: %v", err)
+		}
+		if err := os.WriteFile(goldenFile, []byte(got), 0o644); err != nil {
+			t.Fatalf("sqliteinittest: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(goldenFile)
+	if errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("sqliteinittest: golden file %s does not exist (run with -update to create it)", goldenFile)
+	} else if err != nil {
+		t.Fatalf("sqliteinittest: %v", err)
+	}
+	// Git may check the file out with CRLF line endings.
+	if w := strings.ReplaceAll(string(want), "\r\n", "\n"); w != got {
+		t.Errorf("sqliteinittest: schema differs from %s (run with -update to accept it):\n%s", goldenFile, lineDiff(w, got))
+	}
+}
+
+// updating reports whether the test binary has a boolean -update flag
+// that is set.
+func updating() bool {
+	f := flag.Lookup("update")
+	if f == nil {
+		return false
+	}
+	getter, ok := f.Value.(flag.Getter)
+	if !ok {
+		return false
+	}
+	on, _ := getter.Get().(bool)
+	return on
+}
+
+// lineDiff returns the lines of want and got that differ, prefixed with
+// "-" and "+", with unchanged lines prefixed with a space for context. It
+// uses a longest common subsequence, which is fine for schema-sized text.
+func lineDiff(want, got string) string {
+	a := strings.Split(strings.TrimSuffix(want, "\n"), "\n")
+	b := strings.Split(strings.TrimSuffix(got, "\n"), "\n")
+
+	// lcs[i][j] is the length of the LCS of a[i:] and b[j:].
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var sb strings.Builder
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			sb.WriteString("  " + a[i] + "\n")
+			i++
+			j++
+		case j < len(b) && (i == len(a) || lcs[i][j+1] >= lcs[i+1][j]):
+			sb.WriteString("+ " + b[j] + "\n")
+			j++
+		default:
+			sb.WriteString("- " + a[i] + "\n")
+			i++
+		}
+	}
+	return sb.String()
+}
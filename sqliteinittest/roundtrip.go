@@ -0,0 +1,50 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinittest
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"testing"
+
+	"github.com/mdhender/sqliteinit"
+)
+
+// RoundTrip applies each migration in migrations to a scratch database,
+// rolls it back with its down script, applies it again and compares the
+// schemas along the way, as sqliteinit.CheckRoundTrip does, so
+// irreversible or non-idempotent migrations fail in CI rather than in
+// production:
+//
+//	func TestMigrationsRoundTrip(t *testing.T) {
+//	    sqliteinittest.RoundTrip(t, migrations)
+//	}
+//
+// It fails t at the first migration whose down script doesn't restore the
+// previous schema, with a line diff, or that can't be applied again.
+// Migrations without a down script are applied but not rolled back; they
+// are logged. The environment is "test".
+func RoundTrip(t testing.TB, migrations fs.FS) {
+	t.Helper()
+	w := &testWriter{t: t}
+	defer w.stop()
+	report, err := sqliteinit.CheckRoundTrip(context.Background(), sqliteinit.RoundTripTest{
+		Migrations: migrations,
+		Config: sqliteinit.Config{
+			Environment: "test",
+			Logger:      slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{Level: slog.LevelDebug})),
+		},
+		Dir: t.TempDir(),
+	})
+	var rerr *sqliteinit.RoundTripError
+	if errors.As(err, &rerr) {
+		t.Fatalf("sqliteinittest: %v:\n%s", err, lineDiff(rerr.Want, rerr.Got))
+	} else if err != nil {
+		t.Fatalf("sqliteinittest: %v", err)
+	}
+	for _, path := range report.Irreversible {
+		t.Logf("sqliteinittest: %s has no down script; not rolled back", path)
+	}
+}
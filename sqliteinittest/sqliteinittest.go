@@ -0,0 +1,117 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+// Package sqliteinittest opens migrated databases for tests, so projects
+// don't each copy the same helper:
+//
+//	func TestOrders(t *testing.T) {
+//	    db := sqliteinittest.New(t, sqliteinittest.Options{Migrations: migrations})
+//	    ...
+//	}
+//
+// Each database is private to the test that made it and closed when the
+// test finishes.
+package sqliteinittest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/mdhender/sqliteinit"
+)
+
+// Options controls New.
+type Options struct {
+	// Migrations are applied to the new database, as Config.Migrations.
+	Migrations fs.FS
+
+	// File makes New create a database file in t.TempDir() instead of an
+	// in-memory database, for tests that need WAL behavior, several
+	// connections or the file itself.
+	File bool
+
+	// Config is the base configuration. New sets its Path and, if
+	// Migrations is set, its Migrations. Logger defaults to one that
+	// writes to t.Log, so its output shows only for failed or verbose
+	// tests, and Environment defaults to "test".
+	Config sqliteinit.Config
+}
+
+// New opens a new, migrated database for t and closes it when t and its
+// subtests finish. If the database can't be opened it fails t, reporting
+// the failing migration's file, line and statement when there is one.
+func New(t testing.TB, opts Options) *sql.DB {
+	t.Helper()
+	ctx := context.Background()
+
+	cfg := opts.Config
+	if opts.Migrations != nil {
+		cfg.Migrations = opts.Migrations
+	}
+	if cfg.Environment == "" {
+		cfg.Environment = "test"
+	}
+	if cfg.Logger == nil {
+		w := &testWriter{t: t}
+		t.Cleanup(w.stop) // registered first, so it runs after the Close below
+		cfg.Logger = slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	}
+
+	var db *sql.DB
+	var err error
+	if opts.File {
+		cfg.Path = filepath.Join(t.TempDir(), "test.db")
+		if err = sqliteinit.Create(ctx, cfg); err == nil {
+			db, err = sqliteinit.Open(ctx, cfg)
+		}
+	} else {
+		cfg.Path = ":memory:"
+		cfg.IsolatedMemory = true
+		cfg.AllowMemoryInProduction = true
+		db, err = sqliteinit.Open(ctx, cfg)
+	}
+	if err != nil {
+		var merr *sqliteinit.MigrationError
+		if errors.As(err, &merr) && merr.Statement != 0 {
+			t.Fatalf("sqliteinittest: migration %s failed at statement %d, line %d:\n\t%s\n%v", merr.Path, merr.Statement, merr.Line, merr.SQL, merr.Err)
+		}
+		t.Fatalf("sqliteinittest: open %s: %v", cfg.Path, err)
+	}
+	t.Cleanup(func() {
+		if err := db.Close(); err != nil {
+			t.Errorf("sqliteinittest: close: %v", err)
+		}
+	})
+	return db
+}
+
+// testWriter sends log output to t.Log until the test is over, when
+// testing no longer allows it; later output, e.g. from a background
+// migration, is dropped.
+type testWriter struct {
+	mu   sync.Mutex
+	t    testing.TB
+	done bool
+}
+
+func (w *testWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.done {
+		w.t.Helper()
+		w.t.Log(strings.TrimSuffix(string(p), "\n"))
+	}
+	return len(p), nil
+}
+
+func (w *testWriter) stop() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.done = true
+}
@@ -0,0 +1,186 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinittest_test
+
+import (
+	"database/sql"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/mdhender/sqliteinit/sqliteinittest"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+var migrations = fstest.MapFS{
+	"20260101000001_items.sql": {Data: []byte("CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT NOT NULL);\n")},
+}
+
+// TestNew tests that each database is migrated and private to its test.
+func TestNew(t *testing.T) {
+	for _, file := range []bool{false, true} {
+		t.Run(fmt.Sprintf("file=%v", file), func(t *testing.T) {
+			a := sqliteinittest.New(t, sqliteinittest.Options{Migrations: migrations, File: file})
+			b := sqliteinittest.New(t, sqliteinittest.Options{Migrations: migrations, File: file})
+			if _, err := a.Exec(`INSERT INTO items (name) VALUES ('a')`); err != nil {
+				t.Fatalf("insert: %v", err)
+			}
+			var n int
+			if err := b.QueryRow(`SELECT count(*) FROM items`).Scan(&n); err != nil || n != 0 {
+				t.Errorf("expected an empty second database, got %d rows (%v)", n, err)
+			}
+		})
+	}
+}
+
+// fatalTB records the message of a failed test instead of failing.
+type fatalTB struct {
+	testing.TB
+	msg      string
+	cleanups []func()
+}
+
+func (f *fatalTB) Helper()           {}
+func (f *fatalTB) Log(args ...any)   {}
+func (f *fatalTB) Cleanup(fn func()) { f.cleanups = append(f.cleanups, fn) }
+
+func (f *fatalTB) Errorf(format string, args ...any) {
+	f.msg = fmt.Sprintf(format, args...)
+}
+
+func (f *fatalTB) Fatalf(format string, args ...any) {
+	f.msg = fmt.Sprintf(format, args...)
+	runtime.Goexit()
+}
+
+// TestNew_MigrationFailure tests that a failing migration is reported with
+// its file, line and statement.
+func TestNew_MigrationFailure(t *testing.T) {
+	bad := fstest.MapFS{
+		"20260101000001_items.sql": {Data: []byte("CREATE TABLE items (id INTEGER PRIMARY KEY);\n\nINSERT INTO nowhere VALUES (1);\n")},
+	}
+	tb := &fatalTB{TB: t}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		sqliteinittest.New(tb, sqliteinittest.Options{Migrations: bad})
+	}()
+	<-done
+	for _, fn := range tb.cleanups {
+		fn()
+	}
+	for _, want := range []string{"20260101000001_items.sql", "statement 2, line 3", "INSERT INTO nowhere", "no such table"} {
+		if !strings.Contains(tb.msg, want) {
+			t.Errorf("expected %q in the failure, got %q", want, tb.msg)
+		}
+	}
+}
+
+// TestAssertSchema tests the schema against a golden file, and that a
+// changed schema is reported as a diff and accepted with -update.
+func TestAssertSchema(t *testing.T) {
+	db := sqliteinittest.New(t, sqliteinittest.Options{Migrations: migrations})
+	sqliteinittest.AssertSchema(t, db, "testdata/schema.golden")
+
+	if _, err := db.Exec(`CREATE INDEX items_name ON items (name)`); err != nil {
+		t.Fatal(err)
+	}
+	defer flag.Set("update", fmt.Sprint(*update))
+	flag.Set("update", "false")
+	tb := &fatalTB{TB: t}
+	sqliteinittest.AssertSchema(tb, db, "testdata/schema.golden")
+	if !strings.Contains(tb.msg, "+ CREATE INDEX items_name ON items (name);") {
+		t.Errorf("expected the new index in the diff, got %q", tb.msg)
+	}
+
+	golden := filepath.Join(t.TempDir(), "testdata", "schema.golden")
+	flag.Set("update", "true")
+	sqliteinittest.AssertSchema(t, db, golden)
+	if data, err := os.ReadFile(golden); err != nil || !strings.Contains(string(data), "items_name") {
+		t.Errorf("expected -update to write the golden file, got %v", err)
+	}
+}
+
+// TestWithTx tests that each test's changes are rolled back before the
+// next test sharing the database runs.
+func TestWithTx(t *testing.T) {
+	db := sqliteinittest.New(t, sqliteinittest.Options{Migrations: migrations})
+	for i := range 2 {
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			sqliteinittest.WithTx(t, db, func(tx *sql.Tx) {
+				var n int
+				if err := tx.QueryRow(`SELECT count(*) FROM items`).Scan(&n); err != nil || n != 0 {
+					t.Errorf("expected no rows left by earlier tests, got %d (%v)", n, err)
+				}
+				if _, err := tx.Exec(`INSERT INTO items (name) VALUES ('a')`); err != nil {
+					t.Fatalf("insert: %v", err)
+				}
+			})
+		})
+	}
+	var n int
+	if err := db.QueryRow(`SELECT count(*) FROM items`).Scan(&n); err != nil || n != 0 {
+		t.Errorf("expected the inserts to be rolled back, got %d rows (%v)", n, err)
+	}
+
+	// db is free again as soon as fn returns, so a second call in the same
+	// test works, and a nested call fails instead of waiting forever.
+	tb := &fatalTB{TB: t}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		sqliteinittest.WithTx(t, db, func(tx *sql.Tx) {})
+		sqliteinittest.WithTx(t, db, func(tx *sql.Tx) {
+			sqliteinittest.WithTx(tb, db, func(tx *sql.Tx) {})
+		})
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("WithTx deadlocked")
+	}
+	if !strings.Contains(tb.msg, "connections of db are in use") {
+		t.Errorf("expected the nested WithTx to fail, got %q", tb.msg)
+	}
+	if err := db.QueryRow(`SELECT count(*) FROM items`).Scan(&n); err != nil {
+		t.Errorf("expected db to be usable after WithTx, got %v", err)
+	}
+}
+
+// TestRoundTrip tests that reversible migrations pass and that a down
+// script that doesn't restore the schema fails with a diff.
+func TestRoundTrip(t *testing.T) {
+	good := fstest.MapFS{
+		"20260101000001_items.sql":      {Data: []byte("CREATE TABLE items (id INTEGER PRIMARY KEY, name TEXT NOT NULL);\n")},
+		"20260101000001_items.down.sql": {Data: []byte("DROP TABLE items;\n")},
+		"20260101000002_index.sql":      {Data: []byte("CREATE INDEX items_name ON items (name);\n")},
+		"20260101000002_index.down.sql": {Data: []byte("DROP INDEX items_name;\n")},
+	}
+	sqliteinittest.RoundTrip(t, good)
+
+	bad := fstest.MapFS{
+		"20260101000001_items.sql":      good["20260101000001_items.sql"],
+		"20260101000001_items.down.sql": good["20260101000001_items.down.sql"],
+		"20260101000002_index.sql":      good["20260101000002_index.sql"],
+		"20260101000002_index.down.sql": {Data: []byte("SELECT 1;\n")},
+	}
+	tb := &fatalTB{TB: t}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		sqliteinittest.RoundTrip(tb, bad)
+	}()
+	<-done
+	for _, want := range []string{"20260101000002_index.sql", "doesn't restore", "+ CREATE INDEX items_name ON items (name);"} {
+		if !strings.Contains(tb.msg, want) {
+			t.Errorf("expected %q in the failure, got %q", want, tb.msg)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinittest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+// WithTx begins a transaction on db, calls fn with it, and rolls it back
+// when fn returns, even if it fails the test, so tests that share one
+// migrated database don't leak rows into each other:
+//
+//	db := sqliteinittest.New(t, opts)
+//	t.Run("creates an order", func(t *testing.T) {
+//	    sqliteinittest.WithTx(t, db, func(tx *sql.Tx) {
+//	        ...
+//	    })
+//	})
+//
+// fn must use tx rather than db: handles opened by sqliteinit have a
+// single connection, which the transaction holds until fn returns, so
+// using db inside fn, or calling WithTx on it again, would wait forever.
+// WithTx fails the test at once if every connection of db is already in
+// use. fn must not commit or roll back tx; WithTx reports it if it does.
+func WithTx(t testing.TB, db *sql.DB, fn func(tx *sql.Tx)) {
+	t.Helper()
+	if stats := db.Stats(); stats.MaxOpenConnections > 0 && stats.InUse >= stats.MaxOpenConnections {
+		t.Fatalf("sqliteinittest: WithTx: all %d connections of db are in use, e.g. by an enclosing WithTx or unclosed rows; use its tx instead", stats.MaxOpenConnections)
+	}
+	tx, err := db.BeginTx(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("sqliteinittest: begin: %v", err)
+	}
+	defer func() {
+		if err := tx.Rollback(); errors.Is(err, sql.ErrTxDone) {
+			t.Errorf("sqliteinittest: the test ended the transaction itself; its changes may have leaked")
+		} else if err != nil {
+			t.Errorf("sqliteinittest: rollback: %v", err)
+		}
+	}()
+	fn(tx)
+}
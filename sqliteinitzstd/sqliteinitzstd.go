@@ -0,0 +1,34 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+// Package sqliteinitzstd registers a Zstandard codec for sqliteinit
+// backups, so that Backup, Restore and BackupRotate accept ".db.zst" files
+// and sqliteinit.CompressionZstd. Import it for its side effect:
+//
+//	import _ "github.com/mdhender/sqliteinit/sqliteinitzstd"
+//
+// It is a separate module so that applications which don't use Zstandard
+// don't depend on github.com/klauspost/compress.
+package sqliteinitzstd
+
+import (
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/mdhender/sqliteinit"
+)
+
+func init() {
+	sqliteinit.RegisterCompression(sqliteinit.CompressionZstd, compress, decompress)
+}
+
+func compress(w io.Writer) (io.WriteCloser, error) {
+	return zstd.NewWriter(w)
+}
+
+func decompress(r io.Reader) (io.ReadCloser, error) {
+	d, err := zstd.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	return d.IOReadCloser(), nil
+}
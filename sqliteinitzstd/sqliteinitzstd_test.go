@@ -0,0 +1,61 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinitzstd_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/mdhender/sqliteinit"
+	_ "github.com/mdhender/sqliteinit/sqliteinitzstd"
+)
+
+// TestBackup tests taking, rotating and restoring Zstandard backups.
+func TestBackup(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	migrations := fstest.MapFS{
+		"20260101000001_items.sql": {Data: []byte("CREATE TABLE items (id INTEGER PRIMARY KEY);\nINSERT INTO items (id) VALUES (1);\n")},
+	}
+	db, err := sqliteinit.Open(ctx, sqliteinit.Config{Path: ":memory:", Migrations: migrations})
+	if err != nil {
+		t.Fatalf("Open failed: %v", err)
+	}
+	defer db.Close()
+
+	backup := filepath.Join(dir, "backup.db.zst")
+	if err := sqliteinit.Backup(ctx, db, backup, sqliteinit.BackupOptions{Verify: true}); err != nil {
+		t.Fatalf("Backup failed: %v", err)
+	}
+	data, err := os.ReadFile(backup)
+	if err != nil || !bytes.HasPrefix(data, []byte{0x28, 0xb5, 0x2f, 0xfd}) {
+		t.Errorf("expected a zstd file, got %v", err)
+	}
+
+	restored, err := sqliteinit.Restore(ctx, backup, sqliteinit.Config{
+		Path:       filepath.Join(dir, "restored.db"),
+		Migrations: migrations,
+	})
+	if err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	defer restored.Close()
+	var n int
+	if err := restored.QueryRow(`SELECT count(*) FROM items`).Scan(&n); err != nil || n != 1 {
+		t.Errorf("expected 1 restored item, got %d (%v)", n, err)
+	}
+
+	rotated := filepath.Join(dir, "rotated")
+	if err := os.Mkdir(rotated, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	path, err := sqliteinit.BackupRotate(ctx, db, rotated, sqliteinit.RotationPolicy{Keep: 1, Compression: sqliteinit.CompressionZstd})
+	if err != nil || !strings.HasSuffix(path, ".db.zst") {
+		t.Errorf("expected a .db.zst backup, got %q (%v)", path, err)
+	}
+}
@@ -0,0 +1,189 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import "strings"
+
+// splitStatements splits a SQL script into individual statements on
+// semicolons that are outside of quotes and comments. Comments are
+// removed, surrounding whitespace is trimmed and empty statements are
+// dropped. Trigger bodies (BEGIN ... END) are kept intact.
+func splitStatements(script string) []string {
+	var stmts []string
+	for _, st := range splitScript(script) {
+		stmts = append(stmts, st.SQL)
+	}
+	return stmts
+}
+
+// scriptStatement is a statement of a script and where it starts.
+type scriptStatement struct {
+	SQL  string
+	Line int // 1-based line of the statement's first character
+}
+
+// splitScript splits a script like splitStatements, also reporting the
+// line each statement starts on.
+func splitScript(script string) []scriptStatement {
+	var stmts []scriptStatement
+	var sb strings.Builder
+	depth := 0  // nesting of BEGIN ... END inside CREATE TRIGGER
+	start := -1 // offset of the current statement's first character
+
+	flush := func() {
+		if s := strings.TrimSpace(sb.String()); s != "" {
+			stmts = append(stmts, scriptStatement{SQL: s, Line: strings.Count(script[:start], "\n") + 1})
+		}
+		sb.Reset()
+		start = -1
+	}
+
+	for i := 0; i < len(script); i++ {
+		c := script[i]
+		if start < 0 && !isSpaceByte(c) && !isCommentStart(script, i) && c != ';' {
+			start = i
+		}
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			j := skipQuoted(script, i)
+			sb.WriteString(script[i:j])
+			i = j - 1
+		case c == '$' && dollarTag(script, i) != "":
+			// PostgreSQL dollar-quoted string
+			tag := dollarTag(script, i)
+			j := strings.Index(script[i+len(tag):], tag)
+			if j < 0 {
+				j = len(script) - i - len(tag)
+			} else {
+				j += len(tag)
+			}
+			sb.WriteString(script[i : i+len(tag)+j])
+			i += len(tag) + j - 1
+		case c == '[':
+			j := strings.IndexByte(script[i:], ']')
+			if j < 0 {
+				j = len(script) - i - 1
+			}
+			sb.WriteString(script[i : i+j+1])
+			i += j
+		case c == '-' && i+1 < len(script) && script[i+1] == '-':
+			j := strings.IndexByte(script[i:], '\n')
+			if j < 0 {
+				i = len(script)
+			} else {
+				i += j - 1
+			}
+		case c == '/' && i+1 < len(script) && script[i+1] == '*':
+			j := strings.Index(script[i+2:], "*/")
+			if j < 0 {
+				i = len(script)
+			} else {
+				i += j + 3
+			}
+			sb.WriteByte(' ')
+		case c == ';' && depth == 0:
+			flush()
+		default:
+			if isWordStart(script, i) {
+				w := wordAt(script, i)
+				switch strings.ToUpper(w) {
+				case "BEGIN":
+					if isCreateTrigger(sb.String()) {
+						depth++
+					}
+				case "CASE":
+					if depth > 0 {
+						depth++
+					}
+				case "END":
+					if depth > 0 {
+						depth--
+					}
+				}
+				sb.WriteString(w)
+				i += len(w) - 1
+				continue
+			}
+			sb.WriteByte(c)
+		}
+	}
+	flush()
+	return stmts
+}
+
+// skipQuoted returns the index just past the quoted token starting at i.
+// A doubled quote character inside the token is an escaped quote.
+func skipQuoted(s string, i int) int {
+	q := s[i]
+	for j := i + 1; j < len(s); j++ {
+		if s[j] == q {
+			if j+1 < len(s) && s[j+1] == q {
+				j++
+				continue
+			}
+			return j + 1
+		}
+	}
+	return len(s)
+}
+
+// dollarTag returns the PostgreSQL dollar-quote tag ($$ or $name$)
+// starting at i, or "" if there is none.
+func dollarTag(s string, i int) string {
+	j := i + 1
+	for j < len(s) && (s[j] == '_' || s[j] >= 'a' && s[j] <= 'z' || s[j] >= 'A' && s[j] <= 'Z') {
+		j++
+	}
+	if j < len(s) && s[j] == '$' {
+		return s[i : j+1]
+	}
+	return ""
+}
+
+// isWordStart returns true if an identifier-like word begins at i.
+func isWordStart(s string, i int) bool {
+	if !isWordByte(s[i]) || (s[i] >= '0' && s[i] <= '9') {
+		return false
+	}
+	return i == 0 || !isWordByte(s[i-1])
+}
+
+// wordAt returns the identifier-like word starting at i.
+func wordAt(s string, i int) string {
+	j := i
+	for j < len(s) && isWordByte(s[j]) {
+		j++
+	}
+	return s[i:j]
+}
+
+func isSpaceByte(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v'
+}
+
+// isCommentStart returns true if a -- or /* comment starts at i.
+func isCommentStart(s string, i int) bool {
+	return i+1 < len(s) && (s[i] == '-' && s[i+1] == '-' || s[i] == '/' && s[i+1] == '*')
+}
+
+func isWordByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// isCreateTrigger returns true if stmt starts a CREATE TRIGGER statement.
+func isCreateTrigger(stmt string) bool {
+	f := strings.Fields(strings.ToUpper(stmt))
+	if len(f) < 2 || f[0] != "CREATE" {
+		return false
+	}
+	for _, w := range f[1:] {
+		switch w {
+		case "TEMP", "TEMPORARY":
+			continue
+		case "TRIGGER":
+			return true
+		}
+		return false
+	}
+	return false
+}
@@ -0,0 +1,233 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// ErrPromoted is returned by Standby methods after Promote.
+var ErrPromoted = errors.New("standby promoted")
+
+// StandbyConfig configures a Standby.
+type StandbyConfig struct {
+	// StandbyPath is the standby database file, checked against
+	// PathPolicy. Ideally it is on a different disk than the primary.
+	StandbyPath string
+
+	// PathPolicy checks StandbyPath. Default: DefaultPathPolicy.
+	PathPolicy PathPolicy
+
+	// Interval between copies. This bounds the standby's lag, and each
+	// copy costs a full read of the primary and write of the standby, so
+	// choose it with the database size in mind. Default: 1m.
+	Interval time.Duration
+
+	// MaxLag logs a warning when the standby falls further behind than
+	// this, e.g. because copies are failing. Default: 3 * Interval.
+	MaxLag time.Duration
+
+	// Logger for operational logging. Uses slog.Default() if nil.
+	Logger *slog.Logger
+}
+
+// defaults returns a copy of cfg with default values applied.
+func (cfg StandbyConfig) defaults() StandbyConfig {
+	if cfg.Interval == 0 {
+		cfg.Interval = time.Minute
+	}
+	if cfg.MaxLag == 0 {
+		cfg.MaxLag = 3 * cfg.Interval
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = slog.Default()
+	}
+	return cfg
+}
+
+// Standby keeps a warm standby of a database by copying periodic
+// snapshots of it. Each copy writes a consistent snapshot of the primary
+// to a temporary file with VACUUM INTO and renames it over the standby, so
+// the standby is always a complete, transactionally consistent database no
+// older than Interval (plus the time a copy takes).
+//
+// It does not ship individual changes. Every copy reads every page of the
+// primary and writes the whole database again, and needs free space for a
+// second copy beside the standby, however little has changed: its I/O is
+// proportional to the database size on each Interval. That suits small and
+// medium databases; for large or busy ones, use a WAL-shipping tool.
+//
+// The standby must not be opened while the Standby is running.
+type Standby struct {
+	primary *sql.DB
+	cfg     StandbyConfig
+
+	mu       sync.Mutex
+	lastCopy time.Time
+	promoted bool
+	cancel   context.CancelFunc
+	done     chan struct{}
+
+	copyMu sync.Mutex
+}
+
+// NewStandby creates a Standby that copies primary to cfg.StandbyPath.
+// Call Start to begin copying in the background.
+func NewStandby(primary *sql.DB, cfg StandbyConfig) (*Standby, error) {
+	cfg = cfg.defaults()
+	if err := validatePersistentPath(cfg.StandbyPath, orDefaultPolicy(cfg.PathPolicy)); err != nil {
+		return nil, fmt.Errorf("standby: %w", err)
+	}
+	if cfg.Interval < 0 {
+		return nil, fmt.Errorf("standby interval must be positive")
+	}
+	return &Standby{primary: primary, cfg: cfg}, nil
+}
+
+// Start copies in a background goroutine until ctx is cancelled or Stop is
+// called. Calling Start on a running Standby is a no-op.
+func (r *Standby) Start(ctx context.Context) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cancel != nil || r.promoted {
+		return
+	}
+
+	ctx, r.cancel = context.WithCancel(ctx)
+	r.done = make(chan struct{})
+	go r.loop(ctx, r.done)
+}
+
+// Stop halts background copying and waits for a running copy to finish.
+func (r *Standby) Stop() {
+	r.mu.Lock()
+	cancel, done := r.cancel, r.done
+	r.cancel, r.done = nil, nil
+	r.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+}
+
+// Lag returns the time since the last successful copy, or zero if the
+// standby has never been copied.
+func (r *Standby) Lag() time.Duration {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.lastCopy.IsZero() {
+		return 0
+	}
+	return time.Since(r.lastCopy)
+}
+
+// LastCopy returns the time of the last successful copy.
+func (r *Standby) LastCopy() time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.lastCopy
+}
+
+// CopyNow copies a snapshot of the primary to the standby immediately.
+func (r *Standby) CopyNow(ctx context.Context) error {
+	r.copyMu.Lock()
+	defer r.copyMu.Unlock()
+
+	r.mu.Lock()
+	promoted := r.promoted
+	r.mu.Unlock()
+	if promoted {
+		return ErrPromoted
+	}
+
+	started := time.Now()
+	tmp := r.cfg.StandbyPath + ".tmp"
+	os.Remove(tmp)
+
+	if err := checkDiskSpace(tmp, databaseSize(ctx, r.primary)); err != nil {
+		return err
+	}
+	if _, err := r.primary.ExecContext(ctx, `VACUUM INTO ?`, tmp); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("copy primary: %w", err)
+	}
+
+	// A stale WAL next to the new standby file would be replayed into it.
+	for _, suffix := range []string{"-wal", "-shm"} {
+		if err := os.Remove(r.cfg.StandbyPath + suffix); err != nil && !os.IsNotExist(err) {
+			os.Remove(tmp)
+			return fmt.Errorf("remove standby %s: %w", suffix, err)
+		}
+	}
+	if err := os.Rename(tmp, r.cfg.StandbyPath); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("replace standby: %w", err)
+	}
+
+	r.mu.Lock()
+	r.lastCopy = started
+	r.mu.Unlock()
+	return nil
+}
+
+// Promote stops copying and opens the standby as the new primary using
+// cfg, whose Path is replaced with the standby path. It first tries a
+// final copy; if the primary is unusable, the standby is promoted as of
+// the last successful copy and the copy error is logged.
+func (r *Standby) Promote(ctx context.Context, cfg Config) (*sql.DB, error) {
+	r.Stop()
+
+	if err := r.CopyNow(ctx); err != nil {
+		if errors.Is(err, ErrPromoted) {
+			return nil, err
+		}
+		if !fileExists(r.cfg.StandbyPath) {
+			return nil, fmt.Errorf("promote: standby never copied: %w", err)
+		}
+		r.cfg.Logger.Warn("final copy failed, promoting last copied standby",
+			"standby", r.cfg.StandbyPath, "last_copy", r.LastCopy(), "error", err)
+	}
+
+	r.mu.Lock()
+	r.promoted = true
+	r.mu.Unlock()
+
+	cfg.Path = r.cfg.StandbyPath
+	db, err := Open(ctx, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("promote: %w", err)
+	}
+	r.cfg.Logger.Info("standby promoted", "path", r.cfg.StandbyPath)
+	return db, nil
+}
+
+// loop copies every Interval.
+func (r *Standby) loop(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(r.cfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		if err := r.CopyNow(ctx); err != nil && ctx.Err() == nil {
+			r.cfg.Logger.Warn("standby copy failed", "standby", r.cfg.StandbyPath, "error", err)
+		}
+		if lag := r.Lag(); lag > r.cfg.MaxLag {
+			r.cfg.Logger.Warn("standby lag exceeds limit", "lag", lag, "max", r.cfg.MaxLag)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
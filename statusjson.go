@@ -0,0 +1,47 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// MarshalJSON encodes the status in the package's stable JSON schema:
+//
+//	{
+//	  "schema_version": 20260115143000,
+//	  "infra_version": 2,
+//	  "applied": [
+//	    {"id": 0, "comment": "init", "path": "schema.sql", "checksum": "9f86d0...",
+//	     "applied_by": "deploy@web-1", "applied_at": "2026-01-15T14:30:00Z", "duration_ms": 3}
+//	  ],
+//	  "pending": ["20260201090000_add_tags.sql"],
+//	  "is_initialized": true
+//	}
+//
+// applied and pending are always arrays, never null. Fields are only ever
+// added to the schema, never renamed or removed.
+func (s MigrationStatus) MarshalJSON() ([]byte, error) {
+	type status MigrationStatus // without the MarshalJSON method
+	out := status(s)
+	if out.Applied == nil {
+		out.Applied = []AppliedMigration{}
+	}
+	if out.Pending == nil {
+		out.Pending = []string{}
+	}
+	return json.Marshal(out)
+}
+
+// MarshalJSON encodes the migration with applied_at as an RFC 3339
+// timestamp in UTC, to the second, matching the precision it is recorded
+// with, and the duration as whole milliseconds in duration_ms.
+func (m AppliedMigration) MarshalJSON() ([]byte, error) {
+	type migration AppliedMigration // without the MarshalJSON method
+	return json.Marshal(struct {
+		migration
+		AppliedAt  string `json:"applied_at"`
+		DurationMS int64  `json:"duration_ms"`
+	}{migration(m), m.AppliedAt.UTC().Format(time.RFC3339), m.Duration.Milliseconds()})
+}
@@ -0,0 +1,231 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// ErrMisuse is returned in DevStrict mode when a statement would silently
+// misbehave in production. Use errors.Is to test for it.
+var ErrMisuse = errors.New("sqliteinit misuse")
+
+// internalKey marks a context used by the package's own statements, which
+// DevStrict checks don't apply to.
+type internalKey struct{}
+
+// withInternal returns a context exempt from DevStrict checks.
+func withInternal(ctx context.Context) context.Context {
+	return context.WithValue(ctx, internalKey{}, true)
+}
+
+// isInternal reports whether ctx was marked by withInternal.
+func isInternal(ctx context.Context) bool {
+	return ctx.Value(internalKey{}) != nil
+}
+
+// strictConnector opens connections that enforce DevStrict checks.
+type strictConnector struct {
+	next     driver.Connector
+	readOnly bool
+}
+
+func (c *strictConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.next.Connect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &strictConn{Conn: conn, readOnly: c.readOnly}, nil
+}
+
+func (c *strictConnector) Driver() driver.Driver {
+	return c.next.Driver()
+}
+
+// strictConn wraps a driver connection and rejects statements that are
+// likely mistakes:
+//
+//   - writes on a read-only handle (such as DB.Reader)
+//   - statements without a cancellable context (db.Query instead of
+//     db.QueryContext, or context.Background())
+//   - statements on a transaction from a goroutine other than the one
+//     that began it
+//   - schema changes (CREATE, ALTER, DROP) outside migrations
+type strictConn struct {
+	driver.Conn
+	readOnly bool
+
+	// txGoroutine is the goroutine that began the open transaction, or 0.
+	txGoroutine int64
+}
+
+// check returns an error wrapping ErrMisuse if query may not run.
+func (c *strictConn) check(ctx context.Context, query string) error {
+	if isInternal(ctx) {
+		return nil
+	}
+	if ctx.Done() == nil {
+		return fmt.Errorf("%w: statement run without a cancellable context: %s", ErrMisuse, abbreviate(query))
+	}
+	if c.txGoroutine != 0 && c.txGoroutine != goroutineID() {
+		return fmt.Errorf("%w: transaction used from a different goroutine: %s", ErrMisuse, abbreviate(query))
+	}
+
+	switch strings.ToUpper(leadingKeyword(query)) {
+	case "CREATE", "ALTER", "DROP":
+		if !isTempDDL(query) {
+			return fmt.Errorf("%w: schema change outside migrations: %s", ErrMisuse, abbreviate(query))
+		}
+	case "INSERT", "UPDATE", "DELETE", "REPLACE", "VACUUM", "REINDEX":
+	default:
+		return nil
+	}
+	if c.readOnly {
+		return fmt.Errorf("%w: write on read-only handle: %s", ErrMisuse, abbreviate(query))
+	}
+	return nil
+}
+
+func (c *strictConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	execer, ok := c.Conn.(driver.ExecerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	if err := c.check(ctx, query); err != nil {
+		return nil, err
+	}
+	return execer.ExecContext(ctx, query, args)
+}
+
+func (c *strictConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	queryer, ok := c.Conn.(driver.QueryerContext)
+	if !ok {
+		return nil, driver.ErrSkip
+	}
+	if err := c.check(ctx, query); err != nil {
+		return nil, err
+	}
+	return queryer.QueryContext(ctx, query, args)
+}
+
+func (c *strictConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	if err := c.check(ctx, query); err != nil {
+		return nil, err
+	}
+	if p, ok := c.Conn.(driver.ConnPrepareContext); ok {
+		return p.PrepareContext(ctx, query)
+	}
+	return c.Conn.Prepare(query)
+}
+
+func (c *strictConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	var tx driver.Tx
+	var err error
+	if b, ok := c.Conn.(driver.ConnBeginTx); ok {
+		tx, err = b.BeginTx(ctx, opts)
+	} else {
+		tx, err = c.Conn.Begin()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !isInternal(ctx) {
+		c.txGoroutine = goroutineID()
+	}
+	return &strictTx{Tx: tx, conn: c}, nil
+}
+
+func (c *strictConn) Ping(ctx context.Context) error {
+	if p, ok := c.Conn.(driver.Pinger); ok {
+		return p.Ping(ctx)
+	}
+	return nil
+}
+
+func (c *strictConn) ResetSession(ctx context.Context) error {
+	if r, ok := c.Conn.(driver.SessionResetter); ok {
+		return r.ResetSession(ctx)
+	}
+	return nil
+}
+
+func (c *strictConn) IsValid() bool {
+	if v, ok := c.Conn.(driver.Validator); ok {
+		return v.IsValid()
+	}
+	return true
+}
+
+func (c *strictConn) CheckNamedValue(nv *driver.NamedValue) error {
+	if n, ok := c.Conn.(driver.NamedValueChecker); ok {
+		return n.CheckNamedValue(nv)
+	}
+	return driver.ErrSkip
+}
+
+// strictTx clears the owning goroutine when the transaction ends.
+type strictTx struct {
+	driver.Tx
+	conn *strictConn
+}
+
+func (t *strictTx) Commit() error {
+	t.conn.txGoroutine = 0
+	return t.Tx.Commit()
+}
+
+func (t *strictTx) Rollback() error {
+	t.conn.txGoroutine = 0
+	return t.Tx.Rollback()
+}
+
+// leadingKeyword returns the first word of query, skipping whitespace and
+// comments.
+func leadingKeyword(query string) string {
+	for i := 0; i < len(query); i++ {
+		switch {
+		case strings.HasPrefix(query[i:], "--"):
+			j := strings.IndexByte(query[i:], '\n')
+			if j < 0 {
+				return ""
+			}
+			i += j
+		case strings.HasPrefix(query[i:], "/*"):
+			j := strings.Index(query[i:], "*/")
+			if j < 0 {
+				return ""
+			}
+			i += j + 1
+		case isWordStart(query, i):
+			return wordAt(query, i)
+		}
+	}
+	return ""
+}
+
+// isTempDDL returns true for CREATE TEMP/TEMPORARY statements, which only
+// affect the connection and are not schema changes.
+func isTempDDL(query string) bool {
+	fields := strings.Fields(strings.ToUpper(query))
+	return len(fields) > 1 && fields[0] == "CREATE" && (fields[1] == "TEMP" || fields[1] == "TEMPORARY")
+}
+
+// goroutineID returns the current goroutine's ID, parsed from the stack
+// header. It is slow and only used in DevStrict mode.
+func goroutineID() int64 {
+	buf := make([]byte, 64)
+	buf = buf[:runtime.Stack(buf, false)]
+	buf = bytes.TrimPrefix(buf, []byte("goroutine "))
+	if i := bytes.IndexByte(buf, ' '); i > 0 {
+		buf = buf[:i]
+	}
+	id, _ := strconv.ParseInt(string(buf), 10, 64)
+	return id
+}
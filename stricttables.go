@@ -0,0 +1,67 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// queryer is satisfied by *sql.DB, *sql.Conn and *sql.Tx.
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// NonStrictTables returns the user tables in db's main schema that are not
+// STRICT tables. The package's own tables are excluded. It requires SQLite
+// 3.37 or later.
+func NonStrictTables(ctx context.Context, db *sql.DB) ([]string, error) {
+	return nonStrictTables(ctx, db)
+}
+
+// nonStrictTables lists the non-STRICT user tables visible to q.
+func nonStrictTables(ctx context.Context, q queryer) ([]string, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT name FROM pragma_table_list
+		WHERE schema = 'main' AND type = 'table' AND strict = 0
+		  AND name NOT LIKE 'sqlite\_%' ESCAPE '\'
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("list tables: %w", err)
+	}
+	defer rows.Close()
+
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		if !isInternalTable(name) {
+			tables = append(tables, name)
+		}
+	}
+	return tables, rows.Err()
+}
+
+// checkStrictTables returns an error naming any table in after that is not
+// in before, i.e. the non-STRICT tables a migration created.
+func checkStrictTables(before, after []string) error {
+	existing := make(map[string]bool, len(before))
+	for _, name := range before {
+		existing[name] = true
+	}
+	var created []string
+	for _, name := range after {
+		if !existing[name] {
+			created = append(created, name)
+		}
+	}
+	if len(created) != 0 {
+		return fmt.Errorf("StrictTables: tables must be created STRICT: %s", strings.Join(created, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// applySymlinkPolicy enforces cfg.ForbidSymlinks and cfg.ResolveSymlinks
+// for a local persistent path. With ResolveSymlinks, the returned config's
+// Path names the real file, so every later step (the DSN, sidecars, the
+// read pool, Delete and backups) works on where the bytes actually live.
+func applySymlinkPolicy(cfg Config) (Config, error) {
+	if cfg.isMemory() || cfg.isRemote() || (!cfg.ForbidSymlinks && !cfg.ResolveSymlinks) {
+		return cfg, nil
+	}
+	if cfg.ForbidSymlinks && cfg.ResolveSymlinks {
+		return cfg, fmt.Errorf("ForbidSymlinks and ResolveSymlinks are mutually exclusive")
+	}
+
+	path := cfg.filePath()
+	real, err := realPath(path)
+	if err != nil {
+		return cfg, err
+	}
+	if real == path {
+		return cfg, nil
+	}
+
+	if cfg.ForbidSymlinks {
+		return cfg, fmt.Errorf("%s: path goes through a symlink to %s (ForbidSymlinks)", path, real)
+	}
+
+	cfg.Logger.Info("resolved database symlink", "path", path, "real", real)
+	if _, query, ok := parseFileURI(cfg.Path); ok {
+		cfg.Path = hostPathStyle.uri(real)
+		if query != "" {
+			cfg.Path += "?" + query
+		}
+	} else {
+		cfg.Path = real
+	}
+	return cfg, nil
+}
+
+// realPath returns path with every symlink resolved. The file itself need
+// not exist, but its parent directory must.
+func realPath(path string) (string, error) {
+	if _, err := os.Lstat(path); err == nil {
+		real, err := filepath.EvalSymlinks(path)
+		if err != nil {
+			return "", fmt.Errorf("%s: resolve symlinks: %w", path, err)
+		}
+		return real, nil
+	}
+	dir, err := filepath.EvalSymlinks(filepath.Dir(path))
+	if err != nil {
+		return "", fmt.Errorf("%s: resolve symlinks: %w", path, err)
+	}
+	return filepath.Join(dir, filepath.Base(path)), nil
+}
+
+// isSymlink reports whether path itself is a symbolic link.
+func isSymlink(path string) bool {
+	info, err := os.Lstat(path)
+	return err == nil && info.Mode()&os.ModeSymlink != 0
+}
@@ -0,0 +1,184 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// BackupTarget stores backups, e.g. in a directory (DirTarget) or an
+// object storage bucket. Implement it to send backups to S3, GCS or Azure
+// Blob Storage with their SDKs.
+type BackupTarget interface {
+	// Put stores the contents of r under name. r may be an *os.File, for
+	// SDKs that upload faster when they can seek, or a stream of unknown
+	// length. Put must not leave a partial object under name if it fails.
+	Put(ctx context.Context, name string, r io.Reader) error
+}
+
+// BackupLister is implemented by targets that can list and delete backups,
+// which BackupRotateTo needs to prune old ones.
+type BackupLister interface {
+	// List returns the names of the stored objects, in any order.
+	List(ctx context.Context) ([]string, error)
+
+	// Delete removes the named object. Deleting one that doesn't exist is
+	// not an error.
+	Delete(ctx context.Context, name string) error
+}
+
+// DirTarget stores backups as files in a local directory. It implements
+// BackupTarget and BackupLister.
+type DirTarget struct {
+	Dir string
+}
+
+// Put writes r to a temporary file in the directory, syncs it and renames
+// it to name. It fails with ErrAlreadyExists rather than overwrite a file.
+func (t DirTarget) Put(ctx context.Context, name string, r io.Reader) error {
+	path, err := t.path(name)
+	if err != nil {
+		return err
+	}
+	if fileExists(path) {
+		return fmt.Errorf("%s: file %w", path, ErrAlreadyExists)
+	}
+	tmp := path + ".tmp"
+	os.Remove(tmp)
+	defer os.Remove(tmp)
+
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if fileExists(path) {
+		return fmt.Errorf("%s: file %w", path, ErrAlreadyExists)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+	syncDir(t.Dir)
+	return nil
+}
+
+// List returns the names of the regular files in the directory.
+func (t DirTarget) List(ctx context.Context) ([]string, error) {
+	entries, err := os.ReadDir(t.Dir)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if e.Type().IsRegular() {
+			names = append(names, e.Name())
+		}
+	}
+	return names, nil
+}
+
+// Delete removes the named file.
+func (t DirTarget) Delete(ctx context.Context, name string) error {
+	path, err := t.path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// path returns the path of the file called name, which must be a plain
+// file name.
+func (t DirTarget) path(name string) (string, error) {
+	if name == "" || name != filepath.Base(name) || strings.ContainsAny(name, `/\`) {
+		return "", fmt.Errorf("backup name %q must be a plain file name", name)
+	}
+	return filepath.Join(t.Dir, name), nil
+}
+
+// BackupTo writes a backup of db to target under name, which must end in
+// ".db", ".db.gz" or ".db.zst" and sets the compression as for Backup. The
+// backup is staged in a temporary directory (see BackupOptions.TempDir),
+// verified if asked, and streamed to the target, compressed on the way.
+// With a DirTarget it is written in place with Backup instead.
+func BackupTo(ctx context.Context, db *sql.DB, target BackupTarget, name string, opts BackupOptions) error {
+	compression, base := compressionOf(name)
+	if !strings.HasSuffix(base, ".db") {
+		return fmt.Errorf("backup name %q must end in .db, .db.gz or .db.zst", name)
+	}
+	if err := checkCompression(compression); err != nil {
+		return fmt.Errorf("backup: %w", err)
+	}
+	if t, ok := target.(DirTarget); ok {
+		path, err := t.path(name)
+		if err != nil {
+			return err
+		}
+		return Backup(ctx, db, path, opts)
+	}
+
+	staging, err := os.MkdirTemp(opts.TempDir, "sqliteinit-backup-")
+	if err != nil {
+		return fmt.Errorf("backup: %w", err)
+	}
+	defer os.RemoveAll(staging)
+	tmp := filepath.Join(staging, "backup.db")
+	if err := checkDiskSpace(tmp, databaseSize(ctx, db)); err != nil {
+		return err
+	}
+	if _, err := db.ExecContext(withInternal(ctx), `VACUUM INTO ?`, tmp); err != nil {
+		return fmt.Errorf("backup: vacuum into %s: %w", tmp, err)
+	}
+	if opts.Verify {
+		if err := verifyBackup(ctx, opts.Driver, tmp); err != nil {
+			return fmt.Errorf("backup: verify: %w", err)
+		}
+	}
+
+	f, err := os.Open(tmp)
+	if err != nil {
+		return fmt.Errorf("backup: %w", err)
+	}
+	defer f.Close()
+	if compression == CompressionNone {
+		if err := target.Put(ctx, name, f); err != nil {
+			return fmt.Errorf("backup: put %s: %w", name, err)
+		}
+		return nil
+	}
+
+	pr, pw := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		err := compressTo(compression, pw, f)
+		pw.CloseWithError(err)
+		done <- err
+	}()
+	err = target.Put(ctx, name, pr)
+	pr.CloseWithError(io.ErrClosedPipe) // stop the compressor if Put quit early
+	if cerr := <-done; err == nil && cerr != nil && cerr != io.ErrClosedPipe {
+		err = cerr
+	}
+	if err != nil {
+		return fmt.Errorf("backup: put %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+-- Test migration: WITHOUT ROWID and STRICT tables, generated columns,
+-- composite keys, and partial and expression indexes
+
+CREATE TABLE tags (
+    slug  TEXT PRIMARY KEY,
+    label TEXT
+) WITHOUT ROWID;
+
+CREATE TABLE events (
+    id      INTEGER PRIMARY KEY,
+    kind    TEXT NOT NULL,
+    payload ANY,
+    day     TEXT GENERATED ALWAYS AS (substr(kind, 1, 3)) VIRTUAL,
+    upper   TEXT GENERATED ALWAYS AS (upper(kind)) STORED,
+    deleted INTEGER NOT NULL DEFAULT 0
+) STRICT;
+
+CREATE TABLE memberships (
+    group_id INTEGER,
+    user_id  INTEGER,
+    PRIMARY KEY (group_id, user_id)
+);
+
+CREATE INDEX idx_events_live ON events (kind) WHERE deleted = 0;
+CREATE INDEX idx_tags_lower ON tags (lower(label));
@@ -0,0 +1,5 @@
+-- Test migration: backfill rows, safe to finish after Open returns
+-- sqliteinit:deferrable
+
+WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < 1000)
+INSERT INTO items (name) SELECT 'item ' || i FROM n;
@@ -0,0 +1,6 @@
+-- Test migration: seed users for development and testing only
+-- sqliteinit:env dev,test
+
+INSERT INTO users (email, name, created_at)
+VALUES ('alice@example.com', 'Alice', 0),
+       ('bob@example.com', 'Bob', 0);
@@ -0,0 +1,10 @@
+-- Test migration: plugin-owned comments table
+
+CREATE TABLE comments (
+    id         INTEGER PRIMARY KEY AUTOINCREMENT,
+    post_id    INTEGER NOT NULL,
+    body       TEXT NOT NULL,
+    created_at INTEGER NOT NULL,
+
+    FOREIGN KEY (post_id) REFERENCES posts(id)
+);
@@ -0,0 +1,3 @@
+-- Revert: drop the items table
+
+DROP TABLE items;
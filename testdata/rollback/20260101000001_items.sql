@@ -0,0 +1,6 @@
+-- Test migration: create a table
+
+CREATE TABLE items (
+    id   INTEGER PRIMARY KEY,
+    name TEXT NOT NULL
+);
@@ -0,0 +1,3 @@
+-- Test migration: add a column, with no down script
+
+ALTER TABLE items ADD COLUMN price REAL NOT NULL DEFAULT 0;
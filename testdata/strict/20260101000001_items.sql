@@ -0,0 +1,7 @@
+-- Test migration: create a STRICT table
+
+CREATE TABLE items (
+    id    INTEGER PRIMARY KEY,
+    name  TEXT NOT NULL,
+    price REAL NOT NULL
+) STRICT;
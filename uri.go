@@ -0,0 +1,87 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"net/url"
+	"strings"
+)
+
+// parseFileURI splits a SQLite "file:" URI into its file name and raw query
+// string. ok is false if path is not a URI. The authority, if any, must be
+// empty or "localhost", as SQLite requires.
+//
+//	file:/data/app.db?mode=rwc        -> "/data/app.db", "mode=rwc"
+//	file:///data/app.db               -> "/data/app.db", ""
+//	file:memdb1?mode=memory&cache=shared -> "memdb1", "mode=memory&cache=shared"
+func parseFileURI(path string) (name, query string, ok bool) {
+	rest, ok := strings.CutPrefix(path, "file:")
+	if !ok {
+		return "", "", false
+	}
+	rest, query, _ = strings.Cut(rest, "?")
+	rest, _, _ = strings.Cut(rest, "#")
+	if auth, ok := strings.CutPrefix(rest, "//"); ok {
+		host, p, _ := strings.Cut(auth, "/")
+		if host == "" || host == "localhost" {
+			rest = "/" + p
+		}
+	}
+	if unescaped, err := url.PathUnescape(rest); err == nil {
+		rest = unescaped
+	}
+	return rest, query, true
+}
+
+// filePathOf returns the normalized file system path named by a database
+// path, which may be a plain path or a "file:" URI.
+func filePathOf(path string) string {
+	if name, _, ok := parseFileURI(path); ok {
+		path = name
+	}
+	return hostPathStyle.normalize(path)
+}
+
+// isMemoryPath returns true if path names an in-memory database.
+func isMemoryPath(path string) bool {
+	if path == ":memory:" {
+		return true
+	}
+	name, query, ok := parseFileURI(path)
+	if !ok {
+		return false
+	}
+	if name == ":memory:" {
+		return true
+	}
+	values, _ := url.ParseQuery(query)
+	return values.Get("mode") == "memory"
+}
+
+// isSharedMemoryPath returns true if path names an in-memory database that
+// every connection in the process shares: ":memory:", which the package
+// opens with a shared cache, or a "file:" URI with cache=shared.
+func isSharedMemoryPath(path string) bool {
+	if path == ":memory:" {
+		return true
+	}
+	if !isMemoryPath(path) {
+		return false
+	}
+	_, query, _ := parseFileURI(path)
+	values, _ := url.ParseQuery(query)
+	return values.Get("cache") == "shared"
+}
+
+// dsnBase returns the DSN prefix for path and any query parameters that
+// must precede the package's pragmas.
+func dsnBase(path string) (base, query string) {
+	if path == ":memory:" {
+		return "file::memory:", "cache=shared"
+	}
+	if strings.HasPrefix(path, "file:") {
+		base, query, _ = strings.Cut(path, "?")
+		return base, query
+	}
+	return hostPathStyle.uri(path), ""
+}
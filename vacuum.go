@@ -0,0 +1,179 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// VacuumMode selects what Vacuum does. Use VacuumFull, VacuumIncremental
+// or VacuumInto.
+type VacuumMode struct {
+	pages int    // for incremental vacuum; 0 means all free pages
+	into  string // for VACUUM INTO
+	incr  bool
+}
+
+// VacuumFull rebuilds the whole database file, returning all free pages to
+// the file system and defragmenting tables and indexes. It needs free disk
+// space of up to twice the database size, and blocks writers while it
+// runs; see VacuumTask for running it with a Gate.
+var VacuumFull = VacuumMode{}
+
+// VacuumIncremental returns up to pages free pages to the file system, or
+// all of them if pages is 0, without rebuilding the file. The database
+// must have auto_vacuum set to INCREMENTAL.
+func VacuumIncremental(pages int) VacuumMode {
+	return VacuumMode{incr: true, pages: pages}
+}
+
+// VacuumInto writes a compacted copy of the database to path, leaving the
+// database itself alone. It is Backup without verification.
+func VacuumInto(path string) VacuumMode {
+	return VacuumMode{into: path}
+}
+
+// Vacuum reclaims space in db as mode says. VACUUM can't run inside a
+// transaction, so Vacuum runs it on a connection of its own; it fails if
+// that connection has a transaction open, e.g. from a BEGIN issued through
+// db.Exec. Before a full vacuum of a database file it checks that the file
+// system has free space of twice the database size, returning an error
+// wrapping ErrInsufficientSpace if not.
+func Vacuum(ctx context.Context, db *sql.DB, mode VacuumMode) error {
+	ctx = withInternal(ctx)
+	if mode.into != "" {
+		if err := Backup(ctx, db, mode.into, BackupOptions{}); err != nil {
+			return fmt.Errorf("vacuum: %w", err)
+		}
+		return nil
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("vacuum: %w", err)
+	}
+	defer conn.Close()
+
+	query := `VACUUM`
+	if mode.incr {
+		var autoVacuum int
+		if err := conn.QueryRowContext(ctx, `PRAGMA auto_vacuum`).Scan(&autoVacuum); err != nil {
+			return fmt.Errorf("vacuum: %w", err)
+		}
+		if autoVacuum != 2 {
+			return fmt.Errorf("vacuum: incremental vacuum requires auto_vacuum=INCREMENTAL; see EnableIncrementalVacuum")
+		}
+		query = incrementalVacuumSQL(mode.pages)
+	} else if err := vacuumPreflight(ctx, conn); err != nil {
+		return fmt.Errorf("vacuum: %w", err)
+	}
+	if err := execToCompletion(ctx, conn, query); err != nil {
+		if strings.Contains(err.Error(), "within a transaction") {
+			return fmt.Errorf("vacuum: connection has an open transaction: %w", err)
+		}
+		return fmt.Errorf("vacuum: %w", err)
+	}
+	return nil
+}
+
+// vacuumPreflight checks that the file system holding the database of
+// conn can take a full vacuum, which writes a rebuilt copy of the database
+// and then journals it back into place. In-memory databases need no space.
+func vacuumPreflight(ctx context.Context, conn *sql.Conn) error {
+	var path string
+	if err := conn.QueryRowContext(ctx, `SELECT file FROM pragma_database_list WHERE name = 'main'`).Scan(&path); err != nil || path == "" {
+		return err
+	}
+	var pageSize, pageCount int64
+	if err := conn.QueryRowContext(ctx, `SELECT page_size, page_count FROM pragma_page_size, pragma_page_count`).Scan(&pageSize, &pageCount); err != nil {
+		return err
+	}
+	return checkDiskSpace(path, 2*pageSize*pageCount)
+}
+
+// AutoVacuum is the auto_vacuum setting Create gives a new database. See
+// Config.AutoVacuum.
+type AutoVacuum int
+
+const (
+	// AutoVacuumNone keeps free pages in the file for reuse; the file
+	// never shrinks without a VacuumFull. This is SQLite's default.
+	AutoVacuumNone AutoVacuum = iota
+
+	// AutoVacuumFull returns free pages to the file system at every
+	// commit, at the cost of slower commits and more fragmentation.
+	AutoVacuumFull
+
+	// AutoVacuumIncremental keeps free pages until VacuumIncremental or
+	// IncrementalVacuumTask frees them.
+	AutoVacuumIncremental
+)
+
+// pragmaValue returns the auto_vacuum pragma value for a.
+func (a AutoVacuum) pragmaValue() string {
+	switch a {
+	case AutoVacuumFull:
+		return "FULL"
+	case AutoVacuumIncremental:
+		return "INCREMENTAL"
+	}
+	return "NONE"
+}
+
+// EnableIncrementalVacuum switches an existing database to
+// auto_vacuum=INCREMENTAL. That takes a full VACUUM, with the disk space
+// and write blocking VacuumFull needs, so it is done only if the database
+// isn't already switched. New databases can be created that way instead;
+// see Config.AutoVacuum.
+func EnableIncrementalVacuum(ctx context.Context, db *sql.DB) error {
+	ctx = withInternal(ctx)
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("enable incremental vacuum: %w", err)
+	}
+	defer conn.Close()
+
+	var autoVacuum int
+	if err := conn.QueryRowContext(ctx, `PRAGMA auto_vacuum`).Scan(&autoVacuum); err != nil {
+		return fmt.Errorf("enable incremental vacuum: %w", err)
+	}
+	if autoVacuum == 2 {
+		return nil
+	}
+	// The new setting is pending on this connection until the VACUUM.
+	for _, stmt := range []string{`PRAGMA auto_vacuum = INCREMENTAL`, `VACUUM`} {
+		if _, err := conn.ExecContext(ctx, stmt); err != nil {
+			return fmt.Errorf("enable incremental vacuum: %w", err)
+		}
+	}
+	return nil
+}
+
+// incrementalVacuumSQL returns the pragma that frees up to pages free
+// pages, or all of them if pages is 0.
+func incrementalVacuumSQL(pages int) string {
+	if pages > 0 {
+		return fmt.Sprintf(`PRAGMA incremental_vacuum(%d)`, pages)
+	}
+	return `PRAGMA incremental_vacuum`
+}
+
+// execToCompletion runs query as a query and steps through every row.
+// incremental_vacuum frees one page per step, and some drivers step a
+// statement only once on Exec.
+func execToCompletion(ctx context.Context, conn *sql.Conn, query string) error {
+	rows, err := conn.QueryContext(ctx, query)
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+	}
+	if err := rows.Err(); err != nil {
+		rows.Close()
+		return err
+	}
+	return rows.Close()
+}
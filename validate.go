@@ -0,0 +1,181 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"strings"
+)
+
+// Validate checks cfg for problems that would otherwise surface one at a
+// time inside Open: path shape, options that don't apply to the database
+// mode or contradict each other, negative sizes and timeouts, invalid
+// extra pragmas, and an unreadable migrations filesystem. It returns all
+// problems joined with errors.Join, or nil.
+//
+// Validate doesn't touch the database file, so it can't tell whether the
+// file exists; Open and Create still check that.
+func (cfg Config) Validate() error {
+	busyTimeoutSet := cfg.BusyTimeout != 0
+	cfg = cfg.defaults()
+	var errs []error
+	problem := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	memory, remote := cfg.isMemory(), cfg.isRemote()
+	local := !memory && !remote
+
+	// Path shape
+	switch {
+	case cfg.Path == "":
+		problem("Path: required")
+	case local:
+		err := cfg.pathPolicy().CheckPath(cfg.filePath())
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			for _, e := range joined.Unwrap() {
+				problem("Path: %v", e)
+			}
+		} else if err != nil {
+			problem("Path: %v", err)
+		}
+	}
+
+	// Options that don't apply to the database mode
+	if cfg.AuthToken != "" && !remote {
+		problem("AuthToken: only applies to remote libSQL paths")
+	}
+	if cfg.IdleClose != 0 && !local {
+		problem("IdleClose: requires a local persistent database")
+	}
+	if cfg.MmapSize != 0 && !local {
+		problem("MmapSize: requires a local persistent database")
+	}
+	if (cfg.WALAutocheckpoint != 0 || cfg.JournalSizeLimit != 0) && !local {
+		problem("WALAutocheckpoint, JournalSizeLimit: require a local persistent database")
+	}
+	if cfg.AutoVacuum != AutoVacuumNone && !local {
+		problem("AutoVacuum: requires a local persistent database")
+	}
+	if cfg.AutoVacuum < AutoVacuumNone || cfg.AutoVacuum > AutoVacuumIncremental {
+		problem("AutoVacuum: unknown mode %d", cfg.AutoVacuum)
+	}
+	if cfg.DetectDrift < DriftIgnore || cfg.DetectDrift > DriftFail {
+		problem("DetectDrift: unknown policy %d", cfg.DetectDrift)
+	}
+	if cfg.IntegrityCheckOnOpen < IntegrityCheckOff || cfg.IntegrityCheckOnOpen > IntegrityCheckFull {
+		problem("IntegrityCheckOnOpen: unknown check %d", cfg.IntegrityCheckOnOpen)
+	}
+	if f := cfg.RequiredSchemaFingerprint; f != "" && (len(f) != 64 || strings.Trim(f, "0123456789abcdef") != "") {
+		problem("RequiredSchemaFingerprint: expected 64 lowercase hex digits, as returned by SchemaFingerprint")
+	}
+	if cfg.AdoptForeign && memory {
+		problem("AdoptForeign: in-memory databases are always new")
+	}
+	if cfg.PathPolicy != nil && len(cfg.AllowedExtensions) != 0 {
+		problem("PathPolicy, AllowedExtensions: AllowedExtensions is ignored when PathPolicy is set")
+	}
+	for _, ext := range cfg.AllowedExtensions {
+		if strings.Trim(ext, ".") == "" {
+			problem("AllowedExtensions: %q is not an extension", ext)
+		}
+	}
+	if cfg.RollbackPlanDir != "" && !isDirectory(cfg.RollbackPlanDir) {
+		problem("RollbackPlanDir: %s: not a directory", cfg.RollbackPlanDir)
+	}
+	if cfg.FileMode != 0 && !local {
+		problem("FileMode: requires a local persistent database")
+	}
+	if cfg.FileMode&^fs.ModePerm != 0 {
+		problem("FileMode: %v has bits other than permissions", cfg.FileMode)
+	}
+	if cfg.DirMode != 0 && !local {
+		problem("DirMode: requires a local persistent database")
+	}
+	if cfg.DirMode&^fs.ModePerm != 0 {
+		problem("DirMode: %v has bits other than permissions", cfg.DirMode)
+	}
+	if cfg.ForbidSymlinks && cfg.ResolveSymlinks {
+		problem("ForbidSymlinks, ResolveSymlinks: mutually exclusive")
+	}
+	if cfg.MaxDatabaseSize != 0 && remote {
+		problem("MaxDatabaseSize: not supported for remote databases")
+	}
+	if len(cfg.Extensions) != 0 && (cfg.driver() == Modernc || cfg.driver() == LibSQL) {
+		problem("Extensions: driver %q does not support loading extensions", cfg.driver().Name())
+	}
+	for _, f := range cfg.Functions {
+		if err := f.validate(); err != nil {
+			problem("Functions: %v", err)
+		}
+	}
+	if len(cfg.Functions) != 0 && cfg.driver() == LibSQL {
+		problem("Functions: driver %q does not support custom functions", cfg.driver().Name())
+	}
+	if err := validateAttach(cfg); err != nil {
+		problem("Attach: %v", err)
+	}
+	if cfg.EncryptionKey != "" {
+		if _, ok := cfg.driver().(KeyedDriver); !ok {
+			problem("EncryptionKey: driver %q does not support encryption", cfg.driver().Name())
+		}
+	}
+	if cfg.Deterministic && cfg.Audit != nil {
+		problem("Audit, Deterministic: audit sampling is random")
+	}
+	if memory && cfg.isProduction() && !cfg.AllowMemoryInProduction {
+		problem("Path: %w (%s)", ErrMemoryInProduction, cfg.productionSource())
+	}
+
+	// Sizes and timeouts
+	for _, d := range []struct {
+		name  string
+		value int64
+	}{
+		{"MigrationTimeout", int64(cfg.MigrationTimeout)},
+		{"MigrationBudget", int64(cfg.MigrationBudget)},
+		{"QueryTimeout", int64(cfg.QueryTimeout)},
+		{"BusyTimeout", int64(cfg.BusyTimeout)},
+		{"IdleClose", int64(cfg.IdleClose)},
+		{"ReadConns", int64(cfg.ReadConns)},
+		{"CacheSizeKB", int64(cfg.CacheSizeKB)},
+		{"MmapSize", cfg.MmapSize},
+		{"MaxDatabaseSize", cfg.MaxDatabaseSize},
+	} {
+		if d.value < 0 {
+			problem("%s: must not be negative", d.name)
+		}
+	}
+	if cfg.JournalSizeLimit < -1 {
+		problem("JournalSizeLimit: must be -1 (no limit) or more")
+	}
+	// Only a BusyTimeout the caller chose; the default isn't their mistake.
+	if busyTimeoutSet && cfg.QueryTimeout > 0 && cfg.BusyTimeout > cfg.QueryTimeout {
+		problem("BusyTimeout: %s exceeds QueryTimeout %s, so lock waits would time out as queries", cfg.BusyTimeout, cfg.QueryTimeout)
+	}
+
+	// Pragmas
+	if _, err := withExtraPragmas(cfg.builtinPragmas(), cfg.ExtraPragmas); err != nil {
+		problem("ExtraPragmas: %w", err)
+	}
+
+	// Migrations
+	if cfg.Migrations != nil {
+		quiet := slog.New(slog.NewTextHandler(io.Discard, nil))
+		if _, err := listMigrationFiles(cfg.Migrations, quiet); err != nil {
+			problem("Migrations: %w", err)
+		}
+	}
+
+	if cfg.Seeds != nil {
+		if _, err := fs.ReadDir(cfg.Seeds, "."); err != nil {
+			problem("Seeds: %w", err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
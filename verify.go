@@ -0,0 +1,112 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// VerifyOptions controls Verify.
+type VerifyOptions struct {
+	// Quick runs PRAGMA quick_check instead of integrity_check. It skips
+	// checking that indexes match their tables, so it runs in about linear
+	// time on large databases.
+	Quick bool
+}
+
+// Violation is a problem found by Verify.
+type Violation struct {
+	// Check is "integrity" for a problem reported by integrity_check or
+	// quick_check, and "foreign_key" for one reported by foreign_key_check.
+	Check string `json:"check"`
+
+	// Table is the table with the violating row. It is empty for integrity
+	// problems, which SQLite reports as text.
+	Table string `json:"table,omitempty"`
+
+	// RowID is the rowid of the violating row, or 0 for WITHOUT ROWID
+	// tables and integrity problems.
+	RowID int64 `json:"rowid,omitempty"`
+
+	// Parent is the table the foreign key refers to.
+	Parent string `json:"parent,omitempty"`
+
+	// ForeignKey is the index of the foreign key in
+	// pragma_foreign_key_list(Table).
+	ForeignKey int `json:"foreign_key,omitempty"`
+
+	// Message is SQLite's description of an integrity problem.
+	Message string `json:"message,omitempty"`
+}
+
+// String formats the violation for logs.
+func (v Violation) String() string {
+	if v.Check == "foreign_key" {
+		return fmt.Sprintf("foreign key %d: %s row %d: missing parent in %s", v.ForeignKey, v.Table, v.RowID, v.Parent)
+	}
+	return "integrity: " + v.Message
+}
+
+// Verify checks db's integrity and foreign keys, returning the problems
+// found, or nil if there are none. It is meant for nightly audits from cron
+// jobs; an error means the checks couldn't run, not that they failed.
+//
+// Foreign keys are checked even if enforcement is off, so it also finds
+// rows written while it was.
+func Verify(ctx context.Context, db *sql.DB, opts VerifyOptions) ([]Violation, error) {
+	ctx = withInternal(ctx)
+	violations, err := integrityViolations(ctx, db, opts.Quick)
+	if err != nil {
+		return nil, fmt.Errorf("verify: %w", err)
+	}
+
+	rows, err := db.QueryContext(ctx, `PRAGMA foreign_key_check`)
+	if err != nil {
+		return nil, fmt.Errorf("verify: foreign_key_check: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		v := Violation{Check: "foreign_key"}
+		var rowID sql.NullInt64
+		if err := rows.Scan(&v.Table, &rowID, &v.Parent, &v.ForeignKey); err != nil {
+			return nil, fmt.Errorf("verify: foreign_key_check: %w", err)
+		}
+		v.RowID = rowID.Int64
+		violations = append(violations, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("verify: foreign_key_check: %w", err)
+	}
+	return violations, nil
+}
+
+// integrityViolations runs PRAGMA integrity_check, or quick_check if quick
+// is set, and returns the problems it reports.
+func integrityViolations(ctx context.Context, q queryer, quick bool) ([]Violation, error) {
+	pragma := "integrity_check"
+	if quick {
+		pragma = "quick_check"
+	}
+	rows, err := q.QueryContext(ctx, `PRAGMA `+pragma)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", pragma, err)
+	}
+	defer rows.Close()
+
+	var violations []Violation
+	for rows.Next() {
+		var msg string
+		if err := rows.Scan(&msg); err != nil {
+			return nil, fmt.Errorf("%s: %w", pragma, err)
+		}
+		if msg != "ok" {
+			violations = append(violations, Violation{Check: "integrity", Message: msg})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", pragma, err)
+	}
+	return violations, nil
+}
@@ -0,0 +1,209 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package sqliteinit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// ChangeEvent describes a change to the database detected by a Watcher.
+type ChangeEvent struct {
+	// Time the change was detected.
+	Time time.Time
+
+	// DataVersion is the watcher connection's PRAGMA data_version after
+	// the change.
+	DataVersion int64
+
+	// Replaced is true if the file itself was replaced (e.g. restored from
+	// a backup or moved into place) rather than written through SQLite.
+	Replaced bool
+}
+
+// WatchConfig configures a Watcher.
+type WatchConfig struct {
+	// OnChange is called from the watcher goroutine for each detected
+	// change. It must not call the Watcher's methods. Required.
+	OnChange func(ChangeEvent)
+
+	// Interval between polls. Default: 1s.
+	Interval time.Duration
+
+	// Logger for operational logging. Uses slog.Default() if nil.
+	Logger *slog.Logger
+}
+
+// defaults returns a copy of cfg with default values applied.
+func (cfg WatchConfig) defaults() WatchConfig {
+	if cfg.Interval == 0 {
+		cfg.Interval = time.Second
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = slog.Default()
+	}
+	return cfg
+}
+
+// Watcher detects commits to a persistent database made by other
+// connections, such as a CLI sharing the file with a long-running service,
+// so that callers can invalidate caches.
+//
+// It holds its own read-only connection and polls PRAGMA data_version,
+// which changes whenever any other connection commits, including other
+// connections in this process. It also stats the file to notice when it is
+// replaced outright.
+type Watcher struct {
+	cfg  Config
+	wcfg WatchConfig
+
+	mu      sync.Mutex
+	db      *sql.DB
+	info    os.FileInfo
+	version int64
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+// NewWatcher opens a watcher on the persistent database described by cfg.
+// Call Start to begin polling, or Check to poll manually. Close releases
+// the watcher's connection.
+func NewWatcher(ctx context.Context, cfg Config, wcfg WatchConfig) (*Watcher, error) {
+	cfg = cfg.defaults()
+	wcfg = wcfg.defaults()
+	if cfg.isMemory() || cfg.isRemote() {
+		return nil, fmt.Errorf("watcher requires a local persistent database")
+	}
+	if wcfg.OnChange == nil {
+		return nil, fmt.Errorf("watcher requires an OnChange function")
+	}
+	cfg.ReadConns = 1
+
+	w := &Watcher{cfg: cfg, wcfg: wcfg}
+	if err := w.open(ctx); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// open (re)opens the watcher's connection and records the file identity
+// and data version.
+func (w *Watcher) open(ctx context.Context) error {
+	info, err := os.Stat(w.cfg.filePath())
+	if err != nil {
+		return err
+	}
+	db, err := openReader(ctx, w.cfg)
+	if err != nil {
+		return fmt.Errorf("open watcher connection: %w", err)
+	}
+	version, err := dataVersion(ctx, db)
+	if err != nil {
+		db.Close()
+		return err
+	}
+
+	if w.db != nil {
+		w.db.Close()
+	}
+	w.db, w.info, w.version = db, info, version
+	return nil
+}
+
+// Check polls once and calls OnChange if the database changed since the
+// last poll. It reports whether a change was detected.
+func (w *Watcher) Check(ctx context.Context) (bool, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	info, err := os.Stat(w.cfg.filePath())
+	if err != nil {
+		return false, err
+	}
+	if !os.SameFile(info, w.info) {
+		if err := w.open(ctx); err != nil {
+			return false, err
+		}
+		w.wcfg.OnChange(ChangeEvent{Time: time.Now(), DataVersion: w.version, Replaced: true})
+		return true, nil
+	}
+
+	version, err := dataVersion(ctx, w.db)
+	if err != nil {
+		return false, err
+	}
+	if version == w.version {
+		return false, nil
+	}
+	w.version = version
+	w.wcfg.OnChange(ChangeEvent{Time: time.Now(), DataVersion: version})
+	return true, nil
+}
+
+// Start polls in a background goroutine until ctx is cancelled or Stop is
+// called. Calling Start on a running watcher is a no-op.
+func (w *Watcher) Start(ctx context.Context) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.cancel != nil {
+		return
+	}
+
+	ctx, w.cancel = context.WithCancel(ctx)
+	w.done = make(chan struct{})
+	go w.loop(ctx, w.done)
+}
+
+// Stop halts background polling and waits for a running poll to finish.
+func (w *Watcher) Stop() {
+	w.mu.Lock()
+	cancel, done := w.cancel, w.done
+	w.cancel, w.done = nil, nil
+	w.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+		<-done
+	}
+}
+
+// Close stops the watcher and closes its connection.
+func (w *Watcher) Close() error {
+	w.Stop()
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.db.Close()
+}
+
+// loop polls every Interval.
+func (w *Watcher) loop(ctx context.Context, done chan struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(w.wcfg.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := w.Check(ctx); err != nil && ctx.Err() == nil {
+				w.wcfg.Logger.Warn("database watch failed", "path", w.cfg.Path, "error", err)
+			}
+		}
+	}
+}
+
+// dataVersion returns PRAGMA data_version for db's connection.
+func dataVersion(ctx context.Context, db *sql.DB) (int64, error) {
+	var v int64
+	if err := db.QueryRowContext(withInternal(ctx), `PRAGMA data_version`).Scan(&v); err != nil {
+		return 0, fmt.Errorf("data_version: %w", err)
+	}
+	return v, nil
+}
@@ -0,0 +1,100 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+// Package zombiesqlite runs sqliteinit's schema initialization and
+// migrations and returns a zombiezen.com/go/sqlite connection pool instead
+// of a *sql.DB, for projects that use the zombiezen API.
+//
+// It is a separate module so that applications on database/sql don't
+// depend on the zombiezen packages.
+package zombiesqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/mdhender/sqliteinit"
+	"zombiezen.com/go/sqlite"
+	"zombiezen.com/go/sqlite/sqlitex"
+
+	// Registers the database/sql driver used for migrations. zombiezen
+	// links the same SQLite library, so this adds little.
+	_ "modernc.org/sqlite"
+)
+
+// Pool is a zombiezen connection pool over a database prepared by
+// sqliteinit.
+type Pool struct {
+	*sqlitex.Pool
+
+	// anchor keeps a shared-cache in-memory database alive for the life of
+	// the pool. It is nil for persistent databases.
+	anchor *sql.DB
+}
+
+// Close closes the pool and releases the database.
+func (p *Pool) Close() error {
+	err := p.Pool.Close()
+	if p.anchor != nil {
+		err = errors.Join(err, p.anchor.Close())
+	}
+	return err
+}
+
+// Open initializes and migrates the database described by cfg with
+// sqliteinit.Open, then opens it as a zombiezen pool. The same rules apply
+// as for sqliteinit.Open: a persistent database must already exist (use
+// sqliteinit.Create first) and production environments refuse in-memory
+// databases.
+//
+// Both use the modernc SQLite library, so cfg.Driver defaults to
+// sqliteinit.Modernc rather than sqliteinit.DefaultDriver. Every pooled
+// connection gets the same built-in and extra pragmas that sqliteinit
+// would apply. opts.PrepareConn, if set, runs after the pragmas.
+func Open(ctx context.Context, cfg sqliteinit.Config, opts sqlitex.PoolOptions) (*Pool, error) {
+	if cfg.Driver == nil {
+		cfg.Driver = sqliteinit.Modernc
+	}
+
+	db, err := sqliteinit.Open(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	pragmas, err := cfg.Pragmas()
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	memory := cfg.Path == ":memory:"
+	uri, _ := sqliteinit.Modernc.BuildDSN(cfg.Path, nil)
+
+	prepare := opts.PrepareConn
+	opts.PrepareConn = func(conn *sqlite.Conn) error {
+		for _, p := range pragmas {
+			if err := sqlitex.ExecuteTransient(conn, fmt.Sprintf("PRAGMA %s = %s", p.Name, p.Value), nil); err != nil {
+				return fmt.Errorf("pragma %s: %w", p.Name, err)
+			}
+		}
+		if prepare != nil {
+			return prepare(conn)
+		}
+		return nil
+	}
+
+	pool, err := sqlitex.NewPool(uri, opts)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("open pool: %w", err)
+	}
+
+	if !memory {
+		if err := db.Close(); err != nil {
+			pool.Close()
+			return nil, err
+		}
+		db = nil
+	}
+	return &Pool{Pool: pool, anchor: db}, nil
+}
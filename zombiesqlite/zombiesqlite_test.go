@@ -0,0 +1,69 @@
+// Copyright (c) 2026 Michael D Henderson. All rights reserved.
+
+package zombiesqlite_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/mdhender/sqliteinit"
+	"github.com/mdhender/sqliteinit/zombiesqlite"
+	"zombiezen.com/go/sqlite"
+	"zombiezen.com/go/sqlite/sqlitex"
+)
+
+// TestOpen tests that migrations are visible through the pool for both
+// in-memory and persistent databases.
+func TestOpen(t *testing.T) {
+	ctx := context.Background()
+
+	persistent := filepath.Join(t.TempDir(), "test.db")
+	if err := sqliteinit.Create(ctx, sqliteinit.Config{Path: persistent}); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	for _, path := range []string{":memory:", persistent} {
+		t.Run(path, func(t *testing.T) {
+			pool, err := zombiesqlite.Open(ctx, sqliteinit.Config{Path: path}, sqlitex.PoolOptions{PoolSize: 2})
+			if err != nil {
+				t.Fatalf("Open failed: %v", err)
+			}
+			defer pool.Close()
+
+			conn, err := pool.Take(ctx)
+			if err != nil {
+				t.Fatalf("Take failed: %v", err)
+			}
+			defer pool.Put(conn)
+
+			var version string
+			err = sqlitex.Execute(conn, `SELECT value FROM config WHERE key = 'schema.version'`, &sqlitex.ExecOptions{
+				ResultFunc: func(stmt *sqlite.Stmt) error {
+					version = stmt.ColumnText(0)
+					return nil
+				},
+			})
+			if err != nil {
+				t.Fatalf("query config: %v", err)
+			}
+			if version == "" {
+				t.Error("expected schema.version to be set")
+			}
+
+			var fk int64
+			err = sqlitex.Execute(conn, `PRAGMA foreign_keys`, &sqlitex.ExecOptions{
+				ResultFunc: func(stmt *sqlite.Stmt) error {
+					fk = stmt.ColumnInt64(0)
+					return nil
+				},
+			})
+			if err != nil {
+				t.Fatalf("query pragma: %v", err)
+			}
+			if fk != 1 {
+				t.Errorf("expected foreign_keys on, got %d", fk)
+			}
+		})
+	}
+}